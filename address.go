@@ -0,0 +1,56 @@
+package bacnet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// RoutedAddr is the address of a device on a remote BACnet network, reached
+// through the BACnet router at Router. Messages from remote devices are
+// handed over to the Client handler with a *RoutedAddr, so they can be
+// answered right away.
+type RoutedAddr struct {
+	Router net.Addr
+	Dst    plumbing.BACnetAddress
+}
+
+// Network returns the network of the router.
+func (a *RoutedAddr) Network() string {
+	return a.Router.Network()
+}
+
+// String returns the router address followed by the remote address, as in
+// 192.168.1.10:47808/2001:0a.
+func (a *RoutedAddr) String() string {
+	return a.Router.String() + "/" + a.Dst.String()
+}
+
+// Route addresses the BACnet/IP message b to dst, a device on a remote BACnet
+// network, by setting the NPDU destination. The BVLC length is fixed accordingly.
+func Route(b []byte, dst plumbing.BACnetAddress) ([]byte, error) {
+	var bvlc plumbing.BVLC
+	var npdu plumbing.NPDU
+
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("routing: %v", err)
+	}
+	offset := bvlc.MarshalLen()
+	if err := npdu.UnmarshalBinary(b[offset:]); err != nil {
+		return nil, fmt.Errorf("routing: %v", err)
+	}
+	rest := b[offset+npdu.MarshalLen():]
+
+	npdu.SetDestination(dst)
+	routed := make([]byte, offset+npdu.MarshalLen()+len(rest))
+	copy(routed, b[:offset])
+	if err := npdu.MarshalTo(routed[offset:]); err != nil {
+		return nil, fmt.Errorf("routing: %v", err)
+	}
+	copy(routed[offset+npdu.MarshalLen():], rest)
+	binary.BigEndian.PutUint16(routed[2:4], uint16(len(routed)))
+
+	return routed, nil
+}
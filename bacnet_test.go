@@ -18,3 +18,18 @@ func TestParseUnicastIam(t *testing.T) {
 func TestParseReadPropertyMultiple(t *testing.T) {
 	test_utils.TestParseReadPropertyMultiple(t, Parse)
 }
+func TestParseIAmRouterToNetwork(t *testing.T) {
+	test_utils.TestParseIAmRouterToNetwork(t, Parse)
+}
+func TestParseWhatIsNetworkNumber(t *testing.T) {
+	test_utils.TestParseWhatIsNetworkNumber(t, Parse)
+}
+func TestParseForwardedWhois(t *testing.T) {
+	test_utils.TestParseForwardedWhois(t, Parse)
+}
+func TestParseBIP6UnicastReadProperty(t *testing.T) {
+	test_utils.TestParseBIP6UnicastReadProperty(t, Parse)
+}
+func TestParseNPDUReadProperty(t *testing.T) {
+	test_utils.TestParseNPDUReadProperty(t, ParseNPDU)
+}
@@ -0,0 +1,566 @@
+// Package bacnettest runs simulated BACnet devices on the in-memory networks
+// of package transport, so that discovery, retries, segmentation and COV are
+// tested within go test, without sockets nor a real BACnet stack.
+package bacnettest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet"
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	"github.com/Nortech-ai/bacnet/transport"
+)
+
+// DEFAULT_VENDOR_ID is the vendor ID the simulated devices announce.
+const DEFAULT_VENDOR_ID = 0xffff
+
+// Device is a simulated BACnet device holding the present values of a set of
+// objects. It answers Who-Is with I-Am, Who-Has of its objects with I-Have,
+// ReadProperty and ReadPropertyMultiple of the object identifiers, names and
+// present values and of the object list of the device, WriteProperty and
+// WritePropertyMultiple of the present values and SubscribeCOV, notifying the
+// subscribers of every change, and rejects the requests failing to decode. It
+// is safe for concurrent use.
+type Device struct {
+	instance uint32
+	client   *bacnet.Client
+
+	mu            sync.Mutex
+	values        map[objects.ObjectIdentifier]float32
+	names         map[objects.ObjectIdentifier]string
+	subscriptions []subscription
+}
+
+type subscription struct {
+	addr      net.Addr
+	processId uint32
+	oid       objects.ObjectIdentifier
+	confirmed bool
+	// expires is zero for subscriptions without a lifetime.
+	expires time.Time
+}
+
+// NewDevice starts a Device with the instance number instance on t, holding
+// the present values given in values. The Device owns t from now on and
+// closes it on Close.
+func NewDevice(t transport.Transport, instance uint32, values map[objects.ObjectIdentifier]float32) *Device {
+	d := &Device{
+		instance: instance,
+		client:   bacnet.NewTransportClient(t),
+		values:   map[objects.ObjectIdentifier]float32{},
+		names:    map[objects.ObjectIdentifier]string{},
+	}
+	for oid, v := range values {
+		d.values[oid] = v
+	}
+	d.client.Handle(d.handle)
+	return d
+}
+
+// Instance returns the instance number of the Device.
+func (d *Device) Instance() uint32 {
+	return d.instance
+}
+
+// Client returns the Client of the Device, such as for tuning its TSM.
+func (d *Device) Client() *bacnet.Client {
+	return d.client
+}
+
+// Value returns the present value of oid, if the Device holds it.
+func (d *Device) Value(oid objects.ObjectIdentifier) (float32, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v, ok := d.values[oid]
+	return v, ok
+}
+
+// SetValue sets the present value of oid, adding the object if new, and
+// notifies its subscribers.
+func (d *Device) SetValue(oid objects.ObjectIdentifier, v float32) {
+	d.mu.Lock()
+	d.values[oid] = v
+	d.mu.Unlock()
+	d.notify(oid, v)
+}
+
+// SetName names the object oid, found by name with Who-Has. The objects not
+// named are found by identifier only.
+func (d *Device) SetName(oid objects.ObjectIdentifier, name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.names[oid] = name
+}
+
+// Close stops the Device and closes its transport.
+func (d *Device) Close() error {
+	return d.client.Close()
+}
+
+func (d *Device) handle(addr net.Addr, msg plumbing.BACnet) {
+	switch m := msg.(type) {
+	case *services.UnconfirmedWhoIs:
+		d.whoIs(addr, m)
+	case *services.UnconfirmedWhoHas:
+		d.whoHas(addr, m)
+	case *services.ConfirmedReadProperty:
+		if m.APDU.Service == services.ServiceConfirmedReadPropMultiple {
+			d.readPropertyMultiple(addr, m)
+			return
+		}
+		d.readProperty(addr, m)
+	case *services.ConfirmedWriteProperty:
+		d.writeProperty(addr, m)
+	case *services.ConfirmedWritePropertyMultiple:
+		d.writePropertyMultiple(addr, m)
+	case *services.ConfirmedCOV:
+		d.subscribeCOV(addr, m)
+	}
+}
+
+func (d *Device) whoIs(addr net.Addr, m *services.UnconfirmedWhoIs) {
+	dec, err := m.Decode()
+	if err != nil {
+		return
+	}
+	if len(dec.Tags) == 2 {
+		low, lok := dec.Tags[0].Value.(uint32)
+		high, hok := dec.Tags[1].Value.(uint32)
+		if lok && hok && (d.instance < low || d.instance > high) {
+			return
+		}
+	}
+	if iam, err := bacnet.NewIAm(d.instance, DEFAULT_VENDOR_ID); err == nil {
+		d.client.Send(addr, iam)
+	}
+}
+
+func (d *Device) whoHas(addr net.Addr, m *services.UnconfirmedWhoHas) {
+	dec, err := m.Decode()
+	if err != nil || !dec.Matches(d.instance) {
+		return
+	}
+	d.mu.Lock()
+	var oid objects.ObjectIdentifier
+	found := false
+	if dec.ObjectId != nil {
+		oid = *dec.ObjectId
+		_, found = d.values[oid]
+	} else {
+		for o, name := range d.names {
+			if name == dec.ObjectName {
+				oid, found = o, true
+				break
+			}
+		}
+	}
+	name := d.names[oid]
+	d.mu.Unlock()
+	if !found {
+		return
+	}
+	if ihave, err := bacnet.NewIHave(d.instance, oid.ObjectType, oid.InstanceNumber, name); err == nil {
+		d.client.Send(addr, ihave)
+	}
+}
+
+func (d *Device) readProperty(addr net.Addr, m *services.ConfirmedReadProperty) {
+	dec, err := m.Decode()
+	if err != nil {
+		d.replyReject(addr, m.APDU, err)
+		return
+	}
+	oid := objects.ObjectIdentifier{ObjectType: dec.ObjectType, InstanceNumber: dec.InstanceNum}
+
+	d.mu.Lock()
+	tags := d.property(oid, dec.PropertyId)
+	d.mu.Unlock()
+	if tags == nil {
+		d.replyError(addr, m.APDU, oid)
+		return
+	}
+	values := make([]objects.APDUPayload, len(tags))
+	for i, o := range tags {
+		values[i] = o
+	}
+
+	cack := services.NewComplexACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	cack.APDU.Service = m.APDU.Service
+	cack.APDU.InvokeID = m.APDU.InvokeID
+	cack.APDU.Objects = append([]objects.APDUPayload{
+		objects.EncObjectIdentifier(true, 0, oid.ObjectType, oid.InstanceNumber),
+		objects.ContextTag(1, objects.EncUnsignedInteger(uint(dec.PropertyId))),
+		objects.EncOpeningTag(3),
+	}, append(values, objects.EncClosingTag(3))...)
+	cack.SetLength()
+	if b, err := cack.MarshalBinary(); err == nil {
+		d.client.Respond(context.Background(), addr, b, m.APDU)
+	}
+}
+
+// readPropertyMultiple answers with a result per property, the error of the
+// properties that cannot be read included. The objects hold no optional
+// property.
+func (d *Device) readPropertyMultiple(addr net.Addr, m *services.ConfirmedReadProperty) {
+	specs, err := m.DecodeRPMSpecs()
+	if err != nil {
+		d.replyReject(addr, m.APDU, err)
+		return
+	}
+
+	results := make([]services.ReadAccessResult, len(specs))
+	d.mu.Lock()
+	for i, spec := range specs {
+		results[i].ObjectId = spec.ObjectId
+		for _, ref := range spec.Properties {
+			switch ref.PropertyId {
+			case objects.PropertyIdAll, objects.PropertyIdRequired:
+				pids := d.propertyIds(spec.ObjectId)
+				if pids == nil {
+					results[i].Results = append(results[i].Results, d.readResult(spec.ObjectId, ref))
+				}
+				for _, pid := range pids {
+					results[i].Results = append(results[i].Results, d.readResult(spec.ObjectId,
+						objects.BACnetPropertyReference{PropertyId: pid, ArrayIndex: objects.ArrayAll}))
+				}
+			case objects.PropertyIdOptional:
+			default:
+				results[i].Results = append(results[i].Results, d.readResult(spec.ObjectId, ref))
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	objs, err := services.ReadPropertyMultipleACKObjects(results)
+	if err != nil {
+		return
+	}
+	cack := services.NewComplexACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	cack.APDU.Service = m.APDU.Service
+	cack.APDU.InvokeID = m.APDU.InvokeID
+	cack.APDU.Objects = objs
+	cack.SetLength()
+	if b, err := cack.MarshalBinary(); err == nil {
+		d.client.Respond(context.Background(), addr, b, m.APDU)
+	}
+}
+
+// readResult reads the property ref of oid for ReadPropertyMultiple. d.mu must
+// be held.
+func (d *Device) readResult(oid objects.ObjectIdentifier, ref objects.BACnetPropertyReference) services.PropertyResult {
+	res := services.PropertyResult{PropertyId: ref.PropertyId, ArrayIndex: ref.ArrayIndex}
+	_, known := d.values[oid]
+	tags := d.property(oid, ref.PropertyId)
+	switch {
+	case !known && !d.isDevice(oid):
+		res.Error = &services.PropertyAccessError{ErrorClass: objects.ErrorClassObject, ErrorCode: objects.ErrorCodeUnknownObject}
+	case tags == nil:
+		res.Error = &services.PropertyAccessError{ErrorClass: objects.ErrorClassProperty, ErrorCode: objects.ErrorCodeUnknownProperty}
+	case ref.ArrayIndex == objects.ArrayAll:
+		res.Tags = tags
+	case ref.PropertyId != objects.PropertyIdObjectList:
+		res.Error = &services.PropertyAccessError{ErrorClass: objects.ErrorClassProperty, ErrorCode: objects.ErrorCodePropertyIsNotAnArray}
+	case ref.ArrayIndex == 0:
+		res.Tags = []*objects.Object{objects.EncUnsignedInteger(uint(len(tags)))}
+	case ref.ArrayIndex <= uint32(len(tags)):
+		res.Tags = tags[ref.ArrayIndex-1 : ref.ArrayIndex]
+	default:
+		res.Error = &services.PropertyAccessError{ErrorClass: objects.ErrorClassProperty, ErrorCode: objects.ErrorCodeInvalidArrayIndex}
+	}
+	return res
+}
+
+// propertyIds returns the properties of oid, nil for the objects the Device
+// does not hold. d.mu must be held.
+func (d *Device) propertyIds(oid objects.ObjectIdentifier) []uint16 {
+	_, known := d.values[oid]
+	if !known && !d.isDevice(oid) {
+		return nil
+	}
+	pids := []uint16{objects.PropertyIdObjectIdentifier}
+	if _, ok := d.names[oid]; ok {
+		pids = append(pids, objects.PropertyIdObjectName)
+	}
+	if known {
+		return append(pids, objects.PropertyIdPresentValue)
+	}
+	return append(pids, objects.PropertyIdObjectList)
+}
+
+// property returns the tags of the value of the property propertyId of oid,
+// nil if the Device does not hold it. d.mu must be held.
+func (d *Device) property(oid objects.ObjectIdentifier, propertyId uint16) []*objects.Object {
+	v, known := d.values[oid]
+	if !known && !d.isDevice(oid) {
+		return nil
+	}
+	switch propertyId {
+	case objects.PropertyIdObjectIdentifier:
+		return []*objects.Object{objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, oid.ObjectType, oid.InstanceNumber)}
+	case objects.PropertyIdObjectName:
+		if name, ok := d.names[oid]; ok {
+			return []*objects.Object{objects.EncString(name)}
+		}
+	case objects.PropertyIdPresentValue:
+		if known {
+			return []*objects.Object{objects.EncReal(v)}
+		}
+	case objects.PropertyIdObjectList:
+		if d.isDevice(oid) {
+			return d.objectList()
+		}
+	}
+	return nil
+}
+
+func (d *Device) writeProperty(addr net.Addr, m *services.ConfirmedWriteProperty) {
+	dec, err := m.Decode()
+	if err != nil {
+		d.replyReject(addr, m.APDU, err)
+		return
+	}
+	oid := objects.ObjectIdentifier{ObjectType: dec.ObjectType, InstanceNumber: dec.InstanceNum}
+	var v float32
+	ok := dec.PropertyId == objects.PropertyIdPresentValue && len(dec.Tags) == 1
+	if ok {
+		v, ok = dec.Tags[0].Value.(float32)
+	}
+	if _, known := d.Value(oid); !known || !ok {
+		d.replyError(addr, m.APDU, oid)
+		return
+	}
+
+	d.mu.Lock()
+	d.values[oid] = v
+	d.mu.Unlock()
+	d.replySimpleACK(addr, m.APDU)
+	d.notify(oid, v)
+}
+
+// writePropertyMultiple writes the values in order, stopping at the first
+// that fails, as the standard has it.
+func (d *Device) writePropertyMultiple(addr net.Addr, m *services.ConfirmedWritePropertyMultiple) {
+	dec, err := m.Decode()
+	if err != nil {
+		d.replyReject(addr, m.APDU, err)
+		return
+	}
+	var written []objects.ObjectIdentifier
+	for _, spec := range dec.Specs {
+		for _, pv := range spec.Values {
+			ok := pv.PropertyId == objects.PropertyIdPresentValue && pv.ArrayIndex == objects.ArrayAll &&
+				len(pv.Value) == 1 && !pv.Value[0].TagClass && pv.Value[0].TagNumber == objects.TagReal
+			var v float32
+			if ok {
+				v, err = objects.DecReal(pv.Value[0])
+				ok = err == nil
+			}
+			if _, known := d.Value(spec.ObjectId); !known || !ok {
+				d.replyWPMError(addr, m.APDU, objects.BACnetObjectPropertyReference{
+					ObjectId:   spec.ObjectId,
+					PropertyId: pv.PropertyId,
+					ArrayIndex: pv.ArrayIndex,
+				})
+				d.notifyAll(written)
+				return
+			}
+			d.mu.Lock()
+			d.values[spec.ObjectId] = v
+			d.mu.Unlock()
+			written = append(written, spec.ObjectId)
+		}
+	}
+	d.replySimpleACK(addr, m.APDU)
+	d.notifyAll(written)
+}
+
+// notifyAll notifies the subscribers of the objects in oids of their present
+// values.
+func (d *Device) notifyAll(oids []objects.ObjectIdentifier) {
+	for _, oid := range oids {
+		if v, ok := d.Value(oid); ok {
+			d.notify(oid, v)
+		}
+	}
+}
+
+func (d *Device) subscribeCOV(addr net.Addr, m *services.ConfirmedCOV) {
+	dec, err := m.Decode()
+	if err != nil {
+		d.replyReject(addr, m.APDU, err)
+		return
+	}
+	oid := objects.ObjectIdentifier{ObjectType: dec.MonitoredObjType, InstanceNumber: dec.MonitoredInstNum}
+	v, ok := d.Value(oid)
+	if !ok {
+		d.replyError(addr, m.APDU, oid)
+		return
+	}
+
+	s := subscription{addr: addr, processId: dec.ProcessId, oid: oid, confirmed: dec.ExpectConfirmed}
+	if dec.Lifetime > 0 {
+		s.expires = time.Now().Add(time.Duration(dec.Lifetime) * time.Second)
+	}
+	// Cancellations carry the process ID and the object only.
+	cancel := len(m.APDU.Objects) == 2
+
+	d.mu.Lock()
+	subs := d.subscriptions[:0]
+	for _, old := range d.subscriptions {
+		if old.addr.String() != addr.String() || old.processId != s.processId || old.oid != oid {
+			subs = append(subs, old)
+		}
+	}
+	if !cancel {
+		subs = append(subs, s)
+	}
+	d.subscriptions = subs
+	d.mu.Unlock()
+
+	d.replySimpleACK(addr, m.APDU)
+	if !cancel {
+		d.send(s, v, time.Now())
+	}
+}
+
+// notify sends the present value v of oid to its subscribers, dropping the
+// expired subscriptions.
+func (d *Device) notify(oid objects.ObjectIdentifier, v float32) {
+	now := time.Now()
+	d.mu.Lock()
+	var subs []subscription
+	live := d.subscriptions[:0]
+	for _, s := range d.subscriptions {
+		if !s.expires.IsZero() && now.After(s.expires) {
+			continue
+		}
+		live = append(live, s)
+		if s.oid == oid {
+			subs = append(subs, s)
+		}
+	}
+	d.subscriptions = live
+	d.mu.Unlock()
+
+	for _, s := range subs {
+		d.send(s, v, now)
+	}
+}
+
+func (d *Device) send(s subscription, v float32, now time.Time) {
+	var remaining uint
+	if !s.expires.IsZero() {
+		remaining = uint(s.expires.Sub(now).Round(time.Second) / time.Second)
+	}
+	b, err := bacnet.NewCOVNotification(s.confirmed, uint(s.processId), d.instance,
+		s.oid.ObjectType, s.oid.InstanceNumber, remaining, v)
+	if err != nil {
+		return
+	}
+	if s.confirmed {
+		go d.client.Request(s.addr, b)
+		return
+	}
+	d.client.Send(s.addr, b)
+}
+
+// isDevice tells whether oid is the Device object.
+func (d *Device) isDevice(oid objects.ObjectIdentifier) bool {
+	return oid.ObjectType == objects.ObjectTypeDevice && oid.InstanceNumber == d.instance
+}
+
+// objectList returns the identifiers of the Device object and of the objects
+// it holds, sorted. d.mu must be held.
+func (d *Device) objectList() []*objects.Object {
+	oids := make([]objects.ObjectIdentifier, 0, len(d.values)+1)
+	oids = append(oids, objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: d.instance})
+	for oid := range d.values {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids[1:], func(i, j int) bool {
+		a, b := oids[i+1], oids[j+1]
+		return a.ObjectType < b.ObjectType || a.ObjectType == b.ObjectType && a.InstanceNumber < b.InstanceNumber
+	})
+
+	list := make([]*objects.Object, len(oids))
+	for i, oid := range oids {
+		list[i] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, oid.ObjectType, oid.InstanceNumber)
+	}
+	return list
+}
+
+func (d *Device) replyError(addr net.Addr, req *plumbing.APDU, oid objects.ObjectIdentifier) {
+	class, code := objects.ErrorClassProperty, objects.ErrorCodeUnknownProperty
+	if _, ok := d.Value(oid); !ok && !d.isDevice(oid) {
+		class, code = objects.ErrorClassObject, objects.ErrorCodeUnknownObject
+	}
+	e := services.NewError(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	e.APDU.Service = req.Service
+	e.APDU.InvokeID = req.InvokeID
+	e.APDU.Objects = services.ErrorObjects(class, code)
+	e.SetLength()
+	if b, err := e.MarshalBinary(); err == nil {
+		d.client.Send(addr, b)
+	}
+}
+
+// replyWPMError answers a WritePropertyMultiple whose write of ref failed.
+func (d *Device) replyWPMError(addr net.Addr, req *plumbing.APDU, ref objects.BACnetObjectPropertyReference) {
+	class, code := objects.ErrorClassProperty, objects.ErrorCodeUnknownProperty
+	if _, ok := d.Value(ref.ObjectId); !ok {
+		class, code = objects.ErrorClassObject, objects.ErrorCodeUnknownObject
+	}
+	objs, err := services.WritePropertyMultipleErrorObjects(class, code, ref)
+	if err != nil {
+		return
+	}
+	e := services.NewError(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	e.APDU.Service = req.Service
+	e.APDU.InvokeID = req.InvokeID
+	e.APDU.Objects = objs
+	e.SetLength()
+	if b, err := e.MarshalBinary(); err == nil {
+		d.client.Send(addr, b)
+	}
+}
+
+// replyReject rejects a request failing to decode with err.
+func (d *Device) replyReject(addr net.Addr, req *plumbing.APDU, err error) {
+	if b, err := bacnet.NewReject(req.InvokeID, rejectReason(err)); err == nil {
+		d.client.Send(addr, b)
+	}
+}
+
+// rejectReason returns the reason rejecting a request failing to decode with
+// err, RejectReasonOther when the error tells nothing more.
+func rejectReason(err error) services.RejectReason {
+	switch {
+	case errors.Is(err, common.ErrMissingParameter),
+		errors.Is(err, common.ErrTooShortToParse),
+		errors.Is(err, common.ErrWrongObjectCount):
+		return services.RejectReasonMissingRequiredParameter
+	case errors.Is(err, common.ErrWrongTagNumber), errors.Is(err, common.ErrWrongStructure):
+		return services.RejectReasonInvalidTag
+	case errors.Is(err, common.ErrTooBigValue):
+		return services.RejectReasonParameterOutOfRange
+	}
+	return services.RejectReasonOther
+}
+
+func (d *Device) replySimpleACK(addr net.Addr, req *plumbing.APDU) {
+	s := services.NewSimpleACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	s.APDU.Service = req.Service
+	s.APDU.InvokeID = req.InvokeID
+	s.SetLength()
+	if b, err := s.MarshalBinary(); err == nil {
+		d.client.Send(addr, b)
+	}
+}
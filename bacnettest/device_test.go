@@ -0,0 +1,420 @@
+package bacnettest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	"github.com/Nortech-ai/bacnet/transport"
+)
+
+var analogValue1 = objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 1}
+
+func newTestDevice(t *testing.T, network *transport.MemoryNetwork, mac byte, instance uint32, values map[objects.ObjectIdentifier]float32) (*Device, *transport.Addr) {
+	tr, err := network.Attach([]byte{mac})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDevice(tr, instance, values)
+	t.Cleanup(func() { d.Close() })
+	return d, &transport.Addr{BACnetAddress: tr.LocalAddress()}
+}
+
+func newTestClient(t *testing.T, network *transport.MemoryNetwork) *bacnet.Client {
+	tr, err := network.Attach([]byte{0xf0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := bacnet.NewTransportClient(tr)
+	c.APDUTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestDiscovery(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	for i := byte(1); i <= 3; i++ {
+		newTestDevice(t, network, i, 100+uint32(i), nil)
+	}
+	c := newTestClient(t, network)
+
+	var mu sync.Mutex
+	var found []uint32
+	iams := make(chan struct{}, 3)
+	c.Handle(func(_ net.Addr, msg plumbing.BACnet) {
+		iam, ok := msg.(*services.UnconfirmedIAm)
+		if !ok {
+			return
+		}
+		dec, err := iam.Decode()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		mu.Lock()
+		found = append(found, dec.InstanceNum)
+		mu.Unlock()
+		iams <- struct{}{}
+	})
+
+	whois, err := bacnet.NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&transport.Addr{}, whois); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-iams:
+		case <-time.After(time.Second):
+			t.Fatalf("found %v only", found)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+	if len(found) != 3 || found[0] != 101 || found[2] != 103 {
+		t.Errorf("unexpected devices %v", found)
+	}
+}
+
+func TestWhoHas(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	for i := byte(1); i <= 3; i++ {
+		d, _ := newTestDevice(t, network, i, 100+uint32(i), map[objects.ObjectIdentifier]float32{analogValue1: 0})
+		if i == 2 {
+			d.SetName(analogValue1, "AHU-2 Supply Temp")
+		}
+	}
+	c := newTestClient(t, network)
+
+	ihaves := make(chan services.UnconfirmedIHaveDec, 3)
+	c.Handle(func(_ net.Addr, msg plumbing.BACnet) {
+		ihave, ok := msg.(*services.UnconfirmedIHave)
+		if !ok {
+			return
+		}
+		dec, err := ihave.Decode()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		ihaves <- dec
+	})
+	expect := func(instances ...uint32) {
+		t.Helper()
+		var found []uint32
+		for range instances {
+			select {
+			case dec := <-ihaves:
+				found = append(found, dec.DeviceId.InstanceNumber)
+			case <-time.After(time.Second):
+				t.Fatalf("found %v only", found)
+			}
+		}
+		sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+		for i := range instances {
+			if found[i] != instances[i] {
+				t.Fatalf("expected %v, found %v", instances, found)
+			}
+		}
+		select {
+		case dec := <-ihaves:
+			t.Errorf("unexpected I-Have %+v", dec)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	byName, err := bacnet.NewWhoHasName(-1, -1, "AHU-2 Supply Temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&transport.Addr{}, byName); err != nil {
+		t.Fatal(err)
+	}
+	expect(102)
+
+	byId, err := bacnet.NewWhoHas(102, 103, analogValue1.ObjectType, analogValue1.InstanceNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&transport.Addr{}, byId); err != nil {
+		t.Fatal(err)
+	}
+	expect(102, 103)
+}
+
+func TestRetries(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	_, addr := newTestDevice(t, network, 1, 1, map[objects.ObjectIdentifier]float32{analogValue1: 21.5})
+	c := newTestClient(t, network)
+	c.APDURetries = 5
+	network.SetImpairments(transport.Impairments{Loss: 0.2, Duplication: 0.1, Seed: 1})
+
+	for i := 0; i < 20; i++ {
+		value, err := c.ReadProperty(context.Background(), addr, analogValue1, objects.PropertyIdPresentValue, objects.ArrayAll)
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		if value != float32(21.5) {
+			t.Fatalf("read %d: unexpected value %v", i, value)
+		}
+	}
+	if stats := network.Stats(); stats.Lost == 0 || stats.Duplicated == 0 {
+		t.Errorf("expected losses and duplicates, got %+v", stats)
+	}
+}
+
+func TestWritePropertyMultiple(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	analogValue2 := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 2}
+	d, addr := newTestDevice(t, network, 1, 1, map[objects.ObjectIdentifier]float32{analogValue1: 1, analogValue2: 2})
+	c := newTestClient(t, network)
+
+	presentValue := func(v float32) []objects.BACnetPropertyValue {
+		return []objects.BACnetPropertyValue{{
+			PropertyId: objects.PropertyIdPresentValue,
+			ArrayIndex: objects.ArrayAll,
+			Value:      []*objects.Object{objects.EncReal(v)},
+		}}
+	}
+	if err := c.WritePropertyMultiple(context.Background(), addr, []services.WriteAccessSpecification{
+		{ObjectId: analogValue1, Values: presentValue(10)},
+		{ObjectId: analogValue2, Values: presentValue(20)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for oid, want := range map[objects.ObjectIdentifier]float32{analogValue1: 10, analogValue2: 20} {
+		if v, _ := d.Value(oid); v != want {
+			t.Errorf("%v: expected %v, got %v", oid, want, v)
+		}
+	}
+
+	unknown := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	err := c.WritePropertyMultiple(context.Background(), addr, []services.WriteAccessSpecification{
+		{ObjectId: analogValue1, Values: presentValue(11)},
+		{ObjectId: unknown, Values: presentValue(30)},
+		{ObjectId: analogValue2, Values: presentValue(21)},
+	})
+	var wpmErr *bacnet.WritePropertyMultipleError
+	if !errors.As(err, &wpmErr) {
+		t.Fatalf("expected a WritePropertyMultipleError, got %v", err)
+	}
+	want := objects.BACnetObjectPropertyReference{
+		ObjectId: unknown, PropertyId: objects.PropertyIdPresentValue, ArrayIndex: objects.ArrayAll,
+	}
+	if wpmErr.FirstFailedWrite != want || wpmErr.ErrorCode != objects.ErrorCodeUnknownObject {
+		t.Errorf("unexpected error %v", wpmErr)
+	}
+	var svcErr *bacnet.ServiceError
+	if !errors.As(err, &svcErr) || svcErr.Service != services.ServiceConfirmedWritePropMultiple {
+		t.Errorf("expected a ServiceError, got %v", err)
+	}
+	if !errors.Is(err, objects.ErrUnknownObject) {
+		t.Errorf("expected %v, got %v", objects.ErrUnknownObject, err)
+	}
+	// The writes before the failed one are done, not those after it.
+	if v, _ := d.Value(analogValue1); v != 11 {
+		t.Errorf("expected 11, got %v", v)
+	}
+	if v, _ := d.Value(analogValue2); v != 20 {
+		t.Errorf("expected 20, got %v", v)
+	}
+}
+
+func TestReadPropertyMultiple(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	d, addr := newTestDevice(t, network, 1, 5, map[objects.ObjectIdentifier]float32{analogValue1: 21.5})
+	d.SetName(analogValue1, "AHU-1 Supply Temp")
+	c := newTestClient(t, network)
+
+	device := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 5}
+	unknown := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 2}
+	all := objects.BACnetPropertyReference{PropertyId: objects.PropertyIdAll, ArrayIndex: objects.ArrayAll}
+	results, err := c.ReadPropertyMultiple(context.Background(), addr, []services.ReadAccessSpecification{
+		{ObjectId: analogValue1, Properties: []objects.BACnetPropertyReference{all}},
+		{ObjectId: device, Properties: []objects.BACnetPropertyReference{
+			{PropertyId: objects.PropertyIdObjectList, ArrayIndex: 0},
+			{PropertyId: objects.PropertyIdObjectList, ArrayIndex: 2},
+			{PropertyId: objects.PropertyIdOptional, ArrayIndex: objects.ArrayAll},
+			{PropertyId: objects.PropertyIdDescription, ArrayIndex: objects.ArrayAll},
+		}},
+		{ObjectId: unknown, Properties: []objects.BACnetPropertyReference{all}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[uint16]interface{}{}
+	for _, r := range results[analogValue1] {
+		if r.Error != nil {
+			t.Errorf("property %d: %v", r.PropertyId, r.Error)
+		}
+		values[r.PropertyId] = r.Value
+	}
+	want := map[uint16]interface{}{
+		objects.PropertyIdObjectIdentifier: analogValue1,
+		objects.PropertyIdObjectName:       "AHU-1 Supply Temp",
+		objects.PropertyIdPresentValue:     float32(21.5),
+	}
+	if len(values) != len(want) {
+		t.Errorf("expected %v, got %v", want, values)
+	}
+	for pid, v := range want {
+		if values[pid] != v {
+			t.Errorf("property %d: expected %v, got %v", pid, v, values[pid])
+		}
+	}
+
+	dev := results[device]
+	if len(dev) != 3 {
+		t.Fatalf("unexpected device results %+v", dev)
+	}
+	if dev[0].Value != uint32(2) || dev[1].Value != analogValue1 {
+		t.Errorf("unexpected object list results %+v %+v", dev[0], dev[1])
+	}
+	if dev[2].Error == nil || dev[2].Error.ErrorCode != objects.ErrorCodeUnknownProperty {
+		t.Errorf("expected unknown property, got %+v", dev[2])
+	}
+
+	if r := results[unknown]; len(r) != 1 || r[0].Error == nil || r[0].Error.ErrorCode != objects.ErrorCodeUnknownObject {
+		t.Errorf("expected unknown object, got %+v", r)
+	}
+}
+
+func TestReject(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	_, addr := newTestDevice(t, network, 1, 1, map[objects.ObjectIdentifier]float32{analogValue1: 1})
+	c := newTestClient(t, network)
+
+	// A WritePropertyMultiple without object identifier.
+	wpm := services.NewConfirmedWritePropertyMultiple(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, true))
+	wpm.APDU.MaxSize = 5
+	wpm.APDU.Objects = []objects.APDUPayload{objects.EncReal(1)}
+	wpm.SetLength()
+	req, err := wpm.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Request(addr, req)
+	var rejectErr *bacnet.RejectError
+	if !errors.As(err, &rejectErr) || rejectErr.Reason != services.RejectReasonInvalidTag {
+		t.Errorf("expected a RejectError, got %v", err)
+	}
+
+	// A WritePropertyMultiple without values.
+	wpm.APDU.Objects = []objects.APDUPayload{
+		objects.EncObjectIdentifier(true, 0, analogValue1.ObjectType, analogValue1.InstanceNumber),
+	}
+	wpm.SetLength()
+	if req, err = wpm.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Request(addr, req)
+	if !errors.As(err, &rejectErr) || rejectErr.Reason != services.RejectReasonMissingRequiredParameter {
+		t.Errorf("expected a RejectError for a missing parameter, got %v", err)
+	}
+}
+
+func TestSegmentation(t *testing.T) {
+	const count = 400
+	values := map[objects.ObjectIdentifier]float32{}
+	for i := uint32(0); i < count; i++ {
+		values[objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: i}] = 0
+	}
+	network := transport.NewMemoryNetwork()
+	network.SetImpairments(transport.Impairments{Delay: time.Millisecond})
+	_, addr := newTestDevice(t, network, 1, 7, values)
+	c := newTestClient(t, network)
+	c.APDUTimeout = time.Second
+
+	device := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 7}
+	value, err := c.ReadProperty(context.Background(), addr, device, objects.PropertyIdObjectList, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := value.([]objects.ObjectIdentifier)
+	if !ok || len(list) != count+1 {
+		t.Fatalf("expected %d objects, got %v", count+1, value)
+	}
+	if list[count].InstanceNumber != count-1 {
+		t.Errorf("unexpected last object %v", list[count])
+	}
+}
+
+func TestCOV(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	d, addr := newTestDevice(t, network, 1, 9, map[objects.ObjectIdentifier]float32{analogValue1: 1})
+	c := newTestClient(t, network)
+	c.APDUTimeout = time.Second
+
+	notifications := make(chan float32, 4)
+	c.Handle(func(_ net.Addr, msg plumbing.BACnet) {
+		n, ok := msg.(*services.COVNotification)
+		if !ok {
+			return
+		}
+		dec, err := n.Decode()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if dec.ProcessId != 42 || dec.DevInstanceNum != 9 || len(dec.Tags) < 2 ||
+			len(dec.Values) != 2 || dec.Values[0].PropertyId != objects.PropertyIdPresentValue {
+			t.Errorf("unexpected notification %+v", dec)
+			return
+		}
+		notifications <- dec.Tags[1].Value.(float32)
+	})
+	expect := func(want float32) {
+		t.Helper()
+		select {
+		case v := <-notifications:
+			if v != want {
+				t.Errorf("expected %v, got %v", want, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no notification of %v", want)
+		}
+	}
+
+	subscribe, err := bacnet.NewSubscribeCOV(analogValue1.ObjectType, analogValue1.InstanceNumber, 42, 60, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Request(addr, subscribe); err != nil {
+		t.Fatal(err)
+	}
+	expect(1)
+
+	if err := c.WriteProperty(context.Background(), addr, analogValue1, objects.PropertyIdPresentValue,
+		objects.ArrayAll, float32(2), 0); err != nil {
+		t.Fatal(err)
+	}
+	expect(2)
+	d.SetValue(analogValue1, 3)
+	expect(3)
+
+	cancel, err := bacnet.NewSubscribeCOV(analogValue1.ObjectType, analogValue1.InstanceNumber, 42, 0, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Request(addr, cancel); err != nil {
+		t.Fatal(err)
+	}
+	d.SetValue(analogValue1, 4)
+	select {
+	case v := <-notifications:
+		t.Errorf("unexpected notification of %v after cancelling", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,329 @@
+// Package bbmd implements a BACnet Broadcast Management Device, as defined in
+// Annex J of the standard, forwarding BACnet/IP broadcasts between subnets and
+// to registered foreign devices.
+package bbmd
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+// fdtGracePeriod is added to the TTL foreign devices register with.
+const fdtGracePeriod = 30 * time.Second
+
+const maxFrameLen = 1 << 16
+
+// BBMD forwards the broadcasts of its subnet to the peers of its Broadcast
+// Distribution Table and to its foreign devices, and the broadcasts they
+// forward to its subnet. It is safe for concurrent use.
+type BBMD struct {
+	conn      net.PacketConn
+	addr      *net.UDPAddr
+	broadcast *net.UDPAddr
+
+	mu  sync.Mutex
+	bdt []services.BDTEntry
+	fdt map[string]*fdtEntry
+	// now is replaced by tests to expire foreign devices.
+	now func() time.Time
+}
+
+type fdtEntry struct {
+	addr    *net.UDPAddr
+	ttl     uint16
+	expires time.Time
+}
+
+// New creates a BBMD serving on conn, which should be bound to the B/IP
+// address of the BBMD as listed in the BDT, and broadcasting on its subnet to
+// broadcast. Several BBMDs can run on a single host by giving each of them a
+// loopback address and port of its own, and a broadcast address where the
+// devices of its simulated subnet listen.
+func New(conn net.PacketConn, broadcast *net.UDPAddr) *BBMD {
+	addr, _ := conn.LocalAddr().(*net.UDPAddr)
+	return &BBMD{
+		conn:      conn,
+		addr:      addr,
+		broadcast: broadcast,
+		fdt:       map[string]*fdtEntry{},
+		now:       time.Now,
+	}
+}
+
+// SetBDT replaces the Broadcast Distribution Table, which should list the
+// BBMD itself along with its peers.
+func (b *BBMD) SetBDT(bdt []services.BDTEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bdt = append([]services.BDTEntry{}, bdt...)
+}
+
+// BDT returns the Broadcast Distribution Table.
+func (b *BBMD) BDT() []services.BDTEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]services.BDTEntry{}, b.bdt...)
+}
+
+// FDT returns the Foreign Device Table, purged of expired registrations.
+func (b *BBMD) FDT() []services.FDTEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.purge(now)
+	fdt := make([]services.FDTEntry, 0, len(b.fdt))
+	for _, e := range b.fdt {
+		// The grace period can take the remaining time past 16 bits.
+		remaining := math.Min(math.Ceil(e.expires.Sub(now).Seconds()), math.MaxUint16)
+		fdt = append(fdt, services.FDTEntry{
+			Address:   e.addr,
+			TTL:       e.ttl,
+			Remaining: uint16(remaining),
+		})
+	}
+	return fdt
+}
+
+// Serve handles the messages received on the BBMD socket until it is closed.
+func (b *BBMD) Serve() error {
+	buf := make([]byte, maxFrameLen)
+	for {
+		n, addr, err := b.conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("reading: %v", err)
+		}
+		src, ok := addr.(*net.UDPAddr)
+		if !ok || b.isSelf(src) {
+			continue
+		}
+		msg := make([]byte, n)
+		copy(msg, buf[:n])
+		b.handle(src, msg)
+	}
+}
+
+// Close stops the BBMD and closes its socket.
+func (b *BBMD) Close() error {
+	return b.conn.Close()
+}
+
+func (b *BBMD) handle(src *net.UDPAddr, msg []byte) {
+	var bvlc plumbing.BVLC
+	if err := bvlc.UnmarshalBinary(msg); err != nil || bvlc.Type != plumbing.BVLCType {
+		return
+	}
+
+	switch bvlc.Function {
+	case plumbing.BVLCFuncBroadcast:
+		// Broadcasts on our subnet go to every peer and foreign device.
+		fwd, err := bacnet.SetBVLCFunction(msg, plumbing.BVLCFuncForwardedNPDU, src)
+		if err != nil {
+			return
+		}
+		b.forwardToPeers(fwd)
+		b.forwardToForeignDevices(fwd, nil)
+	case plumbing.BVLCFuncForwardedNPDU:
+		// Only the peers of the BDT forward broadcasts to us.
+		peer, ok := b.peer(src)
+		if !ok {
+			return
+		}
+		// Broadcasts forwarded by a peer as unicast are broadcast on our
+		// subnet, while directed broadcasts already reached it.
+		if unicastMask(peer.Mask) {
+			b.send(b.broadcast, msg)
+		}
+		b.forwardToForeignDevices(msg, nil)
+	case plumbing.BVLCFuncDistributeBroadcast:
+		if !b.isForeignDevice(src) {
+			b.result(src, plumbing.BVLCResultDistributeBroadcastNAK)
+			return
+		}
+		fwd, err := bacnet.SetBVLCFunction(msg, plumbing.BVLCFuncForwardedNPDU, src)
+		if err != nil {
+			return
+		}
+		b.send(b.broadcast, fwd)
+		b.forwardToPeers(fwd)
+		b.forwardToForeignDevices(fwd, src)
+	case plumbing.BVLCFuncRegisterForeignDevice:
+		dec, err := b.decode(msg)
+		if err != nil {
+			b.result(src, plumbing.BVLCResultRegisterForeignDeviceNAK)
+			return
+		}
+		b.register(src, dec.TTL)
+		b.result(src, plumbing.BVLCResultSuccessful)
+	case plumbing.BVLCFuncDeleteFDTEntry:
+		dec, err := b.decode(msg)
+		if err != nil || !b.deregister(dec.Address) {
+			b.result(src, plumbing.BVLCResultDeleteFDTEntryNAK)
+			return
+		}
+		b.result(src, plumbing.BVLCResultSuccessful)
+	case plumbing.BVLCFuncReadBDT:
+		reply, err := bacnet.NewReadBDTAck(b.BDT())
+		if err != nil {
+			b.result(src, plumbing.BVLCResultReadBDTNAK)
+			return
+		}
+		b.send(src, reply)
+	case plumbing.BVLCFuncWriteBDT:
+		dec, err := b.decode(msg)
+		if err != nil {
+			b.result(src, plumbing.BVLCResultWriteBDTNAK)
+			return
+		}
+		b.SetBDT(dec.BDT)
+		b.result(src, plumbing.BVLCResultSuccessful)
+	case plumbing.BVLCFuncReadFDT:
+		reply, err := bacnet.NewReadFDTAck(b.FDT())
+		if err != nil {
+			b.result(src, plumbing.BVLCResultReadFDTNAK)
+			return
+		}
+		b.send(src, reply)
+	}
+}
+
+func (b *BBMD) decode(msg []byte) (services.BVLLMessageDec, error) {
+	m := services.NewBVLLMessage(&plumbing.BVLC{})
+	if err := m.UnmarshalBinary(msg); err != nil {
+		return services.BVLLMessageDec{}, err
+	}
+	return m.Decode()
+}
+
+// forwardToPeers sends the Forwarded-NPDU fwd to every peer of the BDT, as
+// a directed broadcast to its subnet unless its mask is all ones.
+func (b *BBMD) forwardToPeers(fwd []byte) {
+	for _, e := range b.BDT() {
+		if b.isSelf(e.Address) {
+			continue
+		}
+		dst := &net.UDPAddr{IP: make(net.IP, net.IPv4len), Port: e.Address.Port}
+		ip := e.Address.IP.To4()
+		for i := range dst.IP {
+			dst.IP[i] = ip[i] | ^maskByte(e.Mask, i)
+		}
+		b.send(dst, fwd)
+	}
+}
+
+// forwardToForeignDevices sends fwd to every foreign device but except, if any.
+func (b *BBMD) forwardToForeignDevices(fwd []byte, except *net.UDPAddr) {
+	b.mu.Lock()
+	b.purge(b.now())
+	dsts := make([]*net.UDPAddr, 0, len(b.fdt))
+	for _, e := range b.fdt {
+		if except == nil || e.addr.String() != except.String() {
+			dsts = append(dsts, e.addr)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, dst := range dsts {
+		b.send(dst, fwd)
+	}
+}
+
+// peer returns the entry of the BDT of src, if any.
+func (b *BBMD) peer(src *net.UDPAddr) (services.BDTEntry, bool) {
+	for _, e := range b.BDT() {
+		if sameAddr(e.Address, src) {
+			return e, true
+		}
+	}
+	return services.BDTEntry{}, false
+}
+
+// unicastMask tells whether a peer with the mask m of the BDT forwards
+// broadcasts as unicast, that is whether m is all ones.
+func unicastMask(m net.IPMask) bool {
+	for i := 0; i < net.IPv4len; i++ {
+		if maskByte(m, i) != 0xFF {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BBMD) register(addr *net.UDPAddr, ttl uint16) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fdt[addr.String()] = &fdtEntry{
+		addr:    addr,
+		ttl:     ttl,
+		expires: b.now().Add(time.Duration(ttl)*time.Second + fdtGracePeriod),
+	}
+}
+
+func (b *BBMD) deregister(addr *net.UDPAddr) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.purge(b.now())
+	if _, ok := b.fdt[addr.String()]; !ok {
+		return false
+	}
+	delete(b.fdt, addr.String())
+	return true
+}
+
+func (b *BBMD) isForeignDevice(addr *net.UDPAddr) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.purge(b.now())
+	_, ok := b.fdt[addr.String()]
+	return ok
+}
+
+// purge removes the expired foreign devices. b.mu must be held.
+func (b *BBMD) purge(now time.Time) {
+	for k, e := range b.fdt {
+		if !now.Before(e.expires) {
+			delete(b.fdt, k)
+		}
+	}
+}
+
+func (b *BBMD) result(dst *net.UDPAddr, code uint16) {
+	reply, err := bacnet.NewBVLCResult(code)
+	if err != nil {
+		return
+	}
+	b.send(dst, reply)
+}
+
+func (b *BBMD) send(dst *net.UDPAddr, msg []byte) {
+	if dst == nil {
+		return
+	}
+	b.conn.WriteTo(msg, dst)
+}
+
+func (b *BBMD) isSelf(addr *net.UDPAddr) bool {
+	return sameAddr(b.addr, addr)
+}
+
+func sameAddr(a, b *net.UDPAddr) bool {
+	return a != nil && b != nil && a.IP.Equal(b.IP) && a.Port == b.Port
+}
+
+// maskByte returns the i-th byte of the IPv4 mask m, missing masks being all ones.
+func maskByte(m net.IPMask, i int) byte {
+	if len(m) == net.IPv6len {
+		m = m[12:]
+	}
+	if len(m) != net.IPv4len {
+		return 0xFF
+	}
+	return m[i]
+}
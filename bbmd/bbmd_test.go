@@ -0,0 +1,196 @@
+package bbmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+// newSubnets runs two BBMDs peering with each other, each on a loopback
+// address of its own with a device listening on its subnet broadcast address.
+func newSubnets(t *testing.T) (bbmds [2]*BBMD, devices [2]net.PacketConn) {
+	var bdt []services.BDTEntry
+	for i := range bbmds {
+		devices[i] = Listen(t, fmt.Sprintf("127.0.0.%d", 3+i))
+		conn := Listen(t, fmt.Sprintf("127.0.0.%d", 1+i))
+		bbmds[i] = New(conn, devices[i].LocalAddr().(*net.UDPAddr))
+		bdt = append(bdt, services.BDTEntry{
+			Address: conn.LocalAddr().(*net.UDPAddr),
+			Mask:    net.IPv4Mask(0xff, 0xff, 0xff, 0xff),
+		})
+	}
+	for _, b := range bbmds {
+		b.SetBDT(bdt)
+		go b.Serve()
+	}
+	return bbmds, devices
+}
+
+func TestForwardBroadcast(t *testing.T) {
+	bbmds, devices := newSubnets(t)
+
+	// The broadcast of the device on the first subnet reaches its BBMD.
+	whois, err := bacnet.NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices[0].WriteTo(whois, bbmds[0].conn.LocalAddr())
+
+	msg, ok := Receive(t, devices[1], bacnet.Parse).(*services.UnconfirmedWhoIs)
+	if !ok {
+		t.Fatalf("expected WhoIs, got %T", msg)
+	}
+	if msg.BVLC.Function != plumbing.BVLCFuncForwardedNPDU {
+		t.Errorf("expected Forwarded-NPDU, got function %x", msg.BVLC.Function)
+	}
+	if got, want := msg.BVLC.Origin.String(), devices[0].LocalAddr().String(); got != want {
+		t.Errorf("expected origin %s, got %s", want, got)
+	}
+}
+
+func TestForwardedFromStranger(t *testing.T) {
+	bbmds, devices := newSubnets(t)
+	fd := Listen(t, "127.0.0.5")
+	bbmds[0].register(fd.LocalAddr().(*net.UDPAddr), 60)
+
+	// Forwarded-NPDUs from outside the BDT reach neither the subnet nor the
+	// foreign devices.
+	whois, err := bacnet.NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fwd, err := bacnet.SetBVLCFunction(whois, plumbing.BVLCFuncForwardedNPDU, devices[1].LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices[1].WriteTo(fwd, bbmds[0].conn.LocalAddr())
+
+	buf := make([]byte, 1500)
+	for _, conn := range []net.PacketConn{devices[0], fd} {
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, _, err := conn.ReadFrom(buf); err == nil {
+			t.Errorf("expected the Forwarded-NPDU to be dropped, got it on %s", conn.LocalAddr())
+		}
+	}
+}
+
+func TestForeignDevice(t *testing.T) {
+	bbmds, devices := newSubnets(t)
+
+	c := bacnet.NewClient(Listen(t, "127.0.0.5"))
+	c.APDUTimeout = 100 * time.Millisecond
+	fd, err := c.RegisterForeignDevice(context.Background(), bbmds[1].conn.LocalAddr(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fdt := bbmds[1].FDT()
+	if len(fdt) != 1 || fdt[0].TTL != 60 || fdt[0].Remaining != 90 {
+		t.Errorf("unexpected FDT %+v", fdt)
+	}
+
+	// Broadcasts of the foreign device reach both subnets.
+	whois, err := bacnet.NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&net.UDPAddr{IP: net.IPv4bcast, Port: 47808}, whois); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range devices {
+		if _, ok := Receive(t, d, bacnet.Parse).(*services.UnconfirmedWhoIs); !ok {
+			t.Errorf("expected WhoIs on %s", d.LocalAddr())
+		}
+	}
+
+	// And the foreign device gets the broadcasts of both subnets.
+	iams := make(chan net.Addr, 2)
+	c.Handle(func(addr net.Addr, msg plumbing.BACnet) {
+		if _, ok := msg.(*services.UnconfirmedIAm); ok {
+			iams <- addr
+		}
+	})
+	iam, err := bacnet.NewIAm(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, d := range devices {
+		d.WriteTo(iam, bbmds[i].conn.LocalAddr())
+		select {
+		case addr := <-iams:
+			if addr.String() != d.LocalAddr().String() {
+				t.Errorf("expected IAm from %s, got %s", d.LocalAddr(), addr)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no IAm from %s", d.LocalAddr())
+		}
+	}
+
+	bbmds[1].mu.Lock()
+	bbmds[1].now = func() time.Time { return time.Now().Add(91 * time.Second) }
+	bbmds[1].mu.Unlock()
+	if fdt := bbmds[1].FDT(); len(fdt) != 0 {
+		t.Errorf("expected expired FDT, got %+v", fdt)
+	}
+
+	// The remaining time of the longest TTL doesn't wrap around.
+	bbmds[1].register(c.LocalAddr().(*net.UDPAddr), math.MaxUint16)
+	if fdt := bbmds[1].FDT(); len(fdt) != 1 || fdt[0].Remaining != math.MaxUint16 {
+		t.Errorf("unexpected FDT %+v", fdt)
+	}
+
+	// Closing the registration deletes the entry.
+	if err := fd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fdt := bbmds[1].FDT(); len(fdt) != 0 {
+		t.Errorf("expected empty FDT, got %+v", fdt)
+	}
+}
+
+func TestReadWriteBDT(t *testing.T) {
+	bbmds, devices := newSubnets(t)
+	bbmd := bbmds[0].conn.LocalAddr()
+
+	bdt := []services.BDTEntry{{
+		Address: bbmd.(*net.UDPAddr),
+		Mask:    net.IPv4Mask(0xff, 0xff, 0xff, 0),
+	}}
+	req, err := bacnet.NewWriteBDT(bdt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices[0].WriteTo(req, bbmd)
+	result, ok := Receive(t, devices[0], bacnet.Parse).(*services.BVLLMessage)
+	if !ok {
+		t.Fatalf("expected BVLC-Result, got %v", result)
+	}
+	if dec, err := result.Decode(); err != nil || dec.ResultCode != plumbing.BVLCResultSuccessful {
+		t.Errorf("expected successful result, got %+v %v", dec, err)
+	}
+
+	req, err = bacnet.NewReadBDT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices[0].WriteTo(req, bbmd)
+	ack, ok := Receive(t, devices[0], bacnet.Parse).(*services.BVLLMessage)
+	if !ok || ack.GetType() != plumbing.BVLCFuncReadBDTAck {
+		t.Fatalf("expected Read-BDT-Ack, got %v", ack)
+	}
+	dec, err := ack.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.BDT) != 1 || dec.BDT[0].Address.String() != bbmd.String() || dec.BDT[0].Mask.String() != "ffffff00" {
+		t.Errorf("unexpected BDT %+v", dec.BDT)
+	}
+}
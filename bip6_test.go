@@ -0,0 +1,92 @@
+package bacnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	"github.com/Nortech-ai/bacnet/transport"
+)
+
+// newBIP6Pair returns the BACnet/IPv6 Clients of instances 1 and 2 on the
+// IPv6 loopback, each multicasting to the other one.
+func newBIP6Pair(t *testing.T) (*Client, *Client) {
+	var conns [2]net.PacketConn
+	for i := range conns {
+		conn, err := net.ListenPacket("udp6", "[::1]:0")
+		if err != nil {
+			t.Skipf("no IPv6 loopback: %v", err)
+		}
+		conns[i] = conn
+	}
+	var clients [2]*Client
+	for i := range clients {
+		c, err := NewBIP6Client(conns[i], plumbing.NewVMAC(uint32(i+1)), conns[1-i].LocalAddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.APDUTimeout = time.Second
+		t.Cleanup(func() { c.Close() })
+		clients[i] = c
+	}
+	return clients[0], clients[1]
+}
+
+func TestClientBIP6Request(t *testing.T) {
+	c, srv := newBIP6Pair(t)
+	srv.Handle(func(addr net.Addr, msg plumbing.BACnet) {
+		req, ok := msg.(*services.ConfirmedWriteProperty)
+		if !ok {
+			return
+		}
+		if a, ok := addr.(*transport.Addr); !ok || !bytes.Equal(a.Mac, c.VMAC()) {
+			t.Errorf("expected request from VMAC %x, got %s", c.VMAC(), addr)
+		}
+		sack := services.NewSimpleACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+		sack.APDU.Service = req.APDU.Service
+		sack.APDU.InvokeID = req.APDU.InvokeID
+		sack.SetLength()
+		reply, err := sack.MarshalBinary()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if err := srv.Send(addr, reply); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The server is addressed by its VMAC, resolved with an Address-Resolution.
+	if a, ok := srv.LocalAddr().(*transport.Addr); !ok || !bytes.Equal(a.Mac, srv.VMAC()) {
+		t.Fatalf("expected the address of VMAC %x, got %s", srv.VMAC(), srv.LocalAddr())
+	}
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	if err := c.WriteProperty(context.Background(), srv.LocalAddr(), oid, objects.PropertyIdPresentValue,
+		objects.ArrayAll, float32(1), 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientBIP6ResolutionCancel(t *testing.T) {
+	c, _ := newBIP6Pair(t)
+	// No node has VMAC 99, so nobody ever answers its resolution.
+	addr := &transport.Addr{BACnetAddress: plumbing.NewBACnetAddress(plumbing.LocalNetwork, plumbing.NewVMAC(99))}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	if err := c.WriteProperty(ctx, addr, oid, objects.PropertyIdPresentValue,
+		objects.ArrayAll, float32(1), 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d >= time.Second {
+		t.Errorf("resolution took %s", d)
+	}
+}
@@ -0,0 +1,119 @@
+package bacnet
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+// ReadProperty reads a property from the device at addr and returns its decoded
+// value. The properties known to the schema of the objects package decode to the
+// Go type of their datatype, such as objects.EngineeringUnits or a
+// []objects.ObjectIdentifier. The others, and the values not fitting the
+// schema, come back as their application tags decode, a []interface{} for
+// several values. Pass objects.ArrayAll as arrayIndex to read the whole
+// property.
+func (c *Client) ReadProperty(ctx context.Context, addr net.Addr, oid objects.ObjectIdentifier, propertyId uint16, arrayIndex uint32) (interface{}, error) {
+	req, err := NewReadPropertyArray(oid.ObjectType, oid.InstanceNumber, propertyId, arrayIndex)
+	if err != nil {
+		return nil, fmt.Errorf("building ReadProperty: %w", err)
+	}
+
+	reply, err := c.RequestContext(ctx, addr, req)
+	if err != nil {
+		return nil, err
+	}
+
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		return nil, fmt.Errorf("ReadProperty reply %T: %v", reply, common.ErrWrongPayload)
+	}
+	dec, err := cack.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("decoding ReadProperty reply: %v", err)
+	}
+	if dec.Value != nil {
+		return dec.Value, nil
+	}
+
+	switch len(dec.Tags) {
+	case 0:
+		return nil, fmt.Errorf("decoding ReadProperty reply: %v", common.ErrWrongObjectCount)
+	case 1:
+		return dec.Tags[0].Value, nil
+	}
+	values := make([]interface{}, len(dec.Tags))
+	for i, t := range dec.Tags {
+		values[i] = t.Value
+	}
+	return values, nil
+}
+
+// ReadPropertyMultiple reads the properties of specs from the device at addr
+// in a single request. The results are keyed by object, each holding either
+// the value of a property, decoded as by ReadProperty, or the error reading it.
+// Reading objects.PropertyIdAll and the like returns a result per property.
+func (c *Client) ReadPropertyMultiple(ctx context.Context, addr net.Addr, specs []services.ReadAccessSpecification) (map[objects.ObjectIdentifier][]services.PropertyResult, error) {
+	req, err := NewReadPropertyMultipleSpecs(specs)
+	if err != nil {
+		return nil, fmt.Errorf("building ReadPropertyMultiple: %w", err)
+	}
+
+	reply, err := c.RequestContext(ctx, addr, req)
+	if err != nil {
+		return nil, err
+	}
+
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		return nil, fmt.Errorf("ReadPropertyMultiple reply %T: %v", reply, common.ErrWrongPayload)
+	}
+	results, err := cack.DecodeRPMResults()
+	if err != nil {
+		return nil, fmt.Errorf("decoding ReadPropertyMultiple reply: %v", err)
+	}
+	return results, nil
+}
+
+// WriteProperty writes value to a property of the device at addr. The value can be
+// anything objects.EncValue accepts. A priority of 0 writes without priority.
+func (c *Client) WriteProperty(ctx context.Context, addr net.Addr, oid objects.ObjectIdentifier, propertyId uint16, arrayIndex uint32, value interface{}, priority uint8) error {
+	req, err := NewWritePropertyValue(oid.ObjectType, oid.InstanceNumber, propertyId, arrayIndex, value, priority)
+	if err != nil {
+		return fmt.Errorf("building WriteProperty: %w", err)
+	}
+
+	reply, err := c.RequestContext(ctx, addr, req)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		return fmt.Errorf("WriteProperty reply %T: %v", reply, common.ErrWrongPayload)
+	}
+	return nil
+}
+
+// WritePropertyMultiple writes the values of specs to the device at addr in a
+// single request. When a write fails, the error is a *WritePropertyMultipleError
+// telling which one; the writes before it have been done.
+func (c *Client) WritePropertyMultiple(ctx context.Context, addr net.Addr, specs []services.WriteAccessSpecification) error {
+	req, err := NewWritePropertyMultiple(specs)
+	if err != nil {
+		return fmt.Errorf("building WritePropertyMultiple: %w", err)
+	}
+
+	reply, err := c.RequestContext(ctx, addr, req)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		return fmt.Errorf("WritePropertyMultiple reply %T: %v", reply, common.ErrWrongPayload)
+	}
+	return nil
+}
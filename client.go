@@ -0,0 +1,613 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	"github.com/Nortech-ai/bacnet/transport"
+)
+
+// Default client TSM parameters as defined for the Device object.
+const (
+	DEFAULT_APDU_TIMEOUT         = 3 * time.Second
+	DEFAULT_APDU_SEGMENT_TIMEOUT = 2 * time.Second
+	DEFAULT_APDU_RETRIES         = 3
+	DEFAULT_MAX_APDU_LENGTH      = 1476
+	DEFAULT_WINDOW_SIZE          = 16
+)
+
+// Client sends confirmed requests over a datalink and matches the replies to
+// the outstanding transactions. It is safe for concurrent use.
+type Client struct {
+	// APDUTimeout and APDURetries configure the client TSM. They should be
+	// set before issuing the first request.
+	APDUTimeout time.Duration
+	APDURetries int
+	// SegmentTimeout bounds the wait for a Segment-ACK or for the next
+	// segment of a segmented message.
+	SegmentTimeout time.Duration
+	// MaxAPDULength is the largest APDU sent unsegmented and WindowSize the
+	// number of segments sent or accepted before waiting for a Segment-ACK.
+	MaxAPDULength int
+	WindowSize    uint8
+
+	// transport is the datalink of the Client, carrying its NPDUs.
+	transport transport.Transport
+
+	mu      sync.Mutex
+	peers   map[string]*invokeIDPool
+	pending map[tsmKey]chan tsmResult
+	handler func(net.Addr, plumbing.BACnet)
+	done    chan struct{}
+	err     error
+}
+
+// tsmKey identifies a transaction. server tells whether the peer acts as the
+// server of the transaction, telling apart our own requests from the requests
+// of the peer using the same invoke ID.
+type tsmKey struct {
+	peer     string
+	invokeID uint8
+	server   bool
+}
+
+// tsmResult carries a message to a transaction. Segments and Segment-ACKs come
+// with apdu set and msg unset, and header holding the BVLC and NPDU they came in.
+type tsmResult struct {
+	msg    plumbing.BACnet
+	apdu   *plumbing.APDU
+	header []byte
+	err    error
+}
+
+// invokeIDPool hands out the invoke IDs used towards a single peer.
+type invokeIDPool struct {
+	next  uint8
+	inUse [256]bool
+	count int
+}
+
+// NewClient creates a BACnet/IP Client over conn, an IPv4 socket, its peers
+// being addressed with *net.UDPAddr. Broadcasts are sent to the limited
+// broadcast address on the port conn is bound to, NewTransportClient with a
+// transport.BIP choosing another one. The Client owns conn from now on and
+// closes it on Close.
+func NewClient(conn net.PacketConn) *Client {
+	broadcast := &net.UDPAddr{IP: net.IPv4bcast, Port: plumbing.BIPPort}
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.Port != 0 {
+		broadcast.Port = addr.Port
+	}
+	return NewTransportClient(transport.NewBIP(conn, broadcast))
+}
+
+// NewBIP6Client creates a BACnet/IPv6 Client over conn, an IPv6 socket, with
+// the virtual MAC address vmac, such as plumbing.NewVMAC of its device
+// instance, and broadcasting to multicast, such as plumbing.BIP6Multicast. It
+// runs over a transport.BIP6, its peers being addressed with the
+// *transport.Addr of their virtual MAC address. Broadcasts are only received
+// on a conn that joined the multicast group, as those of
+// net.ListenMulticastUDP do.
+func NewBIP6Client(conn net.PacketConn, vmac []byte, multicast net.Addr) (*Client, error) {
+	t, err := transport.NewBIP6(conn, vmac, multicast)
+	if err != nil {
+		return nil, err
+	}
+	return NewTransportClient(t), nil
+}
+
+// NewTransportClient creates a Client over the datalink of t, such as MS/TP or
+// BACnet/SC, its peers being addressed with *transport.Addr, or with
+// *net.UDPAddr over a transport.BIP. The BACnet/IP messages given to Send,
+// Request and Respond go out as NPDUs on the datalink, unicasts to addr and
+// broadcasts to every station whatever the address. The messages received are
+// parsed with ParseNPDU, so they have no BVLC. The Client owns t from now on
+// and closes it on Close.
+func NewTransportClient(t transport.Transport) *Client {
+	c := &Client{
+		APDUTimeout:    DEFAULT_APDU_TIMEOUT,
+		APDURetries:    DEFAULT_APDU_RETRIES,
+		SegmentTimeout: DEFAULT_APDU_SEGMENT_TIMEOUT,
+		MaxAPDULength:  DEFAULT_MAX_APDU_LENGTH,
+		WindowSize:     DEFAULT_WINDOW_SIZE,
+		transport:      t,
+		peers:          map[string]*invokeIDPool{},
+		pending:        map[tsmKey]chan tsmResult{},
+		done:           make(chan struct{}),
+	}
+	go c.receiveLoop()
+	return c
+}
+
+// Handle registers h to be called with every message that is not a reply to
+// an outstanding request, such as IAm, COV notifications or network layer
+// messages. Segmented requests are reassembled before reaching h. h is called
+// on its own goroutine, so it may answer confirmed requests with Respond.
+func (c *Client) Handle(h func(net.Addr, plumbing.BACnet)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = h
+}
+
+// Send writes an unconfirmed message to addr. Messages to a *RoutedAddr are
+// routed to its remote network. Broadcasts, the messages whose BVLC function is
+// Original-Broadcast-NPDU, reach every station whatever addr, SetBVLCFunction
+// turning them into unicasts. Broadcasts of a foreign device are sent to its
+// BBMD instead.
+func (c *Client) Send(addr net.Addr, b []byte) error {
+	return c.send(context.Background(), addr, b)
+}
+
+// send is Send giving up on resolving the address of addr, as the
+// transport.ContextSenders do, when ctx is done.
+func (c *Client) send(ctx context.Context, addr net.Addr, b []byte) error {
+	if r, ok := addr.(*RoutedAddr); ok {
+		routed, err := Route(b, r.Dst)
+		if err != nil {
+			return err
+		}
+		b, addr = routed, r.Router
+	}
+
+	var bvlc plumbing.BVLC
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return fmt.Errorf("sending to %s: %v", addr, err)
+	}
+	if bvlc.Type != plumbing.BVLCType {
+		return fmt.Errorf("sending BVLC type %x: %v", bvlc.Type, common.ErrWrongStructure)
+	}
+	var dst plumbing.BACnetAddress
+	switch bvlc.Function {
+	case plumbing.BVLCFuncUnicast:
+		var err error
+		if dst, err = c.datalinkAddress(addr); err != nil {
+			return err
+		}
+	case plumbing.BVLCFuncBroadcast, plumbing.BVLCFuncDistributeBroadcast:
+	default:
+		return fmt.Errorf("sending BVLL function %x: %v", bvlc.Function, common.ErrWrongPayload)
+	}
+	npdu := b[bvlc.MarshalLen():]
+	var err error
+	if t, ok := c.transport.(transport.ContextSender); ok {
+		err = t.SendContext(ctx, dst, npdu)
+	} else {
+		err = c.transport.Send(dst, npdu)
+	}
+	if err != nil {
+		return fmt.Errorf("sending to %s: %w", addr, err)
+	}
+	return nil
+}
+
+// datalinkAddress returns the address of the peer addr on the transport of
+// the Client.
+func (c *Client) datalinkAddress(addr net.Addr) (plumbing.BACnetAddress, error) {
+	switch a := addr.(type) {
+	case *transport.Addr:
+		return a.BACnetAddress, nil
+	case *net.UDPAddr:
+		if _, ok := c.transport.(*transport.BIP); ok && a.IP.To4() != nil {
+			return plumbing.NewBACnetIPAddress(plumbing.LocalNetwork, a), nil
+		}
+	}
+	return plumbing.BACnetAddress{}, fmt.Errorf("sending to %s address %s: %v", addr.Network(), addr, common.ErrWrongStructure)
+}
+
+// peerAddr returns the address of the station src of the transport, as the
+// handler and the transactions know it.
+func (c *Client) peerAddr(src plumbing.BACnetAddress) net.Addr {
+	if _, ok := c.transport.(*transport.BIP); ok && len(src.Mac) == plumbing.BIPAddressLen {
+		return transport.BIPAddr(src.Mac)
+	}
+	return &transport.Addr{BACnetAddress: src}
+}
+
+// Request sends the confirmed request req to addr and waits for its reply. The
+// invoke ID in req is replaced with one allocated for addr, so the output of the
+// New* functions can be used as is. Requests longer than MaxAPDULength are sent
+// segmented and segmented replies are reassembled. Error, Reject and Abort
+// replies are returned as *ServiceError, *RejectError and *AbortError respectively.
+func (c *Client) Request(addr net.Addr, req []byte) (plumbing.BACnet, error) {
+	return c.RequestContext(context.Background(), addr, req)
+}
+
+// RequestContext is like Request but abandons the transaction as soon as ctx is
+// done, returning ctx.Err(). Late replies to an abandoned transaction are dropped.
+func (c *Client) RequestContext(ctx context.Context, addr net.Addr, req []byte) (plumbing.BACnet, error) {
+	offset, _, err := apduOffset(req)
+	if err != nil {
+		return nil, fmt.Errorf("building request: %v", err)
+	}
+	if req[offset]>>4 != plumbing.ConfirmedReq || len(req) < offset+4 {
+		return nil, fmt.Errorf("building request %x: %v", req, common.ErrWrongStructure)
+	}
+
+	peer := addr.String()
+	invokeID, err := c.acquireInvokeID(peer)
+	if err != nil {
+		return nil, err
+	}
+	defer c.releaseInvokeID(peer, invokeID)
+
+	b := make([]byte, len(req))
+	copy(b, req)
+	b[offset] |= plumbing.SA
+	b[offset+2] = invokeID
+
+	key := tsmKey{peer, invokeID, true}
+	ch := c.register(key)
+	defer c.unregister(key)
+
+	if len(b)-offset > c.MaxAPDULength {
+		return c.requestSegmented(ctx, addr, key, ch, b, offset)
+	}
+
+	for retry := 0; retry <= c.APDURetries; retry++ {
+		if err := c.send(ctx, addr, b); err != nil {
+			return nil, err
+		}
+
+		r, err := c.await(ctx, ch, c.APDUTimeout)
+		if errors.Is(err, common.ErrTimeout) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return c.confirm(ctx, addr, key, ch, r)
+	}
+
+	return nil, fmt.Errorf(
+		"invoke ID %d to %s after %d retries: %w", invokeID, addr, c.APDURetries, common.ErrTimeout,
+	)
+}
+
+// Respond sends reply to the confirmed request req from addr, segmenting
+// ComplexACKs longer than the request accepts. Replies needing segments the
+// request doesn't accept, because its SA flag is clear or they are more than its
+// Max-Segments-Accepted, are aborted instead.
+func (c *Client) Respond(ctx context.Context, addr net.Addr, reply []byte, req *plumbing.APDU) error {
+	offset, _, err := apduOffset(reply)
+	if err != nil {
+		return fmt.Errorf("building reply: %v", err)
+	}
+	maxAPDU := plumbing.MaxAPDULength(req.MaxSize)
+	if len(reply)-offset <= maxAPDU {
+		return c.send(ctx, addr, reply)
+	}
+
+	var apdu plumbing.APDU
+	if err := apdu.UnmarshalBinary(reply[offset:]); err != nil {
+		return fmt.Errorf("building reply: %v", err)
+	}
+	if apdu.Type != plumbing.ComplexAck {
+		return fmt.Errorf("segmenting reply type %d: %v", apdu.Type, common.ErrWrongStructure)
+	}
+	if req.Flags&plumbing.SA == 0 {
+		return c.abort(addr, apdu.InvokeID, services.AbortReasonSegmentationNotSupported, fmt.Errorf(
+			"reply of %d bytes to %s not accepting segments: %v", len(reply)-offset, addr, common.ErrTooBigValue,
+		))
+	}
+	frames, err := segmentFrames(reply[:offset], &apdu, maxAPDU, c.WindowSize)
+	if err != nil {
+		return err
+	}
+	if max := plumbing.MaxSegments(req.MaxSeg); max > 0 && len(frames) > max {
+		return c.abort(addr, apdu.InvokeID, services.AbortReasonAPDUTooLong, fmt.Errorf(
+			"reply of %d segments to %s accepting %d: %v", len(frames), addr, max, common.ErrTooBigValue,
+		))
+	}
+
+	key := tsmKey{addr.String(), apdu.InvokeID, false}
+	ch := c.register(key)
+	defer c.unregister(key)
+
+	_, err = c.sendSegments(ctx, addr, ch, frames)
+	return err
+}
+
+// abort aborts the transaction invokeID of the server side with addr for
+// reason, returning err.
+func (c *Client) abort(addr net.Addr, invokeID uint8, reason services.AbortReason, err error) error {
+	b, abortErr := NewAbort(invokeID, reason, true)
+	if abortErr != nil {
+		return abortErr
+	}
+	if abortErr := c.Send(addr, b); abortErr != nil {
+		return abortErr
+	}
+	return err
+}
+
+// VMAC returns the BACnet/IPv6 virtual MAC address of the Client, or nil off
+// BACnet/IPv6.
+func (c *Client) VMAC() []byte {
+	if _, ok := c.transport.(*transport.BIP6); !ok {
+		return nil
+	}
+	return c.transport.LocalAddress().Mac
+}
+
+// LocalAddr returns the address of the Client on its transport.
+func (c *Client) LocalAddr() net.Addr {
+	return c.peerAddr(c.transport.LocalAddress())
+}
+
+// Close stops the Client and closes its transport. Outstanding requests fail
+// with common.ErrClientClosed.
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
+
+func (c *Client) acquireInvokeID(peer string) (uint8, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pool, ok := c.peers[peer]
+	if !ok {
+		pool = &invokeIDPool{}
+		c.peers[peer] = pool
+	}
+	if pool.count == len(pool.inUse) {
+		return 0, fmt.Errorf("requesting %s: %v", peer, common.ErrNoFreeInvokeID)
+	}
+	for pool.inUse[pool.next] {
+		pool.next++
+	}
+	id := pool.next
+	pool.inUse[id] = true
+	pool.count++
+	pool.next++
+
+	return id, nil
+}
+
+func (c *Client) releaseInvokeID(peer string, id uint8) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pool := c.peers[peer]
+	pool.inUse[id] = false
+	pool.count--
+	if pool.count == 0 {
+		delete(c.peers, peer)
+	}
+}
+
+// receiveLoop dispatches the NPDUs received on the transport of the Client.
+func (c *Client) receiveLoop() {
+	for {
+		p, err := c.transport.Receive(context.Background())
+		if err != nil {
+			c.stop(fmt.Errorf("receiving: %v: %w", err, common.ErrClientClosed))
+			return
+		}
+		c.dispatch(c.peerAddr(p.Source), p.NPDU)
+	}
+}
+
+// stop fails the outstanding and later transactions with err.
+func (c *Client) stop(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// register opens the channel transaction key receives its messages on. It is
+// buffered for a whole window of segments.
+func (c *Client) register(key tsmKey) chan tsmResult {
+	ch := make(chan tsmResult, 256)
+	c.mu.Lock()
+	c.pending[key] = ch
+	c.mu.Unlock()
+	return ch
+}
+
+func (c *Client) unregister(key tsmKey) {
+	c.mu.Lock()
+	delete(c.pending, key)
+	c.mu.Unlock()
+}
+
+// await waits up to timeout for the next message of a transaction, returning
+// an error wrapping common.ErrTimeout if none arrived.
+func (c *Client) await(ctx context.Context, ch chan tsmResult, timeout time.Duration) (tsmResult, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-ch:
+		return r, nil
+	case <-c.done:
+		return tsmResult{}, c.err
+	case <-ctx.Done():
+		return tsmResult{}, ctx.Err()
+	case <-timer.C:
+		return tsmResult{}, common.ErrTimeout
+	}
+}
+
+// confirm turns the reply r to one of our requests into the request result,
+// receiving the remaining segments first if r is a segment.
+func (c *Client) confirm(ctx context.Context, addr net.Addr, key tsmKey, ch chan tsmResult, r tsmResult) (plumbing.BACnet, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.apdu == nil {
+		return r.msg, nil
+	}
+	if !r.apdu.IsSegmented() {
+		return nil, fmt.Errorf("reply APDU type %d: %v", r.apdu.Type, common.ErrWrongPayload)
+	}
+	return c.receiveSegments(ctx, addr, key, ch, r)
+}
+
+// dispatch hands the NPDU b from addr over to its transaction or to the
+// handler.
+func (c *Client) dispatch(addr net.Addr, b []byte) {
+	offset, npdu, err := npduAPDUOffset(b, 0)
+	if npdu != nil && npdu.IsNetworkMessage() {
+		c.handle(addr, b)
+		return
+	}
+	if err != nil || len(b) < offset+2 {
+		return
+	}
+	if src, ok := npdu.Source(); ok {
+		addr = &RoutedAddr{Router: addr, Dst: src}
+	}
+
+	invokeID := b[offset+1]
+	key := tsmKey{addr.String(), invokeID, true}
+	switch b[offset] >> 4 {
+	case plumbing.Reject:
+		msg, err := ParseNPDU(b)
+		if err != nil {
+			return
+		}
+		dec, err := msg.(*services.Reject).Decode()
+		if err != nil {
+			return
+		}
+		c.complete(key, tsmResult{err: &RejectError{InvokeID: dec.InvokeID, Reason: dec.Reason}})
+	case plumbing.Abort:
+		msg, err := ParseNPDU(b)
+		if err != nil {
+			return
+		}
+		dec, err := msg.(*services.Abort).Decode()
+		if err != nil {
+			return
+		}
+		key.server = dec.Server
+		c.complete(key, tsmResult{err: &AbortError{
+			InvokeID: dec.InvokeID,
+			Reason:   dec.Reason,
+			Server:   dec.Server,
+		}})
+	case plumbing.SegmentAck:
+		var apdu plumbing.APDU
+		if err := apdu.UnmarshalBinary(b[offset:]); err != nil {
+			return
+		}
+		key.server = apdu.Flags&plumbing.SRV != 0
+		c.complete(key, tsmResult{apdu: &apdu})
+	case plumbing.ComplexAck, plumbing.SimpleAck, plumbing.Error:
+		if b[offset]&plumbing.SegmentedRequest != 0 {
+			var apdu plumbing.APDU
+			if err := apdu.UnmarshalBinary(b[offset:]); err != nil {
+				c.complete(key, tsmResult{err: err})
+				return
+			}
+			c.complete(key, tsmResult{apdu: &apdu, header: b[:offset]})
+			return
+		}
+		msg, err := ParseNPDU(b)
+		if err != nil {
+			c.complete(key, tsmResult{err: err})
+			return
+		}
+		if e, ok := msg.(*services.Error); ok {
+			c.complete(key, tsmResult{err: serviceError(e)})
+			return
+		}
+		c.complete(key, tsmResult{msg: msg})
+	case plumbing.ConfirmedReq:
+		if b[offset]&plumbing.SegmentedRequest == 0 {
+			c.handle(addr, b)
+			return
+		}
+		var apdu plumbing.APDU
+		if err := apdu.UnmarshalBinary(b[offset:]); err != nil {
+			return
+		}
+		r := tsmResult{apdu: &apdu, header: b[:offset]}
+		key.invokeID = apdu.InvokeID
+		key.server = false
+
+		c.mu.Lock()
+		_, ok := c.pending[key]
+		if !ok && apdu.SequenceNumber == 0 {
+			ch := make(chan tsmResult, 256)
+			c.pending[key] = ch
+			c.mu.Unlock()
+			go c.receiveRequest(addr, key, ch, r)
+			return
+		}
+		c.mu.Unlock()
+		c.complete(key, r)
+	default:
+		c.handle(addr, b)
+	}
+}
+
+// handle parses b and passes it to the handler, if any.
+func (c *Client) handle(addr net.Addr, b []byte) {
+	c.mu.Lock()
+	h := c.handler
+	c.mu.Unlock()
+	if h == nil {
+		return
+	}
+	msg, err := ParseNPDU(b)
+	if err != nil {
+		return
+	}
+	go h(addr, msg)
+}
+
+// complete hands r over to the transaction key, dropping messages nobody is
+// waiting for.
+func (c *Client) complete(key tsmKey, r tsmResult) {
+	c.mu.Lock()
+	ch, ok := c.pending[key]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ch <- r:
+	default:
+	}
+}
+
+// apduOffset returns the offset of the APDU within a BACnet/IP frame, along
+// with the NPDU preceding it. Network layer messages carry no APDU, but their
+// NPDU is returned along with the error.
+func apduOffset(b []byte) (int, *plumbing.NPDU, error) {
+	var bvlc plumbing.BVLC
+
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return 0, nil, err
+	}
+	if !bvlc.CarriesNPDU() {
+		return 0, nil, fmt.Errorf("BVLL function %x: %v", bvlc.Function, common.ErrWrongPayload)
+	}
+	return npduAPDUOffset(b, bvlc.MarshalLen())
+}
+
+// npduAPDUOffset is apduOffset for the message b whose NPDU starts at offset.
+func npduAPDUOffset(b []byte, offset int) (int, *plumbing.NPDU, error) {
+	var npdu plumbing.NPDU
+
+	if err := npdu.UnmarshalBinary(b[offset:]); err != nil {
+		return 0, nil, err
+	}
+	if npdu.IsNetworkMessage() {
+		return 0, &npdu, fmt.Errorf("network layer message type %x: %v", npdu.MessageType, common.ErrWrongPayload)
+	}
+	offset += npdu.MarshalLen()
+
+	if offset >= len(b) {
+		return 0, nil, common.ErrTooShortToParse
+	}
+	return offset, &npdu, nil
+}
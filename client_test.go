@@ -0,0 +1,785 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	"github.com/Nortech-ai/bacnet/transport"
+)
+
+// serveProperties answers ReadProperty requests on conn with a CACK for the
+// requested instance and WriteProperty requests with a SACK, ignoring the
+// first drop requests it gets. Instance 404 is answered with an Error,
+// instance 503 with an Abort and instance 0 is never answered at all.
+func serveProperties(t *testing.T, conn net.PacketConn, drop int) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if drop > 0 {
+			drop--
+			continue
+		}
+		msg, err := Parse(buf[:n])
+		if err != nil {
+			t.Errorf("parsing request: %v", err)
+			return
+		}
+
+		var reply []byte
+		var invokeID uint8
+		switch req := msg.(type) {
+		case *services.ConfirmedWriteProperty:
+			invokeID = req.APDU.InvokeID
+			reply, err = NewSACK(services.ServiceConfirmedWriteProperty)
+		case *services.ConfirmedReadProperty:
+			invokeID = req.APDU.InvokeID
+			dec, err := req.Decode()
+			if err != nil {
+				t.Errorf("decoding request: %v", err)
+				return
+			}
+			switch dec.InstanceNum {
+			case 0:
+				continue
+			case 404:
+				reply, err = NewError(services.ServiceConfirmedReadProperty,
+					objects.ErrorClassObject, objects.ErrorCodeUnknownObject)
+			case 503:
+				reply, err = NewAbort(invokeID, services.AbortReasonOutOfResources, true)
+			default:
+				reply, err = NewCACK(services.ServiceConfirmedReadProperty,
+					dec.ObjectType, dec.InstanceNum, objects.PropertyIdPresentValue, float32(dec.InstanceNum))
+			}
+		}
+		if err != nil {
+			t.Errorf("building reply: %v", err)
+			return
+		}
+		// Replies carry the invoke ID right after the PDU type.
+		reply[7] = invokeID
+		conn.WriteTo(reply, addr)
+	}
+}
+
+func newTestPair(t *testing.T, drop int) (*Client, net.Addr) {
+	srv, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	go serveProperties(t, srv, drop)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn)
+	c.APDUTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+
+	return c, srv.LocalAddr()
+}
+
+func TestClientRequestRetries(t *testing.T) {
+	c, addr := newTestPair(t, 2)
+
+	req, err := NewReadProperty(objects.ObjectTypeAnalogInput, 7, objects.PropertyIdPresentValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := c.Request(addr, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cack, ok := reply.(*services.ComplexACK)
+	if !ok {
+		t.Fatalf("expected ComplexACK, got %T", reply)
+	}
+	dec, err := cack.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.InstanceId != 7 {
+		t.Errorf("expected instance 7, got %d", dec.InstanceId)
+	}
+}
+
+func TestClientConcurrentRequests(t *testing.T) {
+	c, addr := newTestPair(t, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(inst uint32) {
+			defer wg.Done()
+			req, err := NewReadProperty(objects.ObjectTypeAnalogInput, inst, objects.PropertyIdPresentValue)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			reply, err := c.Request(addr, req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			dec, err := reply.(*services.ComplexACK).Decode()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if dec.InstanceId != inst {
+				t.Errorf("expected instance %d, got %d", inst, dec.InstanceId)
+			}
+		}(uint32(i + 1))
+	}
+	wg.Wait()
+}
+
+func TestClientErrorReply(t *testing.T) {
+	c, addr := newTestPair(t, 0)
+
+	req, err := NewReadProperty(objects.ObjectTypeAnalogInput, 404, objects.PropertyIdPresentValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Request(addr, req)
+	var svcErr *ServiceError
+	if !errors.As(err, &svcErr) {
+		t.Fatalf("expected ServiceError, got %v", err)
+	}
+	if svcErr.ErrorCode != objects.ErrorCodeUnknownObject {
+		t.Errorf("expected error code %d, got %d", objects.ErrorCodeUnknownObject, svcErr.ErrorCode)
+	}
+}
+
+func TestClientAbortReply(t *testing.T) {
+	c, addr := newTestPair(t, 0)
+
+	req, err := NewReadProperty(objects.ObjectTypeAnalogInput, 503, objects.PropertyIdPresentValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Request(addr, req)
+	var abortErr *AbortError
+	if !errors.As(err, &abortErr) {
+		t.Fatalf("expected AbortError, got %v", err)
+	}
+	if abortErr.Reason != services.AbortReasonOutOfResources || !abortErr.Server {
+		t.Errorf("unexpected abort %+v", abortErr)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	c, addr := newTestPair(t, 10)
+	c.APDURetries = 1
+
+	req, err := NewReadProperty(objects.ObjectTypeAnalogInput, 1, objects.PropertyIdPresentValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Request(addr, req); !errors.Is(err, common.ErrTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+func TestClientReadProperty(t *testing.T) {
+	c, addr := newTestPair(t, 0)
+
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	value, err := c.ReadProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != float32(3) {
+		t.Errorf("expected 3, got %v (%T)", value, value)
+	}
+
+	oid.InstanceNumber = 404
+	_, err = c.ReadProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+	var svcErr *ServiceError
+	if !errors.As(err, &svcErr) || svcErr.ErrorClass != objects.ErrorClassObject {
+		t.Errorf("expected object ServiceError, got %v", err)
+	}
+}
+
+func TestClientWriteProperty(t *testing.T) {
+	c, addr := newTestPair(t, 0)
+
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	if err := c.WriteProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue,
+		objects.ArrayAll, float32(21.5), 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue,
+		objects.ArrayAll, struct{}{}, 8); !errors.Is(err, common.ErrNotImplemented) {
+		t.Errorf("expected unsupported value error, got %v", err)
+	}
+}
+
+func TestClientContextCancel(t *testing.T) {
+	c, addr := newTestPair(t, 0)
+	c.APDUTimeout = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 0}
+	_, err := c.ReadProperty(ctx, addr, oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) != 0 || len(c.peers) != 0 {
+		t.Errorf("transaction not released: %d pending %d peers", len(c.pending), len(c.peers))
+	}
+}
+
+// newSegmentingPair returns a client and the address of a Client based server
+// calling serve with every request it gets.
+func newSegmentingPair(t *testing.T, serve func(srv *Client, addr net.Addr, msg plumbing.BACnet)) (*Client, *Client, net.Addr) {
+	srvConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewClient(srvConn)
+	srv.SegmentTimeout = 100 * time.Millisecond
+	// A small window takes several Segment-ACKs per message.
+	srv.WindowSize = 3
+	srv.Handle(func(addr net.Addr, msg plumbing.BACnet) { serve(srv, addr, msg) })
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn)
+	c.APDUTimeout = time.Second
+	c.SegmentTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+
+	return c, srv, srvConn.LocalAddr()
+}
+
+func TestClientSegmentedReply(t *testing.T) {
+	const count = 600
+	responded := make(chan error, 1)
+	c, _, addr := newSegmentingPair(t, func(srv *Client, addr net.Addr, msg plumbing.BACnet) {
+		req, ok := msg.(*services.ConfirmedReadProperty)
+		if !ok {
+			return
+		}
+		cack := services.NewComplexACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+		cack.APDU.InvokeID = req.APDU.InvokeID
+		cack.APDU.Objects = []objects.APDUPayload{
+			objects.EncObjectIdentifier(true, 0, objects.ObjectTypeAnalogValue, 1),
+			objects.ContextTag(1, objects.EncUnsignedInteger(uint(objects.PropertyIdPresentValue))),
+			objects.EncOpeningTag(3),
+		}
+		for i := 0; i < count; i++ {
+			cack.APDU.Objects = append(cack.APDU.Objects, objects.EncReal(float32(i)))
+		}
+		cack.APDU.Objects = append(cack.APDU.Objects, objects.EncClosingTag(3))
+		cack.SetLength()
+		reply, err := cack.MarshalBinary()
+		if err == nil {
+			err = srv.Respond(context.Background(), addr, reply, req.APDU)
+		}
+		responded <- err
+	})
+
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 1}
+	value, err := c.ReadProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, ok := value.([]interface{})
+	if !ok || len(values) != count {
+		t.Fatalf("expected %d values, got %v", count, value)
+	}
+	for i, v := range values {
+		if v != float32(i) {
+			t.Fatalf("expected %d at %d, got %v", i, i, v)
+		}
+	}
+	if err := <-responded; err != nil {
+		t.Errorf("responding: %v", err)
+	}
+}
+
+func TestClientSegmentedRequest(t *testing.T) {
+	propertyIds := make([]uint16, 100)
+	for i := range propertyIds {
+		propertyIds[i] = uint16(i)
+	}
+	c, _, addr := newSegmentingPair(t, func(srv *Client, addr net.Addr, msg plumbing.BACnet) {
+		req, ok := msg.(*services.ConfirmedReadProperty)
+		if !ok {
+			return
+		}
+		// Object identifier, opening tag, the properties and closing tag.
+		if n := len(req.APDU.Objects); n != len(propertyIds)+3 {
+			t.Errorf("expected %d objects, got %d", len(propertyIds)+3, n)
+		}
+		reply, err := NewSACK(req.APDU.Service)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		reply[7] = req.APDU.InvokeID
+		srv.Send(addr, reply)
+	})
+	c.MaxAPDULength = 50
+	c.WindowSize = 2
+
+	req, err := NewReadPropertyMultiple(objects.ObjectTypeAnalogValue, 1, propertyIds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := c.Request(addr, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reply.(*services.SimpleACK); !ok {
+		t.Errorf("expected SimpleACK, got %T", reply)
+	}
+}
+
+func TestClientRoutedRequest(t *testing.T) {
+	dst := plumbing.NewBACnetAddress(2001, []byte{0x0a})
+
+	router, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { router.Close() })
+	// The router answers on behalf of dst, setting it as the reply source.
+	go func() {
+		buf := make([]byte, 1500)
+		n, addr, err := router.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		msg, err := Parse(buf[:n])
+		if err != nil {
+			t.Errorf("parsing request: %v", err)
+			return
+		}
+		req := msg.(*services.ConfirmedWriteProperty)
+		if got, ok := req.NPDU.Destination(); !ok || got.String() != dst.String() {
+			t.Errorf("expected destination %s, got %s", dst, got)
+		}
+
+		npdu := plumbing.NewNPDU(false, false, false, false)
+		npdu.SetSource(dst)
+		sack := services.NewSimpleACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+		sack.APDU.Service = req.APDU.Service
+		sack.APDU.InvokeID = req.APDU.InvokeID
+		sack.SetLength()
+		reply, err := sack.MarshalBinary()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		router.WriteTo(reply, addr)
+	}()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn)
+	c.APDUTimeout = time.Second
+	t.Cleanup(func() { c.Close() })
+
+	addr := &RoutedAddr{Router: router.LocalAddr(), Dst: dst}
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	if err := c.WriteProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue,
+		objects.ArrayAll, float32(1), 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientNetworkMessages(t *testing.T) {
+	c, _, addr := newSegmentingPair(t, func(srv *Client, addr net.Addr, msg plumbing.BACnet) {
+		if m, ok := msg.(*services.NetworkMessage); ok && m.GetType() == services.NetworkMessageWhoIsRouterToNetwork {
+			reply, err := NewIAmRouterToNetwork([]uint16{2001, 2002})
+			if err == nil {
+				reply, err = SetBVLCFunction(reply, plumbing.BVLCFuncUnicast, nil)
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			srv.Send(addr, reply)
+		}
+	})
+
+	networks := make(chan []uint16, 1)
+	c.Handle(func(addr net.Addr, msg plumbing.BACnet) {
+		if m, ok := msg.(*services.NetworkMessage); ok && m.GetType() == services.NetworkMessageIAmRouterToNetwork {
+			dec, err := m.Decode()
+			if err != nil {
+				t.Error(err)
+			}
+			networks <- dec.Networks
+		}
+	})
+
+	// Network messages are broadcast unless sent as unicasts.
+	req, err := NewWhoIsRouterToNetwork()
+	if err == nil {
+		req, err = SetBVLCFunction(req, plumbing.BVLCFuncUnicast, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(addr, req); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-networks:
+		if len(got) != 2 || got[0] != 2001 || got[1] != 2002 {
+			t.Errorf("expected networks 2001 and 2002, got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no I-Am-Router-To-Network")
+	}
+}
+
+func TestClientTransport(t *testing.T) {
+	network := transport.NewMemoryNetwork()
+	tr, err := network.Attach([]byte{0x0a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewTransportClient(tr)
+	t.Cleanup(func() { srv.Close() })
+	srv.Handle(func(addr net.Addr, msg plumbing.BACnet) {
+		req, ok := msg.(*services.ConfirmedReadProperty)
+		if !ok {
+			return
+		}
+		// The NPDUs are parsed as they are, without a BVLC.
+		if req.BVLC != nil {
+			t.Errorf("expected no BVLC, got %+v", req.BVLC)
+		}
+		dec, err := req.Decode()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		reply, err := NewCACK(services.ServiceConfirmedReadProperty, dec.ObjectType, dec.InstanceNum,
+			objects.PropertyIdPresentValue, float32(dec.InstanceNum))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		reply[7] = req.APDU.InvokeID
+		if err := srv.Send(addr, reply); err != nil {
+			t.Error(err)
+		}
+	})
+
+	tr, err = network.Attach([]byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewTransportClient(tr)
+	c.APDUTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 12}
+	value, err := c.ReadProperty(context.Background(), srv.LocalAddr(), oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != float32(12) {
+		t.Errorf("expected 12, got %v", value)
+	}
+}
+
+// realsACK returns a ReadProperty ComplexACK for invokeID carrying count reals.
+func realsACK(t *testing.T, invokeID uint8, count int) []byte {
+	cack := services.NewComplexACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	cack.APDU.InvokeID = invokeID
+	cack.APDU.Objects = []objects.APDUPayload{
+		objects.EncObjectIdentifier(true, 0, objects.ObjectTypeAnalogValue, 1),
+		objects.ContextTag(1, objects.EncUnsignedInteger(uint(objects.PropertyIdPresentValue))),
+		objects.EncOpeningTag(3),
+	}
+	for i := 0; i < count; i++ {
+		cack.APDU.Objects = append(cack.APDU.Objects, objects.EncReal(float32(i)))
+	}
+	cack.APDU.Objects = append(cack.APDU.Objects, objects.EncClosingTag(3))
+	cack.SetLength()
+	b, err := cack.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// readAPDU reads the next frame arriving on conn and returns its APDU.
+func readAPDU(t *testing.T, conn net.PacketConn) (*plumbing.APDU, net.Addr) {
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, addr, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, _, err := apduOffset(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apdu plumbing.APDU
+	if err := apdu.UnmarshalBinary(buf[offset:n]); err != nil {
+		t.Fatal(err)
+	}
+	return &apdu, addr
+}
+
+func TestRespondAbort(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  uint8
+		maxSeg uint8
+		reason services.AbortReason
+	}{
+		{"segments not accepted", 0, 0, services.AbortReasonSegmentationNotSupported},
+		{"too many segments", plumbing.SA, 1, services.AbortReasonAPDUTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srvConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := NewClient(srvConn)
+			t.Cleanup(func() { srv.Close() })
+			peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { peer.Close() })
+
+			// 40 reals take 5 segments of 50 octets.
+			req := &plumbing.APDU{Type: plumbing.ConfirmedReq, Flags: tt.flags, MaxSeg: tt.maxSeg, InvokeID: 9}
+			if err := srv.Respond(context.Background(), peer.LocalAddr(), realsACK(t, 9, 40), req); err == nil {
+				t.Error("expected an error")
+			}
+
+			apdu, _ := readAPDU(t, peer)
+			if apdu.Type != plumbing.Abort || apdu.InvokeID != 9 || services.AbortReason(apdu.Reason) != tt.reason || apdu.Flags&plumbing.SRV == 0 {
+				t.Errorf("expected a server Abort for reason %s, got %+v", tt.reason, apdu)
+			}
+		})
+	}
+}
+
+func TestRespondSegmentNAK(t *testing.T) {
+	srvConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewClient(srvConn)
+	// Segments are only resent on the NAK, never on timeouts.
+	srv.SegmentTimeout = 5 * time.Second
+	srv.WindowSize = 3
+	t.Cleanup(func() { srv.Close() })
+	peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { peer.Close() })
+
+	const invokeID = 9
+	req := &plumbing.APDU{Type: plumbing.ConfirmedReq, Flags: plumbing.SA, InvokeID: invokeID}
+	responded := make(chan error, 1)
+	go func() {
+		responded <- srv.Respond(context.Background(), peer.LocalAddr(), realsACK(t, invokeID, 40), req)
+	}()
+	ack := func(addr net.Addr, seq uint8, nak bool) {
+		b, err := NewSegmentACK(invokeID, seq, 3, nak, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := peer.WriteTo(b, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, addr := readAPDU(t, peer)
+	if first.SequenceNumber != 0 {
+		t.Fatalf("expected segment 0, got %d", first.SequenceNumber)
+	}
+	ack(addr, 0, false)
+	for seq := uint8(1); seq <= 3; seq++ {
+		if s, _ := readAPDU(t, peer); s.SequenceNumber != seq {
+			t.Fatalf("expected segment %d, got %d", seq, s.SequenceNumber)
+		}
+	}
+	// Segment 2 got lost: the window resumes after segment 1.
+	ack(addr, 1, true)
+	for seq, more := uint8(2), true; more; {
+		var s *plumbing.APDU
+		for i := 0; i < 3 && more; i++ {
+			if s, _ = readAPDU(t, peer); s.SequenceNumber != seq {
+				t.Fatalf("expected segment %d, got %d", seq, s.SequenceNumber)
+			}
+			seq++
+			more = s.Flags&plumbing.MoreSegments != 0
+		}
+		ack(addr, s.SequenceNumber, false)
+	}
+
+	if err := <-responded; err != nil {
+		t.Errorf("responding: %v", err)
+	}
+}
+
+func TestRespondSegmentRetries(t *testing.T) {
+	srvConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewClient(srvConn)
+	srv.SegmentTimeout = 5 * time.Second
+	srv.APDURetries = 2
+	srv.WindowSize = 3
+	t.Cleanup(func() { srv.Close() })
+	peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { peer.Close() })
+
+	const invokeID = 9
+	req := &plumbing.APDU{Type: plumbing.ConfirmedReq, Flags: plumbing.SA, InvokeID: invokeID}
+	responded := make(chan error, 1)
+	go func() {
+		responded <- srv.Respond(context.Background(), peer.LocalAddr(), realsACK(t, invokeID, 40), req)
+	}()
+	ack := func(addr net.Addr, seq uint8, nak bool) {
+		b, err := NewSegmentACK(invokeID, seq, 3, nak, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := peer.WriteTo(b, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, addr := readAPDU(t, peer)
+	ack(addr, 0, false)
+	// Every window is lost: the NAKs for segment 0 and a stale ACK each
+	// cost a retry.
+	for _, a := range []struct {
+		seq uint8
+		nak bool
+	}{{0, true}, {200, false}, {0, true}} {
+		for seq := uint8(1); seq <= 3; seq++ {
+			if s, _ := readAPDU(t, peer); s.SequenceNumber != seq {
+				t.Fatalf("expected segment %d, got %d", seq, s.SequenceNumber)
+			}
+		}
+		ack(addr, a.seq, a.nak)
+	}
+
+	select {
+	case err := <-responded:
+		if err == nil {
+			t.Error("expected an error after the retries")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("still sending segments after the retries")
+	}
+}
+
+func TestClientSegmentOutOfOrder(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn)
+	c.APDUTimeout = time.Second
+	c.SegmentTimeout = time.Second
+	c.WindowSize = 3
+	t.Cleanup(func() { c.Close() })
+	peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { peer.Close() })
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	read := make(chan result, 1)
+	go func() {
+		oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 1}
+		v, err := c.ReadProperty(context.Background(), peer.LocalAddr(), oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+		read <- result{v, err}
+	}()
+
+	req, addr := readAPDU(t, peer)
+	reply := realsACK(t, req.InvokeID, 40)
+	offset, _, err := apduOffset(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apdu plumbing.APDU
+	if err := apdu.UnmarshalBinary(reply[offset:]); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := segmentFrames(reply[:offset], &apdu, plumbing.MaxAPDULength(0), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(seqs ...int) {
+		for _, seq := range seqs {
+			if _, err := peer.WriteTo(frames[seq], addr); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectAck := func(seq uint8, nak bool) {
+		a, _ := readAPDU(t, peer)
+		if a.Type != plumbing.SegmentAck || a.SequenceNumber != seq || (a.Flags&plumbing.NAK != 0) != nak {
+			t.Fatalf("expected Segment-ACK %d with NAK %v, got %+v", seq, nak, a)
+		}
+	}
+
+	send(0)
+	expectAck(0, false)
+	// Segments 2 and 3 overtake segment 1, which takes a single NAK.
+	send(2, 3)
+	expectAck(0, true)
+	send(1, 2, 3)
+	expectAck(3, false)
+	for seq := 4; seq < len(frames); seq++ {
+		send(seq)
+	}
+	expectAck(uint8(len(frames)-1), false)
+
+	r := <-read
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if values, ok := r.value.([]interface{}); !ok || len(values) != 40 {
+		t.Errorf("expected 40 values, got %v", r.value)
+	}
+}
@@ -18,4 +18,9 @@ var (
 	ErrWrongPayload            = errors.New("wrong payload type")
 	ErrInvalidObjectType       = errors.New("invalid object type")
 	ErrInvalidData             = errors.New("invalid data")
+	ErrTimeout                 = errors.New("transaction timed out")
+	ErrClientClosed            = errors.New("client closed")
+	ErrNoFreeInvokeID          = errors.New("no free invoke ID")
+	ErrUnsupportedCharset      = errors.New("unsupported character set")
+	ErrMissingParameter        = errors.New("missing required parameter")
 )
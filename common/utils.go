@@ -18,11 +18,41 @@ func IntToBool(n int) bool {
 	return n != 0
 }
 
+// IsLocalAddr tells whether remoteAddr has the IP address of one of
+// localAddrs, such as the addresses of an interface. Both IPv4 and IPv6
+// addresses are supported.
 func IsLocalAddr(localAddrs []net.Addr, remoteAddr net.Addr) bool {
+	remoteIP := addrIP(remoteAddr)
+	if remoteIP == nil {
+		return false
+	}
 	for _, localAddr := range localAddrs {
-		if strings.Split(localAddr.String(), "/")[0] == strings.Split(remoteAddr.String(), ":")[0] {
+		if addrIP(localAddr).Equal(remoteIP) {
 			return true
 		}
 	}
 	return false
 }
+
+// addrIP returns the IP address of addr, if any.
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.IPNet:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case *net.TCPAddr:
+		return a.IP
+	}
+
+	s := addr.String()
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	} else if ip, _, err := net.ParseCIDR(s); err == nil {
+		return ip
+	}
+	// Zones of link-local IPv6 addresses are not part of the IP address.
+	return net.ParseIP(strings.SplitN(s, "%", 2)[0])
+}
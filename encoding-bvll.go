@@ -0,0 +1,85 @@
+package bacnet
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+func newBVLLMessage(function uint8, data []byte) ([]byte, error) {
+	m := services.NewBVLLMessage(plumbing.NewBVLC(function))
+	m.Data = data
+	m.SetLength()
+
+	return m.MarshalBinary()
+}
+
+func NewBVLCResult(code uint16) ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncResult, services.ResultData(code))
+}
+
+func NewWriteBDT(entries []services.BDTEntry) ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncWriteBDT, services.BDTData(entries))
+}
+
+func NewReadBDT() ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncReadBDT, nil)
+}
+
+func NewReadBDTAck(entries []services.BDTEntry) ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncReadBDTAck, services.BDTData(entries))
+}
+
+// NewRegisterForeignDevice registers with a BBMD for ttl seconds.
+func NewRegisterForeignDevice(ttl uint16) ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncRegisterForeignDevice, services.RegisterForeignDeviceData(ttl))
+}
+
+func NewReadFDT() ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncReadFDT, nil)
+}
+
+func NewReadFDTAck(entries []services.FDTEntry) ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncReadFDTAck, services.FDTData(entries))
+}
+
+func NewDeleteFDTEntry(addr *net.UDPAddr) ([]byte, error) {
+	return newBVLLMessage(plumbing.BVLCFuncDeleteFDTEntry, services.DeleteFDTEntryData(addr))
+}
+
+// SetBVLCFunction rewrites the BACnet/IP or BACnet/IPv6 message b, carrying an
+// NPDU, with the BVLL function f of the same BVLC type, such as
+// BVLCFuncDistributeBroadcast for foreign devices. Forwarded-NPDUs are given
+// origin as their original source, which is ignored for every other function.
+func SetBVLCFunction(b []byte, f uint8, origin *net.UDPAddr) ([]byte, error) {
+	var bvlc plumbing.BVLC
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("setting BVLL function: %v", err)
+	}
+	if !bvlc.CarriesNPDU() {
+		return nil, fmt.Errorf("setting BVLL function of %x: %v", bvlc.Function, common.ErrWrongPayload)
+	}
+	npdu := b[bvlc.MarshalLen():]
+
+	bvlc.Function = f
+	bvlc.Origin = nil
+	if bvlc.Type == plumbing.BVLCType && f == plumbing.BVLCFuncForwardedNPDU ||
+		bvlc.Type == plumbing.BVLCType6 && f == plumbing.BVLC6FuncForwardedNPDU {
+		bvlc.Origin = origin
+	}
+	if !bvlc.CarriesNPDU() {
+		return nil, fmt.Errorf("setting BVLL function to %x: %v", f, common.ErrWrongStructure)
+	}
+	bvlc.Length = uint16(bvlc.MarshalLen() + len(npdu))
+
+	out := make([]byte, bvlc.Length)
+	if err := bvlc.MarshalTo(out); err != nil {
+		return nil, fmt.Errorf("setting BVLL function: %v", err)
+	}
+	copy(out[bvlc.MarshalLen():], npdu)
+
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package bacnet
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+func newBVLL6Message(function uint8, src, dst, data []byte) ([]byte, error) {
+	m := services.NewBVLLMessage(plumbing.NewBVLC6(function, src, dst))
+	m.Data = data
+	m.SetLength()
+
+	return m.MarshalBinary()
+}
+
+func NewBVLC6Result(src []byte, code uint16) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncResult, src, nil, services.ResultData(code))
+}
+
+// NewAddressResolution asks for the B/IPv6 address of the node whose virtual
+// MAC address is target.
+func NewAddressResolution(src, target []byte) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncAddressResolution, src, nil,
+		services.AddressResolutionData(target, nil))
+}
+
+// NewForwardedAddressResolution is an Address-Resolution from origin forwarded by a BBMD.
+func NewForwardedAddressResolution(src, target []byte, origin *net.UDPAddr) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncForwardedAddressResolution, src, nil,
+		services.AddressResolutionData(target, origin))
+}
+
+func NewAddressResolutionAck(src, dst []byte) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncAddressResolutionAck, src, dst, nil)
+}
+
+// NewVirtualAddressResolution asks the node it is sent to for its virtual MAC address.
+func NewVirtualAddressResolution(src []byte) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncVirtualAddressResolution, src, nil, nil)
+}
+
+func NewVirtualAddressResolutionAck(src, dst []byte) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncVirtualAddressResolutionAck, src, dst, nil)
+}
+
+// NewRegisterForeignDevice6 registers with a BACnet/IPv6 BBMD for ttl seconds.
+func NewRegisterForeignDevice6(src []byte, ttl uint16) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncRegisterForeignDevice, src, nil,
+		services.RegisterForeignDeviceData(ttl))
+}
+
+func NewDeleteFDTEntry6(src []byte, addr *net.UDPAddr) ([]byte, error) {
+	return newBVLL6Message(plumbing.BVLC6FuncDeleteFDTEntry, src, nil, services.BIP6AddressData(addr))
+}
+
+// ToBIP6 rewrites the BACnet/IP message b, carrying an NPDU, as a BACnet/IPv6
+// message from the node with the virtual MAC address src. Unicasts become
+// Original-Unicast-NPDUs to dst, broadcasts Original-Broadcast-NPDUs and
+// Distribute-Broadcast-To-Network keeps its function.
+func ToBIP6(b []byte, src, dst []byte) ([]byte, error) {
+	var bvlc plumbing.BVLC
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("converting to BVLL6: %v", err)
+	}
+	if bvlc.Type != plumbing.BVLCType {
+		return nil, fmt.Errorf("converting BVLC type %x to BVLL6: %v", bvlc.Type, common.ErrWrongStructure)
+	}
+
+	var f uint8
+	switch bvlc.Function {
+	case plumbing.BVLCFuncUnicast:
+		f = plumbing.BVLC6FuncOriginalUnicast
+	case plumbing.BVLCFuncBroadcast:
+		f = plumbing.BVLC6FuncOriginalBroadcast
+	case plumbing.BVLCFuncDistributeBroadcast:
+		f = plumbing.BVLC6FuncDistributeBroadcast
+	default:
+		return nil, fmt.Errorf("converting BVLL function %x to BVLL6: %v", bvlc.Function, common.ErrWrongPayload)
+	}
+	npdu := b[bvlc.MarshalLen():]
+
+	bvlc6 := plumbing.NewBVLC6(f, src, dst)
+	bvlc6.Length = uint16(bvlc6.MarshalLen() + len(npdu))
+
+	out := make([]byte, bvlc6.Length)
+	if err := bvlc6.MarshalTo(out); err != nil {
+		return nil, fmt.Errorf("converting to BVLL6: %v", err)
+	}
+	copy(out[bvlc6.MarshalLen():], npdu)
+
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package bacnet
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+func newNetworkMessage(bvlcFunc, messageType uint8, data []byte) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(bvlcFunc)
+	npdu := plumbing.NewNPDU(true, false, false, false)
+	npdu.MessageType = messageType
+
+	m := services.NewNetworkMessage(bvlc, npdu)
+	m.Data = data
+	m.SetLength()
+
+	return m.MarshalBinary()
+}
+
+// NewWhoIsRouterToNetwork looks for the routers to network, or to every
+// network when no network is given.
+func NewWhoIsRouterToNetwork(network ...uint16) ([]byte, error) {
+	if len(network) > 1 {
+		return nil, fmt.Errorf("Who-Is-Router-To-Network %v: %v", network, common.ErrWrongObjectCount)
+	}
+	return newNetworkMessage(plumbing.BVLCFuncBroadcast,
+		services.NetworkMessageWhoIsRouterToNetwork, services.NetworksData(network))
+}
+
+func NewIAmRouterToNetwork(networks []uint16) ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncBroadcast,
+		services.NetworkMessageIAmRouterToNetwork, services.NetworksData(networks))
+}
+
+func NewICouldBeRouterToNetwork(network uint16, performanceIndex uint8) ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncUnicast,
+		services.NetworkMessageICouldBeRouterToNetwork, services.ICouldBeRouterData(network, performanceIndex))
+}
+
+func NewRejectMessageToNetwork(reason uint8, network uint16) ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncUnicast,
+		services.NetworkMessageRejectMessageToNetwork, services.RejectMessageData(reason, network))
+}
+
+func NewRouterBusyToNetwork(networks []uint16) ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncBroadcast,
+		services.NetworkMessageRouterBusyToNetwork, services.NetworksData(networks))
+}
+
+func NewRouterAvailableToNetwork(networks []uint16) ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncBroadcast,
+		services.NetworkMessageRouterAvailableToNetwork, services.NetworksData(networks))
+}
+
+// NewInitializeRoutingTable updates the routing table of a router with ports,
+// or queries the whole table when ports is empty.
+func NewInitializeRoutingTable(ports []services.RoutingTablePort) ([]byte, error) {
+	data, err := services.RoutingTableData(ports)
+	if err != nil {
+		return nil, err
+	}
+	return newNetworkMessage(plumbing.BVLCFuncUnicast, services.NetworkMessageInitializeRoutingTable, data)
+}
+
+func NewInitializeRoutingTableAck(ports []services.RoutingTablePort) ([]byte, error) {
+	data, err := services.RoutingTableData(ports)
+	if err != nil {
+		return nil, err
+	}
+	return newNetworkMessage(plumbing.BVLCFuncUnicast, services.NetworkMessageInitializeRoutingTableAck, data)
+}
+
+func NewWhatIsNetworkNumber() ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncBroadcast, services.NetworkMessageWhatIsNetworkNumber, nil)
+}
+
+func NewNetworkNumberIs(network uint16, configured bool) ([]byte, error) {
+	return newNetworkMessage(plumbing.BVLCFuncBroadcast,
+		services.NetworkMessageNetworkNumberIs, services.NetworkNumberIsData(network, configured))
+}
@@ -1,13 +1,14 @@
 package bacnet
 
 import (
+	"github.com/Nortech-ai/bacnet/objects"
 	"github.com/Nortech-ai/bacnet/plumbing"
 	"github.com/Nortech-ai/bacnet/services"
 )
 
 const (
 	DEFAULT_ACCEPTED_SIZE        = 1024
-	DEFAULT_SEGMENTATION_SUPPORT = 0x3 // No segmentation
+	DEFAULT_SEGMENTATION_SUPPORT = 0x0 // Segmentation of both requests and responses
 )
 
 func NewWhois() ([]byte, error) {
@@ -17,12 +18,45 @@ func NewWhois() ([]byte, error) {
 	return u.MarshalBinary()
 }
 
+// NewWhoHas looks for the object of objectType and instanceNumber on the devices
+// from lowLimit to highLimit, or on every device when the limits are negative.
+func NewWhoHas(lowLimit, highLimit int32, objectType uint16, instanceNumber uint32) ([]byte, error) {
+	oid := objects.ObjectIdentifier{ObjectType: objectType, InstanceNumber: instanceNumber}
+	return newWhoHas(services.WhoHasObjects(lowLimit, highLimit, &oid, ""))
+}
+
+// NewWhoHasName looks for the object named name on the devices from lowLimit to
+// highLimit, or on every device when the limits are negative.
+func NewWhoHasName(lowLimit, highLimit int32, name string) ([]byte, error) {
+	return newWhoHas(services.WhoHasObjects(lowLimit, highLimit, nil, name))
+}
+
+func newWhoHas(objs []objects.APDUPayload) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+	u := services.NewUnconfirmedWhoHas(bvlc, npdu)
+	u.APDU.Objects = objs
+	u.SetLength()
+	return u.MarshalBinary()
+}
+
+// NewIHave announces that the device deviceInstance has the object of
+// objectType and instanceNumber named name.
+func NewIHave(deviceInstance uint32, objectType uint16, instanceNumber uint32, name string) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+	u := services.NewUnconfirmedIHave(bvlc, npdu)
+	u.APDU.Objects = services.IHaveObjects(deviceInstance,
+		objects.ObjectIdentifier{ObjectType: objectType, InstanceNumber: instanceNumber}, name)
+	u.SetLength()
+	return u.MarshalBinary()
+}
+
 func NewIAm(instN uint32, vendorId uint16) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncBroadcast)
 
 	npdu := plumbing.NewNPDU(false, true, false, false)
 	npdu.DNET = 0xFFFF
-	npdu.DLEN = 0
 	npdu.Hop = 0xFF
 
 	u := services.NewUnconfirmedIAm(bvlc, npdu)
@@ -63,7 +97,33 @@ func NewSACK(service uint8) ([]byte, error) {
 	return s.MarshalBinary()
 }
 
-func NewError(service, errorClass, errorCode uint8) ([]byte, error) {
+// NewSegmentACK acknowledges the segments up to sequenceNumber of a segmented
+// message. Setting nak requests the retransmission of the following segments and
+// server marks the SegmentACK as sent by the server side of the transaction.
+func NewSegmentACK(invokeID, sequenceNumber, windowSize uint8, nak, server bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	s := services.NewSegmentACK(bvlc, npdu)
+
+	s.APDU.InvokeID = invokeID
+	s.APDU.SequenceNumber = sequenceNumber
+	s.APDU.WindowSize = windowSize
+	if nak {
+		s.APDU.Flags |= plumbing.NAK
+	}
+	if server {
+		s.APDU.Flags |= plumbing.SRV
+	}
+
+	s.SetLength()
+
+	return s.MarshalBinary()
+}
+
+// NewError answers a confirmed request of service with the error class and
+// code, one of the objects.ErrorClass and objects.ErrorCode constants.
+func NewError(service uint8, errorClass, errorCode uint16) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, false)
 
@@ -78,6 +138,64 @@ func NewError(service, errorClass, errorCode uint8) ([]byte, error) {
 	return e.MarshalBinary()
 }
 
+// NewReject rejects the confirmed request invokeID for reason, one of the
+// services.RejectReason constants.
+func NewReject(invokeID uint8, reason services.RejectReason) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	r := services.NewReject(bvlc, npdu)
+
+	r.APDU.InvokeID = invokeID
+	r.APDU.Reason = uint8(reason)
+
+	r.SetLength()
+
+	return r.MarshalBinary()
+}
+
+// NewAbort aborts the transaction invokeID for reason, one of the
+// services.AbortReason constants. server tells whether the server of the
+// transaction sends it.
+func NewAbort(invokeID uint8, reason services.AbortReason, server bool) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	a := services.NewAbort(bvlc, npdu)
+
+	if server {
+		a.APDU.Flags = plumbing.SRV
+	}
+	a.APDU.InvokeID = invokeID
+	a.APDU.Reason = uint8(reason)
+
+	a.SetLength()
+
+	return a.MarshalBinary()
+}
+
+// NewWritePropertyMultipleError answers the WritePropertyMultiple invokeID
+// whose write of ref failed first.
+func NewWritePropertyMultipleError(invokeID uint8, errorClass, errorCode uint16, ref objects.BACnetObjectPropertyReference) ([]byte, error) {
+	objs, err := services.WritePropertyMultipleErrorObjects(errorClass, errorCode, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	e := services.NewError(bvlc, npdu)
+
+	e.APDU.Service = services.ServiceConfirmedWritePropMultiple
+	e.APDU.InvokeID = invokeID
+	e.APDU.Objects = objs
+
+	e.SetLength()
+
+	return e.MarshalBinary()
+}
+
 func NewReadProperty(objectType uint16, instanceNumber uint32, propertyId uint16) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, true)
@@ -94,6 +212,24 @@ func NewReadProperty(objectType uint16, instanceNumber uint32, propertyId uint16
 	return c.MarshalBinary()
 }
 
+// NewReadPropertyArray reads a single element of an array property. Passing
+// objects.ArrayAll as arrayIndex reads the whole property.
+func NewReadPropertyArray(objectType uint16, instanceNumber uint32, propertyId uint16, arrayIndex uint32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedReadProperty(bvlc, npdu)
+
+	c.APDU.Service = services.ServiceConfirmedReadProperty
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedReadPropertyArrayObjects(objectType, instanceNumber, propertyId, arrayIndex)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
 func NewReadPropertyMultiple(objectType uint16, instanceNumber uint32, propertyIds []uint16) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, true)
@@ -111,6 +247,30 @@ func NewReadPropertyMultiple(objectType uint16, instanceNumber uint32, propertyI
 	return c.MarshalBinary()
 }
 
+// NewReadPropertyMultipleSpecs reads the properties of several objects,
+// possibly with array indices or objects.PropertyIdAll and the like.
+func NewReadPropertyMultipleSpecs(specs []services.ReadAccessSpecification) ([]byte, error) {
+	objs, err := services.ReadPropertyMultipleObjects(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedReadProperty(bvlc, npdu)
+
+	c.APDU.Service = services.ServiceConfirmedReadPropMultiple
+	c.APDU.MaxSeg = 7
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Flags = 2
+	c.APDU.Objects = objs
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
 func NewReadRange(objectType uint16, instanceNumber uint32, propertyId uint16, rangeStart uint16, length int32) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, true)
@@ -151,6 +311,29 @@ func NewSubscribeCOV(objectType uint16, instanceNumber uint32, processId uint, l
 	return c.MarshalBinary()
 }
 
+// NewCOVNotification notifies the subscriber processId of the present value
+// of an object of the device deviceInstance, timeRemaining being the seconds
+// left in the subscription. Confirmed notifications are to be sent with Request.
+func NewCOVNotification(confirmed bool, processId uint, deviceInstance uint32, objectType uint16, instanceNumber uint32, timeRemaining uint, value float32) ([]byte, error) {
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, confirmed)
+
+	var c *services.COVNotification
+	if confirmed {
+		c = services.NewConfirmedCOVNotification(bvlc, npdu)
+		c.APDU.MaxSize = 5
+		c.APDU.InvokeID = 1
+	} else {
+		c = services.NewUnconfirmedCOVNotification(bvlc, npdu)
+	}
+	c.APDU.Objects = services.COVNotificationObjects(
+		processId, deviceInstance, objectType, instanceNumber, timeRemaining, value)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
 func NewWriteProperty(objectType uint16, instanceNumber uint32, propertyId uint16, data interface{}) ([]byte, error) {
 	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
 	npdu := plumbing.NewNPDU(false, false, false, true)
@@ -166,3 +349,94 @@ func NewWriteProperty(objectType uint16, instanceNumber uint32, propertyId uint1
 
 	return c.MarshalBinary()
 }
+
+// NewWritePropertyValue writes any value objects.EncValue can encode. Passing
+// objects.ArrayAll as arrayIndex and 0 as priority leaves them out.
+func NewWritePropertyValue(objectType uint16, instanceNumber uint32, propertyId uint16, arrayIndex uint32, value interface{}, priority uint8) ([]byte, error) {
+	obj, err := objects.EncValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedWriteProperty(bvlc, npdu)
+
+	c.APDU.Service = services.ServiceConfirmedWriteProperty
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = services.ConfirmedWritePropertyValueObjects(
+		objectType, instanceNumber, propertyId, arrayIndex, obj, priority)
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewWritePropertyMultiple writes the values of specs, several properties of
+// several objects, in a single request.
+func NewWritePropertyMultiple(specs []services.WriteAccessSpecification) ([]byte, error) {
+	objs, err := services.WritePropertyMultipleObjects(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, true)
+
+	c := services.NewConfirmedWritePropertyMultiple(bvlc, npdu)
+
+	c.APDU.MaxSize = 5
+	c.APDU.InvokeID = 1
+	c.APDU.Objects = objs
+
+	c.SetLength()
+
+	return c.MarshalBinary()
+}
+
+// NewChangeListError answers the AddListElement or RemoveListElement invokeID,
+// given by service, whose element firstFailedElement, counted from 1, failed
+// first.
+func NewChangeListError(invokeID, service uint8, errorClass, errorCode uint16, firstFailedElement uint32) ([]byte, error) {
+	objs, err := services.ChangeListErrorObjects(errorClass, errorCode, firstFailedElement)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	e := services.NewError(bvlc, npdu)
+
+	e.APDU.Service = service
+	e.APDU.InvokeID = invokeID
+	e.APDU.Objects = objs
+
+	e.SetLength()
+
+	return e.MarshalBinary()
+}
+
+// NewCreateObjectError answers the CreateObject invokeID whose initial value
+// firstFailedElement, counted from 1, failed first, 0 when none did.
+func NewCreateObjectError(invokeID uint8, errorClass, errorCode uint16, firstFailedElement uint32) ([]byte, error) {
+	objs, err := services.CreateObjectErrorObjects(errorClass, errorCode, firstFailedElement)
+	if err != nil {
+		return nil, err
+	}
+
+	bvlc := plumbing.NewBVLC(plumbing.BVLCFuncUnicast)
+	npdu := plumbing.NewNPDU(false, false, false, false)
+
+	e := services.NewError(bvlc, npdu)
+
+	e.APDU.Service = services.ServiceConfirmedCreateObject
+	e.APDU.InvokeID = invokeID
+	e.APDU.Objects = objs
+
+	e.SetLength()
+
+	return e.MarshalBinary()
+}
@@ -0,0 +1,150 @@
+package bacnet
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+// ServiceError is returned when a peer answers a confirmed request with an Error PDU.
+// It unwraps to an *objects.BACnetError, which errors.Is matches with the
+// sentinel errors of the objects package such as objects.ErrUnknownProperty.
+type ServiceError struct {
+	Service    uint8
+	ErrorClass uint16
+	ErrorCode  uint16
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("%v - service %d", e.Unwrap(), e.Service)
+}
+
+func (e *ServiceError) Unwrap() error {
+	return objects.NewBACnetError(e.ErrorClass, e.ErrorCode)
+}
+
+// WritePropertyMultipleError is returned when a peer answers a
+// WritePropertyMultiple with an Error PDU. The writes before FirstFailedWrite
+// have been done. errors.As finds the embedded ServiceError too.
+type WritePropertyMultipleError struct {
+	ServiceError
+	FirstFailedWrite objects.BACnetObjectPropertyReference
+}
+
+func (e *WritePropertyMultipleError) Error() string {
+	return fmt.Sprintf(
+		"%s - first failed write %v property %d",
+		e.ServiceError.Error(), e.FirstFailedWrite.ObjectId, e.FirstFailedWrite.PropertyId,
+	)
+}
+
+func (e *WritePropertyMultipleError) Unwrap() error {
+	return &e.ServiceError
+}
+
+// ChangeListError is returned when a peer answers an AddListElement or a
+// RemoveListElement with an Error PDU. FirstFailedElement counts from 1.
+type ChangeListError struct {
+	ServiceError
+	FirstFailedElement uint32
+}
+
+func (e *ChangeListError) Error() string {
+	return fmt.Sprintf("%s - first failed element %d", e.ServiceError.Error(), e.FirstFailedElement)
+}
+
+func (e *ChangeListError) Unwrap() error {
+	return &e.ServiceError
+}
+
+// CreateObjectError is returned when a peer answers a CreateObject with an
+// Error PDU. FirstFailedElement counts the initial values from 1, being 0 when
+// the error is not about one of them.
+type CreateObjectError struct {
+	ServiceError
+	FirstFailedElement uint32
+}
+
+func (e *CreateObjectError) Error() string {
+	return fmt.Sprintf("%s - first failed element %d", e.ServiceError.Error(), e.FirstFailedElement)
+}
+
+func (e *CreateObjectError) Unwrap() error {
+	return &e.ServiceError
+}
+
+// serviceError decodes the Error PDU e, the service specific errors included.
+func serviceError(e *services.Error) error {
+	service := e.APDU.Service
+	switch service {
+	case services.ServiceConfirmedWritePropMultiple:
+		decErr, err := e.DecodeWPM()
+		if err != nil {
+			break
+		}
+		return &WritePropertyMultipleError{
+			ServiceError:     ServiceError{service, decErr.ErrorClass, decErr.ErrorCode},
+			FirstFailedWrite: decErr.FirstFailedWrite,
+		}
+	case services.ServiceConfirmedAddListElement, services.ServiceConfirmedRemoveListElement:
+		decErr, err := e.DecodeChangeList()
+		if err != nil {
+			break
+		}
+		return &ChangeListError{
+			ServiceError:       ServiceError{service, decErr.ErrorClass, decErr.ErrorCode},
+			FirstFailedElement: decErr.FirstFailedElement,
+		}
+	case services.ServiceConfirmedCreateObject:
+		decErr, err := e.DecodeCreateObject()
+		if err != nil {
+			break
+		}
+		return &CreateObjectError{
+			ServiceError:       ServiceError{service, decErr.ErrorClass, decErr.ErrorCode},
+			FirstFailedElement: decErr.FirstFailedElement,
+		}
+	}
+
+	// Devices answering with a plain error class and code are fine too.
+	decErr, err := e.Decode()
+	if err != nil {
+		return err
+	}
+	return &ServiceError{Service: service, ErrorClass: decErr.ErrorClass, ErrorCode: decErr.ErrorCode}
+}
+
+// RejectError is returned when a peer answers a confirmed request with a Reject PDU.
+type RejectError struct {
+	InvokeID uint8
+	Reason   services.RejectReason
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("BACnet reject - invoke ID %d reason %s", e.InvokeID, e.Reason)
+}
+
+// AbortError is returned when a transaction is terminated with an Abort PDU.
+type AbortError struct {
+	InvokeID uint8
+	Reason   services.AbortReason
+	Server   bool
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf(
+		"BACnet abort - invoke ID %d reason %s sent by server %t",
+		e.InvokeID, e.Reason, e.Server,
+	)
+}
+
+// BVLCResultError is returned when a BBMD answers with a BVLC-Result NAK,
+// such as plumbing.BVLCResultRegisterForeignDeviceNAK.
+type BVLCResultError struct {
+	Code uint16
+}
+
+func (e *BVLCResultError) Error() string {
+	return fmt.Sprintf("BVLC result NAK %#04x", e.Code)
+}
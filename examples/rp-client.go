@@ -5,12 +5,12 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net"
 	"time"
 
 	"github.com/Nortech-ai/bacnet"
-	"github.com/Nortech-ai/bacnet/plumbing"
 	"github.com/Nortech-ai/bacnet/services"
 	"github.com/spf13/cobra"
 )
@@ -49,44 +49,33 @@ func ReadPropertyClientExample(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to begin listening for packets: %v\n", err)
 	}
-	defer listenConn.Close()
+	client := bacnet.NewClient(listenConn)
+	defer client.Close()
 
 	mReadProperty, err := bacnet.NewReadProperty(rpObjectType, rpInstanceId, rpPropertyId)
 	if err != nil {
 		log.Fatalf("error generating initial ReadProperty: %v\n", err)
 	}
 
-	replyRaw := make([]byte, 1024)
 	sentRequests := 0
 	for {
-		listenConn.SetDeadline(time.Now().Add(5 * time.Second))
-		if _, err := listenConn.WriteTo(mReadProperty, remoteUDPAddr); err != nil {
-			log.Fatalf("Failed to write the request: %s\n", err)
-		}
-
-		log.Printf("sent: %x", mReadProperty)
-
-		nBytes, remoteAddr, err := listenConn.ReadFrom(replyRaw)
-		if err != nil {
-			log.Fatalf("error reading incoming packet: %v\n", err)
-		}
+		log.Printf("sending: %x", mReadProperty)
 
-		log.Printf("read %d bytes from %s: %x\n", nBytes, remoteAddr, replyRaw[:nBytes])
-
-		serviceMsg, err := bacnet.Parse(replyRaw[:nBytes])
-		if err != nil {
-			log.Fatalf("error parsing the received message: %v\n", err)
+		serviceMsg, err := client.Request(remoteUDPAddr, mReadProperty)
+		var svcErr *bacnet.ServiceError
+		if errors.As(err, &svcErr) {
+			log.Printf("decoded Error reply:\n\tError Class: %d\n\tError Code: %d\n",
+				svcErr.ErrorClass, svcErr.ErrorCode,
+			)
+		} else if err != nil {
+			log.Fatalf("error requesting the property: %v\n", err)
 		}
 
-		// switch between recieved message type
-		t := serviceMsg.GetType()
-		switch t {
-		case plumbing.ComplexAck:
+		if serviceMsg != nil {
 			cACKEnc, ok := serviceMsg.(*services.ComplexACK)
 			if !ok {
 				log.Fatalf("we didn't receive a CACK reply...\n")
 			}
-			log.Printf("unmarshalled BVLC: %#v\n", cACKEnc.BVLC)
 			log.Printf("unmarshalled NPDU: %#v\n", cACKEnc.NPDU)
 
 			decodedCACK, err := cACKEnc.Decode()
@@ -94,21 +83,6 @@ func ReadPropertyClientExample(cmd *cobra.Command, args []string) {
 				log.Fatalf("couldn't decode the CACK reply: %v\n", err)
 			}
 			printCACK(&decodedCACK)
-		case plumbing.Error:
-			errEnc, ok := serviceMsg.(*services.Error)
-			if !ok {
-				log.Fatalf("we didn't receive an Error reply...\n")
-			}
-			log.Printf("unmarshalled BVLC: %#v\n", errEnc.BVLC)
-			log.Printf("unmarshalled NPDU: %#v\n", errEnc.NPDU)
-
-			decodedErr, err := errEnc.Decode()
-			if err != nil {
-				log.Fatalf("couldn't decode the Error reply: %v\n", err)
-			}
-			log.Printf("decoded Error reply:\n\tError Class: %d\n\tError Code: %d\n",
-				decodedErr.ErrorClass, decodedErr.ErrorCode,
-			)
 		}
 
 		sentRequests++
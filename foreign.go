@@ -0,0 +1,137 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/transport"
+)
+
+// ForeignDevice is the registration of a Client as a foreign device with a
+// BBMD, renewed halfway through its TTL until closed.
+type ForeignDevice struct {
+	c    *Client
+	bip  *transport.BIP
+	bbmd net.Addr
+	ttl  uint16
+
+	// ctx is done once the renewals are stopped, done closed once they are.
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+// RegisterForeignDevice registers the Client as a foreign device with the BBMD
+// at bbmd for ttl, rounded up to whole seconds. It waits for the first
+// registration until ctx is done, returning a *BVLCResultError if the BBMD
+// refuses it. The registration is then renewed halfway through ttl until the
+// ForeignDevice or the Client is closed. While registered, broadcasts given to
+// Send are sent to the BBMD as Distribute-Broadcast-To-Network. Only the
+// Clients over BACnet/IP register.
+func (c *Client) RegisterForeignDevice(ctx context.Context, bbmd net.Addr, ttl time.Duration) (*ForeignDevice, error) {
+	bip, ok := c.transport.(*transport.BIP)
+	if !ok {
+		return nil, fmt.Errorf("registering with %s off BACnet/IP: %v", bbmd, common.ErrWrongStructure)
+	}
+	secs := math.Ceil(ttl.Seconds())
+	if secs < 1 || secs > math.MaxUint16 {
+		return nil, fmt.Errorf("foreign device TTL %s: %v", ttl, common.ErrWrongStructure)
+	}
+
+	fd := &ForeignDevice{c: c, bip: bip, bbmd: bbmd, ttl: uint16(secs), done: make(chan struct{})}
+	if err := fd.register(ctx); err != nil {
+		return nil, err
+	}
+	fd.ctx, fd.cancel = context.WithCancel(context.Background())
+	go fd.renew()
+	return fd, nil
+}
+
+// Err returns the error of the last re-registration, such as a
+// *BVLCResultError if the BBMD refused it, or nil if it succeeded.
+func (fd *ForeignDevice) Err() error {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.err
+}
+
+// Close stops renewing the registration and asks the BBMD to delete the
+// Client from its foreign device table, returning a *BVLCResultError if the
+// BBMD refuses. Broadcasts are no longer sent to the BBMD from now on.
+func (fd *ForeignDevice) Close() error {
+	var err error
+	fd.once.Do(func() {
+		fd.cancel()
+		<-fd.done
+		err = fd.c.requestBVLL(context.Background(), fd.bbmd, func(ctx context.Context) (uint16, error) {
+			return fd.bip.DeleteForeignDevice(ctx, fd.bbmd)
+		})
+	})
+	return err
+}
+
+// renew re-registers halfway through the TTL until the ForeignDevice or the
+// Client is closed.
+func (fd *ForeignDevice) renew() {
+	defer close(fd.done)
+
+	ticker := time.NewTicker(time.Duration(fd.ttl) * time.Second / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fd.ctx.Done():
+			return
+		case <-fd.c.done:
+			return
+		case <-ticker.C:
+		}
+		err := fd.register(fd.ctx)
+		if fd.ctx.Err() != nil {
+			return
+		}
+		fd.mu.Lock()
+		fd.err = err
+		fd.mu.Unlock()
+	}
+}
+
+func (fd *ForeignDevice) register(ctx context.Context) error {
+	return fd.c.requestBVLL(ctx, fd.bbmd, func(ctx context.Context) (uint16, error) {
+		return fd.bip.RegisterForeignDevice(ctx, fd.bbmd, fd.ttl)
+	})
+}
+
+// requestBVLL makes request, a BVLL request to bbmd answered with a
+// BVLC-Result, retrying as confirmed requests do.
+func (c *Client) requestBVLL(ctx context.Context, bbmd net.Addr, request func(context.Context) (uint16, error)) error {
+	for retry := 0; retry <= c.APDURetries; retry++ {
+		attempt, cancel := context.WithTimeout(ctx, c.APDUTimeout)
+		code, err := request(attempt)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if code != plumbing.BVLCResultSuccessful {
+			return &BVLCResultError{Code: code}
+		}
+		return nil
+	}
+
+	return fmt.Errorf(
+		"requesting %s after %d retries: %w", bbmd, c.APDURetries, common.ErrTimeout,
+	)
+}
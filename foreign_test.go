@@ -0,0 +1,153 @@
+package bacnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+// serveBBMD answers Register-Foreign-Device and Delete-Foreign-Device-Table-
+// Entry on conn with results, the last one answering the requests past them,
+// and passes on every message it gets to msgs.
+func serveBBMD(t *testing.T, conn net.PacketConn, results []uint16, msgs chan<- plumbing.BACnet) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		msg, err := Parse(buf[:n])
+		if err != nil {
+			t.Errorf("parsing: %v", err)
+			return
+		}
+		msgs <- msg
+		if m, ok := msg.(*services.BVLLMessage); ok && (m.GetType() == plumbing.BVLCFuncRegisterForeignDevice ||
+			m.GetType() == plumbing.BVLCFuncDeleteFDTEntry) {
+			reply, err := NewBVLCResult(results[0])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(results) > 1 {
+				results = results[1:]
+			}
+			conn.WriteTo(reply, addr)
+		}
+	}
+}
+
+func newForeignPair(t *testing.T, results ...uint16) (*Client, net.Addr, chan plumbing.BACnet) {
+	bbmd, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bbmd.Close() })
+	msgs := make(chan plumbing.BACnet, 16)
+	go serveBBMD(t, bbmd, results, msgs)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(conn)
+	c.APDUTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+
+	return c, bbmd.LocalAddr(), msgs
+}
+
+func TestClientForeignDevice(t *testing.T) {
+	c, bbmd, msgs := newForeignPair(t, plumbing.BVLCResultSuccessful)
+	// The context only bounds the first registration.
+	ctx, cancel := context.WithCancel(context.Background())
+	fd, err := c.RegisterForeignDevice(ctx, bbmd, time.Second)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := (<-msgs).(*services.BVLLMessage)
+	if dec, err := m.Decode(); err != nil || dec.TTL != 1 {
+		t.Errorf("expected TTL 1, got %+v %v", dec, err)
+	}
+
+	whois, err := NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	broadcast := &net.UDPAddr{IP: net.IPv4bcast, Port: 47808}
+	if err := c.Send(broadcast, whois); err != nil {
+		t.Fatal(err)
+	}
+	w, ok := (<-msgs).(*services.UnconfirmedWhoIs)
+	if !ok || w.BVLC.Function != plumbing.BVLCFuncDistributeBroadcast {
+		t.Errorf("expected Distribute-Broadcast-To-Network WhoIs, got %v", w)
+	}
+
+	// The registration is renewed halfway through the TTL.
+	select {
+	case msg := <-msgs:
+		if msg.GetType() != plumbing.BVLCFuncRegisterForeignDevice {
+			t.Errorf("expected Register-Foreign-Device, got %v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("registration not renewed")
+	}
+	if err := fd.Err(); err != nil {
+		t.Error(err)
+	}
+
+	// Closing deletes the FDT entry of the Client.
+	if err := fd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d, ok := (<-msgs).(*services.BVLLMessage)
+	if !ok || d.GetType() != plumbing.BVLCFuncDeleteFDTEntry {
+		t.Fatalf("expected Delete-Foreign-Device-Table-Entry, got %v", d)
+	}
+	if dec, err := d.Decode(); err != nil || dec.Address.String() != c.LocalAddr().String() {
+		t.Errorf("expected the entry of %s, got %+v %v", c.LocalAddr(), dec, err)
+	}
+	select {
+	case msg := <-msgs:
+		t.Errorf("expected no renewal after Close, got %v", msg)
+	case <-time.After(700 * time.Millisecond):
+	}
+}
+
+func TestClientForeignDeviceNAK(t *testing.T) {
+	c, bbmd, _ := newForeignPair(t, plumbing.BVLCResultRegisterForeignDeviceNAK)
+
+	_, err := c.RegisterForeignDevice(context.Background(), bbmd, time.Minute)
+	var nak *BVLCResultError
+	if !errors.As(err, &nak) || nak.Code != plumbing.BVLCResultRegisterForeignDeviceNAK {
+		t.Fatalf("expected Register-Foreign-Device NAK, got %v", err)
+	}
+}
+
+func TestClientForeignDeviceRenewalNAK(t *testing.T) {
+	c, bbmd, msgs := newForeignPair(t, plumbing.BVLCResultSuccessful, plumbing.BVLCResultRegisterForeignDeviceNAK)
+
+	fd, err := c.RegisterForeignDevice(context.Background(), bbmd, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fd.Close() })
+	<-msgs
+	<-msgs
+
+	// The refused renewal is reported through the ForeignDevice.
+	var nak *BVLCResultError
+	deadline := time.Now().Add(time.Second)
+	for !errors.As(fd.Err(), &nak) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if nak == nil || nak.Code != plumbing.BVLCResultRegisterForeignDeviceNAK {
+		t.Errorf("expected Register-Foreign-Device NAK, got %v", fd.Err())
+	}
+}
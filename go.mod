@@ -5,6 +5,7 @@ go 1.19
 require (
 	github.com/google/go-cmp v0.5.0
 	github.com/spf13/cobra v1.7.0
+	golang.org/x/text v0.14.0
 )
 
 require (
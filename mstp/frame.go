@@ -0,0 +1,240 @@
+package mstp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Frame types, as defined in Clause 9.
+const (
+	FrameToken                         = 0x00
+	FramePollForMaster                 = 0x01
+	FrameReplyToPollForMaster          = 0x02
+	FrameTestRequest                   = 0x03
+	FrameTestResponse                  = 0x04
+	FrameDataExpectingReply            = 0x05
+	FrameDataNotExpectingReply         = 0x06
+	FrameReplyPostponed                = 0x07
+	FrameExtendedDataExpectingReply    = 0x20
+	FrameExtendedDataNotExpectingReply = 0x21
+	FrameExtendedTestRequest           = 0x22
+	FrameExtendedTestResponse          = 0x23
+)
+
+const (
+	// BroadcastAddress is the MS/TP address reaching every station.
+	BroadcastAddress = 0xff
+	// MaxDataLen is the largest data of a frame that is not COBS-encoded and
+	// MaxExtendedDataLen the largest data of an extended frame.
+	MaxDataLen         = 501
+	MaxExtendedDataLen = 1497
+)
+
+const (
+	preamble1  = 0x55
+	preamble2  = 0xff
+	headerLen  = 8
+	crc16Len   = 2
+	cobsMask   = 0x55
+	cobsCRCLen = 5
+)
+
+// Frame is an MS/TP frame.
+type Frame struct {
+	Type        uint8
+	Destination uint8
+	Source      uint8
+	Data        []byte
+}
+
+// IsExtended tells whether the frame type carries COBS-encoded data.
+func (f *Frame) IsExtended() bool {
+	return isExtended(f.Type)
+}
+
+func isExtended(t uint8) bool {
+	return t >= FrameExtendedDataExpectingReply && t <= FrameExtendedTestResponse
+}
+
+// MarshalBinary returns the byte sequence generated from a Frame instance,
+// with its preamble and CRCs.
+func (f *Frame) MarshalBinary() ([]byte, error) {
+	data := f.Data
+	if f.IsExtended() {
+		if len(f.Data) > MaxExtendedDataLen {
+			return nil, fmt.Errorf("marshalling extended frame data of %d octets: %v", len(f.Data), common.ErrTooBigValue)
+		}
+		data = cobsFrameEncode(f.Data)
+	} else if len(f.Data) > MaxDataLen {
+		return nil, fmt.Errorf("marshalling frame data of %d octets: %v", len(f.Data), common.ErrTooBigValue)
+	}
+
+	length := len(data)
+	if f.IsExtended() {
+		// The COBS-encoded data replaces the data CRC and is counted without it.
+		length -= crc16Len
+	}
+	b := make([]byte, headerLen, headerLen+len(data)+crc16Len)
+	b[0], b[1] = preamble1, preamble2
+	b[2], b[3], b[4] = f.Type, f.Destination, f.Source
+	binary.BigEndian.PutUint16(b[5:7], uint16(length))
+	b[7] = ^headerCRC(b[2:7])
+
+	if len(data) == 0 {
+		return b, nil
+	}
+	b = append(b, data...)
+	if !f.IsExtended() {
+		b = binary.LittleEndian.AppendUint16(b, ^dataCRC(data))
+	}
+	return b, nil
+}
+
+// unmarshalHeader decodes the header b following the preamble, returning the
+// number of octets that follow it.
+func (f *Frame) unmarshalHeader(b []byte) (int, error) {
+	if len(b) < headerLen-2 {
+		return 0, fmt.Errorf("unmarshalling MS/TP header %x: %v", b, common.ErrTooShortToParse)
+	}
+	if headerCRC(b[:6]) != 0x55 {
+		return 0, fmt.Errorf("unmarshalling MS/TP header %x: %v", b, common.ErrInvalidData)
+	}
+	f.Type, f.Destination, f.Source = b[0], b[1], b[2]
+	f.Data = nil
+
+	length := int(binary.BigEndian.Uint16(b[3:5]))
+	if length == 0 {
+		return 0, nil
+	}
+	return length + crc16Len, nil
+}
+
+// unmarshalData decodes the data b following the header, along with its CRC.
+func (f *Frame) unmarshalData(b []byte) error {
+	if f.IsExtended() {
+		data, err := cobsFrameDecode(b)
+		if err != nil {
+			return err
+		}
+		f.Data = data
+		return nil
+	}
+	if len(b) < crc16Len || dataCRC(b) != 0xf0b8 {
+		return fmt.Errorf("unmarshalling MS/TP data %x: %v", b, common.ErrInvalidData)
+	}
+	f.Data = append([]byte{}, b[:len(b)-crc16Len]...)
+	return nil
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a Frame,
+// starting with its preamble.
+func (f *Frame) UnmarshalBinary(b []byte) error {
+	if len(b) < headerLen {
+		return fmt.Errorf("unmarshalling MS/TP frame %x: %v", b, common.ErrTooShortToParse)
+	}
+	if b[0] != preamble1 || b[1] != preamble2 {
+		return fmt.Errorf("unmarshalling MS/TP preamble %x: %v", b[:2], common.ErrInvalidData)
+	}
+	n, err := f.unmarshalHeader(b[2:headerLen])
+	if err != nil || n == 0 {
+		return err
+	}
+	if len(b) < headerLen+n {
+		return fmt.Errorf("unmarshalling MS/TP frame %x: %v", b, common.ErrTooShortToParse)
+	}
+	return f.unmarshalData(b[headerLen : headerLen+n])
+}
+
+// headerCRC computes the CRC-8 of the header octets b.
+func headerCRC(b []byte) uint8 {
+	crc := uint8(0xff)
+	for _, d := range b {
+		c := uint16(crc ^ d)
+		c = c ^ c<<1 ^ c<<2 ^ c<<3 ^ c<<4 ^ c<<5 ^ c<<6 ^ c<<7
+		crc = uint8(c&0xfe) ^ uint8(c>>8&1)
+	}
+	return crc
+}
+
+// dataCRC computes the CRC-16 of the data octets b.
+func dataCRC(b []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, d := range b {
+		low := crc&0xff ^ uint16(d)
+		crc = crc>>8 ^ low<<8 ^ low<<3 ^ low<<12 ^ low>>4 ^ low&0x0f ^ (low&0x0f)<<7
+	}
+	return crc
+}
+
+var crc32K = crc32.MakeTable(crc32.Koopman)
+
+// cobsFrameEncode COBS-encodes data followed by its CRC-32K, as extended
+// frames carry them.
+func cobsFrameEncode(data []byte) []byte {
+	b := cobsEncode(data)
+	crc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(crc, crc32.Checksum(b, crc32K))
+	return append(b, cobsEncode(crc)...)
+}
+
+func cobsFrameDecode(b []byte) ([]byte, error) {
+	if len(b) < cobsCRCLen {
+		return nil, fmt.Errorf("decoding COBS data %x: %v", b, common.ErrTooShortToParse)
+	}
+	encoded := b[:len(b)-cobsCRCLen]
+	crc, err := cobsDecode(b[len(b)-cobsCRCLen:])
+	if err != nil || len(crc) != 4 || binary.LittleEndian.Uint32(crc) != crc32.Checksum(encoded, crc32K) {
+		return nil, fmt.Errorf("decoding COBS data %x: %v", b, common.ErrInvalidData)
+	}
+	return cobsDecode(encoded)
+}
+
+// cobsEncode encodes b with Consistent Overhead Byte Stuffing, masking every
+// octet so that the encoded data holds no preamble.
+func cobsEncode(b []byte) []byte {
+	out := make([]byte, 1, len(b)+len(b)/254+2)
+	codeIndex := 0
+	code := uint8(1)
+	lastCode := uint8(0)
+	for _, d := range b {
+		if d != 0 {
+			out = append(out, d^cobsMask)
+			code++
+			if code != 0xff {
+				continue
+			}
+		}
+		lastCode = code
+		out[codeIndex] = code ^ cobsMask
+		codeIndex = len(out)
+		out = append(out, 0)
+		code = 1
+	}
+	// Blocks of exactly 254 non-zero octets at the end take no phantom zero.
+	if lastCode == 0xff && code == 1 {
+		return out[:len(out)-1]
+	}
+	out[codeIndex] = code ^ cobsMask
+	return out
+}
+
+func cobsDecode(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		code := b[i] ^ cobsMask
+		if code == 0 || i+int(code) > len(b) {
+			return nil, fmt.Errorf("decoding COBS block %x: %v", b[i:], common.ErrInvalidData)
+		}
+		for _, d := range b[i+1 : i+int(code)] {
+			out = append(out, d^cobsMask)
+		}
+		i += int(code)
+		if code != 0xff && i < len(b) {
+			out = append(out, 0)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package mstp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFrameMarshal(t *testing.T) {
+	cases := []struct {
+		name  string
+		frame Frame
+		want  []byte
+	}{
+		{
+			name:  "token",
+			frame: Frame{Type: FrameToken, Destination: 0x10, Source: 0x05},
+			want:  []byte{0x55, 0xff, 0x00, 0x10, 0x05, 0x00, 0x00, 0x8c},
+		},
+		{
+			name:  "data",
+			frame: Frame{Type: FrameDataNotExpectingReply, Destination: 0xff, Source: 0x01, Data: []byte{0x01, 0x22, 0x30}},
+			want:  []byte{0x55, 0xff, 0x06, 0xff, 0x01, 0x00, 0x03, 0x7d, 0x01, 0x22, 0x30, 0x10, 0xbd},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := c.frame.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, b); diff != "" {
+				t.Errorf("differs: (-want +got)\n%s", diff)
+			}
+
+			var got Frame
+			if err := got.UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.frame, got); diff != "" {
+				t.Errorf("differs: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExtendedFrame(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i % 7)
+	}
+	f := Frame{Type: FrameExtendedDataNotExpectingReply, Destination: 2, Source: 1, Data: data}
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The COBS-encoded data holds no preamble to resynchronize on.
+	if bytes.Contains(b[headerLen:], []byte{preamble1, preamble2}) {
+		t.Error("encoded data holds a preamble")
+	}
+
+	var got Frame
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Data, data) {
+		t.Errorf("unexpected data %x", got.Data)
+	}
+
+	b[headerLen+10] ^= 0x01
+	if err := got.UnmarshalBinary(b); err == nil {
+		t.Error("expected a CRC error")
+	}
+}
+
+func TestCOBS(t *testing.T) {
+	for _, l := range []int{0, 1, 253, 254, 255, 508, 600} {
+		data := bytes.Repeat([]byte{0x42}, l)
+		got, err := cobsDecode(cobsEncode(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%d octets: unexpected round trip %x", l, got)
+		}
+	}
+}
@@ -0,0 +1,615 @@
+// Package mstp implements the BACnet MS/TP datalink of Clause 9: frame
+// encoding and decoding, including the COBS-encoded extended frames, and a
+// master node passing the token over any serial line given as an io.ReadWriter.
+package mstp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Default MS/TP parameters and timings, as defined in Clause 9.5.3 and 9.5.4.
+const (
+	DEFAULT_MAX_MASTER      = 127
+	DEFAULT_MAX_INFO_FRAMES = 1
+
+	npoll       = 50
+	nretryToken = 1
+
+	tnoToken      = 500 * time.Millisecond
+	treplyDelay   = 250 * time.Millisecond
+	treplyTimeout = 255 * time.Millisecond
+	tslot         = 10 * time.Millisecond
+	tusageTimeout = 20 * time.Millisecond
+)
+
+// maxQueued bounds the frames awaiting the token.
+const maxQueued = 64
+
+// NodeConfig configures a Node.
+type NodeConfig struct {
+	// Address is the MS/TP address of the node, from 0 to MaxMaster.
+	Address uint8
+	// MaxMaster is the highest address of the masters polled,
+	// DEFAULT_MAX_MASTER if zero.
+	MaxMaster uint8
+	// MaxInfoFrames is the number of frames sent per token,
+	// DEFAULT_MAX_INFO_FRAMES if zero.
+	MaxInfoFrames int
+}
+
+// Message is an NPDU received from the station at Source, Destination being
+// the node or BroadcastAddress. Messages expecting a reply should be answered
+// with Send within 250 ms to be answered while the requester waits, or are
+// otherwise postponed.
+type Message struct {
+	Source         uint8
+	Destination    uint8
+	NPDU           []byte
+	ExpectingReply bool
+}
+
+type outFrame struct {
+	dst            uint8
+	npdu           []byte
+	expectingReply bool
+}
+
+type state int
+
+const (
+	stateIdle state = iota
+	stateUseToken
+	stateWaitForReply
+	stateDoneWithToken
+	statePassToken
+	stateNoToken
+	statePollForMaster
+)
+
+// Node is an MS/TP master node. It is safe for concurrent use.
+type Node struct {
+	rw            io.ReadWriter
+	ts            uint8
+	maxMaster     uint8
+	maxInfoFrames int
+
+	mu     sync.Mutex
+	queue  []outFrame
+	queued chan struct{}
+
+	frames   chan Frame
+	messages chan Message
+	done     chan struct{}
+	closed   chan struct{}
+	once     sync.Once
+	err      error
+
+	// The state machine variables are only used by run.
+	ns, ps     uint8
+	tokenCount int
+	frameCount int
+	retryCount int
+	soleMaster bool
+	// pending is a frame received while waiting for something else, to be
+	// handled in the idle state.
+	pending *Frame
+}
+
+// NewNode creates a master node configured with config on the serial line rw,
+// and starts taking part in the token passing. The Node owns rw from now on
+// and closes it on Close if it is an io.Closer.
+func NewNode(rw io.ReadWriter, config NodeConfig) (*Node, error) {
+	n := &Node{
+		rw:            rw,
+		ts:            config.Address,
+		maxMaster:     config.MaxMaster,
+		maxInfoFrames: config.MaxInfoFrames,
+		queued:        make(chan struct{}, 1),
+		frames:        make(chan Frame, 16),
+		messages:      make(chan Message, 64),
+		done:          make(chan struct{}),
+		closed:        make(chan struct{}),
+	}
+	if n.maxMaster == 0 {
+		n.maxMaster = DEFAULT_MAX_MASTER
+	}
+	if n.maxInfoFrames <= 0 {
+		n.maxInfoFrames = DEFAULT_MAX_INFO_FRAMES
+	}
+	if n.maxMaster > DEFAULT_MAX_MASTER || n.ts > n.maxMaster {
+		return nil, fmt.Errorf("master address %d of %d: %v", n.ts, n.maxMaster, common.ErrWrongStructure)
+	}
+
+	go n.readLoop()
+	go n.run()
+	return n, nil
+}
+
+// Address returns the MS/TP address of the node.
+func (n *Node) Address() uint8 {
+	return n.ts
+}
+
+// Send queues npdu for the station at dst, or every station for
+// BroadcastAddress, to be sent once the node holds the token. NPDUs longer
+// than MaxDataLen are sent in extended frames.
+func (n *Node) Send(dst uint8, npdu []byte, expectingReply bool) error {
+	if len(npdu) > MaxExtendedDataLen {
+		return fmt.Errorf("sending NPDU of %d octets: %v", len(npdu), common.ErrTooBigValue)
+	}
+	if expectingReply && dst == BroadcastAddress {
+		return fmt.Errorf("broadcasting NPDU expecting reply: %v", common.ErrWrongStructure)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.queue) >= maxQueued {
+		return fmt.Errorf("sending to %d: %d NPDUs queued: %v", dst, len(n.queue), common.ErrTooBigValue)
+	}
+	n.queue = append(n.queue, outFrame{dst, append([]byte{}, npdu...), expectingReply})
+	select {
+	case n.queued <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Receive waits for the next NPDU sent to the node, returning an error once
+// the node is closed or its serial line failed.
+func (n *Node) Receive(ctx context.Context) (Message, error) {
+	select {
+	case m := <-n.messages:
+		return m, nil
+	case <-n.done:
+		return Message{}, n.err
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// Close stops the node, leaving the token to the other masters.
+func (n *Node) Close() error {
+	n.once.Do(func() { close(n.closed) })
+	if c, ok := n.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// readLoop receives the frames from the serial line, dropping the ones with
+// a wrong CRC and the ones the node sent, which half-duplex lines echo.
+func (n *Node) readLoop() {
+	defer close(n.frames)
+	r := bufio.NewReader(n.rw)
+	header := make([]byte, headerLen-2)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			n.fail(err)
+			return
+		}
+		if b != preamble1 {
+			continue
+		}
+		if b, err = r.ReadByte(); err != nil {
+			n.fail(err)
+			return
+		}
+		if b != preamble2 {
+			r.UnreadByte()
+			continue
+		}
+
+		if _, err := io.ReadFull(r, header); err != nil {
+			n.fail(err)
+			return
+		}
+		var f Frame
+		l, err := f.unmarshalHeader(header)
+		if err != nil {
+			continue
+		}
+		if l > 0 {
+			data := make([]byte, l)
+			if _, err := io.ReadFull(r, data); err != nil {
+				n.fail(err)
+				return
+			}
+			if err := f.unmarshalData(data); err != nil {
+				continue
+			}
+		}
+		if f.Source == n.ts {
+			continue
+		}
+		select {
+		case n.frames <- f:
+		case <-n.closed:
+			return
+		}
+	}
+}
+
+func (n *Node) fail(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err == nil {
+		n.err = fmt.Errorf("reading serial line: %v: %w", err, common.ErrClientClosed)
+	}
+}
+
+// run runs the master node state machine of Clause 9.5.6 until the node is
+// closed or its serial line fails.
+func (n *Node) run() {
+	defer func() {
+		n.mu.Lock()
+		if n.err == nil {
+			n.err = common.ErrClientClosed
+		}
+		n.mu.Unlock()
+		close(n.done)
+	}()
+
+	n.ns, n.ps = n.ts, n.ts
+	n.tokenCount = npoll
+	st := stateIdle
+	for {
+		select {
+		case <-n.closed:
+			return
+		default:
+		}
+
+		var ok bool
+		switch st {
+		case stateIdle:
+			st, ok = n.idle()
+		case stateUseToken:
+			st, ok = n.useToken()
+		case stateWaitForReply:
+			st, ok = n.waitForReply()
+		case stateDoneWithToken:
+			st, ok = n.doneWithToken()
+		case statePassToken:
+			st, ok = n.passToken()
+		case stateNoToken:
+			st, ok = n.noToken()
+		case statePollForMaster:
+			st, ok = n.pollForMaster()
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// receive waits up to timeout for the next frame, returning nil on timeout
+// and false once the serial line is gone.
+func (n *Node) receive(timeout time.Duration) (*Frame, bool) {
+	if f := n.pending; f != nil {
+		n.pending = nil
+		return f, true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case f, ok := <-n.frames:
+		if !ok {
+			return nil, false
+		}
+		return &f, true
+	case <-n.closed:
+		return nil, false
+	case <-timer.C:
+		return nil, true
+	}
+}
+
+func (n *Node) idle() (state, bool) {
+	f, ok := n.receive(tnoToken)
+	if !ok {
+		return 0, false
+	}
+	if f == nil {
+		return stateNoToken, true
+	}
+	return n.handle(f)
+}
+
+// handle handles a frame received while not holding the token.
+func (n *Node) handle(f *Frame) (state, bool) {
+	if f.Destination != n.ts && f.Destination != BroadcastAddress {
+		return stateIdle, true
+	}
+
+	switch f.Type {
+	case FrameToken:
+		if f.Destination == n.ts {
+			n.frameCount = 0
+			n.soleMaster = false
+			return stateUseToken, true
+		}
+	case FramePollForMaster:
+		if f.Destination == n.ts {
+			n.write(Frame{Type: FrameReplyToPollForMaster, Destination: f.Source, Source: n.ts})
+		}
+	case FrameDataNotExpectingReply, FrameExtendedDataNotExpectingReply:
+		n.deliver(f, false)
+	case FrameDataExpectingReply, FrameExtendedDataExpectingReply:
+		if f.Destination == n.ts {
+			n.deliver(f, true)
+			n.answer(f.Source)
+		}
+	case FrameTestRequest, FrameExtendedTestRequest:
+		if f.Destination == n.ts {
+			t := uint8(FrameTestResponse)
+			if f.IsExtended() {
+				t = FrameExtendedTestResponse
+			}
+			n.write(Frame{Type: t, Destination: f.Source, Source: n.ts, Data: f.Data})
+		}
+	}
+	return stateIdle, true
+}
+
+// answer sends the reply to a data request from src queued within
+// Treply_delay, or a Reply Postponed frame.
+func (n *Node) answer(src uint8) {
+	timer := time.NewTimer(treplyDelay)
+	defer timer.Stop()
+	for {
+		if out, ok := n.dequeue(func(o outFrame) bool { return o.dst == src && !o.expectingReply }); ok {
+			n.writeData(out)
+			return
+		}
+		select {
+		case <-n.queued:
+		case <-n.closed:
+			return
+		case <-timer.C:
+			n.write(Frame{Type: FrameReplyPostponed, Destination: src, Source: n.ts})
+			return
+		}
+	}
+}
+
+func (n *Node) useToken() (state, bool) {
+	if n.frameCount >= n.maxInfoFrames {
+		return stateDoneWithToken, true
+	}
+	out, ok := n.dequeue(nil)
+	if !ok && n.soleMaster {
+		// Alone on the line, the token is kept until there is something to send.
+		select {
+		case <-n.queued:
+		case <-n.closed:
+			return 0, false
+		case <-time.After(tnoToken / 2):
+		}
+		out, ok = n.dequeue(nil)
+	}
+	if !ok {
+		return stateDoneWithToken, true
+	}
+
+	n.writeData(out)
+	n.frameCount++
+	if out.expectingReply {
+		return stateWaitForReply, true
+	}
+	return stateUseToken, true
+}
+
+func (n *Node) waitForReply() (state, bool) {
+	f, ok := n.receive(treplyTimeout)
+	if !ok {
+		return 0, false
+	}
+	if f == nil {
+		n.frameCount = n.maxInfoFrames
+		return stateDoneWithToken, true
+	}
+	if f.Destination != n.ts {
+		n.pending = f
+		return stateIdle, true
+	}
+
+	switch f.Type {
+	case FrameDataNotExpectingReply, FrameExtendedDataNotExpectingReply:
+		n.deliver(f, false)
+	case FrameTestResponse, FrameExtendedTestResponse, FrameReplyPostponed:
+	default:
+		n.pending = f
+		return stateIdle, true
+	}
+	return stateDoneWithToken, true
+}
+
+func (n *Node) doneWithToken() (state, bool) {
+	if n.frameCount < n.maxInfoFrames && n.hasQueued() {
+		return stateUseToken, true
+	}
+
+	if n.tokenCount < npoll-1 {
+		n.tokenCount++
+		if n.soleMaster {
+			n.frameCount = 0
+			return stateUseToken, true
+		}
+		return n.passTo(n.ns), true
+	}
+
+	// Every Npoll tokens, a station between this one and the next is polled.
+	if next := n.next(n.ps); next != n.ns && next != n.ts {
+		n.ps = next
+		n.pollFor(n.ps)
+		return statePollForMaster, true
+	}
+	n.ps = n.ts
+	n.tokenCount = 1
+	if n.soleMaster || n.ns == n.ts {
+		n.frameCount = 0
+		return stateUseToken, true
+	}
+	return n.passTo(n.ns), true
+}
+
+func (n *Node) passTo(dst uint8) state {
+	n.retryCount = 0
+	n.write(Frame{Type: FrameToken, Destination: dst, Source: n.ts})
+	return statePassToken
+}
+
+func (n *Node) passToken() (state, bool) {
+	f, ok := n.receive(tusageTimeout)
+	if !ok {
+		return 0, false
+	}
+	if f != nil {
+		// The next station uses the token.
+		n.pending = f
+		return stateIdle, true
+	}
+	if n.retryCount < nretryToken {
+		n.retryCount++
+		n.write(Frame{Type: FrameToken, Destination: n.ns, Source: n.ts})
+		return statePassToken, true
+	}
+
+	// The next station is gone, look for a new one after it.
+	n.ps = n.next(n.ns)
+	n.ns = n.ts
+	n.tokenCount = 0
+	if n.ps == n.ts {
+		n.soleMaster = true
+		n.frameCount = 0
+		return stateUseToken, true
+	}
+	n.pollFor(n.ps)
+	return statePollForMaster, true
+}
+
+func (n *Node) noToken() (state, bool) {
+	// Stations wait for a time depending on their address before generating
+	// a token, so that a single one does.
+	f, ok := n.receive(tslot * time.Duration(n.ts+1))
+	if !ok {
+		return 0, false
+	}
+	if f != nil {
+		n.pending = f
+		return stateIdle, true
+	}
+
+	n.ps = n.next(n.ts)
+	n.ns = n.ts
+	n.tokenCount = 0
+	if n.ps == n.ts {
+		n.soleMaster = true
+		n.frameCount = 0
+		return stateUseToken, true
+	}
+	n.pollFor(n.ps)
+	return statePollForMaster, true
+}
+
+func (n *Node) pollForMaster() (state, bool) {
+	f, ok := n.receive(tusageTimeout)
+	if !ok {
+		return 0, false
+	}
+	if f != nil {
+		if f.Destination == n.ts && f.Type == FrameReplyToPollForMaster {
+			n.soleMaster = false
+			n.ns = f.Source
+			n.ps = n.ts
+			n.tokenCount = 0
+			return n.passTo(n.ns), true
+		}
+		n.pending = f
+		return stateIdle, true
+	}
+
+	if n.ns != n.ts {
+		// A maintenance poll went unanswered, the token goes on.
+		return n.passTo(n.ns), true
+	}
+	// Looking for the next station, the following address is polled.
+	n.ps = n.next(n.ps)
+	if n.ps == n.ts {
+		n.soleMaster = true
+		n.frameCount = 0
+		return stateUseToken, true
+	}
+	n.pollFor(n.ps)
+	return statePollForMaster, true
+}
+
+func (n *Node) pollFor(dst uint8) {
+	n.write(Frame{Type: FramePollForMaster, Destination: dst, Source: n.ts})
+}
+
+func (n *Node) next(a uint8) uint8 {
+	return uint8((int(a) + 1) % (int(n.maxMaster) + 1))
+}
+
+func (n *Node) deliver(f *Frame, expectingReply bool) {
+	select {
+	case n.messages <- Message{
+		Source:         f.Source,
+		Destination:    f.Destination,
+		NPDU:           f.Data,
+		ExpectingReply: expectingReply,
+	}:
+	default:
+	}
+}
+
+// dequeue removes the first queued frame matching match, or any if nil.
+func (n *Node) dequeue(match func(outFrame) bool) (outFrame, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, o := range n.queue {
+		if match == nil || match(o) {
+			n.queue = append(n.queue[:i], n.queue[i+1:]...)
+			return o, true
+		}
+	}
+	return outFrame{}, false
+}
+
+func (n *Node) hasQueued() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.queue) > 0
+}
+
+func (n *Node) writeData(o outFrame) {
+	f := Frame{Destination: o.dst, Source: n.ts, Data: o.npdu}
+	switch {
+	case o.expectingReply && len(o.npdu) > MaxDataLen:
+		f.Type = FrameExtendedDataExpectingReply
+	case o.expectingReply:
+		f.Type = FrameDataExpectingReply
+	case len(o.npdu) > MaxDataLen:
+		f.Type = FrameExtendedDataNotExpectingReply
+	default:
+		f.Type = FrameDataNotExpectingReply
+	}
+	n.write(f)
+}
+
+func (n *Node) write(f Frame) {
+	b, err := f.MarshalBinary()
+	if err != nil {
+		return
+	}
+	n.rw.Write(b)
+}
@@ -0,0 +1,129 @@
+package mstp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// openPTY opens a pseudo-terminal pair in raw mode, standing for the two ends
+// of a serial line.
+func openPTY(t *testing.T) (*os.File, *os.File) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	var unlock, n int32
+	if err := ioctl(ptmx, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		ptmx.Close()
+		t.Skipf("unlocking pseudo-terminal: %v", err)
+	}
+	if err := ioctl(ptmx, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		ptmx.Close()
+		t.Skipf("pseudo-terminal number: %v", err)
+	}
+	pts, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		ptmx.Close()
+		t.Skipf("opening pseudo-terminal: %v", err)
+	}
+
+	var tio syscall.Termios
+	if err := ioctl(pts, syscall.TCGETS, unsafe.Pointer(&tio)); err != nil {
+		t.Fatal(err)
+	}
+	tio.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	tio.Oflag &^= syscall.OPOST
+	tio.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	tio.Cflag &^= syscall.CSIZE | syscall.PARENB
+	tio.Cflag |= syscall.CS8
+	tio.Cc[syscall.VMIN], tio.Cc[syscall.VTIME] = 1, 0
+	if err := ioctl(pts, syscall.TCSETS, unsafe.Pointer(&tio)); err != nil {
+		t.Fatal(err)
+	}
+	return ptmx, pts
+}
+
+func newTestNodes(t *testing.T) (*Node, *Node) {
+	ptmx, pts := openPTY(t)
+	a, err := NewNode(ptmx, NodeConfig{Address: 1, MaxMaster: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err := NewNode(pts, NodeConfig{Address: 2, MaxMaster: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return a, b
+}
+
+func receive(t *testing.T, n *Node) Message {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m, err := n.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestNodeRequestReply(t *testing.T) {
+	a, b := newTestNodes(t)
+
+	request := []byte{0x01, 0x04, 0x02, 0x75, 0x01, 0x0c, 0x0c, 0x02, 0x00, 0x00, 0x02, 0x19, 0x4b}
+	if err := a.Send(b.Address(), request, true); err != nil {
+		t.Fatal(err)
+	}
+	m := receive(t, b)
+	if m.Source != a.Address() || !m.ExpectingReply || !bytes.Equal(m.NPDU, request) {
+		t.Fatalf("unexpected request %+v", m)
+	}
+
+	reply := []byte{0x01, 0x00, 0x30, 0x01, 0x0c, 0x0c, 0x02, 0x00, 0x00, 0x02}
+	if err := b.Send(m.Source, reply, false); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, a); m.Source != b.Address() || m.ExpectingReply || !bytes.Equal(m.NPDU, reply) {
+		t.Errorf("unexpected reply %+v", m)
+	}
+}
+
+func TestNodeExtendedFrame(t *testing.T) {
+	a, b := newTestNodes(t)
+
+	npdu := make([]byte, 1000)
+	for i := range npdu {
+		npdu[i] = byte(i)
+	}
+	if err := b.Send(BroadcastAddress, npdu, false); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, a); m.Source != b.Address() || !bytes.Equal(m.NPDU, npdu) {
+		t.Errorf("unexpected NPDU from %d of %d octets", m.Source, len(m.NPDU))
+	}
+}
@@ -0,0 +1,298 @@
+package objects
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Character sets of a CharacterString, given by its first byte. Every one is
+// built in, JIS X 0208 as Shift JIS, and DBCS for the code pages of
+// dbcsCodePages. The other code pages fail with a *CharsetError until their
+// codec is set with RegisterCharset.
+const (
+	CharsetUTF8 uint8 = iota
+	// CharsetDBCS is the IBM/Microsoft DBCS, the code page following the
+	// character set byte.
+	CharsetDBCS
+	CharsetJISX0208
+	CharsetUCS4
+	CharsetUCS2
+	CharsetISO8859_1
+)
+
+var CharsetMap = map[uint8]string{
+	CharsetUTF8:      "ANSI X3.4/UTF-8",
+	CharsetDBCS:      "IBM/Microsoft DBCS",
+	CharsetJISX0208:  "JIS X 0208",
+	CharsetUCS4:      "ISO 10646 UCS-4",
+	CharsetUCS2:      "ISO 10646 UCS-2",
+	CharsetISO8859_1: "ISO 8859-1",
+}
+
+// CharsetError is the error of a character set with no codec, wrapping
+// common.ErrUnsupportedCharset.
+type CharsetError struct {
+	Charset uint8
+	// CodePage is the code page of CharsetDBCS.
+	CodePage uint16
+}
+
+func (e *CharsetError) Error() string {
+	name, ok := CharsetMap[e.Charset]
+	if !ok {
+		name = fmt.Sprintf("character set %d", e.Charset)
+	}
+	if e.Charset == CharsetDBCS {
+		name = fmt.Sprintf("%s code page %d", name, e.CodePage)
+	}
+	return fmt.Sprintf("%s: %v", name, common.ErrUnsupportedCharset)
+}
+
+func (e *CharsetError) Unwrap() error {
+	return common.ErrUnsupportedCharset
+}
+
+// CharsetCodec converts the strings of a character set to and from UTF-8.
+type CharsetCodec interface {
+	Decode(b []byte) (string, error)
+	Encode(s string) ([]byte, error)
+}
+
+// dbcsCodePages are the IBM/Microsoft code pages built in.
+var dbcsCodePages = map[uint16]encoding.Encoding{
+	437:  charmap.CodePage437,
+	850:  charmap.CodePage850,
+	852:  charmap.CodePage852,
+	855:  charmap.CodePage855,
+	858:  charmap.CodePage858,
+	860:  charmap.CodePage860,
+	862:  charmap.CodePage862,
+	863:  charmap.CodePage863,
+	865:  charmap.CodePage865,
+	866:  charmap.CodePage866,
+	874:  charmap.Windows874,
+	932:  japanese.ShiftJIS,
+	936:  simplifiedchinese.GBK,
+	949:  korean.EUCKR,
+	950:  traditionalchinese.Big5,
+	1250: charmap.Windows1250,
+	1251: charmap.Windows1251,
+	1252: charmap.Windows1252,
+	1253: charmap.Windows1253,
+	1254: charmap.Windows1254,
+	1255: charmap.Windows1255,
+	1256: charmap.Windows1256,
+	1257: charmap.Windows1257,
+	1258: charmap.Windows1258,
+}
+
+var (
+	charsetMu     sync.RWMutex
+	charsetCodecs = func() map[uint32]CharsetCodec {
+		codecs := map[uint32]CharsetCodec{
+			uint32(CharsetJISX0208) << 16:  xtext{"JIS X 0208", japanese.ShiftJIS},
+			uint32(CharsetUCS4) << 16:      ucs4{},
+			uint32(CharsetUCS2) << 16:      ucs2{},
+			uint32(CharsetISO8859_1) << 16: latin1{},
+		}
+		for codePage, enc := range dbcsCodePages {
+			codecs[uint32(CharsetDBCS)<<16|uint32(codePage)] = xtext{fmt.Sprintf("code page %d", codePage), enc}
+		}
+		return codecs
+	}()
+)
+
+// RegisterCharset sets the codec of a character set, replacing the built in
+// one if any. The code page is 0 besides CharsetDBCS.
+func RegisterCharset(charset uint8, codePage uint16, codec CharsetCodec) {
+	charsetMu.Lock()
+	defer charsetMu.Unlock()
+	charsetCodecs[uint32(charset)<<16|uint32(codePage)] = codec
+}
+
+func charsetCodec(charset uint8, codePage uint16) (CharsetCodec, error) {
+	charsetMu.RLock()
+	defer charsetMu.RUnlock()
+	codec, ok := charsetCodecs[uint32(charset)<<16|uint32(codePage)]
+	if !ok {
+		return nil, &CharsetError{Charset: charset, CodePage: codePage}
+	}
+	return codec, nil
+}
+
+// decodeCharacterString converts the data of a CharacterString to UTF-8.
+func decodeCharacterString(data []byte) (string, error) {
+	charset, b := data[0], data[1:]
+	if charset == CharsetUTF8 {
+		return string(b), nil
+	}
+	var codePage uint16
+	if charset == CharsetDBCS {
+		if len(b) < 2 {
+			return "", fmt.Errorf("DBCS string without code page: %v", common.ErrTooShortToParse)
+		}
+		codePage, b = binary.BigEndian.Uint16(b), b[2:]
+	}
+	codec, err := charsetCodec(charset, codePage)
+	if err != nil {
+		return "", err
+	}
+	return codec.Decode(b)
+}
+
+// EncStringCharset encodes value as a CharacterString of charset, the error
+// being a *CharsetError for the character sets with no codec.
+func EncStringCharset(value string, charset uint8) (*Object, error) {
+	if charset == CharsetDBCS {
+		return nil, fmt.Errorf("encoding DBCS string without code page: %v", common.ErrWrongStructure)
+	}
+	return encStringCharset(value, charset, 0)
+}
+
+// EncStringDBCS encodes value as a CharacterString of the DBCS code page.
+func EncStringDBCS(value string, codePage uint16) (*Object, error) {
+	return encStringCharset(value, CharsetDBCS, codePage)
+}
+
+func encStringCharset(value string, charset uint8, codePage uint16) (*Object, error) {
+	if charset == CharsetUTF8 {
+		return EncString(value), nil
+	}
+	codec, err := charsetCodec(charset, codePage)
+	if err != nil {
+		return nil, err
+	}
+	b, err := codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte{charset}
+	if charset == CharsetDBCS {
+		data = binary.BigEndian.AppendUint16(data, codePage)
+	}
+	data = append(data, b...)
+	return &Object{TagNumber: TagCharacterString, Data: data, Length: uint32(len(data))}, nil
+}
+
+// xtext is a character set of golang.org/x/text.
+type xtext struct {
+	name string
+	enc  encoding.Encoding
+}
+
+func (x xtext) Decode(b []byte) (string, error) {
+	s, err := x.enc.NewDecoder().String(string(b))
+	if err != nil {
+		return "", fmt.Errorf("decoding %s: %w", x.name, common.ErrInvalidData)
+	}
+	// The decoders turn the invalid bytes into U+FFFD, which none of the
+	// character sets has.
+	if strings.ContainsRune(s, utf8.RuneError) {
+		return "", fmt.Errorf("decoding %s - %x: %w", x.name, b, common.ErrInvalidData)
+	}
+	return s, nil
+}
+
+func (x xtext) Encode(s string) ([]byte, error) {
+	b, err := x.enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("encoding %q in %s: %w", s, x.name, common.ErrInvalidData)
+	}
+	return b, nil
+}
+
+// ucs2 is ISO 10646 UCS-2, big-endian. The surrogate pairs of UTF-16 are
+// decoded too, the unpaired surrogates failing.
+type ucs2 struct{}
+
+func (ucs2) Decode(b []byte) (string, error) {
+	if len(b)%2 != 0 {
+		return "", fmt.Errorf("UCS-2 string of %d bytes: %v", len(b), common.ErrWrongStructure)
+	}
+	runes := make([]rune, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		r := rune(binary.BigEndian.Uint16(b[i:]))
+		if utf16.IsSurrogate(r) {
+			if i+4 > len(b) {
+				return "", fmt.Errorf("UCS-2 unpaired surrogate %#x: %w", r, common.ErrInvalidData)
+			}
+			i += 2
+			if r = utf16.DecodeRune(r, rune(binary.BigEndian.Uint16(b[i:]))); r == utf8.RuneError {
+				return "", fmt.Errorf("UCS-2 unpaired surrogate at %d: %w", i-2, common.ErrInvalidData)
+			}
+		}
+		runes = append(runes, r)
+	}
+	return string(runes), nil
+}
+
+func (ucs2) Encode(s string) ([]byte, error) {
+	b := make([]byte, 0, 2*len(s))
+	for _, r := range s {
+		if r > 0xFFFF {
+			return nil, fmt.Errorf("encoding %q in UCS-2: %v", r, common.ErrInvalidData)
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(r))
+	}
+	return b, nil
+}
+
+// ucs4 is ISO 10646 UCS-4, big-endian.
+type ucs4 struct{}
+
+func (ucs4) Decode(b []byte) (string, error) {
+	if len(b)%4 != 0 {
+		return "", fmt.Errorf("UCS-4 string of %d bytes: %v", len(b), common.ErrWrongStructure)
+	}
+	runes := make([]rune, len(b)/4)
+	for i := range runes {
+		runes[i] = rune(binary.BigEndian.Uint32(b[4*i:]))
+		if !utf8.ValidRune(runes[i]) {
+			return "", fmt.Errorf("UCS-4 character %#x: %v", uint32(runes[i]), common.ErrInvalidData)
+		}
+	}
+	return string(runes), nil
+}
+
+func (ucs4) Encode(s string) ([]byte, error) {
+	b := make([]byte, 0, 4*len(s))
+	for _, r := range s {
+		b = binary.BigEndian.AppendUint32(b, uint32(r))
+	}
+	return b, nil
+}
+
+// latin1 is ISO 8859-1, whose characters are the first 256 of Unicode.
+type latin1 struct{}
+
+func (latin1) Decode(b []byte) (string, error) {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes), nil
+}
+
+func (latin1) Encode(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return nil, fmt.Errorf("encoding %q in ISO 8859-1: %v", r, common.ErrInvalidData)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
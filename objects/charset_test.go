@@ -0,0 +1,151 @@
+package objects_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func characterString(data ...byte) *objects.Object {
+	return objects.NewObject(objects.TagCharacterString, false, data)
+}
+
+func TestDecStringCharsets(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"UTF-8", []byte{objects.CharsetUTF8, 'c', 0xc3, 0xa9}},
+		{"UCS-2", []byte{objects.CharsetUCS2, 0, 'c', 0, 0xe9}},
+		{"UCS-4", []byte{objects.CharsetUCS4, 0, 0, 0, 'c', 0, 0, 0, 0xe9}},
+		{"ISO 8859-1", []byte{objects.CharsetISO8859_1, 'c', 0xe9}},
+	} {
+		s, err := objects.DecString(characterString(tt.data...))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		AssertEqual(t, "cé", s)
+	}
+
+	if _, err := objects.DecString(characterString(objects.CharsetUCS2, 0, 'c', 0)); err == nil {
+		t.Error("expected an error decoding UCS-2 of odd length")
+	}
+
+	// UTF-16 surrogate pairs are decoded, the unpaired surrogates fail.
+	s, err := objects.DecString(characterString(objects.CharsetUCS2, 0xd8, 0x3d, 0xde, 0x00))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, "\U0001F600", s)
+	for _, data := range [][]byte{
+		{objects.CharsetUCS2, 0xd8, 0x3d},
+		{objects.CharsetUCS2, 0xd8, 0x3d, 0, 'c'},
+		{objects.CharsetUCS2, 0xde, 0x00, 0, 'c'},
+	} {
+		if _, err := objects.DecString(characterString(data...)); !errors.Is(err, common.ErrInvalidData) {
+			t.Errorf("%x: expected ErrInvalidData, got %v", data[1:], err)
+		}
+	}
+
+	_, err = objects.DecString(characterString(objects.CharsetDBCS, 0x27, 0x10, 'c'))
+	var charsetErr *objects.CharsetError
+	if !errors.As(err, &charsetErr) || charsetErr.Charset != objects.CharsetDBCS || charsetErr.CodePage != 10000 {
+		t.Errorf("expected a CharsetError, got %v", err)
+	}
+	if !errors.Is(err, common.ErrUnsupportedCharset) {
+		t.Errorf("expected ErrUnsupportedCharset, got %v", err)
+	}
+}
+
+func TestDecStringMultiByte(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"JIS X 0208", []byte{objects.CharsetJISX0208, 0x93, 0xfa, 0x96, 0x7b}, "日本"},
+		{"code page 932", []byte{objects.CharsetDBCS, 0x03, 0xa4, 0x93, 0xfa, 0x96, 0x7b}, "日本"},
+		{"code page 949", []byte{objects.CharsetDBCS, 0x03, 0xb5, 0xc7, 0xd1}, "한"},
+		{"code page 850", []byte{objects.CharsetDBCS, 0x03, 0x52, 'c', 0x82}, "cé"},
+	} {
+		s, err := objects.DecString(characterString(tt.data...))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		AssertEqual(t, tt.want, s)
+	}
+
+	// A lead byte without its trail byte is no character.
+	if _, err := objects.DecString(characterString(objects.CharsetJISX0208, 0x93)); !errors.Is(err, common.ErrInvalidData) {
+		t.Errorf("expected ErrInvalidData, got %v", err)
+	}
+}
+
+func TestEncStringCharsets(t *testing.T) {
+	for _, charset := range []uint8{objects.CharsetUTF8, objects.CharsetUCS2, objects.CharsetUCS4, objects.CharsetISO8859_1} {
+		o, err := objects.EncStringCharset("café", charset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertEqual(t, charset, o.Data[0])
+		s, err := objects.DecString(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertEqual(t, "café", s)
+	}
+
+	if _, err := objects.EncStringCharset("€", objects.CharsetISO8859_1); err == nil {
+		t.Error("expected an error encoding € in ISO 8859-1")
+	}
+	if _, err := objects.EncStringDBCS("abc", 10000); !errors.Is(err, common.ErrUnsupportedCharset) {
+		t.Errorf("expected ErrUnsupportedCharset, got %v", err)
+	}
+
+	o, err := objects.EncStringCharset("日本", objects.CharsetJISX0208)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []byte{objects.CharsetJISX0208, 0x93, 0xfa, 0x96, 0x7b}, o.Data)
+	o, err = objects.EncStringDBCS("café", 1252)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []byte{objects.CharsetDBCS, 0x04, 0xe4, 'c', 'a', 'f', 0xe9}, o.Data)
+	if _, err := objects.EncStringDBCS("日本", 1252); !errors.Is(err, common.ErrInvalidData) {
+		t.Errorf("expected ErrInvalidData, got %v", err)
+	}
+}
+
+// upper is a toy codec storing strings in upper case.
+type upper struct{}
+
+func (upper) Decode(b []byte) (string, error) { return strings.ToLower(string(b)), nil }
+func (upper) Encode(s string) ([]byte, error) { return []byte(strings.ToUpper(s)), nil }
+
+func TestRegisterCharset(t *testing.T) {
+	const codePage = 65000
+	objects.RegisterCharset(objects.CharsetDBCS, codePage, upper{})
+
+	o, err := objects.EncStringDBCS("abc", codePage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []byte{objects.CharsetDBCS, 0xfd, 0xe8, 'A', 'B', 'C'}, o.Data)
+	s, err := objects.DecString(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, "abc", s)
+
+	// Other code pages stay unsupported.
+	if _, err := objects.DecString(characterString(objects.CharsetDBCS, 0x27, 0x10, 'A')); !errors.Is(err, common.ErrUnsupportedCharset) {
+		t.Errorf("expected ErrUnsupportedCharset, got %v", err)
+	}
+}
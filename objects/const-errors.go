@@ -0,0 +1,435 @@
+package objects
+
+// Error classes
+const (
+	ErrorClassDevice uint16 = iota
+	ErrorClassObject
+	ErrorClassProperty
+	ErrorClassResources
+	ErrorClassSecurity
+	ErrorClassServices
+	ErrorClassVT
+	ErrorClassCommunication
+	// ErrorClassVendor is the first of the proprietary error classes.
+	ErrorClassVendor uint16 = 64
+)
+
+// Error codes
+const (
+	ErrorCodeOther uint16 = iota
+	ErrorCodeAuthenticationFailed
+	ErrorCodeConfigurationInProgress
+	ErrorCodeDeviceBusy
+	ErrorCodeDynamicCreationNotSupported
+	ErrorCodeFileAccessDenied
+	ErrorCodeIncompatibleSecurityLevels
+	ErrorCodeInconsistentParameters
+	ErrorCodeInconsistentSelectionCriterion
+	ErrorCodeInvalidDataType
+	ErrorCodeInvalidFileAccessMethod
+	ErrorCodeInvalidFileStartPosition
+	ErrorCodeInvalidOperatorName
+	ErrorCodeInvalidParameterDataType
+	ErrorCodeInvalidTimeStamp
+	ErrorCodeKeyGenerationError
+	ErrorCodeMissingRequiredParameter
+	ErrorCodeNoObjectsOfSpecifiedType
+	ErrorCodeNoSpaceForObject
+	ErrorCodeNoSpaceToAddListElement
+	ErrorCodeNoSpaceToWriteProperty
+	ErrorCodeNoVTSessionsAvailable
+	ErrorCodePropertyIsNotAList
+	ErrorCodeObjectDeletionNotPermitted
+	ErrorCodeObjectIdentifierAlreadyExists
+	ErrorCodeOperationalProblem
+	ErrorCodePasswordFailure
+	ErrorCodeReadAccessDenied
+	ErrorCodeSecurityNotSupported
+	ErrorCodeServiceRequestDenied
+	ErrorCodeTimeout
+	ErrorCodeUnknownObject
+	ErrorCodeUnknownProperty
+	_ // Removed from the standard.
+	ErrorCodeUnknownVTClass
+	ErrorCodeUnknownVTSession
+	ErrorCodeUnsupportedObjectType
+	ErrorCodeValueOutOfRange
+	ErrorCodeVTSessionAlreadyClosed
+	ErrorCodeVTSessionTerminationFailure
+	ErrorCodeWriteAccessDenied
+	ErrorCodeCharacterSetNotSupported
+	ErrorCodeInvalidArrayIndex
+	ErrorCodeCOVSubscriptionFailed
+	ErrorCodeNotCOVProperty
+	ErrorCodeOptionalFunctionalityNotSupported
+	ErrorCodeInvalidConfigurationData
+	ErrorCodeDatatypeNotSupported
+	ErrorCodeDuplicateName
+	ErrorCodeDuplicateObjectId
+	ErrorCodePropertyIsNotAnArray
+	ErrorCodeAbortBufferOverflow
+	ErrorCodeAbortInvalidAPDUInThisState
+	ErrorCodeAbortPreemptedByHigherPriorityTask
+	ErrorCodeAbortSegmentationNotSupported
+	ErrorCodeAbortProprietary
+	ErrorCodeAbortOther
+	ErrorCodeInvalidTag
+	ErrorCodeNetworkDown
+	ErrorCodeRejectBufferOverflow
+	ErrorCodeRejectInconsistentParameters
+	ErrorCodeRejectInvalidParameterDataType
+	ErrorCodeRejectInvalidTag
+	ErrorCodeRejectMissingRequiredParameter
+	ErrorCodeRejectParameterOutOfRange
+	ErrorCodeRejectTooManyArguments
+	ErrorCodeRejectUndefinedEnumeration
+	ErrorCodeRejectUnrecognizedService
+	ErrorCodeRejectProprietary
+	ErrorCodeRejectOther
+	ErrorCodeUnknownDevice
+	ErrorCodeUnknownRoute
+	ErrorCodeValueNotInitialized
+	ErrorCodeInvalidEventState
+	ErrorCodeNoAlarmConfigured
+	ErrorCodeLogBufferFull
+	ErrorCodeLoggedValuePurged
+	ErrorCodeNoPropertySpecified
+	ErrorCodeNotConfiguredForTriggeredLogging
+	ErrorCodeUnknownSubscription
+	ErrorCodeParameterOutOfRange
+	ErrorCodeListElementNotFound
+	ErrorCodeBusy
+	ErrorCodeCommunicationDisabled
+	ErrorCodeSuccess
+	ErrorCodeAccessDenied
+	ErrorCodeBadDestinationAddress
+	ErrorCodeBadDestinationDeviceId
+	ErrorCodeBadSignature
+	ErrorCodeBadSourceAddress
+	ErrorCodeBadTimestamp
+	ErrorCodeCannotUseKey
+	ErrorCodeCannotVerifyMessageId
+	ErrorCodeCorrectKeyRevision
+	ErrorCodeDestinationDeviceIdRequired
+	ErrorCodeDuplicateMessage
+	ErrorCodeEncryptionNotConfigured
+	ErrorCodeEncryptionRequired
+	ErrorCodeIncorrectKey
+	ErrorCodeInvalidKeyData
+	ErrorCodeKeyUpdateInProgress
+	ErrorCodeMalformedMessage
+	ErrorCodeNotKeyServer
+	ErrorCodeSecurityNotConfigured
+	ErrorCodeSourceSecurityRequired
+	ErrorCodeTooManyKeys
+	ErrorCodeUnknownAuthenticationType
+	ErrorCodeUnknownKey
+	ErrorCodeUnknownKeyRevision
+	ErrorCodeUnknownSourceMessage
+	ErrorCodeNotRouterToDNET
+	ErrorCodeRouterBusy
+	ErrorCodeUnknownNetworkMessage
+	ErrorCodeMessageTooLong
+	ErrorCodeSecurityError
+	ErrorCodeAddressingError
+	ErrorCodeWriteBDTFailed
+	ErrorCodeReadBDTFailed
+	ErrorCodeRegisterForeignDeviceFailed
+	ErrorCodeReadFDTFailed
+	ErrorCodeDeleteFDTEntryFailed
+	ErrorCodeDistributeBroadcastFailed
+	ErrorCodeUnknownFileSize
+	ErrorCodeAbortAPDUTooLong
+	ErrorCodeAbortApplicationExceededReplyTime
+	ErrorCodeAbortOutOfResources
+	ErrorCodeAbortTSMTimeout
+	ErrorCodeAbortWindowSizeOutOfRange
+	ErrorCodeFileFull
+	ErrorCodeInconsistentConfiguration
+	ErrorCodeInconsistentObjectType
+	ErrorCodeInternalError
+	ErrorCodeNotConfigured
+	ErrorCodeOutOfMemory
+	ErrorCodeValueTooLong
+	ErrorCodeAbortInsufficientSecurity
+	ErrorCodeAbortSecurityError
+	ErrorCodeDuplicateEntry
+	ErrorCodeInvalidValueInThisState
+	ErrorCodeInvalidOperationInThisState
+	ErrorCodeListItemNotNumbered
+	ErrorCodeListItemNotTimestamped
+	ErrorCodeInvalidDataEncoding
+	ErrorCodeBVLCFunctionUnknown
+	ErrorCodeBVLCProprietaryFunctionUnknown
+	ErrorCodeHeaderEncodingError
+	ErrorCodeHeaderNotUnderstood
+	ErrorCodeMessageIncomplete
+	ErrorCodeNotABACnetSCHub
+	ErrorCodePayloadExpected
+	ErrorCodeUnexpectedData
+	ErrorCodeNodeDuplicateVMAC
+	ErrorCodeHTTPUnexpectedResponseCode
+	ErrorCodeHTTPNoUpgrade
+	ErrorCodeHTTPResourceNotLocal
+	ErrorCodeHTTPProxyAuthenticationFailed
+	ErrorCodeHTTPResponseTimeout
+	ErrorCodeHTTPResponseSyntaxError
+	ErrorCodeHTTPResponseValueError
+	ErrorCodeHTTPResponseMissingHeader
+	ErrorCodeHTTPWebSocketHeaderError
+	ErrorCodeHTTPUpgradeRequired
+	ErrorCodeHTTPUpgradeError
+	ErrorCodeHTTPTemporaryUnavailable
+	ErrorCodeHTTPNotAServer
+	ErrorCodeHTTPError
+	ErrorCodeWebSocketSchemeNotSupported
+	ErrorCodeWebSocketUnknownControlMessage
+	ErrorCodeWebSocketCloseError
+	ErrorCodeWebSocketClosedByPeer
+	ErrorCodeWebSocketEndpointLeaves
+	ErrorCodeWebSocketProtocolError
+	ErrorCodeWebSocketDataNotAccepted
+	ErrorCodeWebSocketClosedAbnormally
+	ErrorCodeWebSocketDataInconsistent
+	ErrorCodeWebSocketDataAgainstPolicy
+	ErrorCodeWebSocketFrameTooLong
+	ErrorCodeWebSocketExtensionMissing
+	ErrorCodeWebSocketRequestUnavailable
+	ErrorCodeWebSocketError
+	ErrorCodeTLSClientCertificateError
+	ErrorCodeTLSServerCertificateError
+	ErrorCodeTLSClientAuthenticationFailed
+	ErrorCodeTLSServerAuthenticationFailed
+	ErrorCodeTLSClientCertificateExpired
+	ErrorCodeTLSServerCertificateExpired
+	ErrorCodeTLSClientCertificateRevoked
+	ErrorCodeTLSServerCertificateRevoked
+	ErrorCodeTLSError
+	ErrorCodeDNSUnavailable
+	ErrorCodeDNSNameResolutionFailed
+	ErrorCodeDNSResolverFailure
+	ErrorCodeDNSError
+	ErrorCodeTCPConnectTimeout
+	ErrorCodeTCPConnectionRefused
+	ErrorCodeTCPClosedByLocal
+	ErrorCodeTCPClosedOther
+	ErrorCodeTCPError
+	ErrorCodeIPAddressNotReachable
+	ErrorCodeIPError
+)
+
+// ErrorCodeVendor is the first of the proprietary error codes.
+const ErrorCodeVendor uint16 = 256
+
+var ErrorClassMap = map[uint16]string{
+	ErrorClassDevice:        "Device",
+	ErrorClassObject:        "Object",
+	ErrorClassProperty:      "Property",
+	ErrorClassResources:     "Resources",
+	ErrorClassSecurity:      "Security",
+	ErrorClassServices:      "Services",
+	ErrorClassVT:            "VT",
+	ErrorClassCommunication: "Communication",
+}
+
+var ErrorCodeMap = map[uint16]string{
+	ErrorCodeOther:                              "Other",
+	ErrorCodeAuthenticationFailed:               "AuthenticationFailed",
+	ErrorCodeConfigurationInProgress:            "ConfigurationInProgress",
+	ErrorCodeDeviceBusy:                         "DeviceBusy",
+	ErrorCodeDynamicCreationNotSupported:        "DynamicCreationNotSupported",
+	ErrorCodeFileAccessDenied:                   "FileAccessDenied",
+	ErrorCodeIncompatibleSecurityLevels:         "IncompatibleSecurityLevels",
+	ErrorCodeInconsistentParameters:             "InconsistentParameters",
+	ErrorCodeInconsistentSelectionCriterion:     "InconsistentSelectionCriterion",
+	ErrorCodeInvalidDataType:                    "InvalidDataType",
+	ErrorCodeInvalidFileAccessMethod:            "InvalidFileAccessMethod",
+	ErrorCodeInvalidFileStartPosition:           "InvalidFileStartPosition",
+	ErrorCodeInvalidOperatorName:                "InvalidOperatorName",
+	ErrorCodeInvalidParameterDataType:           "InvalidParameterDataType",
+	ErrorCodeInvalidTimeStamp:                   "InvalidTimeStamp",
+	ErrorCodeKeyGenerationError:                 "KeyGenerationError",
+	ErrorCodeMissingRequiredParameter:           "MissingRequiredParameter",
+	ErrorCodeNoObjectsOfSpecifiedType:           "NoObjectsOfSpecifiedType",
+	ErrorCodeNoSpaceForObject:                   "NoSpaceForObject",
+	ErrorCodeNoSpaceToAddListElement:            "NoSpaceToAddListElement",
+	ErrorCodeNoSpaceToWriteProperty:             "NoSpaceToWriteProperty",
+	ErrorCodeNoVTSessionsAvailable:              "NoVTSessionsAvailable",
+	ErrorCodePropertyIsNotAList:                 "PropertyIsNotAList",
+	ErrorCodeObjectDeletionNotPermitted:         "ObjectDeletionNotPermitted",
+	ErrorCodeObjectIdentifierAlreadyExists:      "ObjectIdentifierAlreadyExists",
+	ErrorCodeOperationalProblem:                 "OperationalProblem",
+	ErrorCodePasswordFailure:                    "PasswordFailure",
+	ErrorCodeReadAccessDenied:                   "ReadAccessDenied",
+	ErrorCodeSecurityNotSupported:               "SecurityNotSupported",
+	ErrorCodeServiceRequestDenied:               "ServiceRequestDenied",
+	ErrorCodeTimeout:                            "Timeout",
+	ErrorCodeUnknownObject:                      "UnknownObject",
+	ErrorCodeUnknownProperty:                    "UnknownProperty",
+	ErrorCodeUnknownVTClass:                     "UnknownVTClass",
+	ErrorCodeUnknownVTSession:                   "UnknownVTSession",
+	ErrorCodeUnsupportedObjectType:              "UnsupportedObjectType",
+	ErrorCodeValueOutOfRange:                    "ValueOutOfRange",
+	ErrorCodeVTSessionAlreadyClosed:             "VTSessionAlreadyClosed",
+	ErrorCodeVTSessionTerminationFailure:        "VTSessionTerminationFailure",
+	ErrorCodeWriteAccessDenied:                  "WriteAccessDenied",
+	ErrorCodeCharacterSetNotSupported:           "CharacterSetNotSupported",
+	ErrorCodeInvalidArrayIndex:                  "InvalidArrayIndex",
+	ErrorCodeCOVSubscriptionFailed:              "COVSubscriptionFailed",
+	ErrorCodeNotCOVProperty:                     "NotCOVProperty",
+	ErrorCodeOptionalFunctionalityNotSupported:  "OptionalFunctionalityNotSupported",
+	ErrorCodeInvalidConfigurationData:           "InvalidConfigurationData",
+	ErrorCodeDatatypeNotSupported:               "DatatypeNotSupported",
+	ErrorCodeDuplicateName:                      "DuplicateName",
+	ErrorCodeDuplicateObjectId:                  "DuplicateObjectId",
+	ErrorCodePropertyIsNotAnArray:               "PropertyIsNotAnArray",
+	ErrorCodeAbortBufferOverflow:                "AbortBufferOverflow",
+	ErrorCodeAbortInvalidAPDUInThisState:        "AbortInvalidAPDUInThisState",
+	ErrorCodeAbortPreemptedByHigherPriorityTask: "AbortPreemptedByHigherPriorityTask",
+	ErrorCodeAbortSegmentationNotSupported:      "AbortSegmentationNotSupported",
+	ErrorCodeAbortProprietary:                   "AbortProprietary",
+	ErrorCodeAbortOther:                         "AbortOther",
+	ErrorCodeInvalidTag:                         "InvalidTag",
+	ErrorCodeNetworkDown:                        "NetworkDown",
+	ErrorCodeRejectBufferOverflow:               "RejectBufferOverflow",
+	ErrorCodeRejectInconsistentParameters:       "RejectInconsistentParameters",
+	ErrorCodeRejectInvalidParameterDataType:     "RejectInvalidParameterDataType",
+	ErrorCodeRejectInvalidTag:                   "RejectInvalidTag",
+	ErrorCodeRejectMissingRequiredParameter:     "RejectMissingRequiredParameter",
+	ErrorCodeRejectParameterOutOfRange:          "RejectParameterOutOfRange",
+	ErrorCodeRejectTooManyArguments:             "RejectTooManyArguments",
+	ErrorCodeRejectUndefinedEnumeration:         "RejectUndefinedEnumeration",
+	ErrorCodeRejectUnrecognizedService:          "RejectUnrecognizedService",
+	ErrorCodeRejectProprietary:                  "RejectProprietary",
+	ErrorCodeRejectOther:                        "RejectOther",
+	ErrorCodeUnknownDevice:                      "UnknownDevice",
+	ErrorCodeUnknownRoute:                       "UnknownRoute",
+	ErrorCodeValueNotInitialized:                "ValueNotInitialized",
+	ErrorCodeInvalidEventState:                  "InvalidEventState",
+	ErrorCodeNoAlarmConfigured:                  "NoAlarmConfigured",
+	ErrorCodeLogBufferFull:                      "LogBufferFull",
+	ErrorCodeLoggedValuePurged:                  "LoggedValuePurged",
+	ErrorCodeNoPropertySpecified:                "NoPropertySpecified",
+	ErrorCodeNotConfiguredForTriggeredLogging:   "NotConfiguredForTriggeredLogging",
+	ErrorCodeUnknownSubscription:                "UnknownSubscription",
+	ErrorCodeParameterOutOfRange:                "ParameterOutOfRange",
+	ErrorCodeListElementNotFound:                "ListElementNotFound",
+	ErrorCodeBusy:                               "Busy",
+	ErrorCodeCommunicationDisabled:              "CommunicationDisabled",
+	ErrorCodeSuccess:                            "Success",
+	ErrorCodeAccessDenied:                       "AccessDenied",
+	ErrorCodeBadDestinationAddress:              "BadDestinationAddress",
+	ErrorCodeBadDestinationDeviceId:             "BadDestinationDeviceId",
+	ErrorCodeBadSignature:                       "BadSignature",
+	ErrorCodeBadSourceAddress:                   "BadSourceAddress",
+	ErrorCodeBadTimestamp:                       "BadTimestamp",
+	ErrorCodeCannotUseKey:                       "CannotUseKey",
+	ErrorCodeCannotVerifyMessageId:              "CannotVerifyMessageId",
+	ErrorCodeCorrectKeyRevision:                 "CorrectKeyRevision",
+	ErrorCodeDestinationDeviceIdRequired:        "DestinationDeviceIdRequired",
+	ErrorCodeDuplicateMessage:                   "DuplicateMessage",
+	ErrorCodeEncryptionNotConfigured:            "EncryptionNotConfigured",
+	ErrorCodeEncryptionRequired:                 "EncryptionRequired",
+	ErrorCodeIncorrectKey:                       "IncorrectKey",
+	ErrorCodeInvalidKeyData:                     "InvalidKeyData",
+	ErrorCodeKeyUpdateInProgress:                "KeyUpdateInProgress",
+	ErrorCodeMalformedMessage:                   "MalformedMessage",
+	ErrorCodeNotKeyServer:                       "NotKeyServer",
+	ErrorCodeSecurityNotConfigured:              "SecurityNotConfigured",
+	ErrorCodeSourceSecurityRequired:             "SourceSecurityRequired",
+	ErrorCodeTooManyKeys:                        "TooManyKeys",
+	ErrorCodeUnknownAuthenticationType:          "UnknownAuthenticationType",
+	ErrorCodeUnknownKey:                         "UnknownKey",
+	ErrorCodeUnknownKeyRevision:                 "UnknownKeyRevision",
+	ErrorCodeUnknownSourceMessage:               "UnknownSourceMessage",
+	ErrorCodeNotRouterToDNET:                    "NotRouterToDNET",
+	ErrorCodeRouterBusy:                         "RouterBusy",
+	ErrorCodeUnknownNetworkMessage:              "UnknownNetworkMessage",
+	ErrorCodeMessageTooLong:                     "MessageTooLong",
+	ErrorCodeSecurityError:                      "SecurityError",
+	ErrorCodeAddressingError:                    "AddressingError",
+	ErrorCodeWriteBDTFailed:                     "WriteBDTFailed",
+	ErrorCodeReadBDTFailed:                      "ReadBDTFailed",
+	ErrorCodeRegisterForeignDeviceFailed:        "RegisterForeignDeviceFailed",
+	ErrorCodeReadFDTFailed:                      "ReadFDTFailed",
+	ErrorCodeDeleteFDTEntryFailed:               "DeleteFDTEntryFailed",
+	ErrorCodeDistributeBroadcastFailed:          "DistributeBroadcastFailed",
+	ErrorCodeUnknownFileSize:                    "UnknownFileSize",
+	ErrorCodeAbortAPDUTooLong:                   "AbortAPDUTooLong",
+	ErrorCodeAbortApplicationExceededReplyTime:  "AbortApplicationExceededReplyTime",
+	ErrorCodeAbortOutOfResources:                "AbortOutOfResources",
+	ErrorCodeAbortTSMTimeout:                    "AbortTSMTimeout",
+	ErrorCodeAbortWindowSizeOutOfRange:          "AbortWindowSizeOutOfRange",
+	ErrorCodeFileFull:                           "FileFull",
+	ErrorCodeInconsistentConfiguration:          "InconsistentConfiguration",
+	ErrorCodeInconsistentObjectType:             "InconsistentObjectType",
+	ErrorCodeInternalError:                      "InternalError",
+	ErrorCodeNotConfigured:                      "NotConfigured",
+	ErrorCodeOutOfMemory:                        "OutOfMemory",
+	ErrorCodeValueTooLong:                       "ValueTooLong",
+	ErrorCodeAbortInsufficientSecurity:          "AbortInsufficientSecurity",
+	ErrorCodeAbortSecurityError:                 "AbortSecurityError",
+	ErrorCodeDuplicateEntry:                     "DuplicateEntry",
+	ErrorCodeInvalidValueInThisState:            "InvalidValueInThisState",
+	ErrorCodeInvalidOperationInThisState:        "InvalidOperationInThisState",
+	ErrorCodeListItemNotNumbered:                "ListItemNotNumbered",
+	ErrorCodeListItemNotTimestamped:             "ListItemNotTimestamped",
+	ErrorCodeInvalidDataEncoding:                "InvalidDataEncoding",
+	ErrorCodeBVLCFunctionUnknown:                "BVLCFunctionUnknown",
+	ErrorCodeBVLCProprietaryFunctionUnknown:     "BVLCProprietaryFunctionUnknown",
+	ErrorCodeHeaderEncodingError:                "HeaderEncodingError",
+	ErrorCodeHeaderNotUnderstood:                "HeaderNotUnderstood",
+	ErrorCodeMessageIncomplete:                  "MessageIncomplete",
+	ErrorCodeNotABACnetSCHub:                    "NotABACnetSCHub",
+	ErrorCodePayloadExpected:                    "PayloadExpected",
+	ErrorCodeUnexpectedData:                     "UnexpectedData",
+	ErrorCodeNodeDuplicateVMAC:                  "NodeDuplicateVMAC",
+	ErrorCodeHTTPUnexpectedResponseCode:         "HTTPUnexpectedResponseCode",
+	ErrorCodeHTTPNoUpgrade:                      "HTTPNoUpgrade",
+	ErrorCodeHTTPResourceNotLocal:               "HTTPResourceNotLocal",
+	ErrorCodeHTTPProxyAuthenticationFailed:      "HTTPProxyAuthenticationFailed",
+	ErrorCodeHTTPResponseTimeout:                "HTTPResponseTimeout",
+	ErrorCodeHTTPResponseSyntaxError:            "HTTPResponseSyntaxError",
+	ErrorCodeHTTPResponseValueError:             "HTTPResponseValueError",
+	ErrorCodeHTTPResponseMissingHeader:          "HTTPResponseMissingHeader",
+	ErrorCodeHTTPWebSocketHeaderError:           "HTTPWebSocketHeaderError",
+	ErrorCodeHTTPUpgradeRequired:                "HTTPUpgradeRequired",
+	ErrorCodeHTTPUpgradeError:                   "HTTPUpgradeError",
+	ErrorCodeHTTPTemporaryUnavailable:           "HTTPTemporaryUnavailable",
+	ErrorCodeHTTPNotAServer:                     "HTTPNotAServer",
+	ErrorCodeHTTPError:                          "HTTPError",
+	ErrorCodeWebSocketSchemeNotSupported:        "WebSocketSchemeNotSupported",
+	ErrorCodeWebSocketUnknownControlMessage:     "WebSocketUnknownControlMessage",
+	ErrorCodeWebSocketCloseError:                "WebSocketCloseError",
+	ErrorCodeWebSocketClosedByPeer:              "WebSocketClosedByPeer",
+	ErrorCodeWebSocketEndpointLeaves:            "WebSocketEndpointLeaves",
+	ErrorCodeWebSocketProtocolError:             "WebSocketProtocolError",
+	ErrorCodeWebSocketDataNotAccepted:           "WebSocketDataNotAccepted",
+	ErrorCodeWebSocketClosedAbnormally:          "WebSocketClosedAbnormally",
+	ErrorCodeWebSocketDataInconsistent:          "WebSocketDataInconsistent",
+	ErrorCodeWebSocketDataAgainstPolicy:         "WebSocketDataAgainstPolicy",
+	ErrorCodeWebSocketFrameTooLong:              "WebSocketFrameTooLong",
+	ErrorCodeWebSocketExtensionMissing:          "WebSocketExtensionMissing",
+	ErrorCodeWebSocketRequestUnavailable:        "WebSocketRequestUnavailable",
+	ErrorCodeWebSocketError:                     "WebSocketError",
+	ErrorCodeTLSClientCertificateError:          "TLSClientCertificateError",
+	ErrorCodeTLSServerCertificateError:          "TLSServerCertificateError",
+	ErrorCodeTLSClientAuthenticationFailed:      "TLSClientAuthenticationFailed",
+	ErrorCodeTLSServerAuthenticationFailed:      "TLSServerAuthenticationFailed",
+	ErrorCodeTLSClientCertificateExpired:        "TLSClientCertificateExpired",
+	ErrorCodeTLSServerCertificateExpired:        "TLSServerCertificateExpired",
+	ErrorCodeTLSClientCertificateRevoked:        "TLSClientCertificateRevoked",
+	ErrorCodeTLSServerCertificateRevoked:        "TLSServerCertificateRevoked",
+	ErrorCodeTLSError:                           "TLSError",
+	ErrorCodeDNSUnavailable:                     "DNSUnavailable",
+	ErrorCodeDNSNameResolutionFailed:            "DNSNameResolutionFailed",
+	ErrorCodeDNSResolverFailure:                 "DNSResolverFailure",
+	ErrorCodeDNSError:                           "DNSError",
+	ErrorCodeTCPConnectTimeout:                  "TCPConnectTimeout",
+	ErrorCodeTCPConnectionRefused:               "TCPConnectionRefused",
+	ErrorCodeTCPClosedByLocal:                   "TCPClosedByLocal",
+	ErrorCodeTCPClosedOther:                     "TCPClosedOther",
+	ErrorCodeTCPError:                           "TCPError",
+	ErrorCodeIPAddressNotReachable:              "IPAddressNotReachable",
+	ErrorCodeIPError:                            "IPError",
+}
@@ -0,0 +1,193 @@
+package objects
+
+// The datatypes of the properties, after the object definitions of Clause 12.
+
+// commonPropertyTypes holds the datatypes of the properties whose datatype is
+// the same in every object type having them.
+var commonPropertyTypes = map[uint16]PropertyType{
+	PropertyIdAckedTransitions:                 scalar(DatatypeBitString),
+	PropertyIdAckRequired:                      scalar(DatatypeBitString),
+	PropertyIdActiveText:                       scalar(DatatypeCharacterString),
+	PropertyIdAlignIntervals:                   scalar(DatatypeBoolean),
+	PropertyIdApduSegmentTimeout:               scalar(DatatypeUnsigned),
+	PropertyIdApduTimeout:                      scalar(DatatypeUnsigned),
+	PropertyIdApplicationSoftwareVersion:       scalar(DatatypeCharacterString),
+	PropertyIdBackupFailureTimeout:             scalar(DatatypeUnsigned),
+	PropertyIdBias:                             scalar(DatatypeReal),
+	PropertyIdBufferSize:                       scalar(DatatypeUnsigned),
+	PropertyIdChangeOfStateCount:               scalar(DatatypeUnsigned),
+	PropertyIdChangeOfStateTime:                scalar(DatatypeDateTime),
+	PropertyIdConfigurationFiles:               array(DatatypeObjectIdentifier),
+	PropertyIdControlledVariableReference:      scalar(DatatypeObjectPropertyReference),
+	PropertyIdControlledVariableUnits:          scalar(DatatypeEngineeringUnits),
+	PropertyIdControlledVariableValue:          scalar(DatatypeReal),
+	PropertyIdCovIncrement:                     scalar(DatatypeReal),
+	PropertyIdDatabaseRevision:                 scalar(DatatypeUnsigned),
+	PropertyIdDateList:                         list(DatatypeCalendarEntry),
+	PropertyIdDaylightSavingsStatus:            scalar(DatatypeBoolean),
+	PropertyIdDeadband:                         scalar(DatatypeReal),
+	PropertyIdDerivativeConstant:               scalar(DatatypeReal),
+	PropertyIdDerivativeConstantUnits:          scalar(DatatypeEngineeringUnits),
+	PropertyIdDescription:                      scalar(DatatypeCharacterString),
+	PropertyIdDeviceType:                       scalar(DatatypeCharacterString),
+	PropertyIdEffectivePeriod:                  scalar(DatatypeDateRange),
+	PropertyIdElapsedActiveTime:                scalar(DatatypeUnsigned),
+	PropertyIdEnable:                           scalar(DatatypeBoolean),
+	PropertyIdErrorLimit:                       scalar(DatatypeReal),
+	PropertyIdEventEnable:                      scalar(DatatypeBitString),
+	PropertyIdEventState:                       scalar(DatatypeEventState),
+	PropertyIdEventTimeStamps:                  array(DatatypeTimeStamp),
+	PropertyIdFirmwareRevision:                 scalar(DatatypeCharacterString),
+	PropertyIdHighLimit:                        scalar(DatatypeReal),
+	PropertyIdInactiveText:                     scalar(DatatypeCharacterString),
+	PropertyIdIntegralConstant:                 scalar(DatatypeReal),
+	PropertyIdIntegralConstantUnits:            scalar(DatatypeEngineeringUnits),
+	PropertyIdIntervalOffset:                   scalar(DatatypeUnsigned),
+	PropertyIdLastRestoreTime:                  scalar(DatatypeTimeStamp),
+	PropertyIdLimitEnable:                      scalar(DatatypeBitString),
+	PropertyIdListOfObjectPropertyReferences:   list(DatatypeDeviceObjectPropertyReference),
+	PropertyIdLocalDate:                        scalar(DatatypeDate),
+	PropertyIdLocalTime:                        scalar(DatatypeTime),
+	PropertyIdLocation:                         scalar(DatatypeCharacterString),
+	PropertyIdLogDeviceObjectProperty:          scalar(DatatypeDeviceObjectPropertyReference),
+	PropertyIdLogInterval:                      scalar(DatatypeUnsigned),
+	PropertyIdLowLimit:                         scalar(DatatypeReal),
+	PropertyIdManipulatedVariableReference:     scalar(DatatypeObjectPropertyReference),
+	PropertyIdMaxApduLengthAccepted:            scalar(DatatypeUnsigned),
+	PropertyIdMaxInfoFrames:                    scalar(DatatypeUnsigned),
+	PropertyIdMaxMaster:                        scalar(DatatypeUnsigned),
+	PropertyIdMaxPresValue:                     scalar(DatatypeReal),
+	PropertyIdMaxSegmentsAccepted:              scalar(DatatypeUnsigned),
+	PropertyIdMaximumOutput:                    scalar(DatatypeReal),
+	PropertyIdMinPresValue:                     scalar(DatatypeReal),
+	PropertyIdMinimumOffTime:                   scalar(DatatypeUnsigned),
+	PropertyIdMinimumOnTime:                    scalar(DatatypeUnsigned),
+	PropertyIdMinimumOutput:                    scalar(DatatypeReal),
+	PropertyIdModelName:                        scalar(DatatypeCharacterString),
+	PropertyIdNotificationClass:                scalar(DatatypeUnsigned),
+	PropertyIdNotificationThreshold:            scalar(DatatypeUnsigned),
+	PropertyIdNumberOfApduRetries:              scalar(DatatypeUnsigned),
+	PropertyIdNumberOfStates:                   scalar(DatatypeUnsigned),
+	PropertyIdObjectIdentifier:                 scalar(DatatypeObjectIdentifier),
+	PropertyIdObjectList:                       array(DatatypeObjectIdentifier),
+	PropertyIdObjectName:                       scalar(DatatypeCharacterString),
+	PropertyIdObjectType:                       scalar(DatatypeObjectType),
+	PropertyIdOutOfService:                     scalar(DatatypeBoolean),
+	PropertyIdOutputUnits:                      scalar(DatatypeEngineeringUnits),
+	PropertyIdPolarity:                         scalar(DatatypePolarity),
+	PropertyIdPriority:                         array(DatatypeUnsigned),
+	PropertyIdPriorityArray:                    scalar(DatatypePriorityArray),
+	PropertyIdPriorityForWriting:               scalar(DatatypeUnsigned),
+	PropertyIdProfileName:                      scalar(DatatypeCharacterString),
+	PropertyIdPropertyList:                     array(DatatypeUnsigned),
+	PropertyIdProportionalConstant:             scalar(DatatypeReal),
+	PropertyIdProportionalConstantUnits:        scalar(DatatypeEngineeringUnits),
+	PropertyIdProtocolObjectTypesSupported:     scalar(DatatypeBitString),
+	PropertyIdProtocolRevision:                 scalar(DatatypeUnsigned),
+	PropertyIdProtocolServicesSupported:        scalar(DatatypeBitString),
+	PropertyIdProtocolVersion:                  scalar(DatatypeUnsigned),
+	PropertyIdRecipientList:                    list(DatatypeDestination),
+	PropertyIdRecordCount:                      scalar(DatatypeUnsigned),
+	PropertyIdRecordsSinceNotification:         scalar(DatatypeUnsigned),
+	PropertyIdReliability:                      scalar(DatatypeReliability),
+	PropertyIdResolution:                       scalar(DatatypeReal),
+	PropertyIdRestartNotificationRecipients:    list(DatatypeRecipient),
+	PropertyIdScheduleDefault:                  scalar(DatatypeAny),
+	PropertyIdSegmentationSupported:            scalar(DatatypeSegmentation),
+	PropertyIdSerialNumber:                     scalar(DatatypeCharacterString),
+	PropertyIdSetpoint:                         scalar(DatatypeReal),
+	PropertyIdStartTime:                        scalar(DatatypeDateTime),
+	PropertyIdStateText:                        array(DatatypeCharacterString),
+	PropertyIdStatusFlags:                      scalar(DatatypeStatusFlags),
+	PropertyIdStopTime:                         scalar(DatatypeDateTime),
+	PropertyIdStopWhenFull:                     scalar(DatatypeBoolean),
+	PropertyIdStructuredObjectList:             array(DatatypeObjectIdentifier),
+	PropertyIdSystemStatus:                     scalar(DatatypeDeviceStatus),
+	PropertyIdTimeDelay:                        scalar(DatatypeUnsigned),
+	PropertyIdTimeDelayNormal:                  scalar(DatatypeUnsigned),
+	PropertyIdTimeOfActiveTimeReset:            scalar(DatatypeDateTime),
+	PropertyIdTimeOfDeviceRestart:              scalar(DatatypeTimeStamp),
+	PropertyIdTimeOfStateCountReset:            scalar(DatatypeDateTime),
+	PropertyIdTimeSynchronizationInterval:      scalar(DatatypeUnsigned),
+	PropertyIdTimeSynchronizationRecipients:    list(DatatypeRecipient),
+	PropertyIdTotalRecordCount:                 scalar(DatatypeUnsigned),
+	PropertyIdTrigger:                          scalar(DatatypeBoolean),
+	PropertyIdUnits:                            scalar(DatatypeEngineeringUnits),
+	PropertyIdUpdateInterval:                   scalar(DatatypeUnsigned),
+	PropertyIdUtcOffset:                        scalar(DatatypeSigned),
+	PropertyIdUtcTimeSynchronizationRecipients: list(DatatypeRecipient),
+	PropertyIdVendorIdentifier:                 scalar(DatatypeUnsigned),
+	PropertyIdVendorName:                       scalar(DatatypeCharacterString),
+	PropertyIdWeeklySchedule:                   array(DatatypeDailySchedule),
+}
+
+// objectPropertyTypes holds the datatypes of the properties whose datatype
+// depends on the object type, by object type.
+var objectPropertyTypes = map[uint16]map[uint16]PropertyType{
+	ObjectTypeAnalogInput: {
+		PropertyIdPresentValue: scalar(DatatypeReal),
+	},
+	ObjectTypeAnalogOutput: {
+		PropertyIdPresentValue:      scalar(DatatypeReal),
+		PropertyIdRelinquishDefault: scalar(DatatypeReal),
+	},
+	ObjectTypeAnalogValue: {
+		PropertyIdPresentValue:      scalar(DatatypeReal),
+		PropertyIdRelinquishDefault: scalar(DatatypeReal),
+	},
+	ObjectTypeBinaryInput: {
+		PropertyIdPresentValue: scalar(DatatypeBinaryPV),
+		PropertyIdAlarmValue:   scalar(DatatypeBinaryPV),
+	},
+	ObjectTypeBinaryOutput: {
+		PropertyIdPresentValue:      scalar(DatatypeBinaryPV),
+		PropertyIdRelinquishDefault: scalar(DatatypeBinaryPV),
+		PropertyIdFeedbackValue:     scalar(DatatypeBinaryPV),
+	},
+	ObjectTypeBinaryValue: {
+		PropertyIdPresentValue:      scalar(DatatypeBinaryPV),
+		PropertyIdRelinquishDefault: scalar(DatatypeBinaryPV),
+		PropertyIdAlarmValue:        scalar(DatatypeBinaryPV),
+	},
+	ObjectTypeCalendar: {
+		PropertyIdPresentValue: scalar(DatatypeBoolean),
+	},
+	ObjectTypeMultiStateInput: {
+		PropertyIdPresentValue: scalar(DatatypeUnsigned),
+		PropertyIdAlarmValues:  list(DatatypeUnsigned),
+		PropertyIdFaultValues:  list(DatatypeUnsigned),
+	},
+	ObjectTypeMultiStateOutput: {
+		PropertyIdPresentValue:      scalar(DatatypeUnsigned),
+		PropertyIdRelinquishDefault: scalar(DatatypeUnsigned),
+		PropertyIdFeedbackValue:     scalar(DatatypeUnsigned),
+	},
+	ObjectTypeMultiStateValue: {
+		PropertyIdPresentValue:      scalar(DatatypeUnsigned),
+		PropertyIdRelinquishDefault: scalar(DatatypeUnsigned),
+		PropertyIdAlarmValues:       list(DatatypeUnsigned),
+		PropertyIdFaultValues:       list(DatatypeUnsigned),
+	},
+	ObjectTypeLoop: {
+		PropertyIdPresentValue: scalar(DatatypeReal),
+	},
+	ObjectTypeSchedule: {
+		PropertyIdPresentValue: scalar(DatatypeAny),
+	},
+	ObjectTypeAccumulator: {
+		PropertyIdPresentValue: scalar(DatatypeUnsigned),
+		PropertyIdMaxPresValue: scalar(DatatypeUnsigned),
+		PropertyIdPulseRate:    scalar(DatatypeUnsigned),
+		PropertyIdHighLimit:    scalar(DatatypeUnsigned),
+		PropertyIdLowLimit:     scalar(DatatypeUnsigned),
+	},
+	ObjectTypePulseConverter: {
+		PropertyIdPresentValue: scalar(DatatypeReal),
+	},
+	ObjectTypeTrendLog: {
+		PropertyIdLogBuffer: list(DatatypeLogRecord),
+	},
+	ObjectTypeTrendLogMultiple: {
+		PropertyIdLogDeviceObjectProperty: array(DatatypeDeviceObjectPropertyReference),
+	},
+}
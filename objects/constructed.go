@@ -0,0 +1,906 @@
+package objects
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Constructed is a constructed data type, encoded with a TagWriter and
+// decoded with a TagReader.
+type Constructed interface {
+	Encode(w *TagWriter) error
+	Decode(r *TagReader) error
+}
+
+// writeAll writes objs in turn.
+func writeAll(w *TagWriter, objs ...*Object) error {
+	for _, o := range objs {
+		if err := w.Write(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readUnsigned reads the Unsigned context tagged tagNumber.
+func readUnsigned(r *TagReader, tagNumber uint8) (uint32, error) {
+	o, err := r.ReadContext(tagNumber)
+	if err != nil {
+		return 0, err
+	}
+	return DecUnsignedInteger(o)
+}
+
+// readObjectIdentifier reads the BACnetObjectIdentifier context tagged
+// tagNumber.
+func readObjectIdentifier(r *TagReader, tagNumber uint8) (ObjectIdentifier, error) {
+	o, err := r.ReadContext(tagNumber)
+	if err != nil {
+		return ObjectIdentifier{}, err
+	}
+	return DecObjectIdentifier(o)
+}
+
+// readApplication reads the primitive value application tagged tagNumber.
+func readApplication(r *TagReader, tagNumber uint8) (*Object, error) {
+	o, err := r.ReadPrimitive()
+	if err != nil {
+		return nil, err
+	}
+	if o.TagClass || o.TagNumber != tagNumber {
+		return nil, fmt.Errorf(
+			"expecting %s, got tag %d: %v", TagMap[tagNumber], o.TagNumber, common.ErrWrongTagNumber,
+		)
+	}
+	return o, nil
+}
+
+// readAny reads the tags up to the end of the constructed value entered last.
+func readAny(r *TagReader) ([]*Object, error) {
+	var objs []*Object
+	for depth := r.Depth(); r.Depth() > depth || !r.Done(); {
+		o, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+	}
+	return objs, nil
+}
+
+func readDateTime(r *TagReader) (BACnetDateTime, error) {
+	date, err := readApplication(r, TagDate)
+	if err != nil {
+		return BACnetDateTime{}, err
+	}
+	t, err := readApplication(r, TagTime)
+	if err != nil {
+		return BACnetDateTime{}, err
+	}
+	return DecDateTime(date, t)
+}
+
+func writeDateTime(w *TagWriter, dt BACnetDateTime) error {
+	d, err := EncDate(dt.Date)
+	if err != nil {
+		return err
+	}
+	t, err := EncTime(dt.Time)
+	if err != nil {
+		return err
+	}
+	return writeAll(w, d, t)
+}
+
+// BACnetPropertyValue is the value of a property, as written by
+// WritePropertyMultiple or notified by COV.
+type BACnetPropertyValue struct {
+	PropertyId uint16
+	// ArrayIndex is ArrayAll without an array index.
+	ArrayIndex uint32
+	// Value holds the tags of the value, possibly constructed.
+	Value []*Object
+	// Priority is 0 without a priority.
+	Priority uint8
+}
+
+func (v *BACnetPropertyValue) Encode(w *TagWriter) error {
+	if err := w.Write(ContextTag(0, EncUnsignedInteger(uint(v.PropertyId)))); err != nil {
+		return err
+	}
+	if v.ArrayIndex != ArrayAll {
+		if err := w.Write(ContextTag(1, EncUnsignedInteger(uint(v.ArrayIndex)))); err != nil {
+			return err
+		}
+	}
+	w.Open(2)
+	if err := writeAll(w, v.Value...); err != nil {
+		return err
+	}
+	if err := w.Close(2); err != nil {
+		return err
+	}
+	if v.Priority != 0 {
+		return w.Write(ContextTag(3, EncUnsignedInteger(uint(v.Priority))))
+	}
+	return nil
+}
+
+func (v *BACnetPropertyValue) Decode(r *TagReader) error {
+	*v = BACnetPropertyValue{ArrayIndex: ArrayAll}
+	pid, err := readUnsigned(r, 0)
+	if err != nil {
+		return fmt.Errorf("decoding BACnetPropertyValue: %w", err)
+	}
+	v.PropertyId = uint16(pid)
+	if r.PeekContext(1) {
+		if v.ArrayIndex, err = readUnsigned(r, 1); err != nil {
+			return fmt.Errorf("decoding BACnetPropertyValue: %w", err)
+		}
+	}
+	if err := r.Enter(2); err != nil {
+		return fmt.Errorf("decoding BACnetPropertyValue: %w", err)
+	}
+	if v.Value, err = readAny(r); err != nil {
+		return fmt.Errorf("decoding BACnetPropertyValue: %w", err)
+	}
+	if err := r.Leave(2); err != nil {
+		return fmt.Errorf("decoding BACnetPropertyValue: %w", err)
+	}
+	if r.PeekContext(3) {
+		priority, err := readUnsigned(r, 3)
+		if err != nil {
+			return fmt.Errorf("decoding BACnetPropertyValue: %w", err)
+		}
+		v.Priority = uint8(priority)
+	}
+	return nil
+}
+
+// BACnetPriorityArray holds the values commanded at the priorities 1 to 16 of
+// a commandable property, the relinquished ones being Null. The values are
+// application tagged primitives.
+type BACnetPriorityArray [16]*Object
+
+func (a *BACnetPriorityArray) Encode(w *TagWriter) error {
+	for _, o := range a {
+		if o == nil {
+			o = EncNull()
+		}
+		if err := w.Write(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *BACnetPriorityArray) Decode(r *TagReader) error {
+	for i := range a {
+		o, err := r.ReadPrimitive()
+		if err != nil {
+			return fmt.Errorf("decoding BACnetPriorityArray at priority %d: %v", i+1, err)
+		}
+		if o.TagClass {
+			return fmt.Errorf(
+				"decoding BACnetPriorityArray at priority %d - context tag %d: %v", i+1, o.TagNumber, common.ErrNotImplemented,
+			)
+		}
+		a[i] = o
+	}
+	return nil
+}
+
+// Active returns the priority and the value commanded at the highest
+// priority, or 0 when every priority is relinquished.
+func (a *BACnetPriorityArray) Active() (int, *Object) {
+	for i, o := range a {
+		if o != nil && (o.TagClass || o.TagNumber != TagNull) {
+			return i + 1, o
+		}
+	}
+	return 0, nil
+}
+
+// Choices of BACnetTimeStamp.
+const (
+	TimeStampTime           = 0
+	TimeStampSequenceNumber = 1
+	TimeStampDateTime       = 2
+)
+
+// BACnetTimeStamp is a time, a sequence number or a date and time, as
+// selected by Type.
+type BACnetTimeStamp struct {
+	Type           uint8
+	Time           BACnetTime
+	SequenceNumber uint32
+	DateTime       BACnetDateTime
+}
+
+func (ts *BACnetTimeStamp) Encode(w *TagWriter) error {
+	switch ts.Type {
+	case TimeStampTime:
+		t, err := EncTime(ts.Time)
+		if err != nil {
+			return err
+		}
+		return w.Write(ContextTag(TimeStampTime, t))
+	case TimeStampSequenceNumber:
+		return w.Write(ContextTag(TimeStampSequenceNumber, EncUnsignedInteger(uint(ts.SequenceNumber))))
+	case TimeStampDateTime:
+		w.Open(TimeStampDateTime)
+		if err := writeDateTime(w, ts.DateTime); err != nil {
+			return err
+		}
+		return w.Close(TimeStampDateTime)
+	}
+	return fmt.Errorf("encoding BACnetTimeStamp of type %d: %v", ts.Type, common.ErrWrongStructure)
+}
+
+func (ts *BACnetTimeStamp) Decode(r *TagReader) error {
+	*ts = BACnetTimeStamp{}
+	o, err := r.Peek()
+	if err != nil {
+		return fmt.Errorf("decoding BACnetTimeStamp: %v", err)
+	}
+	ts.Type = o.TagNumber
+	switch {
+	case r.PeekContext(TimeStampTime):
+		o, err := r.ReadContext(TimeStampTime)
+		if err == nil {
+			ts.Time, err = DecBACnetTime(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetTimeStamp: %v", err)
+		}
+	case r.PeekContext(TimeStampSequenceNumber):
+		if ts.SequenceNumber, err = readUnsigned(r, TimeStampSequenceNumber); err != nil {
+			return fmt.Errorf("decoding BACnetTimeStamp: %v", err)
+		}
+	case r.PeekContext(TimeStampDateTime):
+		if err := r.Enter(TimeStampDateTime); err != nil {
+			return fmt.Errorf("decoding BACnetTimeStamp: %v", err)
+		}
+		if ts.DateTime, err = readDateTime(r); err != nil {
+			return fmt.Errorf("decoding BACnetTimeStamp: %v", err)
+		}
+		if err := r.Leave(TimeStampDateTime); err != nil {
+			return fmt.Errorf("decoding BACnetTimeStamp: %v", err)
+		}
+	default:
+		return fmt.Errorf("decoding BACnetTimeStamp - tag %d: %v", o.TagNumber, common.ErrWrongTagNumber)
+	}
+	return nil
+}
+
+// BACnetPropertyReference refers to a property, or an element of it.
+type BACnetPropertyReference struct {
+	PropertyId uint16
+	// ArrayIndex is ArrayAll without an array index.
+	ArrayIndex uint32
+}
+
+func (ref *BACnetPropertyReference) Encode(w *TagWriter) error {
+	objs := []*Object{ContextTag(0, EncUnsignedInteger(uint(ref.PropertyId)))}
+	if ref.ArrayIndex != ArrayAll {
+		objs = append(objs, ContextTag(1, EncUnsignedInteger(uint(ref.ArrayIndex))))
+	}
+	return writeAll(w, objs...)
+}
+
+func (ref *BACnetPropertyReference) Decode(r *TagReader) error {
+	*ref = BACnetPropertyReference{ArrayIndex: ArrayAll}
+	pid, err := readUnsigned(r, 0)
+	if err != nil {
+		return fmt.Errorf("decoding BACnetPropertyReference: %w", err)
+	}
+	ref.PropertyId = uint16(pid)
+	if r.PeekContext(1) {
+		if ref.ArrayIndex, err = readUnsigned(r, 1); err != nil {
+			return fmt.Errorf("decoding BACnetPropertyReference: %w", err)
+		}
+	}
+	return nil
+}
+
+// BACnetObjectPropertyReference refers to a property of an object.
+type BACnetObjectPropertyReference struct {
+	ObjectId   ObjectIdentifier
+	PropertyId uint16
+	// ArrayIndex is ArrayAll without an array index.
+	ArrayIndex uint32
+}
+
+func (ref *BACnetObjectPropertyReference) Encode(w *TagWriter) error {
+	objs := []*Object{
+		EncObjectIdentifier(true, 0, ref.ObjectId.ObjectType, ref.ObjectId.InstanceNumber),
+		ContextTag(1, EncUnsignedInteger(uint(ref.PropertyId))),
+	}
+	if ref.ArrayIndex != ArrayAll {
+		objs = append(objs, ContextTag(2, EncUnsignedInteger(uint(ref.ArrayIndex))))
+	}
+	return writeAll(w, objs...)
+}
+
+func (ref *BACnetObjectPropertyReference) Decode(r *TagReader) error {
+	*ref = BACnetObjectPropertyReference{ArrayIndex: ArrayAll}
+	var err error
+	if ref.ObjectId, err = readObjectIdentifier(r, 0); err != nil {
+		return fmt.Errorf("decoding BACnetObjectPropertyReference: %v", err)
+	}
+	pid, err := readUnsigned(r, 1)
+	if err != nil {
+		return fmt.Errorf("decoding BACnetObjectPropertyReference: %v", err)
+	}
+	ref.PropertyId = uint16(pid)
+	if r.PeekContext(2) {
+		if ref.ArrayIndex, err = readUnsigned(r, 2); err != nil {
+			return fmt.Errorf("decoding BACnetObjectPropertyReference: %v", err)
+		}
+	}
+	return nil
+}
+
+// BACnetDeviceObjectPropertyReference refers to a property of an object,
+// possibly in another device.
+type BACnetDeviceObjectPropertyReference struct {
+	BACnetObjectPropertyReference
+	// DeviceId is nil for the objects of the local device.
+	DeviceId *ObjectIdentifier
+}
+
+func (ref *BACnetDeviceObjectPropertyReference) Encode(w *TagWriter) error {
+	if err := ref.BACnetObjectPropertyReference.Encode(w); err != nil {
+		return err
+	}
+	if ref.DeviceId != nil {
+		return w.Write(EncObjectIdentifier(true, 3, ref.DeviceId.ObjectType, ref.DeviceId.InstanceNumber))
+	}
+	return nil
+}
+
+func (ref *BACnetDeviceObjectPropertyReference) Decode(r *TagReader) error {
+	*ref = BACnetDeviceObjectPropertyReference{}
+	if err := ref.BACnetObjectPropertyReference.Decode(r); err != nil {
+		return fmt.Errorf("decoding BACnetDeviceObjectPropertyReference: %v", err)
+	}
+	if r.PeekContext(3) {
+		oid, err := readObjectIdentifier(r, 3)
+		if err != nil {
+			return fmt.Errorf("decoding BACnetDeviceObjectPropertyReference: %v", err)
+		}
+		ref.DeviceId = &oid
+	}
+	return nil
+}
+
+// BACnetAddress is the address of a device, on the local network for the
+// network number 0 and broadcast for an empty MAC address.
+type BACnetAddress struct {
+	Net uint16
+	Mac []byte
+}
+
+func (a *BACnetAddress) Encode(w *TagWriter) error {
+	return writeAll(w, EncUnsignedInteger(uint(a.Net)), EncOctetString(a.Mac))
+}
+
+func (a *BACnetAddress) Decode(r *TagReader) error {
+	o, err := readApplication(r, TagUnsignedInteger)
+	if err != nil {
+		return fmt.Errorf("decoding BACnetAddress: %v", err)
+	}
+	net, err := DecUnsignedInteger(o)
+	if err != nil {
+		return fmt.Errorf("decoding BACnetAddress: %v", err)
+	}
+	if o, err = readApplication(r, TagOctetString); err != nil {
+		return fmt.Errorf("decoding BACnetAddress: %v", err)
+	}
+	*a = BACnetAddress{Net: uint16(net), Mac: append([]byte{}, o.Data...)}
+	return nil
+}
+
+// BACnetRecipient is a device, given by its identifier or its address.
+type BACnetRecipient struct {
+	// Device is nil for a recipient given by Address.
+	Device  *ObjectIdentifier
+	Address BACnetAddress
+}
+
+func (rcpt *BACnetRecipient) Encode(w *TagWriter) error {
+	if rcpt.Device != nil {
+		return w.Write(EncObjectIdentifier(true, 0, rcpt.Device.ObjectType, rcpt.Device.InstanceNumber))
+	}
+	w.Open(1)
+	if err := rcpt.Address.Encode(w); err != nil {
+		return err
+	}
+	return w.Close(1)
+}
+
+func (rcpt *BACnetRecipient) Decode(r *TagReader) error {
+	*rcpt = BACnetRecipient{}
+	if r.PeekContext(0) {
+		oid, err := readObjectIdentifier(r, 0)
+		if err != nil {
+			return fmt.Errorf("decoding BACnetRecipient: %v", err)
+		}
+		rcpt.Device = &oid
+		return nil
+	}
+	if err := r.Enter(1); err != nil {
+		return fmt.Errorf("decoding BACnetRecipient: %v", err)
+	}
+	if err := rcpt.Address.Decode(r); err != nil {
+		return fmt.Errorf("decoding BACnetRecipient: %v", err)
+	}
+	if err := r.Leave(1); err != nil {
+		return fmt.Errorf("decoding BACnetRecipient: %v", err)
+	}
+	return nil
+}
+
+// BACnetDestination is a recipient of the notifications of a Notification
+// Class.
+type BACnetDestination struct {
+	// ValidDays holds 7 days, from Monday.
+	ValidDays []bool
+	FromTime  BACnetTime
+	ToTime    BACnetTime
+	Recipient BACnetRecipient
+	ProcessId uint32
+	// IssueConfirmedNotifications asks for confirmed notifications.
+	IssueConfirmedNotifications bool
+	// Transitions holds the to-offnormal, to-fault and to-normal transitions.
+	Transitions []bool
+}
+
+func (d *BACnetDestination) Encode(w *TagWriter) error {
+	from, err := EncTime(d.FromTime)
+	if err != nil {
+		return err
+	}
+	to, err := EncTime(d.ToTime)
+	if err != nil {
+		return err
+	}
+	if err := writeAll(w, EncBitString(d.ValidDays), from, to); err != nil {
+		return err
+	}
+	if err := d.Recipient.Encode(w); err != nil {
+		return err
+	}
+	return writeAll(w,
+		EncUnsignedInteger(uint(d.ProcessId)),
+		EncBoolean(d.IssueConfirmedNotifications),
+		EncBitString(d.Transitions),
+	)
+}
+
+func (d *BACnetDestination) Decode(r *TagReader) error {
+	*d = BACnetDestination{}
+	o, err := readApplication(r, TagBitString)
+	if err == nil {
+		d.ValidDays, err = DecBitString(o)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding BACnetDestination: %v", err)
+	}
+	for _, t := range []*BACnetTime{&d.FromTime, &d.ToTime} {
+		o, err := readApplication(r, TagTime)
+		if err == nil {
+			*t, err = DecBACnetTime(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetDestination: %v", err)
+		}
+	}
+	if err := d.Recipient.Decode(r); err != nil {
+		return fmt.Errorf("decoding BACnetDestination: %v", err)
+	}
+	if o, err = readApplication(r, TagUnsignedInteger); err == nil {
+		d.ProcessId, err = DecUnsignedInteger(o)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding BACnetDestination: %v", err)
+	}
+	if o, err = readApplication(r, TagBoolean); err == nil {
+		d.IssueConfirmedNotifications, err = DecBoolean(o)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding BACnetDestination: %v", err)
+	}
+	if o, err = readApplication(r, TagBitString); err == nil {
+		d.Transitions, err = DecBitString(o)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding BACnetDestination: %v", err)
+	}
+	return nil
+}
+
+// BACnetDateRange is the range of dates from StartDate to EndDate, both
+// included.
+type BACnetDateRange struct {
+	StartDate BACnetDate
+	EndDate   BACnetDate
+}
+
+func (dr *BACnetDateRange) Encode(w *TagWriter) error {
+	start, err := EncDate(dr.StartDate)
+	if err != nil {
+		return err
+	}
+	end, err := EncDate(dr.EndDate)
+	if err != nil {
+		return err
+	}
+	return writeAll(w, start, end)
+}
+
+func (dr *BACnetDateRange) Decode(r *TagReader) error {
+	for _, d := range []*BACnetDate{&dr.StartDate, &dr.EndDate} {
+		o, err := readApplication(r, TagDate)
+		if err == nil {
+			*d, err = DecBACnetDate(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetDateRange: %v", err)
+		}
+	}
+	return nil
+}
+
+// Matches tells whether the date of t is in the range, an unspecified start
+// or end date leaving the range open.
+func (dr *BACnetDateRange) Matches(t time.Time) bool {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if start, err := dr.StartDate.ToTime(t.Location()); err == nil && day.Before(start) {
+		return false
+	}
+	if end, err := dr.EndDate.ToTime(t.Location()); err == nil && day.After(end) {
+		return false
+	}
+	return true
+}
+
+// Weeks of the month of BACnetWeekNDay besides the first five ones, the days
+// 1 to 7, 8 to 14 and so on.
+const (
+	WeekLast7Days = 6 + iota
+	WeekBeforeLast7Days
+	WeekBeforeLast14Days
+	WeekBeforeLast21Days
+)
+
+// BACnetWeekNDay is a day in a week of a month, the fields being possibly
+// Unspecified, and the month MonthOdd or MonthEven.
+type BACnetWeekNDay struct {
+	Month       uint8
+	WeekOfMonth uint8
+	// Weekday is the day of the week from 1, Monday, to 7, Sunday.
+	Weekday uint8
+}
+
+func (wd *BACnetWeekNDay) Encode(w *TagWriter) error {
+	return w.Write(wd.encode())
+}
+
+func (wd *BACnetWeekNDay) encode() *Object {
+	return EncOctetString([]byte{wd.Month, wd.WeekOfMonth, wd.Weekday})
+}
+
+func (wd *BACnetWeekNDay) Decode(r *TagReader) error {
+	o, err := readApplication(r, TagOctetString)
+	if err != nil {
+		return fmt.Errorf("decoding BACnetWeekNDay: %v", err)
+	}
+	return wd.decode(o)
+}
+
+func (wd *BACnetWeekNDay) decode(o *Object) error {
+	if len(o.Data) != 3 {
+		return fmt.Errorf("decoding BACnetWeekNDay - length %d: %v", len(o.Data), common.ErrWrongStructure)
+	}
+	*wd = BACnetWeekNDay{Month: o.Data[0], WeekOfMonth: o.Data[1], Weekday: o.Data[2]}
+	return nil
+}
+
+// Matches tells whether the date of t is one of wd.
+func (wd *BACnetWeekNDay) Matches(t time.Time) bool {
+	date := BACnetDate{Year: Unspecified, Month: wd.Month, Day: Unspecified, Weekday: wd.Weekday}
+	if !date.Matches(t) {
+		return false
+	}
+	day := t.Day()
+	switch w := int(wd.WeekOfMonth); {
+	case w == Unspecified:
+		return true
+	case w >= 1 && w <= 5:
+		return (day-1)/7+1 == w
+	case w >= WeekLast7Days && w <= WeekBeforeLast21Days:
+		last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		end := last - 7*(w-WeekLast7Days)
+		return day <= end && day > end-7
+	}
+	return false
+}
+
+// Choices of BACnetCalendarEntry.
+const (
+	CalendarEntryDate      = 0
+	CalendarEntryDateRange = 1
+	CalendarEntryWeekNDay  = 2
+)
+
+// BACnetCalendarEntry is a date, a range of dates or a BACnetWeekNDay, as
+// selected by Type.
+type BACnetCalendarEntry struct {
+	Type      uint8
+	Date      BACnetDate
+	DateRange BACnetDateRange
+	WeekNDay  BACnetWeekNDay
+}
+
+func (e *BACnetCalendarEntry) Encode(w *TagWriter) error {
+	switch e.Type {
+	case CalendarEntryDate:
+		d, err := EncDate(e.Date)
+		if err != nil {
+			return err
+		}
+		return w.Write(ContextTag(CalendarEntryDate, d))
+	case CalendarEntryDateRange:
+		w.Open(CalendarEntryDateRange)
+		if err := e.DateRange.Encode(w); err != nil {
+			return err
+		}
+		return w.Close(CalendarEntryDateRange)
+	case CalendarEntryWeekNDay:
+		return w.Write(ContextTag(CalendarEntryWeekNDay, e.WeekNDay.encode()))
+	}
+	return fmt.Errorf("encoding BACnetCalendarEntry of type %d: %v", e.Type, common.ErrWrongStructure)
+}
+
+func (e *BACnetCalendarEntry) Decode(r *TagReader) error {
+	*e = BACnetCalendarEntry{}
+	switch {
+	case r.PeekContext(CalendarEntryDate):
+		e.Type = CalendarEntryDate
+		o, err := r.ReadContext(CalendarEntryDate)
+		if err == nil {
+			e.Date, err = DecBACnetDate(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetCalendarEntry: %v", err)
+		}
+	case r.PeekContext(CalendarEntryDateRange):
+		e.Type = CalendarEntryDateRange
+		if err := r.Enter(CalendarEntryDateRange); err != nil {
+			return fmt.Errorf("decoding BACnetCalendarEntry: %v", err)
+		}
+		if err := e.DateRange.Decode(r); err != nil {
+			return fmt.Errorf("decoding BACnetCalendarEntry: %v", err)
+		}
+		if err := r.Leave(CalendarEntryDateRange); err != nil {
+			return fmt.Errorf("decoding BACnetCalendarEntry: %v", err)
+		}
+	case r.PeekContext(CalendarEntryWeekNDay):
+		e.Type = CalendarEntryWeekNDay
+		o, err := r.ReadContext(CalendarEntryWeekNDay)
+		if err == nil {
+			err = e.WeekNDay.decode(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetCalendarEntry: %v", err)
+		}
+	default:
+		return fmt.Errorf("decoding BACnetCalendarEntry: %v", common.ErrWrongTagNumber)
+	}
+	return nil
+}
+
+// Matches tells whether the date of t is one of e.
+func (e *BACnetCalendarEntry) Matches(t time.Time) bool {
+	switch e.Type {
+	case CalendarEntryDate:
+		return e.Date.Matches(t)
+	case CalendarEntryDateRange:
+		return e.DateRange.Matches(t)
+	case CalendarEntryWeekNDay:
+		return e.WeekNDay.Matches(t)
+	}
+	return false
+}
+
+// BACnetTimeValue is the value a schedule takes from a time of the day.
+type BACnetTimeValue struct {
+	Time BACnetTime
+	// Value is an application tagged primitive.
+	Value *Object
+}
+
+// BACnetDailySchedule holds the values a schedule takes during a day.
+type BACnetDailySchedule struct {
+	DaySchedule []BACnetTimeValue
+}
+
+func (s *BACnetDailySchedule) Encode(w *TagWriter) error {
+	w.Open(0)
+	for _, tv := range s.DaySchedule {
+		v := tv.Value
+		if v == nil {
+			v = EncNull()
+		}
+		t, err := EncTime(tv.Time)
+		if err != nil {
+			return err
+		}
+		if err := writeAll(w, t, v); err != nil {
+			return err
+		}
+	}
+	return w.Close(0)
+}
+
+func (s *BACnetDailySchedule) Decode(r *TagReader) error {
+	*s = BACnetDailySchedule{}
+	if err := r.Enter(0); err != nil {
+		return fmt.Errorf("decoding BACnetDailySchedule: %v", err)
+	}
+	for !r.Done() {
+		var tv BACnetTimeValue
+		o, err := readApplication(r, TagTime)
+		if err == nil {
+			tv.Time, err = DecBACnetTime(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetDailySchedule: %v", err)
+		}
+		if tv.Value, err = r.ReadPrimitive(); err != nil {
+			return fmt.Errorf("decoding BACnetDailySchedule: %v", err)
+		}
+		s.DaySchedule = append(s.DaySchedule, tv)
+	}
+	if err := r.Leave(0); err != nil {
+		return fmt.Errorf("decoding BACnetDailySchedule: %v", err)
+	}
+	return nil
+}
+
+// Choices of the datum of BACnetLogRecord.
+const (
+	LogDatumLogStatus  = 0
+	LogDatumBoolean    = 1
+	LogDatumReal       = 2
+	LogDatumEnumerated = 3
+	LogDatumUnsigned   = 4
+	LogDatumSigned     = 5
+	LogDatumBitString  = 6
+	LogDatumNull       = 7
+	LogDatumFailure    = 8
+	LogDatumTimeChange = 9
+	LogDatumAny        = 10
+)
+
+// BACnetLogRecord is a record of the buffer of a Trend Log.
+type BACnetLogRecord struct {
+	Timestamp BACnetDateTime
+	// DatumType is the choice of the datum.
+	DatumType uint8
+	// Datum holds the datum context tagged DatumType, or the tags of the
+	// failure and any value.
+	Datum []*Object
+	// StatusFlags is nil without status flags.
+	StatusFlags []bool
+}
+
+func (l *BACnetLogRecord) Encode(w *TagWriter) error {
+	w.Open(0)
+	if err := writeDateTime(w, l.Timestamp); err != nil {
+		return err
+	}
+	if err := w.Close(0); err != nil {
+		return err
+	}
+	w.Open(1)
+	if l.DatumType == LogDatumFailure || l.DatumType == LogDatumAny {
+		w.Open(l.DatumType)
+		if err := writeAll(w, l.Datum...); err != nil {
+			return err
+		}
+		if err := w.Close(l.DatumType); err != nil {
+			return err
+		}
+	} else if err := writeAll(w, l.Datum...); err != nil {
+		return err
+	}
+	if err := w.Close(1); err != nil {
+		return err
+	}
+	if l.StatusFlags != nil {
+		return w.Write(ContextTag(2, EncBitString(l.StatusFlags)))
+	}
+	return nil
+}
+
+func (l *BACnetLogRecord) Decode(r *TagReader) error {
+	*l = BACnetLogRecord{}
+	var err error
+	if err := r.Enter(0); err != nil {
+		return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+	}
+	if l.Timestamp, err = readDateTime(r); err != nil {
+		return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+	}
+	if err := r.Leave(0); err != nil {
+		return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+	}
+
+	if err := r.Enter(1); err != nil {
+		return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+	}
+	o, err := r.Peek()
+	if err != nil {
+		return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+	}
+	l.DatumType = o.TagNumber
+	if o.IsOpeningTag() {
+		r.Read()
+		if l.Datum, err = readAny(r); err != nil {
+			return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+		}
+		if err := r.Leave(l.DatumType); err != nil {
+			return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+		}
+	} else {
+		if o, err = r.ReadContext(l.DatumType); err != nil {
+			return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+		}
+		l.Datum = []*Object{o}
+	}
+	if err := r.Leave(1); err != nil {
+		return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+	}
+
+	if r.PeekContext(2) {
+		o, err := r.ReadContext(2)
+		if err == nil {
+			l.StatusFlags, err = DecBitString(o)
+		}
+		if err != nil {
+			return fmt.Errorf("decoding BACnetLogRecord: %v", err)
+		}
+	}
+	return nil
+}
+
+// Value returns the primitive datum as a *LogStatus, bool, float32, uint32,
+// int, []bool or nil, for the log status, boolean, real and time change,
+// enumerated and unsigned, signed, bit string and null datums.
+func (l *BACnetLogRecord) Value() (interface{}, error) {
+	if len(l.Datum) != 1 {
+		return nil, fmt.Errorf("datum of type %d: %v", l.DatumType, common.ErrNotImplemented)
+	}
+	o := l.Datum[0]
+	switch l.DatumType {
+	case LogDatumLogStatus:
+		return DecLogStatus(o)
+	case LogDatumBoolean:
+		return DecContextBool(o)
+	case LogDatumReal, LogDatumTimeChange:
+		return DecReal(o)
+	case LogDatumEnumerated, LogDatumUnsigned:
+		return DecUnsignedInteger(o)
+	case LogDatumSigned:
+		return DecSignedInteger(o)
+	case LogDatumBitString:
+		return DecBitString(o)
+	case LogDatumNull:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("datum of type %d: %v", l.DatumType, common.ErrNotImplemented)
+}
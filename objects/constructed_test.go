@@ -0,0 +1,184 @@
+package objects_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+// roundTrip encodes in, checking that it decodes to out.
+func roundTrip(t *testing.T, in, out objects.Constructed) {
+	t.Helper()
+	w := objects.NewTagWriter()
+	if err := in.Encode(w); err != nil {
+		t.Fatal(err)
+	}
+	b, err := w.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := objects.NewTagReader(b)
+	if err := out.Decode(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.End(); err != nil {
+		t.Fatal(err)
+	}
+	w = objects.NewTagWriter()
+	if err := out.Encode(w); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := w.Bytes()
+	AssertEqual(t, b, again)
+}
+
+func TestConstructedRoundTrip(t *testing.T) {
+	device := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 9}
+	date := objects.BACnetDate{Year: 2026, Month: 10, Day: 17, Weekday: 6}
+	at := objects.BACnetTime{Hour: 8, Minute: 30, Second: 0, Hundredths: 0}
+
+	value := &objects.BACnetPropertyValue{
+		PropertyId: objects.PropertyIdPresentValue,
+		ArrayIndex: objects.ArrayAll,
+		Value:      []*objects.Object{objects.EncReal(21.5)},
+		Priority:   8,
+	}
+	var gotValue objects.BACnetPropertyValue
+	roundTrip(t, value, &gotValue)
+	AssertEqual(t, uint8(8), gotValue.Priority)
+	AssertEqual(t, objects.ArrayAll, gotValue.ArrayIndex)
+
+	var priorities objects.BACnetPriorityArray
+	priorities[7] = objects.EncReal(21.5)
+	priorities[15] = objects.EncReal(18)
+	var gotPriorities objects.BACnetPriorityArray
+	roundTrip(t, &priorities, &gotPriorities)
+	if p, o := gotPriorities.Active(); p != 8 || o.TagNumber != objects.TagReal {
+		t.Errorf("unexpected active priority %d %+v", p, o)
+	}
+
+	for _, ts := range []*objects.BACnetTimeStamp{
+		{Type: objects.TimeStampTime, Time: at},
+		{Type: objects.TimeStampSequenceNumber, SequenceNumber: 1234},
+		{Type: objects.TimeStampDateTime, DateTime: objects.BACnetDateTime{Date: date, Time: at}},
+	} {
+		var got objects.BACnetTimeStamp
+		roundTrip(t, ts, &got)
+		AssertEqual(t, *ts, got)
+	}
+
+	for _, pref := range []*objects.BACnetPropertyReference{
+		{PropertyId: objects.PropertyIdAll, ArrayIndex: objects.ArrayAll},
+		{PropertyId: objects.PropertyIdPriorityArray, ArrayIndex: 16},
+	} {
+		var got objects.BACnetPropertyReference
+		roundTrip(t, pref, &got)
+		AssertEqual(t, *pref, got)
+	}
+
+	ref := &objects.BACnetDeviceObjectPropertyReference{
+		BACnetObjectPropertyReference: objects.BACnetObjectPropertyReference{
+			ObjectId:   objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 1},
+			PropertyId: objects.PropertyIdPresentValue,
+			ArrayIndex: objects.ArrayAll,
+		},
+		DeviceId: &device,
+	}
+	var gotRef objects.BACnetDeviceObjectPropertyReference
+	roundTrip(t, ref, &gotRef)
+	AssertEqual(t, *ref, gotRef)
+
+	dest := &objects.BACnetDestination{
+		ValidDays:   []bool{true, true, true, true, true, false, false},
+		FromTime:    at,
+		ToTime:      objects.BACnetTime{Hour: 17},
+		Recipient:   objects.BACnetRecipient{Address: objects.BACnetAddress{Net: 5, Mac: []byte{0x0a}}},
+		ProcessId:   3,
+		Transitions: []bool{true, false, true},
+	}
+	var gotDest objects.BACnetDestination
+	roundTrip(t, dest, &gotDest)
+	AssertEqual(t, *dest, gotDest)
+
+	entry := &objects.BACnetCalendarEntry{
+		Type:     objects.CalendarEntryWeekNDay,
+		WeekNDay: objects.BACnetWeekNDay{Month: objects.Unspecified, WeekOfMonth: objects.WeekLast7Days, Weekday: 1},
+	}
+	var gotEntry objects.BACnetCalendarEntry
+	roundTrip(t, entry, &gotEntry)
+	AssertEqual(t, *entry, gotEntry)
+
+	schedule := &objects.BACnetDailySchedule{DaySchedule: []objects.BACnetTimeValue{
+		{Time: at, Value: objects.EncReal(21)},
+		{Time: objects.BACnetTime{Hour: 18}, Value: objects.EncNull()},
+	}}
+	var gotSchedule objects.BACnetDailySchedule
+	roundTrip(t, schedule, &gotSchedule)
+	AssertEqual(t, 2, len(gotSchedule.DaySchedule))
+
+	record := &objects.BACnetLogRecord{
+		Timestamp:   objects.BACnetDateTime{Date: date, Time: at},
+		DatumType:   objects.LogDatumReal,
+		Datum:       []*objects.Object{objects.ContextTag(objects.LogDatumReal, objects.EncReal(21.5))},
+		StatusFlags: make([]bool, 4),
+	}
+	var gotRecord objects.BACnetLogRecord
+	roundTrip(t, record, &gotRecord)
+	v, err := gotRecord.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, float32(21.5), v)
+
+	record.DatumType = objects.LogDatumAny
+	record.Datum = []*objects.Object{objects.EncString("any")}
+	roundTrip(t, record, &gotRecord)
+	AssertEqual(t, objects.LogDatumAny, int(gotRecord.DatumType))
+}
+
+func TestConstructedDecodeErrors(t *testing.T) {
+	var v objects.BACnetPropertyValue
+	if err := v.Decode(objects.NewTagReader([]byte{0x09, 0x55, 0x2e, 0x44, 0x3f, 0x80, 0x00, 0x00})); err == nil {
+		t.Error("expected an error decoding a value not closed")
+	}
+
+	var priorities objects.BACnetPriorityArray
+	if err := priorities.Decode(objects.NewTagReader([]byte{0x00, 0x09, 0x01})); err == nil {
+		t.Error("expected an error decoding a context tagged priority")
+	}
+
+	var entry objects.BACnetCalendarEntry
+	if err := entry.Decode(objects.NewTagReader([]byte{0x2a, 0x01, 0x02})); err == nil {
+		t.Error("expected an error decoding a BACnetWeekNDay of 2 bytes")
+	}
+}
+
+func TestCalendarMatches(t *testing.T) {
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2026, m, d, 12, 0, 0, 0, time.UTC)
+	}
+	// The last Monday of every month.
+	lastMonday := objects.BACnetWeekNDay{Month: objects.Unspecified, WeekOfMonth: objects.WeekLast7Days, Weekday: 1}
+	AssertEqual(t, true, lastMonday.Matches(day(time.October, 26)))
+	AssertEqual(t, false, lastMonday.Matches(day(time.October, 19)))
+	AssertEqual(t, true, lastMonday.Matches(day(time.February, 23)))
+
+	// Any day of the second week of odd months.
+	secondWeek := objects.BACnetWeekNDay{Month: objects.MonthOdd, WeekOfMonth: 2, Weekday: objects.Unspecified}
+	AssertEqual(t, true, secondWeek.Matches(day(time.January, 8)))
+	AssertEqual(t, false, secondWeek.Matches(day(time.January, 15)))
+	AssertEqual(t, false, secondWeek.Matches(day(time.February, 8)))
+
+	summer := objects.BACnetCalendarEntry{
+		Type: objects.CalendarEntryDateRange,
+		DateRange: objects.BACnetDateRange{
+			StartDate: objects.BACnetDate{Year: 2026, Month: 6, Day: 21, Weekday: objects.Unspecified},
+			EndDate:   objects.BACnetDate{Year: objects.Unspecified, Month: objects.Unspecified, Day: objects.Unspecified, Weekday: objects.Unspecified},
+		},
+	}
+	AssertEqual(t, true, summer.Matches(day(time.June, 21)))
+	AssertEqual(t, true, summer.Matches(day(time.December, 31)))
+	AssertEqual(t, false, summer.Matches(day(time.June, 20)))
+}
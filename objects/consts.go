@@ -17,6 +17,9 @@ const (
 	TagBACnetObjectIdentifier
 )
 
+// ArrayAll addresses a whole property instead of a single array element.
+const ArrayAll uint32 = 0xFFFFFFFF
+
 // Object types
 const (
 	ObjectTypeAnalogInput uint16 = iota
@@ -59,21 +62,3 @@ const (
 	ObjectTypeCredentialDataInput
 	ObjectTypeNetworkSecurity
 )
-
-// Error classes
-const (
-	ErrorClassDevice   uint8 = iota
-	ErrorClassObject
-	ErrorClassProperty
-	ErrorClassResources
-	ErrorClassSecurity
-	ErrorClassServices
-	ErrorClassVT
-	ErrorClassCommunication
-	ErrorClassVendor
-)
-
-const (
-	ErrorCodeUnknownObject        uint8 = 31
-	ErrorCodeServiceRequestDenied uint8 = 29
-)
@@ -20,7 +20,7 @@ func EncContextBool(tagNumber uint8, value bool) *Object {
 
 func DecContextBool(rawPayload APDUPayload) (bool, error) {
 	encObj, ok := rawPayload.(*Object)
-	if !ok {
+	if !ok || len(encObj.Data) < 1 {
 		return false, common.ErrInvalidObjectType
 	}
 	return common.IntToBool(int(encObj.Data[0] & 0x01)), nil
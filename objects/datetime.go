@@ -0,0 +1,408 @@
+package objects
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Unspecified is the wildcard of every field of BACnetDate and BACnetTime,
+// matching any value.
+const Unspecified = 0xFF
+
+// Wildcards of the months and days of BACnetDate.
+const (
+	MonthOdd  = 13
+	MonthEven = 14
+	DayLast   = 32
+	DayOdd    = 33
+	DayEven   = 34
+)
+
+// BACnetDate is a Date, whose fields may be Unspecified or, for the month and
+// the day, other wildcards.
+type BACnetDate struct {
+	// Year is the year from 1900 to 2154.
+	Year int
+	// Month is the month from 1 to 12, MonthOdd or MonthEven.
+	Month uint8
+	// Day is the day of the month from 1 to 31, DayLast, DayOdd or DayEven.
+	Day uint8
+	// Weekday is the day of the week from 1, Monday, to 7, Sunday.
+	Weekday uint8
+}
+
+// BACnetTime is a Time, whose fields may be Unspecified.
+type BACnetTime struct {
+	Hour       uint8
+	Minute     uint8
+	Second     uint8
+	Hundredths uint8
+}
+
+// BACnetDateTime is a BACnetDate with a BACnetTime, encoded as an application
+// tagged Date followed by an application tagged Time.
+type BACnetDateTime struct {
+	Date BACnetDate
+	Time BACnetTime
+}
+
+// NewBACnetDate returns the BACnetDate of t, without wildcards.
+func NewBACnetDate(t time.Time) BACnetDate {
+	return BACnetDate{
+		Year:    t.Year(),
+		Month:   uint8(t.Month()),
+		Day:     uint8(t.Day()),
+		Weekday: bacnetWeekday(t.Weekday()),
+	}
+}
+
+// NewBACnetTime returns the BACnetTime of t, without wildcards.
+func NewBACnetTime(t time.Time) BACnetTime {
+	return BACnetTime{
+		Hour:       uint8(t.Hour()),
+		Minute:     uint8(t.Minute()),
+		Second:     uint8(t.Second()),
+		Hundredths: uint8(t.Nanosecond() / 10_000_000),
+	}
+}
+
+// NewBACnetDateTime returns the BACnetDateTime of t, without wildcards.
+func NewBACnetDateTime(t time.Time) BACnetDateTime {
+	return BACnetDateTime{Date: NewBACnetDate(t), Time: NewBACnetTime(t)}
+}
+
+// bacnetWeekday returns the BACnet day of the week of w, Sunday being 7.
+func bacnetWeekday(w time.Weekday) uint8 {
+	if w == time.Sunday {
+		return 7
+	}
+	return uint8(w)
+}
+
+// IsSpecified tells whether d is a single date, without wildcards.
+func (d BACnetDate) IsSpecified() bool {
+	return d.Year != Unspecified && d.Month <= 12 && d.Day <= 31 && d.Weekday != Unspecified
+}
+
+// Matches tells whether the date of t is one of d.
+func (d BACnetDate) Matches(t time.Time) bool {
+	if d.Year != Unspecified && d.Year != t.Year() {
+		return false
+	}
+	month := uint8(t.Month())
+	switch d.Month {
+	case Unspecified:
+	case MonthOdd, MonthEven:
+		if month%2 != d.Month%2 {
+			return false
+		}
+	default:
+		if d.Month != month {
+			return false
+		}
+	}
+	day := uint8(t.Day())
+	switch d.Day {
+	case Unspecified:
+	case DayLast:
+		if t.AddDate(0, 0, 1).Day() != 1 {
+			return false
+		}
+	case DayOdd, DayEven:
+		if day%2 != d.Day%2 {
+			return false
+		}
+	default:
+		if d.Day != day {
+			return false
+		}
+	}
+	return d.Weekday == Unspecified || d.Weekday == bacnetWeekday(t.Weekday())
+}
+
+// ToTime returns the midnight starting d in loc, which must be specified but
+// for its day of the week.
+func (d BACnetDate) ToTime(loc *time.Location) (time.Time, error) {
+	return d.at(0, 0, 0, 0, loc)
+}
+
+// at returns the instant of d at the given time of the day in loc, failing
+// for the wildcards and for the days past the end of their month.
+func (d BACnetDate) at(hour, min, sec, nsec int, loc *time.Location) (time.Time, error) {
+	if d.Year == Unspecified || d.Month == 0 || d.Month > 12 || d.Day == 0 || d.Day > 31 {
+		return time.Time{}, fmt.Errorf("date %s: %v", d, common.ErrInvalidData)
+	}
+	t := time.Date(d.Year, time.Month(d.Month), int(d.Day), hour, min, sec, nsec, loc)
+	if t.Day() != int(d.Day) {
+		// time.Date normalizes February 31 into March.
+		return time.Time{}, fmt.Errorf("date %s: %v", d, common.ErrInvalidData)
+	}
+	return t, nil
+}
+
+func (d BACnetDate) String() string {
+	year := "*"
+	if d.Year != Unspecified {
+		year = fmt.Sprintf("%d", d.Year)
+	}
+	month := map[uint8]string{Unspecified: "*", MonthOdd: "odd", MonthEven: "even"}[d.Month]
+	if month == "" {
+		month = fmt.Sprintf("%02d", d.Month)
+	}
+	day := map[uint8]string{Unspecified: "*", DayLast: "last", DayOdd: "odd", DayEven: "even"}[d.Day]
+	if day == "" {
+		day = fmt.Sprintf("%02d", d.Day)
+	}
+	s := year + "-" + month + "-" + day
+	if d.Weekday >= 1 && d.Weekday <= 7 {
+		s += " " + time.Weekday(d.Weekday % 7).String()[:3]
+	}
+	return s
+}
+
+// IsSpecified tells whether t is a single time of the day, without wildcards.
+func (t BACnetTime) IsSpecified() bool {
+	return t.Hour != Unspecified && t.Minute != Unspecified && t.Second != Unspecified && t.Hundredths != Unspecified
+}
+
+// Matches tells whether the time of the day of u is one of t.
+func (t BACnetTime) Matches(u time.Time) bool {
+	match := func(f uint8, v int) bool {
+		return f == Unspecified || int(f) == v
+	}
+	return match(t.Hour, u.Hour()) && match(t.Minute, u.Minute()) && match(t.Second, u.Second()) &&
+		match(t.Hundredths, u.Nanosecond()/10_000_000)
+}
+
+// Duration returns the time elapsed since midnight at t, the unspecified
+// fields counting as zero.
+func (t BACnetTime) Duration() time.Duration {
+	field := func(f uint8) time.Duration {
+		if f == Unspecified {
+			return 0
+		}
+		return time.Duration(f)
+	}
+	return field(t.Hour)*time.Hour + field(t.Minute)*time.Minute + field(t.Second)*time.Second +
+		field(t.Hundredths)*10*time.Millisecond
+}
+
+func (t BACnetTime) String() string {
+	field := func(f uint8) string {
+		if f == Unspecified {
+			return "*"
+		}
+		return fmt.Sprintf("%02d", f)
+	}
+	return field(t.Hour) + ":" + field(t.Minute) + ":" + field(t.Second) + "." + field(t.Hundredths)
+}
+
+// IsSpecified tells whether dt is a single instant, without wildcards.
+func (dt BACnetDateTime) IsSpecified() bool {
+	return dt.Date.IsSpecified() && dt.Time.IsSpecified()
+}
+
+// Matches tells whether t is one of the instants of dt.
+func (dt BACnetDateTime) Matches(t time.Time) bool {
+	return dt.Date.Matches(t) && dt.Time.Matches(t)
+}
+
+// ToTime returns the instant dt in loc, whose date must be specified but for
+// its day of the week and whose time must be specified.
+func (dt BACnetDateTime) ToTime(loc *time.Location) (time.Time, error) {
+	if !dt.Time.IsSpecified() {
+		return time.Time{}, fmt.Errorf("time %s: %v", dt.Time, common.ErrInvalidData)
+	}
+	t := dt.Time
+	return dt.Date.at(int(t.Hour), int(t.Minute), int(t.Second), int(t.Hundredths)*10_000_000, loc)
+}
+
+func (dt BACnetDateTime) String() string {
+	return dt.Date.String() + " " + dt.Time.String()
+}
+
+// TagNumber 10
+//
+// Deprecated: DecDate turns the wildcards into bogus dates, DecBACnetDate
+// keeps them.
+func DecDate(rawPayload APDUPayload) (time.Time, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return time.Time{}, fmt.Errorf(
+			"failed to decode Date - %+v: %v", rawPayload, common.ErrWrongPayload,
+		)
+	}
+
+	if len(rawObject.Data) != 4 {
+		return time.Time{}, fmt.Errorf(
+			"failed to decode Date - wrong length - %+v: %v", len(rawObject.Data), common.ErrWrongStructure,
+		)
+	}
+
+	year := int(rawObject.Data[0]) + 1900
+	month := time.Month(rawObject.Data[1])
+	day := int(rawObject.Data[2])
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	return date, nil
+}
+
+// DecBACnetDate decodes a Date, keeping its wildcards.
+func DecBACnetDate(rawPayload APDUPayload) (BACnetDate, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return BACnetDate{}, fmt.Errorf(
+			"failed to decode Date - %+v: %v", rawPayload, common.ErrWrongPayload,
+		)
+	}
+
+	if len(rawObject.Data) != 4 {
+		return BACnetDate{}, fmt.Errorf(
+			"failed to decode Date - wrong length - %+v: %v", len(rawObject.Data), common.ErrWrongStructure,
+		)
+	}
+
+	d := BACnetDate{
+		Year:    Unspecified,
+		Month:   rawObject.Data[1],
+		Day:     rawObject.Data[2],
+		Weekday: rawObject.Data[3],
+	}
+	if rawObject.Data[0] != Unspecified {
+		d.Year = int(rawObject.Data[0]) + 1900
+	}
+	if d.Month == 0 || d.Month > MonthEven && d.Month != Unspecified ||
+		d.Day == 0 || d.Day > DayEven && d.Day != Unspecified ||
+		d.Weekday == 0 || d.Weekday > 7 && d.Weekday != Unspecified {
+		return BACnetDate{}, fmt.Errorf(
+			"failed to decode Date - %x: %v", rawObject.Data, common.ErrInvalidData,
+		)
+	}
+
+	return d, nil
+}
+
+// EncDate encodes a Date, failing for the years out of 1900 to 2154.
+func EncDate(value BACnetDate) (*Object, error) {
+	newObj := Object{}
+
+	year := uint8(Unspecified)
+	if value.Year != Unspecified {
+		if value.Year < 1900 || value.Year >= 1900+Unspecified {
+			return nil, fmt.Errorf("failed to encode Date - year %d: %v", value.Year, common.ErrInvalidData)
+		}
+		year = uint8(value.Year - 1900)
+	}
+
+	newObj.TagNumber = TagDate
+	newObj.TagClass = false
+	newObj.Data = []byte{year, value.Month, value.Day, value.Weekday}
+	newObj.Length = uint32(len(newObj.Data))
+
+	return &newObj, nil
+}
+
+// TagNumber 11
+//
+// Deprecated: DecTime turns the wildcards into bogus times, DecBACnetTime
+// keeps them.
+func DecTime(rawPayload APDUPayload) (time.Time, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return time.Time{}, fmt.Errorf(
+			"failed to decode Time - %+v: %v", rawPayload, common.ErrWrongPayload,
+		)
+	}
+
+	if len(rawObject.Data) != 4 {
+		return time.Time{}, fmt.Errorf(
+			"failed to decode Time - wrong length - %+v: %v", len(rawObject.Data),
+			common.ErrWrongStructure,
+		)
+	}
+
+	hour := int(rawObject.Data[0])
+	minute := int(rawObject.Data[1])
+	second := int(rawObject.Data[2])
+	hundredths := int(rawObject.Data[3])
+
+	return time.Date(0, 1, 1, hour, minute, second, hundredths*10_000_000, time.UTC), nil
+}
+
+// DecBACnetTime decodes a Time, keeping its wildcards.
+func DecBACnetTime(rawPayload APDUPayload) (BACnetTime, error) {
+	rawObject, ok := rawPayload.(*Object)
+	if !ok {
+		return BACnetTime{}, fmt.Errorf(
+			"failed to decode Time - %+v: %v", rawPayload, common.ErrWrongPayload,
+		)
+	}
+
+	if len(rawObject.Data) != 4 {
+		return BACnetTime{}, fmt.Errorf(
+			"failed to decode Time - wrong length - %+v: %v", len(rawObject.Data),
+			common.ErrWrongStructure,
+		)
+	}
+
+	t := BACnetTime{
+		Hour:       rawObject.Data[0],
+		Minute:     rawObject.Data[1],
+		Second:     rawObject.Data[2],
+		Hundredths: rawObject.Data[3],
+	}
+	if t.Hour > 23 && t.Hour != Unspecified || t.Minute > 59 && t.Minute != Unspecified ||
+		t.Second > 59 && t.Second != Unspecified || t.Hundredths > 99 && t.Hundredths != Unspecified {
+		return BACnetTime{}, fmt.Errorf(
+			"failed to decode Time - %x: %v", rawObject.Data, common.ErrInvalidData,
+		)
+	}
+
+	return t, nil
+}
+
+// EncTime encodes a Time, failing for the fields out of their range but for
+// the wildcards.
+func EncTime(value BACnetTime) (*Object, error) {
+	if value.Hour > 23 && value.Hour != Unspecified || value.Minute > 59 && value.Minute != Unspecified ||
+		value.Second > 59 && value.Second != Unspecified || value.Hundredths > 99 && value.Hundredths != Unspecified {
+		return nil, fmt.Errorf("failed to encode Time - %s: %v", value, common.ErrInvalidData)
+	}
+
+	newObj := Object{}
+
+	newObj.TagNumber = TagTime
+	newObj.TagClass = false
+	newObj.Data = []byte{value.Hour, value.Minute, value.Second, value.Hundredths}
+	newObj.Length = uint32(len(newObj.Data))
+
+	return &newObj, nil
+}
+
+// DecDateTime decodes the Date and the Time of a BACnetDateTime.
+func DecDateTime(date, t APDUPayload) (BACnetDateTime, error) {
+	d, err := DecBACnetDate(date)
+	if err != nil {
+		return BACnetDateTime{}, fmt.Errorf("failed to decode DateTime: %v", err)
+	}
+	tm, err := DecBACnetTime(t)
+	if err != nil {
+		return BACnetDateTime{}, fmt.Errorf("failed to decode DateTime: %v", err)
+	}
+	return BACnetDateTime{Date: d, Time: tm}, nil
+}
+
+// EncDateTime encodes value as a Date followed by a Time.
+func EncDateTime(value BACnetDateTime) ([]APDUPayload, error) {
+	d, err := EncDate(value.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode DateTime: %v", err)
+	}
+	t, err := EncTime(value.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode DateTime: %v", err)
+	}
+	return []APDUPayload{d, t}, nil
+}
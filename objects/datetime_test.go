@@ -0,0 +1,160 @@
+package objects_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func TestDateTimeEncoding(t *testing.T) {
+	dt := objects.BACnetDateTime{
+		Date: objects.BACnetDate{Year: objects.Unspecified, Month: objects.MonthEven, Day: objects.DayLast, Weekday: objects.Unspecified},
+		Time: objects.BACnetTime{Hour: 6, Minute: 30, Second: 0, Hundredths: objects.Unspecified},
+	}
+	objs, err := objects.EncDateTime(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _ := objs[0].MarshalBinary()
+	tm, _ := objs[1].MarshalBinary()
+	if !bytes.Equal(d, []byte{0xa4, 0xff, 14, 32, 0xff}) || !bytes.Equal(tm, []byte{0xb4, 6, 30, 0, 0xff}) {
+		t.Errorf("unexpected encoding %x %x", d, tm)
+	}
+
+	got, err := objects.DecDateTime(objs[0], objs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, dt, got)
+	AssertEqual(t, false, got.IsSpecified())
+	AssertEqual(t, "*-even-last 06:30:00.*", got.String())
+
+	if _, err := objects.DecBACnetDate(objects.NewObject(objects.TagDate, false, []byte{126, 15, 1, 1})); err == nil {
+		t.Error("expected an error decoding month 15")
+	}
+	if _, err := objects.DecBACnetTime(objects.NewObject(objects.TagTime, false, []byte{24, 0, 0, 0})); err == nil {
+		t.Error("expected an error decoding hour 24")
+	}
+
+	// The years out of 1900 to 2154 don't fit in a byte, 2155 being the wildcard.
+	for _, year := range []int{1899, 2155, 2200} {
+		if _, err := objects.EncDate(objects.BACnetDate{Year: year, Month: 1, Day: 1, Weekday: objects.Unspecified}); err == nil {
+			t.Errorf("expected an error encoding year %d", year)
+		}
+	}
+
+	// DecDate and DecTime still decode to a time.Time.
+	date, err := objects.DecDate(objects.NewObject(objects.TagDate, false, []byte{126, 5, 17, 7}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC), date)
+	tod, err := objects.DecTime(objects.NewObject(objects.TagTime, false, []byte{6, 30, 0, 50}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, time.Date(0, 1, 1, 6, 30, 0, 500_000_000, time.UTC), tod)
+}
+
+func TestDateTimeConversion(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 14, 5, 9, 120_000_000, time.UTC)
+	dt := objects.NewBACnetDateTime(now)
+	AssertEqual(t, objects.BACnetDate{Year: 2026, Month: 10, Day: 17, Weekday: 6}, dt.Date)
+	AssertEqual(t, true, dt.IsSpecified())
+	AssertEqual(t, true, dt.Matches(now))
+
+	back, err := dt.ToTime(time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, now, back)
+
+	dt.Time.Second = objects.Unspecified
+	if _, err := dt.ToTime(time.UTC); err == nil {
+		t.Error("expected an error converting an unspecified second")
+	}
+
+	// The time of the day is a wall clock, not the time elapsed since
+	// midnight, which differ on the days the clocks change.
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	dst := objects.BACnetDateTime{
+		Date: objects.BACnetDate{Year: 2024, Month: 3, Day: 10, Weekday: 7},
+		Time: objects.BACnetTime{Hour: 10},
+	}
+	at, err := dst.ToTime(ny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, time.Date(2024, 3, 10, 10, 0, 0, 0, ny), at)
+	AssertEqual(t, 10, at.Hour())
+
+	for _, d := range []objects.BACnetDate{
+		{Year: 2026, Month: 2, Day: 29, Weekday: objects.Unspecified},
+		{Year: 2026, Month: 2, Day: 31, Weekday: objects.Unspecified},
+		{Year: 2026, Month: 4, Day: 31, Weekday: objects.Unspecified},
+	} {
+		if _, err := d.ToTime(time.UTC); err == nil {
+			t.Errorf("expected an error converting %s", d)
+		}
+	}
+}
+
+func TestEncTimeRange(t *testing.T) {
+	for _, tm := range []objects.BACnetTime{
+		{Hour: 24},
+		{Minute: 60},
+		{Second: 60},
+		{Hundredths: 100},
+	} {
+		if _, err := objects.EncTime(tm); err == nil {
+			t.Errorf("expected an error encoding %s", tm)
+		}
+	}
+	o, err := objects.EncTime(objects.BACnetTime{Hour: 23, Minute: objects.Unspecified, Second: 59, Hundredths: 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []byte{23, 0xff, 59, 99}, o.Data)
+}
+
+func TestDateMatches(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	}
+	anyDate := objects.BACnetDate{Year: objects.Unspecified, Month: objects.Unspecified, Day: objects.Unspecified, Weekday: objects.Unspecified}
+	with := func(f func(*objects.BACnetDate)) objects.BACnetDate {
+		d := anyDate
+		f(&d)
+		return d
+	}
+
+	cases := []struct {
+		date  objects.BACnetDate
+		t     time.Time
+		match bool
+	}{
+		{anyDate, day(2026, 10, 17), true},
+		{with(func(d *objects.BACnetDate) { d.Month = objects.MonthOdd }), day(2026, 11, 1), true},
+		{with(func(d *objects.BACnetDate) { d.Month = objects.MonthOdd }), day(2026, 10, 1), false},
+		{with(func(d *objects.BACnetDate) { d.Month = objects.MonthEven }), day(2026, 10, 1), true},
+		{with(func(d *objects.BACnetDate) { d.Day = objects.DayLast }), day(2028, 2, 29), true},
+		{with(func(d *objects.BACnetDate) { d.Day = objects.DayLast }), day(2026, 2, 28), true},
+		{with(func(d *objects.BACnetDate) { d.Day = objects.DayLast }), day(2026, 10, 30), false},
+		{with(func(d *objects.BACnetDate) { d.Day = objects.DayEven }), day(2026, 10, 30), true},
+		{with(func(d *objects.BACnetDate) { d.Day = objects.DayOdd }), day(2026, 10, 30), false},
+		{with(func(d *objects.BACnetDate) { d.Weekday = 7 }), day(2026, 10, 18), true},
+		{with(func(d *objects.BACnetDate) { d.Weekday = 1 }), day(2026, 10, 18), false},
+		{with(func(d *objects.BACnetDate) { d.Year = 2025 }), day(2026, 10, 18), false},
+	}
+	for _, c := range cases {
+		if got := c.date.Matches(c.t); got != c.match {
+			t.Errorf("%s matching %s: expected %t", c.date, c.t.Format("2006-01-02 Mon"), c.match)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package objects
+
+import "fmt"
+
+// BACnetError is an error class and code answered by a device, such as to a
+// request or reading a property. errors.Is matches it with the sentinel errors
+// below by code only, devices differing in the class of a code.
+type BACnetError struct {
+	ErrorClass uint16
+	ErrorCode  uint16
+}
+
+// NewBACnetError creates a BACnetError.
+func NewBACnetError(errorClass, errorCode uint16) *BACnetError {
+	return &BACnetError{ErrorClass: errorClass, ErrorCode: errorCode}
+}
+
+func (e *BACnetError) Error() string {
+	class, ok := ErrorClassMap[e.ErrorClass]
+	if !ok {
+		class = fmt.Sprintf("error class %d", e.ErrorClass)
+	}
+	code, ok := ErrorCodeMap[e.ErrorCode]
+	if !ok {
+		code = fmt.Sprintf("error code %d", e.ErrorCode)
+	}
+	return fmt.Sprintf("BACnet error %s: %s", class, code)
+}
+
+// Is matches the BACnetErrors of the same error class and code, such as the
+// sentinel errors below.
+func (e *BACnetError) Is(target error) bool {
+	t, ok := target.(*BACnetError)
+	return ok && t.ErrorClass == e.ErrorClass && t.ErrorCode == e.ErrorCode
+}
+
+// Sentinel errors of the most common error codes.
+var (
+	ErrDeviceBusy                        = NewBACnetError(ErrorClassDevice, ErrorCodeDeviceBusy)
+	ErrUnknownObject                     = NewBACnetError(ErrorClassObject, ErrorCodeUnknownObject)
+	ErrUnknownProperty                   = NewBACnetError(ErrorClassProperty, ErrorCodeUnknownProperty)
+	ErrUnsupportedObjectType             = NewBACnetError(ErrorClassObject, ErrorCodeUnsupportedObjectType)
+	ErrDynamicCreationNotSupported       = NewBACnetError(ErrorClassObject, ErrorCodeDynamicCreationNotSupported)
+	ErrObjectDeletionNotPermitted        = NewBACnetError(ErrorClassObject, ErrorCodeObjectDeletionNotPermitted)
+	ErrObjectIdentifierAlreadyExists     = NewBACnetError(ErrorClassObject, ErrorCodeObjectIdentifierAlreadyExists)
+	ErrReadAccessDenied                  = NewBACnetError(ErrorClassProperty, ErrorCodeReadAccessDenied)
+	ErrWriteAccessDenied                 = NewBACnetError(ErrorClassProperty, ErrorCodeWriteAccessDenied)
+	ErrInvalidArrayIndex                 = NewBACnetError(ErrorClassProperty, ErrorCodeInvalidArrayIndex)
+	ErrPropertyIsNotAnArray              = NewBACnetError(ErrorClassProperty, ErrorCodePropertyIsNotAnArray)
+	ErrPropertyIsNotAList                = NewBACnetError(ErrorClassServices, ErrorCodePropertyIsNotAList)
+	ErrInvalidDataType                   = NewBACnetError(ErrorClassProperty, ErrorCodeInvalidDataType)
+	ErrValueOutOfRange                   = NewBACnetError(ErrorClassProperty, ErrorCodeValueOutOfRange)
+	ErrCharacterSetNotSupported          = NewBACnetError(ErrorClassProperty, ErrorCodeCharacterSetNotSupported)
+	ErrDuplicateName                     = NewBACnetError(ErrorClassProperty, ErrorCodeDuplicateName)
+	ErrListElementNotFound               = NewBACnetError(ErrorClassServices, ErrorCodeListElementNotFound)
+	ErrNoSpaceForObject                  = NewBACnetError(ErrorClassResources, ErrorCodeNoSpaceForObject)
+	ErrNoSpaceToAddListElement           = NewBACnetError(ErrorClassResources, ErrorCodeNoSpaceToAddListElement)
+	ErrNoSpaceToWriteProperty            = NewBACnetError(ErrorClassResources, ErrorCodeNoSpaceToWriteProperty)
+	ErrServiceRequestDenied              = NewBACnetError(ErrorClassServices, ErrorCodeServiceRequestDenied)
+	ErrInconsistentParameters            = NewBACnetError(ErrorClassServices, ErrorCodeInconsistentParameters)
+	ErrMissingRequiredParameter          = NewBACnetError(ErrorClassServices, ErrorCodeMissingRequiredParameter)
+	ErrParameterOutOfRange               = NewBACnetError(ErrorClassServices, ErrorCodeParameterOutOfRange)
+	ErrCOVSubscriptionFailed             = NewBACnetError(ErrorClassServices, ErrorCodeCOVSubscriptionFailed)
+	ErrNotCOVProperty                    = NewBACnetError(ErrorClassServices, ErrorCodeNotCOVProperty)
+	ErrUnknownSubscription               = NewBACnetError(ErrorClassServices, ErrorCodeUnknownSubscription)
+	ErrOptionalFunctionalityNotSupported = NewBACnetError(ErrorClassServices, ErrorCodeOptionalFunctionalityNotSupported)
+	ErrPasswordFailure                   = NewBACnetError(ErrorClassSecurity, ErrorCodePasswordFailure)
+	ErrCommunicationDisabled             = NewBACnetError(ErrorClassCommunication, ErrorCodeCommunicationDisabled)
+)
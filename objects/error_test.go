@@ -0,0 +1,19 @@
+package objects_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func TestBACnetErrorIs(t *testing.T) {
+	err := fmt.Errorf("reading: %w", objects.NewBACnetError(objects.ErrorClassProperty, objects.ErrorCodeUnknownProperty))
+	AssertEqual(t, true, errors.Is(err, objects.ErrUnknownProperty))
+
+	err = objects.NewBACnetError(objects.ErrorClassObject, objects.ErrorCodeUnknownProperty)
+	AssertEqual(t, false, errors.Is(err, objects.ErrUnknownProperty))
+	AssertEqual(t, false, errors.Is(err, objects.ErrUnknownObject))
+}
@@ -11,7 +11,7 @@ type LogStatus struct {
 // Log Status tags for TrendLogs
 func DecLogStatus(obj APDUPayload) (*LogStatus, error) {
 	enc_obj, ok := obj.(*Object)
-	if !ok {
+	if !ok || len(enc_obj.Data) < 2 {
 		return nil, common.ErrInvalidObjectType
 	}
 	return &LogStatus{
@@ -1,7 +1,9 @@
 package objects
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 
 	"github.com/Nortech-ai/bacnet/common"
 )
@@ -14,29 +16,62 @@ type APDUPayload interface {
 }
 
 // Object is an object in APDU.
+//
+// Length is the length of Data, except for the opening and closing tags, with
+// the Length 6 and 7 and no Data, and for the application tagged Booleans,
+// whose Length is their value. Data of 5 bytes and more, and TagNumber of 15
+// and more, take the extended forms of the tag of Clause 20.2.1.
 type Object struct {
 	TagNumber uint8
 	TagClass  bool
-	Length    uint8
+	Length    uint32
 	Data      []byte
 	Value     interface{}
 }
 
+// Escapes of the initial octet of a tag, Clause 20.2.1.
+const (
+	extendedTagNumber = 0xF
+	extendedLength    = 5
+	openingTag        = 6
+	closingTag        = 7
+	// Lengths from 254 are preceded by one of these in the extended form.
+	extendedLength16 = 254
+	extendedLength32 = 255
+)
+
 // NewObject creates an Object.
 func NewObject(number uint8, class bool, data []byte) *Object {
 	obj := &Object{
 		TagNumber: number,
 		TagClass:  class,
-		Length:    uint8(len(data)),
+		Length:    uint32(len(data)),
 		Data:      data,
 	}
 
 	return obj
 }
 
-const objLenMin int = 2
+// IsOpeningTag tells whether o is the opening tag of a constructed value.
+func (o *Object) IsOpeningTag() bool {
+	return o.TagClass && o.Data == nil && o.Length == openingTag
+}
+
+// IsClosingTag tells whether o is the closing tag of a constructed value.
+func (o *Object) IsClosingTag() bool {
+	return o.TagClass && o.Data == nil && o.Length == closingTag
+}
+
+// hasData tells whether o is followed by its data, being neither an opening
+// or closing tag nor an application tagged Boolean.
+func (o *Object) hasData() bool {
+	return !o.IsOpeningTag() && !o.IsClosingTag() && (o.TagClass || o.TagNumber != TagBoolean)
+}
+
+const objLenMin int = 1
 
 // UnmarshalBinary sets the values retrieved from byte sequence in a Object frame.
+// b may go on after the object, whose length is then given by MarshalLen.
 func (o *Object) UnmarshalBinary(b []byte) error {
 	if l := len(b); l < objLenMin {
 		return fmt.Errorf(
@@ -46,10 +81,72 @@ func (o *Object) UnmarshalBinary(b []byte) error {
 
 	o.TagNumber = b[0] >> 4
 	o.TagClass = common.IntToBool(int(b[0]) & 0x8 >> 3)
-	o.Length = b[0] & 0x7
+	o.Length = uint32(b[0] & 0x7)
+	o.Data = nil
+	offset := 1
+
+	if o.TagNumber == extendedTagNumber {
+		if len(b) < offset+1 {
+			return fmt.Errorf(
+				"failed to unmarshal - binary %x - extended tag number too short: %v", b, common.ErrTooShortToParse,
+			)
+		}
+		o.TagNumber = b[offset]
+		offset++
+	}
+	if o.Length == openingTag || o.Length == closingTag {
+		if !o.TagClass {
+			return fmt.Errorf(
+				"failed to unmarshal - binary %x - application tag with length %d: %v", b, o.Length, common.ErrWrongStructure,
+			)
+		}
+		return nil
+	}
+	if !o.hasData() {
+		return nil
+	}
+
+	if o.Length == extendedLength {
+		if len(b) < offset+1 {
+			return fmt.Errorf(
+				"failed to unmarshal - binary %x - extended length too short: %v", b, common.ErrTooShortToParse,
+			)
+		}
+		o.Length = uint32(b[offset])
+		offset++
+		var n int
+		switch o.Length {
+		case extendedLength16:
+			n = 2
+		case extendedLength32:
+			n = 4
+		}
+		if len(b) < offset+n {
+			return fmt.Errorf(
+				"failed to unmarshal - binary %x - extended length too short: %v", b, common.ErrTooShortToParse,
+			)
+		}
+		switch n {
+		case 2:
+			o.Length = uint32(binary.BigEndian.Uint16(b[offset:]))
+		case 4:
+			o.Length = binary.BigEndian.Uint32(b[offset:])
+		}
+		offset += n
+	}
+	if uint64(len(b)-offset) < uint64(o.Length) {
+		return fmt.Errorf(
+			"failed to unmarshal - binary %x - data of length %d too short: %v", b, o.Length, common.ErrTooShortToParse,
+		)
+	}
+	o.Data = b[offset : offset+int(o.Length)]
 
-	if !o.TagClass || !(o.Length == 6 || o.Length == 7) {
-		o.Data = b[1:o.Length]
+	// MarshalLen must give back the length of the object in b.
+	if offset != o.headerLen() {
+		o.Data = nil
+		return fmt.Errorf(
+			"failed to unmarshal - binary %x - length %d not in its shortest form: %v", b, o.Length, common.ErrWrongStructure,
+		)
 	}
 	return nil
 }
@@ -71,21 +168,65 @@ func (o *Object) MarshalTo(b []byte) error {
 			"failed to marshal object - binary %x - marshal length too short: %v", b, common.ErrTooShortToMarshalBinary,
 		)
 	}
-	b[0] = o.TagNumber<<4 | uint8(common.BoolToInt(o.TagClass))<<3 | o.Length
-	if o.Length > 0 && o.Length < 5 {
-		copy(b[1:o.Length+1], o.Data)
-	} else if o.Length == 5 {
-		b[1] = o.Length
-		copy(b[2:o.Length+2], o.Data)
+
+	tagNumber, lvt := o.TagNumber, uint8(o.Length)
+	if o.TagNumber >= extendedTagNumber {
+		tagNumber = extendedTagNumber
+	}
+	if o.hasData() && o.Length >= extendedLength {
+		lvt = extendedLength
+	}
+	b[0] = tagNumber<<4 | uint8(common.BoolToInt(o.TagClass))<<3 | lvt
+	offset := 1
+	if tagNumber == extendedTagNumber {
+		b[offset] = o.TagNumber
+		offset++
+	}
+	if !o.hasData() {
+		return nil
 	}
+
+	if lvt == extendedLength {
+		switch {
+		case o.Length < extendedLength16:
+			b[offset] = uint8(o.Length)
+			offset++
+		case o.Length <= math.MaxUint16:
+			b[offset] = extendedLength16
+			binary.BigEndian.PutUint16(b[offset+1:], uint16(o.Length))
+			offset += 3
+		default:
+			b[offset] = extendedLength32
+			binary.BigEndian.PutUint32(b[offset+1:], o.Length)
+			offset += 5
+		}
+	}
+	copy(b[offset:offset+int(o.Length)], o.Data)
 	return nil
 }
 
+// headerLen returns the length of the tag preceding the data of o.
+func (o *Object) headerLen() int {
+	l := 1
+	if o.TagNumber >= extendedTagNumber {
+		l++
+	}
+	if !o.hasData() || o.Length < extendedLength {
+		return l
+	}
+	switch {
+	case o.Length < extendedLength16:
+		return l + 1
+	case o.Length <= math.MaxUint16:
+		return l + 3
+	}
+	return l + 5
+}
+
 // MarshalLen returns the serial length of Object.
 func (o *Object) MarshalLen() int {
-	if (!o.TagClass && (o.TagNumber == TagNull || o.TagNumber == TagBoolean)) ||
-		(o.TagClass && (o.Length == 6 || o.Length == 7)) {
-		return 1
+	if !o.hasData() {
+		return o.headerLen()
 	}
-	return 1 + int(o.Length)
+	return o.headerLen() + int(o.Length)
 }
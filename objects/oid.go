@@ -47,6 +47,6 @@ func EncObjectIdentifier(contextTag bool, tagN uint8, objType uint16, instN uint
 	newObj.TagNumber = tagN
 	newObj.TagClass = contextTag
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 	return &newObj
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
-	"time"
 
 	"github.com/Nortech-ai/bacnet/common"
 )
@@ -99,7 +98,7 @@ func DecUnsignedInteger(rawPayload APDUPayload) (uint32, error) {
 	case 2:
 		return uint32(binary.BigEndian.Uint16(rawObject.Data)), nil
 	case 3:
-		return uint32(uint16(uint32(rawObject.Data[0])<<16) | binary.BigEndian.Uint16(rawObject.Data[1:])), nil
+		return uint32(rawObject.Data[0])<<16 | uint32(binary.BigEndian.Uint16(rawObject.Data[1:])), nil
 	case 4:
 		return binary.BigEndian.Uint32(rawObject.Data), nil
 	}
@@ -129,7 +128,7 @@ func EncUnsignedInteger(value uint) *Object {
 	newObj.TagNumber = TagUnsignedInteger
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -180,7 +179,7 @@ func EncSignedInteger(value int) *Object {
 	newObj.TagNumber = TagSignedInteger
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -200,6 +199,12 @@ func DecReal(rawPayload APDUPayload) (float32, error) {
 		)
 	}
 
+	if len(rawObject.Data) != 4 {
+		return 0, fmt.Errorf(
+			"failed to decode Real - wrong length - %+v: %v", len(rawObject.Data), common.ErrWrongStructure,
+		)
+	}
+
 	return math.Float32frombits(binary.BigEndian.Uint32(rawObject.Data)), nil
 }
 
@@ -212,7 +217,7 @@ func EncReal(value float32) *Object {
 	newObj.TagNumber = TagReal
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -225,6 +230,12 @@ func DecDouble(rawPayload APDUPayload) (float64, error) {
 			"failed to decode Double - %+v: %v", rawPayload, common.ErrWrongPayload,
 		)
 	}
+
+	if len(rawObject.Data) != 8 {
+		return 0, fmt.Errorf(
+			"failed to decode Double - wrong length - %+v: %v", len(rawObject.Data), common.ErrWrongStructure,
+		)
+	}
 	return math.Float64frombits(binary.BigEndian.Uint64(rawObject.Data)), nil
 }
 
@@ -237,7 +248,7 @@ func EncDouble(value float64) *Object {
 	newObj.TagNumber = TagDouble
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -259,12 +270,14 @@ func EncOctetString(value []byte) *Object {
 	newObj.TagNumber = TagOctetString
 	newObj.TagClass = false
 	newObj.Data = value
-	newObj.Length = uint8(len(value))
+	newObj.Length = uint32(len(value))
 
 	return &newObj
 }
 
 // TagNumber 7
+// DecString converts the CharacterString to UTF-8 after its character set, the
+// error wrapping a *CharsetError for the character sets with no codec.
 func DecString(rawPayload APDUPayload) (string, error) {
 	rawObject, ok := rawPayload.(*Object)
 	if !ok {
@@ -278,15 +291,26 @@ func DecString(rawPayload APDUPayload) (string, error) {
 			"DecString wrong tag number - %+v: %v", rawObject.TagNumber, common.ErrWrongStructure,
 		)
 	}
-	return string(rawObject.Data[1:]), nil
+
+	if len(rawObject.Data) < 1 {
+		return "", fmt.Errorf(
+			"failed to decode String - wrong length - %+v: %v", len(rawObject.Data), common.ErrWrongStructure,
+		)
+	}
+	value, err := decodeCharacterString(rawObject.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode String: %w", err)
+	}
+	return value, nil
 }
 
+// EncString encodes value as a UTF-8 CharacterString.
 func EncString(value string) *Object {
 	newObj := Object{}
 	newObj.TagNumber = TagCharacterString
 	newObj.TagClass = false
 	newObj.Data = append([]byte{0}, []byte(value)...)
-	newObj.Length = uint8(len(newObj.Data))
+	newObj.Length = uint32(len(newObj.Data))
 	return &newObj
 }
 
@@ -298,7 +322,18 @@ func DecBitString(rawPayload APDUPayload) ([]bool, error) {
 			"failed to decode BitString - %+v: %v", rawPayload, common.ErrWrongPayload,
 		)
 	}
+
+	if len(rawObject.Data) < 1 {
+		return nil, fmt.Errorf(
+			"failed to decode BitString - wrong length - %+v: %v", len(rawObject.Data), common.ErrWrongStructure,
+		)
+	}
 	unused := int(rawObject.Data[0])
+	if unused > 7 || len(rawObject.Data) == 1 && unused != 0 {
+		return nil, fmt.Errorf(
+			"failed to decode BitString - %d unused bits: %v", unused, common.ErrWrongStructure,
+		)
+	}
 	var bits []bool
 	for i := 1; i < len(rawObject.Data); i++ {
 		for j := 0; j < 8; j++ {
@@ -333,7 +368,7 @@ func EncBitString(value []bool) *Object {
 	newObj.TagNumber = TagBitString
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
@@ -359,7 +394,7 @@ func DecEnumerated(rawPayload APDUPayload) (uint32, error) {
 	case 2:
 		return uint32(binary.BigEndian.Uint16(rawObject.Data)), nil
 	case 3:
-		return uint32(uint16(uint32(rawObject.Data[0])<<16) | binary.BigEndian.Uint16(rawObject.Data[1:])), nil
+		return uint32(rawObject.Data[0])<<16 | uint32(binary.BigEndian.Uint16(rawObject.Data[1:])), nil
 	case 4:
 		return binary.BigEndian.Uint32(rawObject.Data), nil
 	}
@@ -378,52 +413,14 @@ func EncEnumerated(value uint8) *Object {
 	newObj.TagNumber = TagEnumerated
 	newObj.TagClass = false
 	newObj.Data = data
-	newObj.Length = uint8(len(data))
+	newObj.Length = uint32(len(data))
 
 	return &newObj
 }
 
-// TagNumber 10
-func DecDate(rawPayload APDUPayload) (time.Time, error) {
-	rawObject, ok := rawPayload.(*Object)
-	if !ok {
-		return time.Time{}, fmt.Errorf(
-			"failed to decode Date - %+v: %v", rawPayload, common.ErrWrongPayload,
-		)
-	}
-
-	year := int(rawObject.Data[0]) + 1900
-	month := time.Month(rawObject.Data[1])
-	day := int(rawObject.Data[2])
-	weekday := time.Weekday(rawObject.Data[3])
-	if weekday == 7 {
-		weekday = time.Weekday(0)
-	}
-	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-
-	return date, nil
-}
-
-// TagNumber 11
-func DecTime(rawPayload APDUPayload) (time.Time, error) {
-	rawObject, ok := rawPayload.(*Object)
-	if !ok {
-		return time.Time{}, fmt.Errorf(
-			"failed to decode Time - %+v: %v", rawPayload, common.ErrWrongPayload,
-		)
-	}
-
-	if rawObject.Length != 4 {
-		return time.Time{}, fmt.Errorf(
-			"failed to decode Time - wrong length - %+v: %v", rawObject.Length,
-			common.ErrWrongStructure,
-		)
-	}
-
-	hour := int(rawObject.Data[0])
-	minute := int(rawObject.Data[1])
-	second := int(rawObject.Data[2])
-	hundredths := int(rawObject.Data[3])
-
-	return time.Date(0, 1, 1, hour, minute, second, hundredths*10_000_000, time.UTC), nil
+// EncEnumerated32 encodes the enumerations beyond 255, such as the error codes.
+func EncEnumerated32(value uint32) *Object {
+	newObj := EncUnsignedInteger(uint(value))
+	newObj.TagNumber = TagEnumerated
+	return newObj
 }
@@ -0,0 +1,475 @@
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Datatype is the datatype of a property value. The primitive datatypes are
+// numbered after their application tag.
+type Datatype uint8
+
+// Datatypes
+const (
+	DatatypeNull Datatype = iota
+	DatatypeBoolean
+	DatatypeUnsigned
+	DatatypeSigned
+	DatatypeReal
+	DatatypeDouble
+	DatatypeOctetString
+	DatatypeCharacterString
+	DatatypeBitString
+	DatatypeEnumerated
+	DatatypeDate
+	DatatypeTime
+	DatatypeObjectIdentifier
+	// DatatypeAny is any primitive value, decoded after its application tag.
+	DatatypeAny
+	DatatypeEngineeringUnits
+	DatatypeObjectType
+	DatatypeEventState
+	DatatypeReliability
+	DatatypePolarity
+	DatatypeBinaryPV
+	DatatypeDeviceStatus
+	DatatypeSegmentation
+	DatatypeStatusFlags
+	DatatypeDateTime
+	DatatypeTimeStamp
+	DatatypeDateRange
+	DatatypeCalendarEntry
+	DatatypeDailySchedule
+	DatatypeDestination
+	DatatypeRecipient
+	DatatypeObjectPropertyReference
+	DatatypeDeviceObjectPropertyReference
+	DatatypeLogRecord
+	// DatatypePriorityArray is the BACnetPriorityArray, an array of values of
+	// DatatypeAny.
+	DatatypePriorityArray
+)
+
+// PropertyType is the datatype of a property, which can be an array or a list
+// of values of the datatype.
+type PropertyType struct {
+	Datatype Datatype
+	Array    bool
+	List     bool
+}
+
+func scalar(dt Datatype) PropertyType { return PropertyType{Datatype: dt} }
+func array(dt Datatype) PropertyType  { return PropertyType{Datatype: dt, Array: true} }
+func list(dt Datatype) PropertyType   { return PropertyType{Datatype: dt, List: true} }
+
+var schemaMu sync.RWMutex
+
+// RegisterPropertyType sets the datatype of a property of an object type, such
+// as a proprietary one, overriding the standard one if any.
+func RegisterPropertyType(objectType, propertyId uint16, pt PropertyType) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if objectPropertyTypes[objectType] == nil {
+		objectPropertyTypes[objectType] = map[uint16]PropertyType{}
+	}
+	objectPropertyTypes[objectType][propertyId] = pt
+}
+
+// LookupPropertyType returns the datatype of a property of an object type, if
+// known.
+func LookupPropertyType(objectType, propertyId uint16) (PropertyType, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	if pt, ok := objectPropertyTypes[objectType][propertyId]; ok {
+		return pt, true
+	}
+	pt, ok := commonPropertyTypes[propertyId]
+	return pt, ok
+}
+
+// DecodeProperty decodes the value of a property read at arrayIndex, up to the
+// end of the constructed value r is in. Arrays and lists come back as a slice
+// of the Go type of their datatype, such as []ObjectIdentifier, and the size of
+// an array read at index 0 as a uint32. The properties missing from the schema
+// fail with common.ErrNotImplemented.
+func DecodeProperty(objectType, propertyId uint16, arrayIndex uint32, r *TagReader) (interface{}, error) {
+	pt, ok := LookupPropertyType(objectType, propertyId)
+	if !ok {
+		return nil, fmt.Errorf(
+			"decoding property %d of object type %d: %w", propertyId, objectType, common.ErrNotImplemented,
+		)
+	}
+	return pt.Decode(arrayIndex, r)
+}
+
+// Decode decodes a value of pt read at arrayIndex, up to the end of the
+// constructed value r is in.
+func (pt PropertyType) Decode(arrayIndex uint32, r *TagReader) (interface{}, error) {
+	var v interface{}
+	var err error
+	switch {
+	case pt.Datatype == DatatypePriorityArray && arrayIndex == ArrayAll:
+		var a BACnetPriorityArray
+		err = a.Decode(r)
+		v = a
+	case pt.Array && arrayIndex == 0:
+		v, err = decodeDatatype(DatatypeUnsigned, r)
+	case pt.Datatype == DatatypePriorityArray:
+		v, err = decodeDatatype(DatatypeAny, r)
+	case pt.List || pt.Array && arrayIndex == ArrayAll:
+		values := reflect.MakeSlice(reflect.SliceOf(datatypeGoType(pt.Datatype)), 0, 0)
+		for !r.Done() {
+			v, err := decodeDatatype(pt.Datatype, r)
+			if err != nil {
+				return nil, err
+			}
+			values = reflect.Append(values, reflect.ValueOf(v))
+		}
+		v = values.Interface()
+	default:
+		v, err = decodeDatatype(pt.Datatype, r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !r.Done() {
+		return nil, fmt.Errorf("decoding value at offset %d: %v", r.Offset(), common.ErrWrongStructure)
+	}
+	return v, nil
+}
+
+// datatypeGoType returns the Go type a value of dt decodes to.
+func datatypeGoType(dt Datatype) reflect.Type {
+	var v interface{}
+	switch dt {
+	case DatatypeBoolean:
+		v = false
+	case DatatypeUnsigned, DatatypeEnumerated:
+		v = uint32(0)
+	case DatatypeSigned:
+		v = 0
+	case DatatypeReal:
+		v = float32(0)
+	case DatatypeDouble:
+		v = float64(0)
+	case DatatypeOctetString:
+		v = []byte{}
+	case DatatypeCharacterString:
+		v = ""
+	case DatatypeBitString:
+		v = []bool{}
+	case DatatypeDate:
+		v = BACnetDate{}
+	case DatatypeTime:
+		v = BACnetTime{}
+	case DatatypeObjectIdentifier:
+		v = ObjectIdentifier{}
+	case DatatypeEngineeringUnits:
+		v = EngineeringUnits(0)
+	case DatatypeObjectType:
+		v = ObjectType(0)
+	case DatatypeEventState:
+		v = EventState(0)
+	case DatatypeReliability:
+		v = Reliability(0)
+	case DatatypePolarity:
+		v = Polarity(0)
+	case DatatypeBinaryPV:
+		v = BinaryPV(0)
+	case DatatypeDeviceStatus:
+		v = DeviceStatus(0)
+	case DatatypeSegmentation:
+		v = Segmentation(0)
+	case DatatypeStatusFlags:
+		v = StatusFlags{}
+	case DatatypeDateTime:
+		v = BACnetDateTime{}
+	case DatatypeTimeStamp:
+		v = BACnetTimeStamp{}
+	case DatatypeDateRange:
+		v = BACnetDateRange{}
+	case DatatypeCalendarEntry:
+		v = BACnetCalendarEntry{}
+	case DatatypeDailySchedule:
+		v = BACnetDailySchedule{}
+	case DatatypeDestination:
+		v = BACnetDestination{}
+	case DatatypeRecipient:
+		v = BACnetRecipient{}
+	case DatatypeObjectPropertyReference:
+		v = BACnetObjectPropertyReference{}
+	case DatatypeDeviceObjectPropertyReference:
+		v = BACnetDeviceObjectPropertyReference{}
+	case DatatypeLogRecord:
+		v = BACnetLogRecord{}
+	default:
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	return reflect.TypeOf(v)
+}
+
+// decodeDatatype decodes the next value of dt.
+func decodeDatatype(dt Datatype, r *TagReader) (interface{}, error) {
+	var c Constructed
+	switch dt {
+	case DatatypeDateTime:
+		return readDateTime(r)
+	case DatatypeTimeStamp:
+		c = &BACnetTimeStamp{}
+	case DatatypeDateRange:
+		c = &BACnetDateRange{}
+	case DatatypeCalendarEntry:
+		c = &BACnetCalendarEntry{}
+	case DatatypeDailySchedule:
+		c = &BACnetDailySchedule{}
+	case DatatypeDestination:
+		c = &BACnetDestination{}
+	case DatatypeRecipient:
+		c = &BACnetRecipient{}
+	case DatatypeObjectPropertyReference:
+		c = &BACnetObjectPropertyReference{}
+	case DatatypeDeviceObjectPropertyReference:
+		c = &BACnetDeviceObjectPropertyReference{}
+	case DatatypeLogRecord:
+		c = &BACnetLogRecord{}
+	}
+	if c != nil {
+		if err := c.Decode(r); err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(c).Elem().Interface(), nil
+	}
+
+	o, err := r.ReadPrimitive()
+	if err != nil {
+		return nil, err
+	}
+	if o.TagClass {
+		return nil, fmt.Errorf("decoding %s - context tag %d: %v", dt, o.TagNumber, common.ErrWrongTagNumber)
+	}
+	if dt == DatatypeAny {
+		dt = Datatype(o.TagNumber)
+	}
+	tag, ok := datatypeTag(dt)
+	if !ok || o.TagNumber != tag {
+		return nil, fmt.Errorf("decoding %s - tag %d: %v", dt, o.TagNumber, common.ErrWrongTagNumber)
+	}
+	switch dt {
+	case DatatypeNull:
+		return nil, DecNull(o)
+	case DatatypeBoolean:
+		return DecBoolean(o)
+	case DatatypeUnsigned:
+		return DecUnsignedInteger(o)
+	case DatatypeSigned:
+		return DecSignedInteger(o)
+	case DatatypeReal:
+		return DecReal(o)
+	case DatatypeDouble:
+		return DecDouble(o)
+	case DatatypeOctetString:
+		return DecOctetString(o)
+	case DatatypeCharacterString:
+		return DecString(o)
+	case DatatypeBitString:
+		return DecBitString(o)
+	case DatatypeDate:
+		return DecBACnetDate(o)
+	case DatatypeTime:
+		return DecBACnetTime(o)
+	case DatatypeObjectIdentifier:
+		return DecObjectIdentifier(o)
+	case DatatypeStatusFlags:
+		return DecStatusFlags(o)
+	}
+
+	v, err := DecEnumerated(o)
+	if err != nil {
+		return nil, err
+	}
+	switch dt {
+	case DatatypeEngineeringUnits:
+		return EngineeringUnits(v), nil
+	case DatatypeObjectType:
+		return ObjectType(v), nil
+	case DatatypeEventState:
+		return EventState(v), nil
+	case DatatypeReliability:
+		return Reliability(v), nil
+	case DatatypePolarity:
+		return Polarity(v), nil
+	case DatatypeBinaryPV:
+		return BinaryPV(v), nil
+	case DatatypeDeviceStatus:
+		return DeviceStatus(v), nil
+	case DatatypeSegmentation:
+		return Segmentation(v), nil
+	}
+	return v, nil
+}
+
+// datatypeTag returns the application tag of the primitive datatype dt.
+func datatypeTag(dt Datatype) (uint8, bool) {
+	switch {
+	case dt <= DatatypeObjectIdentifier:
+		return uint8(dt), true
+	case dt == DatatypeStatusFlags:
+		return TagBitString, true
+	case dt >= DatatypeEngineeringUnits && dt <= DatatypeSegmentation:
+		return TagEnumerated, true
+	}
+	return 0, false
+}
+
+func (dt Datatype) String() string {
+	if dt <= DatatypeObjectIdentifier {
+		return TagMap[uint8(dt)]
+	}
+	if s, ok := datatypeMap[dt]; ok {
+		return s
+	}
+	return fmt.Sprintf("Datatype(%d)", uint8(dt))
+}
+
+var datatypeMap = map[Datatype]string{
+	DatatypeAny:                           "Any",
+	DatatypeEngineeringUnits:              "BACnetEngineeringUnits",
+	DatatypeObjectType:                    "BACnetObjectType",
+	DatatypeEventState:                    "BACnetEventState",
+	DatatypeReliability:                   "BACnetReliability",
+	DatatypePolarity:                      "BACnetPolarity",
+	DatatypeBinaryPV:                      "BACnetBinaryPV",
+	DatatypeDeviceStatus:                  "BACnetDeviceStatus",
+	DatatypeSegmentation:                  "BACnetSegmentation",
+	DatatypeStatusFlags:                   "BACnetStatusFlags",
+	DatatypeDateTime:                      "BACnetDateTime",
+	DatatypeTimeStamp:                     "BACnetTimeStamp",
+	DatatypeDateRange:                     "BACnetDateRange",
+	DatatypeCalendarEntry:                 "BACnetCalendarEntry",
+	DatatypeDailySchedule:                 "BACnetDailySchedule",
+	DatatypeDestination:                   "BACnetDestination",
+	DatatypeRecipient:                     "BACnetRecipient",
+	DatatypeObjectPropertyReference:       "BACnetObjectPropertyReference",
+	DatatypeDeviceObjectPropertyReference: "BACnetDeviceObjectPropertyReference",
+	DatatypeLogRecord:                     "BACnetLogRecord",
+	DatatypePriorityArray:                 "BACnetPriorityArray",
+}
+
+// StatusFlags is the BACnetStatusFlags bit string of an object.
+type StatusFlags struct {
+	InAlarm      bool
+	Fault        bool
+	Overridden   bool
+	OutOfService bool
+}
+
+// DecStatusFlags decodes a BACnetStatusFlags bit string.
+func DecStatusFlags(rawPayload APDUPayload) (StatusFlags, error) {
+	bits, err := DecBitString(rawPayload)
+	if err != nil {
+		return StatusFlags{}, err
+	}
+	if len(bits) < 4 {
+		return StatusFlags{}, fmt.Errorf("decoding StatusFlags - %d bits: %v", len(bits), common.ErrWrongStructure)
+	}
+	return StatusFlags{InAlarm: bits[0], Fault: bits[1], Overridden: bits[2], OutOfService: bits[3]}, nil
+}
+
+// EncStatusFlags encodes a BACnetStatusFlags bit string.
+func EncStatusFlags(value StatusFlags) *Object {
+	return EncBitString([]bool{value.InAlarm, value.Fault, value.Overridden, value.OutOfService})
+}
+
+// EngineeringUnits is a BACnetEngineeringUnits, one of the Unit constants.
+type EngineeringUnits uint16
+
+func (u EngineeringUnits) String() string {
+	if s, ok := UnitMap[uint16(u)]; ok {
+		return s
+	}
+	return fmt.Sprintf("EngineeringUnits(%d)", uint16(u))
+}
+
+// ObjectType is a BACnetObjectType, one of the ObjectType constants.
+type ObjectType uint16
+
+// EventState is a BACnetEventState.
+type EventState uint32
+
+// Event states
+const (
+	EventStateNormal EventState = iota
+	EventStateFault
+	EventStateOffnormal
+	EventStateHighLimit
+	EventStateLowLimit
+	EventStateLifeSafetyAlarm
+)
+
+// Reliability is a BACnetReliability.
+type Reliability uint32
+
+// Reliabilities
+const (
+	ReliabilityNoFaultDetected Reliability = iota
+	ReliabilityNoSensor
+	ReliabilityOverRange
+	ReliabilityUnderRange
+	ReliabilityOpenLoop
+	ReliabilityShortedLoop
+	ReliabilityNoOutput
+	ReliabilityUnreliableOther
+	ReliabilityProcessError
+	ReliabilityMultiStateFault
+	ReliabilityConfigurationError
+	_
+	ReliabilityCommunicationFailure
+	ReliabilityMemberFault
+	ReliabilityMonitoredObjectFault
+	ReliabilityTripped
+)
+
+// Polarity is a BACnetPolarity.
+type Polarity uint32
+
+// Polarities
+const (
+	PolarityNormal Polarity = iota
+	PolarityReverse
+)
+
+// BinaryPV is a BACnetBinaryPV, the value of the binary objects.
+type BinaryPV uint32
+
+// Binary values
+const (
+	BinaryInactive BinaryPV = iota
+	BinaryActive
+)
+
+// DeviceStatus is a BACnetDeviceStatus.
+type DeviceStatus uint32
+
+// Device statuses
+const (
+	DeviceStatusOperational DeviceStatus = iota
+	DeviceStatusOperationalReadOnly
+	DeviceStatusDownloadRequired
+	DeviceStatusDownloadInProgress
+	DeviceStatusNonOperational
+	DeviceStatusBackupInProgress
+)
+
+// Segmentation is a BACnetSegmentation.
+type Segmentation uint32
+
+// Segmentation supports
+const (
+	SegmentationBoth Segmentation = iota
+	SegmentationTransmit
+	SegmentationReceive
+	SegmentationNone
+)
@@ -0,0 +1,117 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func decodeProperty(t *testing.T, objectType, propertyId uint16, arrayIndex uint32, objs ...*objects.Object) (interface{}, error) {
+	t.Helper()
+	w := objects.NewTagWriter()
+	for _, o := range objs {
+		if err := w.Write(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := w.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects.DecodeProperty(objectType, propertyId, arrayIndex, objects.NewTagReader(b))
+}
+
+func TestDecodeProperty(t *testing.T) {
+	av1 := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 1}
+	oid := objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, av1.ObjectType, av1.InstanceNumber)
+
+	v, err := decodeProperty(t, objects.ObjectTypeAnalogInput, objects.PropertyIdUnits, objects.ArrayAll,
+		objects.EncEnumerated(uint8(objects.UnitPercent)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, objects.EngineeringUnits(objects.UnitPercent), v)
+	AssertEqual(t, "Percent", v.(objects.EngineeringUnits).String())
+
+	v, err = decodeProperty(t, objects.ObjectTypeAnalogInput, objects.PropertyIdStatusFlags, objects.ArrayAll,
+		objects.EncStatusFlags(objects.StatusFlags{Fault: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, objects.StatusFlags{Fault: true}, v)
+
+	v, err = decodeProperty(t, objects.ObjectTypeDevice, objects.PropertyIdObjectList, objects.ArrayAll, oid, oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []objects.ObjectIdentifier{av1, av1}, v)
+
+	v, err = decodeProperty(t, objects.ObjectTypeDevice, objects.PropertyIdObjectList, 0, objects.EncUnsignedInteger(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, uint32(2), v)
+
+	v, err = decodeProperty(t, objects.ObjectTypeBinaryValue, objects.PropertyIdPresentValue, objects.ArrayAll,
+		objects.EncEnumerated(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, objects.BinaryActive, v)
+
+	priorities := make([]*objects.Object, 16)
+	for i := range priorities {
+		priorities[i] = objects.EncNull()
+	}
+	priorities[15] = objects.EncReal(20)
+	v, err = decodeProperty(t, objects.ObjectTypeAnalogValue, objects.PropertyIdPriorityArray, objects.ArrayAll, priorities...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := v.(objects.BACnetPriorityArray)
+	if p, _ := a.Active(); p != 16 {
+		t.Errorf("expected priority 16 active, got %d", p)
+	}
+
+	// The object types override the common datatypes.
+	v, err = decodeProperty(t, objects.ObjectTypeAccumulator, objects.PropertyIdHighLimit, objects.ArrayAll,
+		objects.EncUnsignedInteger(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, uint32(1000), v)
+	pt, ok := objects.LookupPropertyType(objects.ObjectTypeTrendLogMultiple, objects.PropertyIdLogDeviceObjectProperty)
+	AssertEqual(t, true, ok && pt.Array)
+
+	if _, err := decodeProperty(t, objects.ObjectTypeAnalogValue, objects.PropertyIdPresentValue, objects.ArrayAll,
+		objects.EncString("21.5")); err == nil {
+		t.Error("expected an error decoding a string as a Real")
+	}
+	if _, err := decodeProperty(t, objects.ObjectTypeAnalogValue, objects.PropertyIdObjectName, objects.ArrayAll,
+		objects.EncString("a"), objects.EncString("b")); err == nil {
+		t.Error("expected an error decoding two values of a single one")
+	}
+	if _, err := decodeProperty(t, objects.ObjectTypeAnalogValue, 600, objects.ArrayAll, objects.EncReal(1)); err == nil {
+		t.Error("expected an error decoding a property missing from the schema")
+	}
+}
+
+func TestRegisterPropertyType(t *testing.T) {
+	const vendorObjectType, vendorProperty = 600, 1000
+	if _, ok := objects.LookupPropertyType(vendorObjectType, vendorProperty); ok {
+		t.Fatal("unexpected datatype of a proprietary property")
+	}
+	objects.RegisterPropertyType(vendorObjectType, vendorProperty, objects.PropertyType{Datatype: objects.DatatypeReal, List: true})
+
+	v, err := decodeProperty(t, vendorObjectType, vendorProperty, objects.ArrayAll, objects.EncReal(1), objects.EncReal(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []float32{1, 2}, v)
+
+	// The common properties stay known to the proprietary object type.
+	pt, ok := objects.LookupPropertyType(vendorObjectType, objects.PropertyIdObjectName)
+	AssertEqual(t, true, ok)
+	AssertEqual(t, "CharacterString", pt.Datatype.String())
+}
@@ -0,0 +1,253 @@
+package objects
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// TagReader reads the tags of BACnet ASN.1 encoded data one after the other,
+// checking that the closing tags match the opening ones.
+type TagReader struct {
+	b      []byte
+	offset int
+	// opened holds the tag numbers of the constructed values entered.
+	opened []uint8
+}
+
+// NewTagReader creates a TagReader of b.
+func NewTagReader(b []byte) *TagReader {
+	return &TagReader{b: b}
+}
+
+// Offset returns the offset in the data of the next tag.
+func (r *TagReader) Offset() int {
+	return r.offset
+}
+
+// Remaining returns the length of the data left to read.
+func (r *TagReader) Remaining() int {
+	return len(r.b) - r.offset
+}
+
+// Depth returns the number of constructed values entered.
+func (r *TagReader) Depth() int {
+	return len(r.opened)
+}
+
+// Context returns the tag number of the innermost constructed value entered,
+// if any.
+func (r *TagReader) Context() (uint8, bool) {
+	if len(r.opened) == 0 {
+		return 0, false
+	}
+	return r.opened[len(r.opened)-1], true
+}
+
+// Done tells whether the constructed value entered last has been read to its
+// closing tag, or the whole data outside of constructed values.
+func (r *TagReader) Done() bool {
+	if r.Remaining() == 0 {
+		return true
+	}
+	o, err := r.Peek()
+	return err == nil && len(r.opened) > 0 && o.IsClosingTag()
+}
+
+// Peek returns the next tag without reading it.
+func (r *TagReader) Peek() (*Object, error) {
+	if r.Remaining() == 0 {
+		return nil, fmt.Errorf("peeking tag at offset %d: %w", r.offset, common.ErrTooShortToParse)
+	}
+	o := &Object{}
+	if err := o.UnmarshalBinary(r.b[r.offset:]); err != nil {
+		return nil, fmt.Errorf("peeking tag at offset %d: %w", r.offset, err)
+	}
+	return o, nil
+}
+
+// PeekContext tells whether the next tag is the context tag tagNumber, either
+// primitive or opening a constructed value.
+func (r *TagReader) PeekContext(tagNumber uint8) bool {
+	if r.Done() {
+		return false
+	}
+	o, err := r.Peek()
+	return err == nil && o.TagClass && o.TagNumber == tagNumber && !o.IsClosingTag()
+}
+
+// Read reads the next tag, whatever it is, entering the constructed values on
+// their opening tag and leaving them on their closing tag.
+func (r *TagReader) Read() (*Object, error) {
+	o, err := r.Peek()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case o.IsOpeningTag():
+		r.opened = append(r.opened, o.TagNumber)
+	case o.IsClosingTag():
+		if n, ok := r.Context(); !ok || n != o.TagNumber {
+			return nil, fmt.Errorf("closing tag %d at offset %d not opened: %w", o.TagNumber, r.offset, common.ErrWrongStructure)
+		}
+		r.opened = r.opened[:len(r.opened)-1]
+	}
+	r.offset += o.MarshalLen()
+	return o, nil
+}
+
+// ReadPrimitive reads the next tag, which must be a primitive value.
+func (r *TagReader) ReadPrimitive() (*Object, error) {
+	o, err := r.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if o.IsOpeningTag() || o.IsClosingTag() {
+		return nil, fmt.Errorf("expecting primitive value at offset %d: %w", r.offset, common.ErrWrongStructure)
+	}
+	return r.Read()
+}
+
+// ReadContext reads the next tag, which must be the primitive context tag
+// tagNumber.
+func (r *TagReader) ReadContext(tagNumber uint8) (*Object, error) {
+	o, err := r.Peek()
+	if err != nil {
+		return nil, err
+	}
+	if o.IsClosingTag() {
+		return nil, fmt.Errorf(
+			"expecting context tag %d at offset %d, got the end of the constructed value: %w", tagNumber, r.offset, common.ErrMissingParameter,
+		)
+	}
+	if !o.TagClass || o.TagNumber != tagNumber || o.IsOpeningTag() {
+		return nil, fmt.Errorf(
+			"expecting context tag %d at offset %d, got tag %d: %w", tagNumber, r.offset, o.TagNumber, common.ErrWrongTagNumber,
+		)
+	}
+	return r.Read()
+}
+
+// Enter reads the opening tag tagNumber of a constructed value.
+func (r *TagReader) Enter(tagNumber uint8) error {
+	o, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	if o.IsClosingTag() {
+		return fmt.Errorf("expecting opening tag %d at offset %d: %w", tagNumber, r.offset, common.ErrMissingParameter)
+	}
+	if !o.IsOpeningTag() || o.TagNumber != tagNumber {
+		return fmt.Errorf("expecting opening tag %d at offset %d: %w", tagNumber, r.offset, common.ErrWrongStructure)
+	}
+	_, err = r.Read()
+	return err
+}
+
+// Leave reads the closing tag tagNumber of the constructed value entered last.
+func (r *TagReader) Leave(tagNumber uint8) error {
+	o, err := r.Peek()
+	if err != nil {
+		return err
+	}
+	if !o.IsClosingTag() || o.TagNumber != tagNumber {
+		return fmt.Errorf("expecting closing tag %d at offset %d: %w", tagNumber, r.offset, common.ErrWrongStructure)
+	}
+	_, err = r.Read()
+	return err
+}
+
+// Skip reads the next value, a primitive one or a whole constructed one.
+func (r *TagReader) Skip() error {
+	o, err := r.Read()
+	if err != nil {
+		return err
+	}
+	if o.IsClosingTag() {
+		return fmt.Errorf("skipping closing tag %d: %w", o.TagNumber, common.ErrWrongStructure)
+	}
+	if !o.IsOpeningTag() {
+		return nil
+	}
+	for depth := len(r.opened); len(r.opened) >= depth; {
+		if _, err := r.Read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// End checks that the data has been read to its end, every constructed value
+// entered having been left.
+func (r *TagReader) End() error {
+	if n, ok := r.Context(); ok {
+		return fmt.Errorf("constructed value %d not closed: %w", n, common.ErrWrongStructure)
+	}
+	if r.Remaining() > 0 {
+		return fmt.Errorf("%d bytes left after offset %d: %w", r.Remaining(), r.offset, common.ErrWrongStructure)
+	}
+	return nil
+}
+
+// TagWriter encodes tags one after the other, checking that the closing tags
+// match the opening ones.
+type TagWriter struct {
+	b      []byte
+	objs   []APDUPayload
+	opened []uint8
+}
+
+// NewTagWriter creates an empty TagWriter.
+func NewTagWriter() *TagWriter {
+	return &TagWriter{}
+}
+
+// Write appends o, opening or closing a constructed value for the opening and
+// closing tags.
+func (w *TagWriter) Write(o *Object) error {
+	switch {
+	case o.IsOpeningTag():
+		w.opened = append(w.opened, o.TagNumber)
+	case o.IsClosingTag():
+		if len(w.opened) == 0 || w.opened[len(w.opened)-1] != o.TagNumber {
+			return fmt.Errorf("closing tag %d not opened: %v", o.TagNumber, common.ErrWrongStructure)
+		}
+		w.opened = w.opened[:len(w.opened)-1]
+	}
+	offset := len(w.b)
+	w.b = append(w.b, make([]byte, o.MarshalLen())...)
+	w.objs = append(w.objs, o)
+	return o.MarshalTo(w.b[offset:])
+}
+
+// Open appends the opening tag tagNumber of a constructed value.
+func (w *TagWriter) Open(tagNumber uint8) {
+	w.Write(EncOpeningTag(tagNumber))
+}
+
+// Close appends the closing tag tagNumber of the constructed value opened last.
+func (w *TagWriter) Close(tagNumber uint8) error {
+	return w.Write(EncClosingTag(tagNumber))
+}
+
+// Depth returns the number of constructed values left open.
+func (w *TagWriter) Depth() int {
+	return len(w.opened)
+}
+
+// Bytes returns the encoded tags, every constructed value having to be closed.
+func (w *TagWriter) Bytes() ([]byte, error) {
+	if len(w.opened) > 0 {
+		return nil, fmt.Errorf("constructed value %d not closed: %v", w.opened[len(w.opened)-1], common.ErrWrongStructure)
+	}
+	return w.b, nil
+}
+
+// Objects returns the tags written, every constructed value having to be
+// closed, such as for the Objects of an APDU.
+func (w *TagWriter) Objects() ([]APDUPayload, error) {
+	if len(w.opened) > 0 {
+		return nil, fmt.Errorf("constructed value %d not closed: %v", w.opened[len(w.opened)-1], common.ErrWrongStructure)
+	}
+	return w.objs, nil
+}
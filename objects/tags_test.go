@@ -0,0 +1,136 @@
+package objects_test
+
+import (
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+// writeTags encodes a ReadProperty-ACK like sequence with a nested value.
+func writeTags(t *testing.T) []byte {
+	w := objects.NewTagWriter()
+	for _, o := range []*objects.Object{
+		objects.EncObjectIdentifier(true, 0, objects.ObjectTypeAnalogValue, 1),
+		objects.ContextTag(1, objects.EncUnsignedInteger(uint(objects.PropertyIdPresentValue))),
+	} {
+		if err := w.Write(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Open(3)
+	w.Write(objects.EncReal(21.5))
+	w.Open(0)
+	w.Write(objects.EncUnsignedInteger(7))
+	if err := w.Close(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(3); err != nil {
+		t.Fatal(err)
+	}
+	w.Write(objects.ContextTag(4, objects.EncUnsignedInteger(16)))
+	b, err := w.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestTagReader(t *testing.T) {
+	r := objects.NewTagReader(writeTags(t))
+
+	o, err := r.ReadContext(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oid, err := objects.DecObjectIdentifier(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, uint32(1), oid.InstanceNumber)
+	if _, err := r.ReadContext(1); err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, false, r.PeekContext(2))
+	AssertEqual(t, true, r.PeekContext(3))
+
+	if err := r.Enter(3); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := r.Context()
+	AssertEqual(t, uint8(3), n)
+	o, err = r.ReadPrimitive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := objects.DecReal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, float32(21.5), v)
+	if _, err := r.ReadPrimitive(); err == nil {
+		t.Error("expected an error reading an opening tag as primitive")
+	}
+	if err := r.Skip(); err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, true, r.Done())
+	if err := r.Leave(3); err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, 0, r.Depth())
+
+	if _, err := r.ReadContext(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.End(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err == nil {
+		t.Error("expected an error reading past the end")
+	}
+}
+
+func TestTagReaderNesting(t *testing.T) {
+	open, close := objects.EncOpeningTag(3), objects.EncClosingTag(4)
+	b, _ := open.MarshalBinary()
+	c, _ := close.MarshalBinary()
+
+	r := objects.NewTagReader(append(b, c...))
+	if err := r.Enter(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.End(); err == nil {
+		t.Error("expected an error ending in a constructed value")
+	}
+	if err := r.Leave(3); err == nil {
+		t.Error("expected an error leaving on another closing tag")
+	}
+	if _, err := r.Read(); err == nil {
+		t.Error("expected an error reading a mismatched closing tag")
+	}
+
+	r = objects.NewTagReader(b)
+	if err := r.Skip(); err == nil {
+		t.Error("expected an error skipping a truncated constructed value")
+	}
+}
+
+func TestTagWriterNesting(t *testing.T) {
+	w := objects.NewTagWriter()
+	w.Open(1)
+	if err := w.Close(2); err == nil {
+		t.Error("expected an error closing another tag")
+	}
+	if _, err := w.Bytes(); err == nil {
+		t.Error("expected an error with a constructed value left open")
+	}
+	if err := w.Close(1); err != nil {
+		t.Fatal(err)
+	}
+	b, err := w.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, 2, len(b))
+}
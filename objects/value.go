@@ -0,0 +1,107 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// Enumerated is a BACnet enumerated value of no more specific type, encoded
+// by EncValue with the Enumerated application tag.
+type Enumerated uint32
+
+// EncValue encodes a Go value as the matching application tagged Object.
+// Values already encoded as an application tagged *Object are returned as is.
+// Integers beyond 32 bits fail with ErrTooBigValue.
+func EncValue(value interface{}) (*Object, error) {
+	switch v := value.(type) {
+	case nil:
+		return EncNull(), nil
+	case bool:
+		return EncBoolean(v), nil
+	case uint:
+		return encUnsigned(uint64(v))
+	case uint8:
+		return EncUnsignedInteger(uint(v)), nil
+	case uint16:
+		return EncUnsignedInteger(uint(v)), nil
+	case uint32:
+		return EncUnsignedInteger(uint(v)), nil
+	case uint64:
+		return encUnsigned(v)
+	case int:
+		return encSigned(int64(v))
+	case int8:
+		return EncSignedInteger(int(v)), nil
+	case int16:
+		return EncSignedInteger(int(v)), nil
+	case int32:
+		return EncSignedInteger(int(v)), nil
+	case int64:
+		return encSigned(v)
+	case float32:
+		return EncReal(v), nil
+	case float64:
+		return EncDouble(v), nil
+	case string:
+		return EncString(v), nil
+	case []byte:
+		return EncOctetString(v), nil
+	case []bool:
+		return EncBitString(v), nil
+	case BACnetDate:
+		return EncDate(v)
+	case BACnetTime:
+		return EncTime(v)
+	case ObjectIdentifier:
+		return EncObjectIdentifier(false, TagBACnetObjectIdentifier, v.ObjectType, v.InstanceNumber), nil
+	case StatusFlags:
+		return EncStatusFlags(v), nil
+	case Enumerated:
+		return EncEnumerated32(uint32(v)), nil
+	case EngineeringUnits:
+		return EncEnumerated32(uint32(v)), nil
+	case ObjectType:
+		return EncEnumerated32(uint32(v)), nil
+	case EventState:
+		return EncEnumerated32(uint32(v)), nil
+	case Reliability:
+		return EncEnumerated32(uint32(v)), nil
+	case Polarity:
+		return EncEnumerated32(uint32(v)), nil
+	case BinaryPV:
+		return EncEnumerated32(uint32(v)), nil
+	case DeviceStatus:
+		return EncEnumerated32(uint32(v)), nil
+	case Segmentation:
+		return EncEnumerated32(uint32(v)), nil
+	case *Object:
+		if v.TagClass {
+			return nil, fmt.Errorf(
+				"failed to encode value - context tag %d: %w", v.TagNumber, common.ErrWrongStructure,
+			)
+		}
+		return v, nil
+	}
+
+	return nil, fmt.Errorf(
+		"failed to encode value %v of type %T: %w", value, value, common.ErrNotImplemented,
+	)
+}
+
+// encUnsigned encodes an Unsigned, which the decoders read up to 32 bits.
+func encUnsigned(v uint64) (*Object, error) {
+	if v > math.MaxUint32 {
+		return nil, fmt.Errorf("failed to encode Unsigned %d: %w", v, common.ErrTooBigValue)
+	}
+	return EncUnsignedInteger(uint(v)), nil
+}
+
+// encSigned encodes a Signed, which the decoders read up to 32 bits.
+func encSigned(v int64) (*Object, error) {
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return nil, fmt.Errorf("failed to encode Signed %d: %w", v, common.ErrTooBigValue)
+	}
+	return EncSignedInteger(int(v)), nil
+}
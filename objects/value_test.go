@@ -0,0 +1,55 @@
+package objects_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func TestEncValue(t *testing.T) {
+	date := objects.BACnetDate{Year: 2024, Month: 3, Day: 10, Weekday: 7}
+	tm := objects.BACnetTime{Hour: 10, Minute: 30, Second: 0, Hundredths: 0}
+	for _, tc := range []struct {
+		value interface{}
+		want  []byte
+	}{
+		{date, []byte{0xa4, 124, 3, 10, 7}},
+		{tm, []byte{0xb4, 10, 30, 0, 0}},
+		{int64(-2), []byte{0x31, 0xfe}},
+		{int64(math.MinInt32), []byte{0x34, 0x80, 0, 0, 0}},
+		{uint64(math.MaxUint32), []byte{0x24, 0xff, 0xff, 0xff, 0xff}},
+		{objects.Enumerated(300), []byte{0x92, 0x01, 0x2c}},
+		{objects.BinaryActive, []byte{0x91, 1}},
+		{objects.EngineeringUnits(objects.UnitPercent), []byte{0x91, 98}},
+	} {
+		o, err := objects.EncValue(tc.value)
+		if err != nil {
+			t.Fatalf("%T %v: %v", tc.value, tc.value, err)
+		}
+		b, err := o.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertEqual(t, tc.want, b)
+	}
+}
+
+func TestEncValueRange(t *testing.T) {
+	for _, v := range []interface{}{
+		int64(math.MaxInt32) + 1,
+		int64(math.MinInt32) - 1,
+		uint64(math.MaxUint32) + 1,
+	} {
+		if _, err := objects.EncValue(v); !errors.Is(err, common.ErrTooBigValue) {
+			t.Errorf("%T %v: got %v, want ErrTooBigValue", v, v, err)
+		}
+	}
+
+	if _, err := objects.EncValue(objects.BACnetTime{Hour: 24}); err == nil {
+		t.Error("hour 24 encoded")
+	}
+}
@@ -8,80 +8,128 @@ import (
 	"github.com/Nortech-ai/bacnet/services"
 )
 
-const bacnetLenMin = 8
-
 func combine(t, s uint8) uint16 {
 	return uint16(t)<<8 | uint16(s)
 }
 
 // Parse decodes the given bytes.
 func Parse(b []byte) (plumbing.BACnet, error) {
+	var bvlc plumbing.BVLC
+	if err := bvlc.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("parsing BVLC %x: %v", b, err)
+	}
 
-	if len(b) < bacnetLenMin {
-		return nil, fmt.Errorf(
-			"parsing length %d: %v", len(b), common.ErrTooShortToParse,
-		)
+	if !bvlc.CarriesNPDU() {
+		bacnet := services.NewBVLLMessage(&bvlc)
+		if err := bacnet.UnmarshalBinary(b); err != nil {
+			return nil, fmt.Errorf("parsing BVLL message %x: %v", b, err)
+		}
+		return bacnet, nil
 	}
+	return parse(&bvlc, b)
+}
 
-	var bvlc plumbing.BVLC
+// ParseNPDU decodes the NPDU b received on a datalink without BVLL, such as
+// those of a transport.Transport. The messages returned have no BVLC.
+func ParseNPDU(b []byte) (plumbing.BACnet, error) {
+	return parse(nil, b)
+}
+
+// parse decodes the NPDU following bvlc in b, bvlc being nil for a bare NPDU.
+func parse(bvlc *plumbing.BVLC, b []byte) (plumbing.BACnet, error) {
 	var npdu plumbing.NPDU
 	var bacnet plumbing.BACnet
 
-	offset := 0
-	if err := bvlc.UnmarshalBinary(b); err != nil {
-		return nil, fmt.Errorf("parsing BVLC %x: %v", b, err)
-	}
-	offset += bvlc.MarshalLen()
-
+	offset := bvlc.MarshalLen()
 	if err := npdu.UnmarshalBinary(b[offset:]); err != nil {
 		return nil, fmt.Errorf("parsing NPDU %x: %v", b[offset:], err)
 	}
 	offset += npdu.MarshalLen()
 
+	if npdu.IsNetworkMessage() {
+		bacnet = services.NewNetworkMessage(bvlc, &npdu)
+		if err := bacnet.UnmarshalBinary(b); err != nil {
+			return nil, fmt.Errorf("parsing network message %x: %v", b, err)
+		}
+		return bacnet, nil
+	}
+
+	if len(b) < offset+2 {
+		return nil, fmt.Errorf(
+			"parsing length %d: %v", len(b), common.ErrTooShortToParse,
+		)
+	}
+
 	var c uint16
 	PDUType := b[offset] >> 4 & 0xFF
 	switch PDUType {
 	case plumbing.UnConfirmedReq:
 		c = combine(b[offset], b[offset+1])
 	case plumbing.ConfirmedReq:
-		c = combine(b[offset]>>4, b[offset+3]) // We need to skip the PDU flags and the InvokeID
-	case plumbing.ComplexAck, plumbing.SimpleAck, plumbing.Error:
-		c = combine(b[offset], 0) // We need to skip the PDU flags and the InvokeID
+		service := offset + 3 // We need to skip the PDU flags, the max APDU size and the InvokeID
+		if b[offset]&plumbing.SegmentedRequest != 0 {
+			service += 2 // Segments also carry their sequence number and window size
+		}
+		if service >= len(b) {
+			return nil, fmt.Errorf(
+				"parsing ConfirmedReq length %d: %v", len(b), common.ErrTooShortToParse,
+			)
+		}
+		c = combine(b[offset]>>4, b[service])
+	case plumbing.ComplexAck, plumbing.SimpleAck, plumbing.Error, plumbing.SegmentAck, plumbing.Reject, plumbing.Abort:
+		c = combine(PDUType<<4, 0) // We need to skip the PDU flags and the InvokeID
 	}
 
 	switch c {
 	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedWhoIs):
-		bacnet = services.NewUnconfirmedWhoIs(&bvlc, &npdu)
+		bacnet = services.NewUnconfirmedWhoIs(bvlc, &npdu)
 	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedIAm):
 		// Check BVLC function to differentiate between broadcast and unicast IAm.
-		if bvlc.Function == plumbing.BVLCFuncBroadcast {
-			bacnet = services.NewUnconfirmedIAm(&bvlc, &npdu)
-		} else if bvlc.Function == plumbing.BVLCFuncUnicast {
+		// Forwarded-NPDUs and Distribute-Broadcast-To-Network carry broadcasts,
+		// and bare NPDUs are taken as broadcasts, as most I-Ams are.
+		if bvlc == nil || bvlc.IsBroadcast() {
+			bacnet = services.NewUnconfirmedIAm(bvlc, &npdu)
+		} else if bvlc.CarriesNPDU() {
 			//For unicast, pass apdu aswell
 			apdu := &plumbing.APDU{}
 			if err := apdu.UnmarshalBinary(b[offset:]); err != nil {
 				return nil, err
 			}
-			bacnet = services.NewUnicastIAm(&bvlc, &npdu, apdu)
+			bacnet = services.NewUnicastIAm(bvlc, &npdu, apdu)
 		} else {
 			return nil, common.ErrNotImplemented
 		}
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedWhoHas):
+		bacnet = services.NewUnconfirmedWhoHas(bvlc, &npdu)
+	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedIHave):
+		bacnet = services.NewUnconfirmedIHave(bvlc, &npdu)
 	case combine(plumbing.UnConfirmedReq<<4, services.ServiceUnconfirmedCOVNotification):
-		bacnet = services.NewUnconfirmedCOVNotification(&bvlc, &npdu)
+		bacnet = services.NewUnconfirmedCOVNotification(bvlc, &npdu)
 	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedCOVNotification):
-		bacnet = services.NewConfirmedCOVNotification(&bvlc, &npdu)
+		bacnet = services.NewConfirmedCOVNotification(bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedSubscribeCOV):
+		// The second value is the APDU type, known here.
+		bacnet, _ = services.NewConfirmedSubscribeCOV(bvlc, &npdu)
 	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedReadProperty):
-		bacnet = services.NewConfirmedReadProperty(&bvlc, &npdu)
+		bacnet = services.NewConfirmedReadProperty(bvlc, &npdu)
 	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedReadPropMultiple):
-		bacnet = services.NewConfirmedReadPropertyMultiple(&bvlc, &npdu)
+		bacnet = services.NewConfirmedReadPropertyMultiple(bvlc, &npdu)
 	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedWriteProperty):
-		bacnet = services.NewConfirmedWriteProperty(&bvlc, &npdu)
+		bacnet = services.NewConfirmedWriteProperty(bvlc, &npdu)
+	case combine(plumbing.ConfirmedReq<<4, services.ServiceConfirmedWritePropMultiple):
+		bacnet = services.NewConfirmedWritePropertyMultiple(bvlc, &npdu)
 	case combine(plumbing.ComplexAck<<4, 0):
-		bacnet = services.NewComplexACK(&bvlc, &npdu)
+		bacnet = services.NewComplexACK(bvlc, &npdu)
 	case combine(plumbing.SimpleAck<<4, 0):
-		bacnet = services.NewSimpleACK(&bvlc, &npdu)
+		bacnet = services.NewSimpleACK(bvlc, &npdu)
 	case combine(plumbing.Error<<4, 0):
-		bacnet = services.NewError(&bvlc, &npdu)
+		bacnet = services.NewError(bvlc, &npdu)
+	case combine(plumbing.SegmentAck<<4, 0):
+		bacnet = services.NewSegmentACK(bvlc, &npdu)
+	case combine(plumbing.Reject<<4, 0):
+		bacnet = services.NewReject(bvlc, &npdu)
+	case combine(plumbing.Abort<<4, 0):
+		bacnet = services.NewAbort(bvlc, &npdu)
 	default:
 		return nil, fmt.Errorf(
 			"parsing service %x: %v", c, common.ErrNotImplemented,
@@ -0,0 +1,52 @@
+package plumbing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// Network numbers with a special meaning in a BACnetAddress.
+const (
+	// LocalNetwork is the network the device is directly attached to.
+	LocalNetwork = 0
+	// GlobalNetwork broadcasts to every network.
+	GlobalNetwork = 0xFFFF
+)
+
+// BACnetAddress is the network number and MAC address of a device. An empty
+// Mac broadcasts on Net.
+type BACnetAddress struct {
+	Net uint16
+	Mac []byte
+}
+
+// NewBACnetAddress creates a BACnetAddress for the device with the MAC address
+// mac on network netNum.
+func NewBACnetAddress(netNum uint16, mac []byte) BACnetAddress {
+	return BACnetAddress{Net: netNum, Mac: mac}
+}
+
+// NewBACnetIPAddress creates a BACnetAddress for the BACnet/IP device at addr
+// on network netNum, its MAC being the IPv4 address and the port.
+func NewBACnetIPAddress(netNum uint16, addr *net.UDPAddr) BACnetAddress {
+	mac := make([]byte, 6)
+	copy(mac, addr.IP.To4())
+	binary.BigEndian.PutUint16(mac[4:], uint16(addr.Port))
+	return BACnetAddress{Net: netNum, Mac: mac}
+}
+
+// GlobalBroadcast returns the address reaching every device on every network.
+func GlobalBroadcast() BACnetAddress {
+	return BACnetAddress{Net: GlobalNetwork}
+}
+
+// IsBroadcast tells whether the address reaches every device on its network.
+func (a BACnetAddress) IsBroadcast() bool {
+	return len(a.Mac) == 0
+}
+
+// String returns the network number and the MAC address in hex, as in 2001:0a.
+func (a BACnetAddress) String() string {
+	return fmt.Sprintf("%d:%x", a.Net, a.Mac)
+}
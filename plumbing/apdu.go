@@ -9,13 +9,24 @@ import (
 
 // APDU is a Application protocol DAta Units.
 type APDU struct {
-	Type     uint8
-	Flags    uint8
-	MaxSeg   uint8
-	MaxSize  uint8
-	InvokeID uint8
-	Service  uint8
-	Objects  []objects.APDUPayload
+	Type           uint8
+	Flags          uint8
+	MaxSeg         uint8
+	MaxSize        uint8
+	InvokeID       uint8
+	SequenceNumber uint8
+	WindowSize     uint8
+	Service        uint8
+	// Reason is the reason of a Reject or an Abort.
+	Reason  uint8
+	Objects []objects.APDUPayload
+	// Data holds the service data the APDU was unmarshalled from, which the
+	// services decode in place of Objects. It has to be reset along with the
+	// Objects of an unmarshalled APDU.
+	Data []byte
+	// Segment holds the raw service data of a segmented APDU. It can only be
+	// decoded into Objects once all the segments have been reassembled.
+	Segment []byte
 }
 
 // NewAPDU creates an APDU.
@@ -29,13 +40,13 @@ func NewAPDU(t, s uint8, objs []objects.APDUPayload) *APDU {
 
 // UnmarshalBinary sets the values retrieved from byte sequence in a APDU frame.
 func (a *APDU) UnmarshalBinary(b []byte) error {
-	a.Type = b[0] >> 4
-	a.Flags = b[0] & 0x7
-
-	if b[0]&0x2 == 1 {
-		a.MaxSeg = b[1] >> 4
-		a.MaxSize = b[1] & 0xF
+	if l := len(b); l < apduLenMin {
+		return fmt.Errorf(
+			"failed to unmarshal APDU - binary length %d: %v", l, common.ErrTooShortToParse,
+		)
 	}
+	a.Type = b[0] >> 4
+	a.Flags = b[0] & 0xF
 
 	var offset int = 1
 	switch a.Type {
@@ -43,105 +54,89 @@ func (a *APDU) UnmarshalBinary(b []byte) error {
 		a.Service = b[offset]
 		offset++
 		if len(b) > 2 {
-			objs := []objects.APDUPayload{}
-			for offset < len(b) {
-				o := objects.Object{
-					TagNumber: b[offset] >> 4,
-					TagClass:  common.IntToBool(int(b[offset]) & 0x8 >> 3),
-					Length:    b[offset] & 0x7,
-				}
-
-				// Handle extended value case
-				if o.Length == 5 {
-					offset++
-					o.Length = uint8(b[offset])
-				} else if o.Length > 5 {
-					offset++
-					objs = append(objs, &o)
-					continue
-				}
-
-				o.Data = b[offset+1 : offset+int(o.Length)+1]
-				objs = append(objs, &o)
-				offset += int(o.Length) + 1
+			objs, err := unmarshalObjects(b[offset:])
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal UnconfirmedReq: %v", err)
 			}
 			a.Objects = objs
+			a.Data = b[offset:]
 		}
 	case ConfirmedReq:
+		if l := len(b); l < a.headerLen() {
+			return fmt.Errorf(
+				"failed to unmarshal ConfirmedReq - binary length %d: %v", l, common.ErrTooShortToParse,
+			)
+		}
+		a.MaxSeg = b[offset] >> 4 & 0x7
+		a.MaxSize = b[offset] & 0xF
 		offset++
 		a.InvokeID = b[offset]
 		offset++
+		if a.IsSegmented() {
+			a.SequenceNumber = b[offset]
+			a.WindowSize = b[offset+1]
+			offset += 2
+		}
 		a.Service = b[offset]
 		offset++
-		if len(b) > 2 {
-			objs := []objects.APDUPayload{}
-			for len(b) > offset {
-				o := objects.Object{
-					TagNumber: b[offset] >> 4,
-					TagClass:  common.IntToBool(int(b[offset]) & 0x8 >> 3),
-					Length:    b[offset] & 0x7,
-				}
-
-				// Handle extended value case
-				if o.Length == 5 {
-					offset++
-					o.Length = uint8(b[offset])
-				} else if o.Length > 5 {
-					offset++
-					objs = append(objs, &o)
-					continue
-				}
-
-				o.Data = b[offset+1 : offset+int(o.Length)+1]
-				objs = append(objs, &o)
-				offset += int(o.Length) + 1
-
-				if offset >= len(b) {
-					break
-				}
+		if a.IsSegmented() {
+			a.Segment = b[offset:]
+		} else if len(b) > 2 {
+			objs, err := unmarshalObjects(b[offset:])
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal ConfirmedReq: %v", err)
 			}
 			a.Objects = objs
+			a.Data = b[offset:]
 		}
 	case ComplexAck, SimpleAck, Error:
+		if l := len(b); l < a.headerLen() {
+			return fmt.Errorf(
+				"failed to unmarshal CACK/SACK/ERROR - binary length %d: %v", l, common.ErrTooShortToParse,
+			)
+		}
 		a.InvokeID = b[offset]
 		offset++
+		if a.IsSegmented() {
+			a.SequenceNumber = b[offset]
+			a.WindowSize = b[offset+1]
+			offset += 2
+		}
 		a.Service = b[offset]
 		offset++
-		objs := []objects.APDUPayload{}
-		for offset < len(b) {
-			o := objects.Object{
-				TagNumber: b[offset] >> 4,
-				TagClass:  common.IntToBool(int(b[offset]) & 0x8 >> 3),
-				Length:    b[offset] & 0x7,
-			}
-
-			// Handle extended value case
-			if o.Length == 5 {
-				offset++
-				o.Length = uint8(b[offset])
-			} else if o.Length > 5 {
-				offset++
-				objs = append(objs, &o)
-				continue
-			}
-
-			// Handle boolean data
-			if !o.TagClass && o.TagNumber == 1 {
-				o.Value = o.Length
-				objs = append(objs, &o)
-				offset++
-				continue
-			}
-
-			o.Data = b[offset+1 : offset+int(o.Length)+1]
-			objs = append(objs, &o)
-			offset += int(o.Length) + 1
-
-			if offset >= len(b) {
-				break
+		if a.IsSegmented() {
+			a.Segment = b[offset:]
+			break
+		}
+		objs, err := unmarshalObjects(b[offset:])
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal CACK/SACK/ERROR: %v", err)
+		}
+		// Application tagged Booleans carry their value in their tag.
+		for _, o := range objs {
+			if o := o.(*objects.Object); !o.TagClass && o.TagNumber == objects.TagBoolean {
+				o.Value = uint8(o.Length)
 			}
 		}
 		a.Objects = objs
+		a.Data = b[offset:]
+	case SegmentAck:
+		if l := len(b); l < a.headerLen() {
+			return fmt.Errorf(
+				"failed to unmarshal SegmentACK - binary length %d: %v", l, common.ErrTooShortToParse,
+			)
+		}
+		a.InvokeID = b[1]
+		a.SequenceNumber = b[2]
+		a.WindowSize = b[3]
+	case Reject, Abort:
+		if l := len(b); l < a.headerLen() {
+			return fmt.Errorf(
+				"failed to unmarshal Reject/Abort - binary length %d: %v", l, common.ErrTooShortToParse,
+			)
+		}
+		a.InvokeID = b[1]
+		a.Reason = b[2]
 	default:
 		return fmt.Errorf("unmarshal APDU: %s", common.ErrNotImplemented)
 	}
@@ -149,6 +144,37 @@ func (a *APDU) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
+// unmarshalObjects parses the tagged values of the service data b.
+func unmarshalObjects(b []byte) ([]objects.APDUPayload, error) {
+	objs := []objects.APDUPayload{}
+	r := objects.NewTagReader(b)
+	for r.Remaining() > 0 {
+		o, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+	}
+	return objs, nil
+}
+
+// ServiceData returns the encoded service data: Data for the APDUs
+// unmarshalled, the encoding of Objects for the others.
+func (a *APDU) ServiceData() ([]byte, error) {
+	if a.Data != nil {
+		return a.Data, nil
+	}
+	b := make([]byte, 0, a.MarshalLen()-a.headerLen())
+	for _, o := range a.Objects {
+		ob, err := o.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal service data: %v", err)
+		}
+		b = append(b, ob...)
+	}
+	return b, nil
+}
+
 // MarshalTo puts the byte sequence in the byte array given as b.
 func (a *APDU) MarshalTo(b []byte) error {
 	if len(b) < a.MarshalLen() {
@@ -164,11 +190,6 @@ func (a *APDU) MarshalTo(b []byte) error {
 	b[offset] = a.Type<<4 | a.Flags
 	offset++
 
-	if a.Flags&0x2 == 1 {
-		b[offset] = (a.MaxSeg & 0x7 << 4) | (a.MaxSize & 0xF)
-		offset++
-	}
-
 	switch a.Type {
 	case UnConfirmedReq:
 		b[offset] = a.Service
@@ -196,9 +217,16 @@ func (a *APDU) MarshalTo(b []byte) error {
 	case ComplexAck, SimpleAck, Error:
 		b[offset] = a.InvokeID
 		offset++
+		if a.IsSegmented() {
+			b[offset] = a.SequenceNumber
+			b[offset+1] = a.WindowSize
+			offset += 2
+		}
 		b[offset] = a.Service
 		offset++
-		if a.MarshalLen() > 4 {
+		if a.IsSegmented() {
+			copy(b[offset:], a.Segment)
+		} else if a.MarshalLen() > 4 {
 			for _, o := range a.Objects {
 				ob, err := o.MarshalBinary()
 				if err != nil {
@@ -222,9 +250,16 @@ func (a *APDU) MarshalTo(b []byte) error {
 		offset++
 		b[offset] = a.InvokeID
 		offset++
+		if a.IsSegmented() {
+			b[offset] = a.SequenceNumber
+			b[offset+1] = a.WindowSize
+			offset += 2
+		}
 		b[offset] = a.Service
 		offset++
-		if a.MarshalLen() > 4 {
+		if a.IsSegmented() {
+			copy(b[offset:], a.Segment)
+		} else if a.MarshalLen() > 4 {
 			for _, o := range a.Objects {
 				ob, err := o.MarshalBinary()
 				if err != nil {
@@ -243,20 +278,43 @@ func (a *APDU) MarshalTo(b []byte) error {
 				}
 			}
 		}
+	case SegmentAck:
+		b[offset] = a.InvokeID
+		b[offset+1] = a.SequenceNumber
+		b[offset+2] = a.WindowSize
+	case Reject, Abort:
+		b[offset] = a.InvokeID
+		b[offset+1] = a.Reason
 	}
 	return nil
 }
 
-// MarshalLen returns the serial length of APDU.
-func (a *APDU) MarshalLen() int {
+const apduLenMin = 2
+
+// headerLen returns the length of the APDU fields preceding the service data.
+func (a *APDU) headerLen() int {
 	var l int = 0
 	switch a.Type {
 	case ConfirmedReq:
 		l += 4
-	case ComplexAck, SimpleAck, Error:
+	case ComplexAck, SimpleAck, Error, Reject, Abort:
 		l += 3
 	case UnConfirmedReq:
 		l += 2
+	case SegmentAck:
+		l += 4
+	}
+	if a.IsSegmented() {
+		l += 2
+	}
+	return l
+}
+
+// MarshalLen returns the serial length of APDU.
+func (a *APDU) MarshalLen() int {
+	l := a.headerLen()
+	if a.IsSegmented() {
+		return l + len(a.Segment)
 	}
 	for _, o := range a.Objects {
 		l += o.MarshalLen()
@@ -264,6 +322,11 @@ func (a *APDU) MarshalLen() int {
 	return l
 }
 
+// IsSegmented reports whether the APDU is a segment of a larger ConfirmedReq or ComplexAck.
+func (a *APDU) IsSegmented() bool {
+	return (a.Type == ConfirmedReq || a.Type == ComplexAck) && a.Flags&SegmentedRequest != 0
+}
+
 // SetAPDUFlags sets APDU Flags to APDU.
 func (a *APDU) SetAPDUFlags(sa, moreSegments, segmentedReq bool) {
 	a.Flags = uint8(
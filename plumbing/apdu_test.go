@@ -0,0 +1,96 @@
+package plumbing_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func TestObjectExtendedTags(t *testing.T) {
+	cases := []struct {
+		obj    *objects.Object
+		header []byte
+	}{
+		{objects.EncOctetString(bytes.Repeat([]byte{1}, 5)), []byte{0x65, 5}},
+		{objects.EncOctetString(bytes.Repeat([]byte{1}, 253)), []byte{0x65, 253}},
+		{objects.EncOctetString(bytes.Repeat([]byte{1}, 254)), []byte{0x65, 254, 0x00, 0xfe}},
+		{objects.EncOctetString(bytes.Repeat([]byte{1}, 70000)), []byte{0x65, 255, 0x00, 0x01, 0x11, 0x70}},
+		{objects.ContextTag(20, objects.EncUnsignedInteger(7)), []byte{0xf9, 20}},
+		{objects.ContextTag(15, objects.EncString("abcdef")), []byte{0xfd, 15, 7}},
+		{objects.EncOpeningTag(30), []byte{0xfe, 30}},
+		{objects.EncClosingTag(30), []byte{0xff, 30}},
+	}
+
+	for _, c := range cases {
+		b, err := c.obj.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertEqual(t, len(c.header)+len(c.obj.Data), len(b))
+		if !bytes.Equal(b[:len(c.header)], c.header) {
+			t.Errorf("expected header %x, got %x", c.header, b[:len(c.header)])
+		}
+
+		var o objects.Object
+		if err := o.UnmarshalBinary(append(b, 0xaa)); err != nil {
+			t.Fatal(err)
+		}
+		AssertEqual(t, c.obj.TagNumber, o.TagNumber)
+		AssertEqual(t, c.obj.TagClass, o.TagClass)
+		AssertEqual(t, c.obj.Length, o.Length)
+		AssertEqual(t, len(b), o.MarshalLen())
+		if !bytes.Equal(o.Data, c.obj.Data) {
+			t.Errorf("unexpected data of tag %x", c.header)
+		}
+	}
+}
+
+func TestObjectUnmarshalErrors(t *testing.T) {
+	for _, b := range [][]byte{
+		{0x65},              // missing extended length
+		{0x65, 254, 0x01},   // truncated 2-byte length
+		{0x65, 10, 1, 2, 3}, // truncated data
+		{0xf9},              // missing extended tag number
+		{0x65, 3, 1, 2, 3},  // length not in its shortest form
+		{0x66},              // application opening tag
+	} {
+		var o objects.Object
+		if err := o.UnmarshalBinary(b); err == nil {
+			t.Errorf("expected an error unmarshalling %x", b)
+		}
+	}
+}
+
+func TestAPDUExtendedTags(t *testing.T) {
+	description := string(bytes.Repeat([]byte("x"), 600))
+	apdu := plumbing.NewAPDU(plumbing.ComplexAck, 12, []objects.APDUPayload{
+		objects.EncObjectIdentifier(true, 0, objects.ObjectTypeDevice, 1),
+		objects.ContextTag(1, objects.EncUnsignedInteger(uint(objects.PropertyIdDescription))),
+		objects.EncOpeningTag(3),
+		objects.EncString(description),
+		objects.EncBoolean(true),
+		objects.EncClosingTag(3),
+		objects.ContextTag(16, objects.EncUnsignedInteger(1)),
+	})
+	b := make([]byte, apdu.MarshalLen())
+	if err := apdu.MarshalTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	var got plumbing.APDU
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, len(apdu.Objects), len(got.Objects))
+	s, err := objects.DecString(got.Objects[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, description, s)
+	AssertEqual(t, uint8(1), got.Objects[4].(*objects.Object).Value)
+	AssertEqual(t, true, got.Objects[5].(*objects.Object).IsClosingTag())
+	AssertEqual(t, uint8(16), got.Objects[6].(*objects.Object).TagNumber)
+}
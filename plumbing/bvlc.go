@@ -3,6 +3,7 @@ package plumbing
 import (
 	"encoding/binary"
 	"fmt"
+	"net"
 
 	"github.com/Nortech-ai/bacnet/common"
 )
@@ -10,17 +11,57 @@ import (
 // BVLCType is used for BACnet/IP in BVLL.
 const BVLCType = 0x81
 
-// BVLCFunc determines unicast or broadcast in BVLL.
+// BVLCFunc determines the BVLL function, as defined in Annex J.
 const (
-	BVLCFuncUnicast   = 0x0a
-	BVLCFuncBroadcast = 0x0b
+	BVLCFuncResult                = 0x00
+	BVLCFuncWriteBDT              = 0x01
+	BVLCFuncReadBDT               = 0x02
+	BVLCFuncReadBDTAck            = 0x03
+	BVLCFuncForwardedNPDU         = 0x04
+	BVLCFuncRegisterForeignDevice = 0x05
+	BVLCFuncReadFDT               = 0x06
+	BVLCFuncReadFDTAck            = 0x07
+	BVLCFuncDeleteFDTEntry        = 0x08
+	BVLCFuncDistributeBroadcast   = 0x09
+	BVLCFuncUnicast               = 0x0a
+	BVLCFuncBroadcast             = 0x0b
+	BVLCFuncSecureBVLL            = 0x0c
 )
 
-// BVLC is a BVLC frame.
+// BVLC-Result codes.
+const (
+	BVLCResultSuccessful               uint16 = 0x0000
+	BVLCResultWriteBDTNAK              uint16 = 0x0010
+	BVLCResultReadBDTNAK               uint16 = 0x0020
+	BVLCResultRegisterForeignDeviceNAK uint16 = 0x0030
+	BVLCResultReadFDTNAK               uint16 = 0x0040
+	BVLCResultDeleteFDTEntryNAK        uint16 = 0x0050
+	BVLCResultDistributeBroadcastNAK   uint16 = 0x0060
+)
+
+const (
+	// BIPAddressLen is the length of a B/IP address, the IPv4 address and the UDP port.
+	BIPAddressLen = 6
+	// BIPPort is the default UDP port of BACnet/IP.
+	BIPPort = 0xBAC0
+)
+
+// BVLC is a BVLC frame, either of BACnet/IP or of BACnet/IPv6 according to Type.
+// A nil *BVLC stands for the missing BVLC of an NPDU carried by another
+// datalink, such as MS/TP or BACnet/SC: it marshals to nothing and unmarshals
+// from nothing.
 type BVLC struct {
 	Type     uint8
 	Function uint8
 	Length   uint16
+	// Origin is the B/IP or B/IPv6 address of the original source of a
+	// Forwarded-NPDU.
+	Origin *net.UDPAddr
+	// SourceVMAC and DestVMAC are the virtual MAC addresses of the source and
+	// of the destination of a BACnet/IPv6 frame. DestVMAC is only carried by
+	// Original-Unicast-NPDU and the address resolution ACKs.
+	SourceVMAC []byte
+	DestVMAC   []byte
 }
 
 // NewBVLC creates a BVLC.
@@ -35,20 +76,115 @@ func NewBVLC(f uint8) *BVLC {
 
 // UnmarshalBinary sets the values retrieved from byte sequence in a BVLC frame.
 func (bvlc *BVLC) UnmarshalBinary(b []byte) error {
-	if l := len(b); l < bvlc.MarshalLen() {
+	if bvlc == nil {
+		return nil
+	}
+	if l := len(b); l < bvlclen {
 		return fmt.Errorf(
 			"failed to unmarshal BVLC - marshal length %d binary length %d: %v",
-			bvlc.MarshalLen(), l,
+			bvlclen, l,
 			common.ErrTooShortToParse,
 		)
 	}
 	bvlc.Type = b[0]
 	bvlc.Function = b[1]
 	bvlc.Length = binary.BigEndian.Uint16(b[2:4])
+	if l := len(b); int(bvlc.Length) != l {
+		return fmt.Errorf(
+			"failed to unmarshal BVLC - length field %d binary length %d: %w",
+			bvlc.Length, l,
+			common.ErrInvalidData,
+		)
+	}
+	bvlc.Origin = nil
+	bvlc.SourceVMAC, bvlc.DestVMAC = nil, nil
+
+	if bvlc.Type == BVLCType6 {
+		return bvlc.unmarshalBinary6(b)
+	}
+	if bvlc.Function == BVLCFuncForwardedNPDU {
+		if l := len(b); l < bvlc.MarshalLen() {
+			return fmt.Errorf(
+				"failed to unmarshal Forwarded-NPDU BVLC - binary length %d: %v", l, common.ErrTooShortToParse,
+			)
+		}
+		bvlc.Origin = DecodeBIPAddress(b[bvlclen:])
+	}
 
 	return nil
 }
 
+func (bvlc *BVLC) unmarshalBinary6(b []byte) error {
+	if l := len(b); l < bvlc.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal BVLC6 function %x - marshal length %d binary length %d: %v",
+			bvlc.Function, bvlc.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+	offset := bvlclen
+	bvlc.SourceVMAC = append([]byte{}, b[offset:offset+VMACLen]...)
+	offset += VMACLen
+	if hasDestVMAC(bvlc.Function) {
+		bvlc.DestVMAC = append([]byte{}, b[offset:offset+VMACLen]...)
+	}
+	if bvlc.Function == BVLC6FuncForwardedNPDU {
+		bvlc.Origin = DecodeBIP6Address(b[offset:])
+	}
+
+	return nil
+}
+
+// CarriesNPDU tells whether the BVLL function is followed by an NPDU.
+func (bvlc *BVLC) CarriesNPDU() bool {
+	if bvlc == nil {
+		return true
+	}
+	if bvlc.Type == BVLCType6 {
+		switch bvlc.Function {
+		case BVLC6FuncOriginalUnicast, BVLC6FuncOriginalBroadcast, BVLC6FuncForwardedNPDU, BVLC6FuncDistributeBroadcast:
+			return true
+		}
+		return false
+	}
+	switch bvlc.Function {
+	case BVLCFuncForwardedNPDU, BVLCFuncDistributeBroadcast, BVLCFuncUnicast, BVLCFuncBroadcast:
+		return true
+	}
+	return false
+}
+
+// IsBroadcast tells whether the BVLL function carries a broadcast NPDU, be it
+// original, forwarded or to be distributed by a BBMD.
+func (bvlc *BVLC) IsBroadcast() bool {
+	if bvlc.Type == BVLCType6 {
+		switch bvlc.Function {
+		case BVLC6FuncOriginalBroadcast, BVLC6FuncForwardedNPDU, BVLC6FuncDistributeBroadcast:
+			return true
+		}
+		return false
+	}
+	switch bvlc.Function {
+	case BVLCFuncBroadcast, BVLCFuncForwardedNPDU, BVLCFuncDistributeBroadcast:
+		return true
+	}
+	return false
+}
+
+// DecodeBIPAddress decodes the B/IP address at the beginning of b.
+func DecodeBIPAddress(b []byte) *net.UDPAddr {
+	return &net.UDPAddr{
+		IP:   net.IPv4(b[0], b[1], b[2], b[3]),
+		Port: int(binary.BigEndian.Uint16(b[4:6])),
+	}
+}
+
+// EncodeBIPAddress puts the B/IP address addr at the beginning of b.
+func EncodeBIPAddress(b []byte, addr *net.UDPAddr) {
+	copy(b[:4], addr.IP.To4())
+	binary.BigEndian.PutUint16(b[4:6], uint16(addr.Port))
+}
+
 // MarshalBinary returns the byte sequence generated from a BVLC instance.
 func (bvlc *BVLC) MarshalBinary() ([]byte, error) {
 	b := make([]byte, bvlc.MarshalLen())
@@ -63,11 +199,30 @@ const bvlclen = 4
 
 // MarshalLen returns the serial length of BVLC.
 func (bvlc *BVLC) MarshalLen() int {
+	if bvlc == nil {
+		return 0
+	}
+	if bvlc.Type == BVLCType6 {
+		l := bvlclen + VMACLen
+		if hasDestVMAC(bvlc.Function) {
+			l += VMACLen
+		}
+		if bvlc.Function == BVLC6FuncForwardedNPDU {
+			l += BIP6AddressLen
+		}
+		return l
+	}
+	if bvlc.Function == BVLCFuncForwardedNPDU {
+		return bvlclen + BIPAddressLen
+	}
 	return bvlclen
 }
 
 // MarshalTo puts the byte sequence in the byte array given as b.
 func (bvlc *BVLC) MarshalTo(b []byte) error {
+	if bvlc == nil {
+		return nil
+	}
 	if len(b) < bvlc.MarshalLen() {
 		return fmt.Errorf(
 			"failed to marshal BVLC - marshal length %d binary length %d: %v",
@@ -79,5 +234,36 @@ func (bvlc *BVLC) MarshalTo(b []byte) error {
 	b[0] = byte(bvlc.Type)
 	b[1] = byte(bvlc.Function)
 	binary.BigEndian.PutUint16(b[2:4], bvlc.Length)
+	if bvlc.Type == BVLCType6 {
+		return bvlc.marshalTo6(b)
+	}
+	if bvlc.Function == BVLCFuncForwardedNPDU {
+		if bvlc.Origin == nil || bvlc.Origin.IP.To4() == nil {
+			return fmt.Errorf("failed to marshal Forwarded-NPDU origin %v: %v", bvlc.Origin, common.ErrWrongStructure)
+		}
+		EncodeBIPAddress(b[bvlclen:], bvlc.Origin)
+	}
+	return nil
+}
+
+func (bvlc *BVLC) marshalTo6(b []byte) error {
+	if len(bvlc.SourceVMAC) != VMACLen {
+		return fmt.Errorf("failed to marshal BVLC6 source VMAC %x: %v", bvlc.SourceVMAC, common.ErrWrongStructure)
+	}
+	offset := bvlclen
+	copy(b[offset:], bvlc.SourceVMAC)
+	offset += VMACLen
+	if hasDestVMAC(bvlc.Function) {
+		if len(bvlc.DestVMAC) != VMACLen {
+			return fmt.Errorf("failed to marshal BVLC6 destination VMAC %x: %v", bvlc.DestVMAC, common.ErrWrongStructure)
+		}
+		copy(b[offset:], bvlc.DestVMAC)
+	}
+	if bvlc.Function == BVLC6FuncForwardedNPDU {
+		if bvlc.Origin == nil || bvlc.Origin.IP.To16() == nil {
+			return fmt.Errorf("failed to marshal Forwarded-NPDU origin %v: %v", bvlc.Origin, common.ErrWrongStructure)
+		}
+		EncodeBIP6Address(b[offset:], bvlc.Origin)
+	}
 	return nil
 }
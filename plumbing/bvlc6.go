@@ -0,0 +1,98 @@
+package plumbing
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// BVLCType6 is used for BACnet/IPv6 in BVLL, as defined in Annex U.
+const BVLCType6 = 0x82
+
+// BVLC6Func determines the BACnet/IPv6 BVLL function, as defined in Annex U.
+const (
+	BVLC6FuncResult                      = 0x00
+	BVLC6FuncOriginalUnicast             = 0x01
+	BVLC6FuncOriginalBroadcast           = 0x02
+	BVLC6FuncAddressResolution           = 0x03
+	BVLC6FuncForwardedAddressResolution  = 0x04
+	BVLC6FuncAddressResolutionAck        = 0x05
+	BVLC6FuncVirtualAddressResolution    = 0x06
+	BVLC6FuncVirtualAddressResolutionAck = 0x07
+	BVLC6FuncForwardedNPDU               = 0x08
+	BVLC6FuncRegisterForeignDevice       = 0x09
+	BVLC6FuncDeleteFDTEntry              = 0x0a
+	BVLC6FuncSecureBVLL                  = 0x0b
+	BVLC6FuncDistributeBroadcast         = 0x0c
+)
+
+// BACnet/IPv6 BVLC-Result codes, BVLCResultSuccessful being shared with BACnet/IP.
+const (
+	BVLC6ResultAddressResolutionNAK        uint16 = 0x0030
+	BVLC6ResultVirtualAddressResolutionNAK uint16 = 0x0060
+	BVLC6ResultRegisterForeignDeviceNAK    uint16 = 0x0090
+	BVLC6ResultDeleteFDTEntryNAK           uint16 = 0x00a0
+	BVLC6ResultDistributeBroadcastNAK      uint16 = 0x00c0
+)
+
+const (
+	// VMACLen is the length of a BACnet/IPv6 virtual MAC address.
+	VMACLen = 3
+	// BIP6AddressLen is the length of a B/IPv6 address, the IPv6 address and the UDP port.
+	BIP6AddressLen = 18
+	// BIP6Port is the default UDP port of BACnet/IPv6.
+	BIP6Port = 0xBAC0
+)
+
+// BIP6Multicast returns the multicast group BACnet/IPv6 broadcasts are sent
+// to on port, with a link-local or a site-local scope.
+func BIP6Multicast(port int, siteLocal bool) *net.UDPAddr {
+	if siteLocal {
+		return &net.UDPAddr{IP: net.ParseIP("ff05::bac0"), Port: port}
+	}
+	return &net.UDPAddr{IP: net.ParseIP("ff02::bac0"), Port: port}
+}
+
+// NewVMAC returns the virtual MAC address of the device with the given
+// instance number, which is unique as long as instances fit in 22 bits.
+func NewVMAC(instance uint32) []byte {
+	return []byte{byte(instance >> 16), byte(instance >> 8), byte(instance)}
+}
+
+// NewBVLC6 creates a BACnet/IPv6 BVLC sent from src to dst, the virtual MAC
+// addresses of the nodes. dst is only used by the functions carrying it.
+func NewBVLC6(f uint8, src, dst []byte) *BVLC {
+	bvlc := &BVLC{
+		Type:       BVLCType6,
+		Function:   f,
+		SourceVMAC: src,
+		DestVMAC:   dst,
+	}
+	bvlc.Length = uint16(bvlc.MarshalLen())
+	return bvlc
+}
+
+// DecodeBIP6Address decodes the B/IPv6 address at the beginning of b.
+func DecodeBIP6Address(b []byte) *net.UDPAddr {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, b[:net.IPv6len])
+	return &net.UDPAddr{
+		IP:   ip,
+		Port: int(binary.BigEndian.Uint16(b[net.IPv6len:BIP6AddressLen])),
+	}
+}
+
+// EncodeBIP6Address puts the B/IPv6 address addr at the beginning of b.
+func EncodeBIP6Address(b []byte, addr *net.UDPAddr) {
+	copy(b[:net.IPv6len], addr.IP.To16())
+	binary.BigEndian.PutUint16(b[net.IPv6len:BIP6AddressLen], uint16(addr.Port))
+}
+
+// hasDestVMAC tells whether the BACnet/IPv6 function f carries the virtual
+// MAC address of its destination.
+func hasDestVMAC(f uint8) bool {
+	switch f {
+	case BVLC6FuncOriginalUnicast, BVLC6FuncAddressResolutionAck, BVLC6FuncVirtualAddressResolutionAck:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package plumbing_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func TestBVLCUnmarshall_Simple(t *testing.T) {
+	var bvlc plumbing.BVLC
+	// Original-Unicast-NPDU of 6 octets, 2 of them NPDU
+	err := bvlc.UnmarshalBinary([]byte{0x81, 0x0a, 0x00, 0x06, 0x01, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, uint8(plumbing.BVLCType), bvlc.Type)
+	AssertEqual(t, uint8(plumbing.BVLCFuncUnicast), bvlc.Function)
+	AssertEqual(t, uint16(6), bvlc.Length)
+}
+
+func TestBVLCUnmarshall_LengthMismatch(t *testing.T) {
+	for _, b := range [][]byte{
+		// Truncated frame
+		{0x81, 0x0a, 0x00, 0x08, 0x01, 0x00},
+		// Trailing octets
+		{0x81, 0x0a, 0x00, 0x04, 0x01, 0x00},
+	} {
+		var bvlc plumbing.BVLC
+		if err := bvlc.UnmarshalBinary(b); !errors.Is(err, common.ErrInvalidData) {
+			t.Errorf("expected %v unmarshalling % x, got %v", common.ErrInvalidData, b, err)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package plumbing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// BVLCSCFunc determines the BACnet/SC BVLC function, as defined in Annex AB.
+const (
+	BVLCSCFuncResult                    = 0x00
+	BVLCSCFuncEncapsulatedNPDU          = 0x01
+	BVLCSCFuncAddressResolution         = 0x02
+	BVLCSCFuncAddressResolutionAck      = 0x03
+	BVLCSCFuncAdvertisement             = 0x04
+	BVLCSCFuncAdvertisementSolicitation = 0x05
+	BVLCSCFuncConnectRequest            = 0x06
+	BVLCSCFuncConnectAccept             = 0x07
+	BVLCSCFuncDisconnectRequest         = 0x08
+	BVLCSCFuncDisconnectAck             = 0x09
+	BVLCSCFuncHeartbeatRequest          = 0x0a
+	BVLCSCFuncHeartbeatAck              = 0x0b
+	BVLCSCFuncProprietaryMessage        = 0x0c
+)
+
+// BACnet/SC control flags telling which optional fields a BVLC-SC message carries.
+const (
+	bvlcSCFlagOrigin      = 0x08
+	bvlcSCFlagDestination = 0x04
+	bvlcSCFlagDestOptions = 0x02
+	bvlcSCFlagDataOptions = 0x01
+)
+
+// BACnet/SC header option marker flags.
+const (
+	scOptionMoreOptions    = 0x80
+	scOptionMustUnderstand = 0x40
+	scOptionHeaderData     = 0x20
+	scOptionTypeMask       = 0x1f
+)
+
+// BACnet/SC header option types.
+const (
+	SCOptionSecurePath  = 1
+	SCOptionProprietary = 31
+)
+
+// BACnet/SC error codes, of the communication error class, carried by BVLC-Result NAKs.
+const (
+	SCErrorCodeOptionalFunctionalityNotSupported uint16 = 45
+	SCErrorCodeBVLCFunctionUnknown               uint16 = 143
+	SCErrorCodeHeaderEncodingError               uint16 = 145
+	SCErrorCodeHeaderNotUnderstood               uint16 = 146
+	SCErrorCodeMessageIncomplete                 uint16 = 147
+	SCErrorCodeNodeDuplicateVMAC                 uint16 = 151
+)
+
+const (
+	// SCVMACLen is the length of a BACnet/SC virtual MAC address.
+	SCVMACLen = 6
+	// SCUUIDLen is the length of the device UUID of a BACnet/SC node.
+	SCUUIDLen = 16
+	// SCHubProtocol and SCDirectProtocol are the WebSocket subprotocols of
+	// the connections to a hub and of the direct connections between nodes.
+	SCHubProtocol    = "hub.bsc.bacnet.org"
+	SCDirectProtocol = "dc.bsc.bacnet.org"
+)
+
+const bvlcsclen = 4
+
+// SCBroadcastVMAC returns the virtual MAC address reaching every BACnet/SC node.
+func SCBroadcastVMAC() []byte {
+	return bytes.Repeat([]byte{0xff}, SCVMACLen)
+}
+
+// SCOption is a header option of a BVLC-SC message.
+type SCOption struct {
+	Type           uint8
+	MustUnderstand bool
+	Data           []byte
+}
+
+// BVLCSC is a BACnet/SC BVLC message, carrying its function specific Payload.
+type BVLCSC struct {
+	Function  uint8
+	MessageID uint16
+	// Origin and Destination are the virtual MAC addresses of the original
+	// source and of the destination, if any.
+	Origin      []byte
+	Destination []byte
+	DestOptions []SCOption
+	DataOptions []SCOption
+	Payload     []byte
+}
+
+// NewBVLCSC creates a BVLCSC.
+func NewBVLCSC(f uint8, messageID uint16, payload []byte) *BVLCSC {
+	return &BVLCSC{
+		Function:  f,
+		MessageID: messageID,
+		Payload:   payload,
+	}
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a BVLC-SC message.
+func (m *BVLCSC) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < bvlcsclen {
+		return fmt.Errorf(
+			"failed to unmarshal BVLC-SC - marshal length %d binary length %d: %v",
+			bvlcsclen, l,
+			common.ErrTooShortToParse,
+		)
+	}
+	m.Function = b[0]
+	flags := b[1]
+	m.MessageID = binary.BigEndian.Uint16(b[2:4])
+	m.Origin, m.Destination, m.DestOptions, m.DataOptions = nil, nil, nil, nil
+
+	offset := bvlcsclen
+	if flags&bvlcSCFlagOrigin != 0 {
+		if len(b) < offset+SCVMACLen {
+			return fmt.Errorf("failed to unmarshal BVLC-SC origin %x: %v", b, common.ErrTooShortToParse)
+		}
+		m.Origin = append([]byte{}, b[offset:offset+SCVMACLen]...)
+		offset += SCVMACLen
+	}
+	if flags&bvlcSCFlagDestination != 0 {
+		if len(b) < offset+SCVMACLen {
+			return fmt.Errorf("failed to unmarshal BVLC-SC destination %x: %v", b, common.ErrTooShortToParse)
+		}
+		m.Destination = append([]byte{}, b[offset:offset+SCVMACLen]...)
+		offset += SCVMACLen
+	}
+
+	var err error
+	if flags&bvlcSCFlagDestOptions != 0 {
+		if m.DestOptions, offset, err = unmarshalSCOptions(b, offset); err != nil {
+			return err
+		}
+	}
+	if flags&bvlcSCFlagDataOptions != 0 {
+		if m.DataOptions, offset, err = unmarshalSCOptions(b, offset); err != nil {
+			return err
+		}
+	}
+	m.Payload = append([]byte{}, b[offset:]...)
+
+	return nil
+}
+
+func unmarshalSCOptions(b []byte, offset int) ([]SCOption, int, error) {
+	var options []SCOption
+	for more := true; more; {
+		if len(b) <= offset {
+			return nil, 0, fmt.Errorf("failed to unmarshal BVLC-SC header options %x: %v", b, common.ErrTooShortToParse)
+		}
+		marker := b[offset]
+		offset++
+		o := SCOption{
+			Type:           marker & scOptionTypeMask,
+			MustUnderstand: marker&scOptionMustUnderstand != 0,
+		}
+		if marker&scOptionHeaderData != 0 {
+			if len(b) < offset+2 {
+				return nil, 0, fmt.Errorf("failed to unmarshal BVLC-SC header option %x: %v", b, common.ErrTooShortToParse)
+			}
+			l := int(binary.BigEndian.Uint16(b[offset:]))
+			offset += 2
+			if len(b) < offset+l {
+				return nil, 0, fmt.Errorf("failed to unmarshal BVLC-SC header option %x: %v", b, common.ErrTooShortToParse)
+			}
+			o.Data = append([]byte{}, b[offset:offset+l]...)
+			offset += l
+		}
+		options = append(options, o)
+		more = marker&scOptionMoreOptions != 0
+	}
+	return options, offset, nil
+}
+
+// MarshalBinary returns the byte sequence generated from a BVLCSC instance.
+func (m *BVLCSC) MarshalBinary() ([]byte, error) {
+	b := make([]byte, m.MarshalLen())
+	if err := m.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+// MarshalLen returns the serial length of BVLCSC.
+func (m *BVLCSC) MarshalLen() int {
+	l := bvlcsclen + len(m.Origin) + len(m.Destination)
+	l += scOptionsLen(m.DestOptions) + scOptionsLen(m.DataOptions)
+	return l + len(m.Payload)
+}
+
+func scOptionsLen(options []SCOption) int {
+	l := 0
+	for _, o := range options {
+		l++
+		if o.Data != nil {
+			l += 2 + len(o.Data)
+		}
+	}
+	return l
+}
+
+// MarshalTo puts the byte sequence in the byte array given as b.
+func (m *BVLCSC) MarshalTo(b []byte) error {
+	if len(b) < m.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal BVLC-SC - marshal length %d binary length %d: %v",
+			m.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	if m.Origin != nil && len(m.Origin) != SCVMACLen || m.Destination != nil && len(m.Destination) != SCVMACLen {
+		return fmt.Errorf(
+			"failed to marshal BVLC-SC VMACs %x %x: %v", m.Origin, m.Destination, common.ErrWrongStructure,
+		)
+	}
+
+	var flags uint8
+	if m.Origin != nil {
+		flags |= bvlcSCFlagOrigin
+	}
+	if m.Destination != nil {
+		flags |= bvlcSCFlagDestination
+	}
+	if len(m.DestOptions) > 0 {
+		flags |= bvlcSCFlagDestOptions
+	}
+	if len(m.DataOptions) > 0 {
+		flags |= bvlcSCFlagDataOptions
+	}
+	b[0] = m.Function
+	b[1] = flags
+	binary.BigEndian.PutUint16(b[2:4], m.MessageID)
+
+	offset := bvlcsclen
+	offset += copy(b[offset:], m.Origin)
+	offset += copy(b[offset:], m.Destination)
+	offset += marshalSCOptions(b[offset:], m.DestOptions)
+	offset += marshalSCOptions(b[offset:], m.DataOptions)
+	copy(b[offset:], m.Payload)
+
+	return nil
+}
+
+func marshalSCOptions(b []byte, options []SCOption) int {
+	offset := 0
+	for i, o := range options {
+		marker := o.Type & scOptionTypeMask
+		if i < len(options)-1 {
+			marker |= scOptionMoreOptions
+		}
+		if o.MustUnderstand {
+			marker |= scOptionMustUnderstand
+		}
+		if o.Data != nil {
+			marker |= scOptionHeaderData
+		}
+		b[offset] = marker
+		offset++
+		if o.Data != nil {
+			binary.BigEndian.PutUint16(b[offset:], uint16(len(o.Data)))
+			offset += 2
+			offset += copy(b[offset:], o.Data)
+		}
+	}
+	return offset
+}
+
+// SCConnect is the payload of a Connect-Request or of a Connect-Accept.
+type SCConnect struct {
+	VMAC       []byte
+	UUID       [SCUUIDLen]byte
+	MaxBVLCLen uint16
+	MaxNPDULen uint16
+}
+
+const scConnectLen = SCVMACLen + SCUUIDLen + 4
+
+// MarshalBinary returns the byte sequence generated from a SCConnect instance.
+func (c *SCConnect) MarshalBinary() ([]byte, error) {
+	if len(c.VMAC) != SCVMACLen {
+		return nil, fmt.Errorf("failed to marshal SC connect VMAC %x: %v", c.VMAC, common.ErrWrongStructure)
+	}
+	b := make([]byte, scConnectLen)
+	copy(b, c.VMAC)
+	copy(b[SCVMACLen:], c.UUID[:])
+	binary.BigEndian.PutUint16(b[SCVMACLen+SCUUIDLen:], c.MaxBVLCLen)
+	binary.BigEndian.PutUint16(b[SCVMACLen+SCUUIDLen+2:], c.MaxNPDULen)
+	return b, nil
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a SCConnect.
+func (c *SCConnect) UnmarshalBinary(b []byte) error {
+	if len(b) < scConnectLen {
+		return fmt.Errorf("failed to unmarshal SC connect %x: %v", b, common.ErrTooShortToParse)
+	}
+	c.VMAC = append([]byte{}, b[:SCVMACLen]...)
+	copy(c.UUID[:], b[SCVMACLen:])
+	c.MaxBVLCLen = binary.BigEndian.Uint16(b[SCVMACLen+SCUUIDLen:])
+	c.MaxNPDULen = binary.BigEndian.Uint16(b[SCVMACLen+SCUUIDLen+2:])
+	return nil
+}
+
+// SCAdvertisement is the payload of an Advertisement.
+type SCAdvertisement struct {
+	// HubStatus is 0 without hub connection, 1 when connected to the primary
+	// hub and 2 when connected to the failover hub.
+	HubStatus     uint8
+	AcceptsDirect bool
+	MaxBVLCLen    uint16
+	MaxNPDULen    uint16
+}
+
+// MarshalBinary returns the byte sequence generated from a SCAdvertisement instance.
+func (a *SCAdvertisement) MarshalBinary() ([]byte, error) {
+	b := []byte{a.HubStatus, uint8(common.BoolToInt(a.AcceptsDirect)), 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], a.MaxBVLCLen)
+	binary.BigEndian.PutUint16(b[4:], a.MaxNPDULen)
+	return b, nil
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a SCAdvertisement.
+func (a *SCAdvertisement) UnmarshalBinary(b []byte) error {
+	if len(b) < 6 {
+		return fmt.Errorf("failed to unmarshal SC advertisement %x: %v", b, common.ErrTooShortToParse)
+	}
+	a.HubStatus = b[0]
+	a.AcceptsDirect = b[1] == 1
+	a.MaxBVLCLen = binary.BigEndian.Uint16(b[2:])
+	a.MaxNPDULen = binary.BigEndian.Uint16(b[4:])
+	return nil
+}
+
+// SCResult is the payload of a BVLC-Result, answering a message of Function.
+// Only NAKs carry an error.
+type SCResult struct {
+	Function          uint8
+	NAK               bool
+	ErrorHeaderMarker uint8
+	ErrorClass        uint16
+	ErrorCode         uint16
+	Details           string
+}
+
+// MarshalBinary returns the byte sequence generated from a SCResult instance.
+func (r *SCResult) MarshalBinary() ([]byte, error) {
+	if !r.NAK {
+		return []byte{r.Function, 0}, nil
+	}
+	b := []byte{r.Function, 1, r.ErrorHeaderMarker, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(b[3:], r.ErrorClass)
+	binary.BigEndian.PutUint16(b[5:], r.ErrorCode)
+	return append(b, r.Details...), nil
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a SCResult.
+func (r *SCResult) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return fmt.Errorf("failed to unmarshal SC result %x: %v", b, common.ErrTooShortToParse)
+	}
+	*r = SCResult{Function: b[0], NAK: b[1] == 1}
+	if !r.NAK {
+		return nil
+	}
+	if len(b) < 7 {
+		return fmt.Errorf("failed to unmarshal SC result NAK %x: %v", b, common.ErrTooShortToParse)
+	}
+	r.ErrorHeaderMarker = b[2]
+	r.ErrorClass = binary.BigEndian.Uint16(b[3:])
+	r.ErrorCode = binary.BigEndian.Uint16(b[5:])
+	r.Details = string(b[7:])
+	return nil
+}
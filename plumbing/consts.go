@@ -12,9 +12,15 @@ const (
 	Abort
 )
 
-// APDU flags for confirmedRequest
+// APDU flags for confirmedRequest and complexACK
 const (
-	SA uint8 = (iota + 1) * 2
-	MoreSegments
-	SegmentedRequest
+	SA               uint8 = 0x02
+	MoreSegments     uint8 = 0x04
+	SegmentedRequest uint8 = 0x08
+)
+
+// APDU flags for segmentACK and abort
+const (
+	SRV uint8 = 0x01
+	NAK uint8 = 0x02
 )
@@ -12,11 +12,17 @@ type NPDU struct {
 	Version uint8
 	Control uint8
 	DNET    uint16
-	DLEN    uint8
-	SNET    uint16
-	SLEN    uint8
-	SADR    uint8
-	Hop     uint8
+	// DADR is the destination MAC address, its length being DLEN. An empty
+	// DADR broadcasts on DNET.
+	DADR []byte
+	SNET uint16
+	// SADR is the source MAC address, its length being SLEN.
+	SADR []byte
+	Hop  uint8
+	// MessageType is the network layer message type of network layer
+	// messages, proprietary ones from 0x80 on also carrying VendorID.
+	MessageType uint8
+	VendorID    uint16
 }
 
 // NewNPDU creates a NPDU.
@@ -38,39 +44,71 @@ func (n *NPDU) SetControlFlags(nsduContain bool, dstSpecifier bool, srcSpecifier
 
 // UnmarshalBinary sets the values retrieved from byte sequence in a NPDU frame.
 func (n *NPDU) UnmarshalBinary(b []byte) error {
-	if l := len(b); l < n.MarshalLen() {
+	if l := len(b); l < npduLenMin {
 		return fmt.Errorf(
-			"failed to unmarshal NPDU - marshal length %d binary length %d: %v",
-			n.MarshalLen(), l,
-			common.ErrTooShortToParse,
+			"failed to unmarshal NPDU - binary length %d: %v", l, common.ErrTooShortToParse,
 		)
 	}
 
 	n.Version = b[0]
 	n.Control = b[1]
+	n.DNET, n.DADR, n.SNET, n.SADR, n.Hop = 0, nil, 0, nil, 0
+	n.MessageType, n.VendorID = 0, 0
 
 	offset := 2
 	if n.flagDNET() {
+		if len(b) < offset+3 || len(b) < offset+3+int(b[offset+2]) {
+			return n.errTooShort(b)
+		}
 		n.DNET = binary.BigEndian.Uint16(b[offset : offset+2])
-		n.DLEN = b[offset+2]
+		dlen := int(b[offset+2])
 		offset += 3
+		n.DADR = append([]byte{}, b[offset:offset+dlen]...)
+		offset += dlen
 	}
 
 	if n.flagSNET() {
+		if len(b) < offset+3 || len(b) < offset+3+int(b[offset+2]) {
+			return n.errTooShort(b)
+		}
 		n.SNET = binary.BigEndian.Uint16(b[offset : offset+2])
-		n.SLEN = b[offset+2]
-		n.SADR = b[offset+3]
-		offset += 4
+		slen := int(b[offset+2])
+		offset += 3
+		n.SADR = append([]byte{}, b[offset:offset+slen]...)
+		offset += slen
 	}
 
 	if n.flagDNET() {
+		if len(b) < offset+1 {
+			return n.errTooShort(b)
+		}
 		n.Hop = b[offset]
 		offset++
 	}
 
+	if n.IsNetworkMessage() {
+		if len(b) < offset+1 {
+			return n.errTooShort(b)
+		}
+		n.MessageType = b[offset]
+		offset++
+		if n.MessageType >= 0x80 {
+			if len(b) < offset+2 {
+				return n.errTooShort(b)
+			}
+			n.VendorID = binary.BigEndian.Uint16(b[offset : offset+2])
+		}
+	}
+
 	return nil
 }
 
+func (n *NPDU) errTooShort(b []byte) error {
+	return fmt.Errorf(
+		"failed to unmarshal NPDU - control %x binary length %d: %v", n.Control, len(b), common.ErrTooShortToParse,
+	)
+}
+
 // MarshalTo puts the byte sequence in the byte array given as b.
 func (n *NPDU) MarshalTo(b []byte) error {
 	if len(b) < n.MarshalLen() {
@@ -81,6 +119,11 @@ func (n *NPDU) MarshalTo(b []byte) error {
 			common.ErrTooShortToMarshalBinary,
 		)
 	}
+	if len(n.DADR) > 0xFF || len(n.SADR) > 0xFF {
+		return fmt.Errorf(
+			"failed to marshall NPDU - DLEN %d SLEN %d: %v", len(n.DADR), len(n.SADR), common.ErrWrongStructure,
+		)
+	}
 
 	b[0] = n.Version
 	b[1] = n.Control
@@ -89,15 +132,16 @@ func (n *NPDU) MarshalTo(b []byte) error {
 
 	if n.flagDNET() {
 		binary.BigEndian.PutUint16(b[offset:offset+2], n.DNET)
-		b[offset+2] = n.DLEN
+		b[offset+2] = uint8(len(n.DADR))
 		offset += 3
+		offset += copy(b[offset:], n.DADR)
 	}
 
 	if n.flagSNET() {
 		binary.BigEndian.PutUint16(b[offset:offset+2], n.SNET)
-		b[offset+2] = n.SLEN
-		b[offset+3] = n.SADR
-		offset += 4
+		b[offset+2] = uint8(len(n.SADR))
+		offset += 3
+		offset += copy(b[offset:], n.SADR)
 	}
 
 	if n.flagDNET() {
@@ -105,6 +149,14 @@ func (n *NPDU) MarshalTo(b []byte) error {
 		offset++
 	}
 
+	if n.IsNetworkMessage() {
+		b[offset] = n.MessageType
+		offset++
+		if n.MessageType >= 0x80 {
+			binary.BigEndian.PutUint16(b[offset:offset+2], n.VendorID)
+		}
+	}
+
 	return nil
 }
 
@@ -112,17 +164,63 @@ const npduLenMin = 2
 
 // MarshalLen returns the serial length of NPDU.
 func (n *NPDU) MarshalLen() int {
-	len := npduLenMin
+	l := npduLenMin
 
 	if n.flagDNET() {
-		len += 4
+		l += 4 + len(n.DADR)
 	}
 
 	if n.flagSNET() {
-		len += 4
+		l += 3 + len(n.SADR)
 	}
 
-	return len
+	if n.IsNetworkMessage() {
+		l++
+		if n.MessageType >= 0x80 {
+			l += 2
+		}
+	}
+
+	return l
+}
+
+// SetDestination addresses the NPDU to dst through the BACnet routers, with
+// the maximum hop count.
+func (n *NPDU) SetDestination(dst BACnetAddress) {
+	n.Control |= 0x20
+	n.DNET = dst.Net
+	n.DADR = dst.Mac
+	n.Hop = 0xFF
+}
+
+// Destination returns the remote destination of the NPDU, if any.
+func (n *NPDU) Destination() (BACnetAddress, bool) {
+	if !n.flagDNET() {
+		return BACnetAddress{}, false
+	}
+	return BACnetAddress{Net: n.DNET, Mac: n.DADR}, true
+}
+
+// SetSource records src, the remote network and MAC address the NPDU comes
+// from. Only routers set the source.
+func (n *NPDU) SetSource(src BACnetAddress) {
+	n.Control |= 0x08
+	n.SNET = src.Net
+	n.SADR = src.Mac
+}
+
+// Source returns the remote source of the NPDU, if any.
+func (n *NPDU) Source() (BACnetAddress, bool) {
+	if !n.flagSNET() {
+		return BACnetAddress{}, false
+	}
+	return BACnetAddress{Net: n.SNET, Mac: n.SADR}, true
+}
+
+// IsNetworkMessage tells whether the NPDU carries a network layer message
+// instead of an APDU.
+func (n *NPDU) IsNetworkMessage() bool {
+	return (n.Control & 0x80) != 0
 }
 
 func (n *NPDU) flagDNET() bool {
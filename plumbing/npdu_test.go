@@ -19,7 +19,7 @@ func TestNPDUUnmarshall_Simple(t *testing.T) {
 	AssertEqual(t, uint8(0), npdu.Control)
 	// AssertEqual(t, uint16(0),  npdu.SNET)
 	AssertEqual(t, uint16(0), npdu.DNET)
-	AssertEqual(t, uint8(0), npdu.DLEN)
+	AssertEqual(t, 0, len(npdu.DADR))
 	AssertEqual(t, uint8(0), npdu.Hop)
 }
 
@@ -34,7 +34,7 @@ func TestNPDUUnmarshall_WithDNET(t *testing.T) {
 	AssertEqual(t, uint8(0x20), npdu.Control)
 	// AssertEqual(t, uint16(0),  npdu.SNET)
 	AssertEqual(t, uint16(0xffff), npdu.DNET)
-	AssertEqual(t, uint8(0), npdu.DLEN)
+	AssertEqual(t, 0, len(npdu.DADR))
 	AssertEqual(t, uint8(0xff), npdu.Hop)
 }
 
@@ -48,10 +48,9 @@ func TestNPDUUnmarshall_WithSNET(t *testing.T) {
 	AssertEqual(t, uint8(1), npdu.Version)
 	AssertEqual(t, uint8(0x08), npdu.Control)
 	AssertEqual(t, uint16(0x0008), npdu.SNET)
-	AssertEqual(t, uint8(1), npdu.SLEN)
-	AssertEqual(t, uint8(8), npdu.SADR)
+	AssertEqual(t, []byte{8}, npdu.SADR)
 	AssertEqual(t, uint16(0), npdu.DNET)
-	AssertEqual(t, uint8(0), npdu.DLEN)
+	AssertEqual(t, 0, len(npdu.DADR))
 	AssertEqual(t, uint8(0), npdu.Hop)
 }
 
@@ -65,10 +64,9 @@ func TestNPDUUnmarshall_WithSNETAndDNET(t *testing.T) {
 	AssertEqual(t, uint8(1), npdu.Version)
 	AssertEqual(t, uint8(0x28), npdu.Control)
 	AssertEqual(t, uint16(0x0008), npdu.SNET)
-	AssertEqual(t, uint8(1), npdu.SLEN)
-	AssertEqual(t, uint8(24), npdu.SADR)
+	AssertEqual(t, []byte{24}, npdu.SADR)
 	AssertEqual(t, uint16(0xffff), npdu.DNET)
-	AssertEqual(t, uint8(0), npdu.DLEN)
+	AssertEqual(t, 0, len(npdu.DADR))
 	AssertEqual(t, uint8(0xfe), npdu.Hop)
 }
 
@@ -77,10 +75,8 @@ func TestNPDUMarshall_WithSNETAndDNET(t *testing.T) {
 
 	npdu.Control = 0x28
 	npdu.SNET = 0x0008
-	npdu.SLEN = 1
-	npdu.SADR = 24
+	npdu.SADR = []byte{24}
 	npdu.DNET = 0xffff
-	npdu.DLEN = 0
 	npdu.Hop = 0xfe
 
 	// This NPDU is version 1, snet and dnet (control flag 0x28)
@@ -95,3 +91,38 @@ func TestNPDUMarshall_WithSNETAndDNET(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, b)
 	}
 }
+
+func TestNPDUMarshall_WithDADRAndSADR(t *testing.T) {
+	npdu := plumbing.NewNPDU(false, false, false, true)
+	npdu.SetDestination(plumbing.NewBACnetAddress(2001, []byte{0x0a}))
+	npdu.SetSource(plumbing.NewBACnetAddress(5, []byte{0xc0, 0xa8, 0x01, 0x02, 0xba, 0xc0}))
+
+	b := make([]byte, npdu.MarshalLen())
+	if err := npdu.MarshalTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0x1, 0x2c, 0x07, 0xd1, 0x1, 0x0a,
+		0x0, 0x05, 0x6, 0xc0, 0xa8, 0x01, 0x02, 0xba, 0xc0, 0xff,
+	}
+	if !bytes.Equal(expected, b) {
+		t.Errorf("Expected %v, got %v", expected, b)
+	}
+
+	decoded := plumbing.NewNPDU(false, false, false, false)
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	dst, ok := decoded.Destination()
+	AssertEqual(t, true, ok)
+	AssertEqual(t, plumbing.NewBACnetAddress(2001, []byte{0x0a}), dst)
+	src, ok := decoded.Source()
+	AssertEqual(t, true, ok)
+	AssertEqual(t, []byte{0xc0, 0xa8, 0x01, 0x02, 0xba, 0xc0}, src.Mac)
+	AssertEqual(t, uint8(0xff), decoded.Hop)
+
+	if err := decoded.UnmarshalBinary(b[:10]); err == nil {
+		t.Error("expected error unmarshalling truncated NPDU")
+	}
+}
@@ -0,0 +1,92 @@
+package plumbing
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// maxAPDULengths maps the max-APDU-length-accepted field of a ConfirmedReq to octets.
+var maxAPDULengths = []int{50, 128, 206, 480, 1024, 1476}
+
+// MaxAPDULength returns the APDU length in octets encoded in the MaxSize field of a ConfirmedReq.
+func MaxAPDULength(maxSize uint8) int {
+	if int(maxSize) >= len(maxAPDULengths) {
+		return maxAPDULengths[0]
+	}
+	return maxAPDULengths[maxSize]
+}
+
+// MaxSegments returns the number of segments encoded in the MaxSeg field of a
+// ConfirmedReq, being 0 unspecified or more than 64 segments.
+func MaxSegments(maxSeg uint8) int {
+	if maxSeg == 0 || maxSeg >= 7 {
+		return 0
+	}
+	return 1 << maxSeg
+}
+
+// Segments splits a ConfirmedReq or ComplexAck APDU into segments whose serial
+// length does not exceed maxLen, all of them proposing windowSize.
+func (a *APDU) Segments(maxLen int, windowSize uint8) ([]*APDU, error) {
+	if a.Type != ConfirmedReq && a.Type != ComplexAck {
+		return nil, fmt.Errorf("segmenting APDU type %d: %v", a.Type, common.ErrNotImplemented)
+	}
+
+	data := make([]byte, 0, a.MarshalLen())
+	for _, o := range a.Objects {
+		ob, err := o.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("segmenting APDU: %v", err)
+		}
+		data = append(data, ob...)
+	}
+
+	seg := *a
+	seg.Flags |= SegmentedRequest
+	seg.Objects = nil
+	chunk := maxLen - seg.headerLen()
+	if chunk <= 0 {
+		return nil, fmt.Errorf("segmenting APDU into %d octets: %v", maxLen, common.ErrTooShortToMarshalBinary)
+	}
+
+	segments := []*APDU{}
+	for i := 0; i == 0 || i*chunk < len(data); i++ {
+		s := seg
+		s.SequenceNumber = uint8(i)
+		s.WindowSize = windowSize
+		if end := (i + 1) * chunk; end < len(data) {
+			s.Flags |= MoreSegments
+			s.Segment = data[i*chunk : end]
+		} else {
+			s.Segment = data[i*chunk:]
+		}
+		segments = append(segments, &s)
+	}
+
+	return segments, nil
+}
+
+// Reassemble decodes the service data collected from every segment of a
+// segmented APDU, whose first segment is given as first.
+func Reassemble(first *APDU, data []byte) (*APDU, error) {
+	a := &APDU{
+		Type:     first.Type,
+		Flags:    first.Flags &^ (SegmentedRequest | MoreSegments),
+		MaxSeg:   first.MaxSeg,
+		MaxSize:  first.MaxSize,
+		InvokeID: first.InvokeID,
+		Service:  first.Service,
+	}
+
+	b := make([]byte, a.headerLen()+len(data))
+	if err := a.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("reassembling APDU: %v", err)
+	}
+	copy(b[a.headerLen():], data)
+
+	if err := a.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("reassembling APDU: %v", err)
+	}
+	return a, nil
+}
@@ -0,0 +1,383 @@
+// Package router implements a BACnet router between BACnet/IP ports, each
+// attached to a BACnet network of its own.
+package router
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+const maxFrameLen = 1 << 16
+
+// Port is a BACnet/IP port of a Router.
+type Port struct {
+	// Network is the number of the network the port is attached to.
+	Network uint16
+	// Conn is the socket of the port, bound to its B/IP address.
+	Conn net.PacketConn
+	// Broadcast is the B/IP broadcast address of the network. Several ports
+	// can run on a single host by giving each of them a loopback address and
+	// a broadcast address where the devices of its simulated network listen.
+	Broadcast *net.UDPAddr
+}
+
+// Route is an entry of the routing table, reaching Network through the
+// router at NextHop on the network of Port.
+type Route struct {
+	Network uint16
+	Port    uint16
+	NextHop *net.UDPAddr
+}
+
+// Router forwards the NPDUs between its ports, answers Who-Is-Router-To-Network,
+// passing on those for unknown networks to its other ports, and learns the
+// networks behind other routers from their I-Am-Router-To-Network.
+// It is safe for concurrent use.
+type Router struct {
+	ports []*Port
+
+	mu     sync.Mutex
+	routes map[uint16]Route
+}
+
+// New creates a Router between ports, whose networks must be distinct.
+func New(ports ...Port) (*Router, error) {
+	r := &Router{routes: map[uint16]Route{}}
+	for i := range ports {
+		p := ports[i]
+		if p.Network == plumbing.LocalNetwork || p.Network == plumbing.GlobalNetwork {
+			return nil, fmt.Errorf("port network %d: %v", p.Network, common.ErrWrongStructure)
+		}
+		if r.port(p.Network) != nil {
+			return nil, fmt.Errorf("duplicate port network %d: %v", p.Network, common.ErrWrongStructure)
+		}
+		r.ports = append(r.ports, &p)
+	}
+	return r, nil
+}
+
+// Serve announces the networks reachable through every port with an
+// I-Am-Router-To-Network and routes the messages received on the ports until
+// they are closed.
+func (r *Router) Serve() error {
+	for _, p := range r.ports {
+		r.announce(p, nil)
+	}
+
+	errs := make(chan error, len(r.ports))
+	for _, p := range r.ports {
+		go func(p *Port) {
+			errs <- r.serve(p)
+		}(p)
+	}
+	var err error
+	for range r.ports {
+		if e := <-errs; err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Close closes the sockets of every port.
+func (r *Router) Close() error {
+	var err error
+	for _, p := range r.ports {
+		if e := p.Conn.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Routes returns the routing table learnt from other routers, sorted by network.
+func (r *Router) Routes() []Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	routes := make([]Route, 0, len(r.routes))
+	for _, rt := range r.routes {
+		routes = append(routes, rt)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Network < routes[j].Network })
+	return routes
+}
+
+func (r *Router) serve(p *Port) error {
+	buf := make([]byte, maxFrameLen)
+	for {
+		n, addr, err := p.Conn.ReadFrom(buf)
+		if err != nil {
+			return fmt.Errorf("reading network %d: %v", p.Network, err)
+		}
+		src, ok := addr.(*net.UDPAddr)
+		if !ok || r.isSelf(src) {
+			continue
+		}
+		msg := make([]byte, n)
+		copy(msg, buf[:n])
+		r.handle(p, src, msg)
+	}
+}
+
+func (r *Router) handle(p *Port, src *net.UDPAddr, b []byte) {
+	var bvlc plumbing.BVLC
+	var npdu plumbing.NPDU
+
+	if err := bvlc.UnmarshalBinary(b); err != nil || bvlc.Type != plumbing.BVLCType || !bvlc.CarriesNPDU() {
+		return
+	}
+	if bvlc.Origin != nil {
+		// Our own broadcasts may come back forwarded by a BBMD.
+		if r.isSelf(bvlc.Origin) {
+			return
+		}
+		src = bvlc.Origin
+	}
+	offset := bvlc.MarshalLen()
+	if err := npdu.UnmarshalBinary(b[offset:]); err != nil {
+		return
+	}
+	offset += npdu.MarshalLen()
+	payload := b[offset:]
+
+	if npdu.IsNetworkMessage() {
+		if r.handleNetworkMessage(p, src, &npdu, payload) {
+			return
+		}
+	}
+
+	dst, ok := npdu.Destination()
+	if !ok {
+		// Messages to the local network are for the devices on it.
+		return
+	}
+	if npdu.Hop == 0 {
+		return
+	}
+	npdu.Hop--
+	origin, remote := npdu.Source()
+	if !remote {
+		npdu.SetSource(plumbing.NewBACnetIPAddress(p.Network, src))
+	}
+
+	if dst.Net == plumbing.GlobalNetwork {
+		for _, q := range r.ports {
+			if q != p {
+				r.send(q, q.Broadcast, &npdu, payload)
+			}
+		}
+		return
+	}
+
+	if q := r.port(dst.Net); q != nil {
+		if q == p {
+			return
+		}
+		// The message reaches its network, where it is sent without destination.
+		to := q.Broadcast
+		if !dst.IsBroadcast() {
+			if len(dst.Mac) != plumbing.BIPAddressLen {
+				return
+			}
+			to = plumbing.DecodeBIPAddress(dst.Mac)
+		}
+		npdu.Control &^= 0x20
+		npdu.DNET, npdu.DADR, npdu.Hop = 0, nil, 0
+		r.send(q, to, &npdu, payload)
+		return
+	}
+
+	r.mu.Lock()
+	rt, ok := r.routes[dst.Net]
+	r.mu.Unlock()
+	if !ok || rt.Port == p.Network {
+		r.reject(p, src, origin, remote, services.NetworkRejectUnknownNetwork, dst.Net)
+		return
+	}
+	r.send(r.port(rt.Port), rt.NextHop, &npdu, payload)
+}
+
+// handleNetworkMessage handles the network layer messages for the router,
+// telling whether the message was consumed.
+func (r *Router) handleNetworkMessage(p *Port, src *net.UDPAddr, npdu *plumbing.NPDU, data []byte) bool {
+	if _, ok := npdu.Destination(); ok {
+		return false
+	}
+	msg := services.NetworkMessage{NPDU: npdu, Data: data}
+	dec, err := msg.Decode()
+	if err != nil {
+		return true
+	}
+
+	switch npdu.MessageType {
+	case services.NetworkMessageWhoIsRouterToNetwork:
+		if len(dec.Networks) == 0 {
+			r.announce(p, src)
+			return true
+		}
+		n := dec.Networks[0]
+		if r.reachable(p, n) {
+			r.sendNetworkMessage(p, src, services.NetworkMessageIAmRouterToNetwork,
+				services.NetworksData([]uint16{n}))
+			return true
+		}
+		if r.known(n) {
+			return true
+		}
+		// The routers on the other networks may reach an unknown network, and
+		// their I-Am-Router-To-Network teaches it to us (Clause 6.6.3.2).
+		if _, ok := npdu.Source(); !ok {
+			npdu.SetSource(plumbing.NewBACnetIPAddress(p.Network, src))
+		}
+		for _, q := range r.ports {
+			if q != p {
+				r.send(q, q.Broadcast, npdu, data)
+			}
+		}
+	case services.NetworkMessageIAmRouterToNetwork:
+		// Only new or changed routes are announced, lest the routers sharing
+		// a network keep on announcing them to each other.
+		var learnt []uint16
+		r.mu.Lock()
+		for _, n := range dec.Networks {
+			if r.port(n) != nil {
+				continue
+			}
+			if rt, ok := r.routes[n]; ok && rt.Port == p.Network && sameAddr(rt.NextHop, src) {
+				continue
+			}
+			r.routes[n] = Route{Network: n, Port: p.Network, NextHop: src}
+			learnt = append(learnt, n)
+		}
+		r.mu.Unlock()
+		// Tell the other networks they reach the learnt networks through us.
+		for _, q := range r.ports {
+			if q != p && len(learnt) > 0 {
+				r.sendNetworkMessage(q, q.Broadcast, services.NetworkMessageIAmRouterToNetwork,
+					services.NetworksData(learnt))
+			}
+		}
+	case services.NetworkMessageWhatIsNetworkNumber:
+		r.sendNetworkMessage(p, src, services.NetworkMessageNetworkNumberIs,
+			services.NetworkNumberIsData(p.Network, true))
+	}
+	return true
+}
+
+// announce sends to dst, or broadcasts on p when dst is nil, an
+// I-Am-Router-To-Network for every network reachable through the other ports.
+func (r *Router) announce(p *Port, dst *net.UDPAddr) {
+	var networks []uint16
+	for _, q := range r.ports {
+		if q != p {
+			networks = append(networks, q.Network)
+		}
+	}
+	for _, rt := range r.Routes() {
+		if rt.Port != p.Network {
+			networks = append(networks, rt.Network)
+		}
+	}
+	if len(networks) == 0 {
+		return
+	}
+	if dst == nil {
+		dst = p.Broadcast
+	}
+	r.sendNetworkMessage(p, dst, services.NetworkMessageIAmRouterToNetwork, services.NetworksData(networks))
+}
+
+// reachable tells whether network n is reachable through a port other than p.
+func (r *Router) reachable(p *Port, n uint16) bool {
+	if q := r.port(n); q != nil {
+		return q != p
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rt, ok := r.routes[n]
+	return ok && rt.Port != p.Network
+}
+
+// known tells whether network n is attached to a port or in the routing table.
+func (r *Router) known(n uint16) bool {
+	if r.port(n) != nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.routes[n]
+	return ok
+}
+
+// reject sends a Reject-Message-To-Network to the originator of a message
+// received from src, routing it back through src to origin when remote.
+func (r *Router) reject(p *Port, src *net.UDPAddr, origin plumbing.BACnetAddress, remote bool, reason uint8, network uint16) {
+	npdu := plumbing.NewNPDU(true, false, false, false)
+	npdu.MessageType = services.NetworkMessageRejectMessageToNetwork
+	if remote {
+		npdu.SetDestination(origin)
+	}
+	r.send(p, src, npdu, services.RejectMessageData(reason, network))
+}
+
+func (r *Router) sendNetworkMessage(p *Port, dst *net.UDPAddr, messageType uint8, data []byte) {
+	npdu := plumbing.NewNPDU(true, false, false, false)
+	npdu.MessageType = messageType
+	r.send(p, dst, npdu, data)
+}
+
+// send writes npdu followed by payload to dst on the network of p, as a
+// broadcast when dst is the broadcast address of p.
+func (r *Router) send(p *Port, dst *net.UDPAddr, npdu *plumbing.NPDU, payload []byte) {
+	if p == nil || dst == nil {
+		return
+	}
+	f := uint8(plumbing.BVLCFuncUnicast)
+	if dst == p.Broadcast {
+		f = plumbing.BVLCFuncBroadcast
+	}
+	bvlc := plumbing.NewBVLC(f)
+
+	b := make([]byte, bvlc.MarshalLen()+npdu.MarshalLen()+len(payload))
+	bvlc.Length = uint16(len(b))
+	if err := bvlc.MarshalTo(b); err != nil {
+		return
+	}
+	if err := npdu.MarshalTo(b[bvlc.MarshalLen():]); err != nil {
+		return
+	}
+	copy(b[bvlc.MarshalLen()+npdu.MarshalLen():], payload)
+
+	p.Conn.WriteTo(b, dst)
+}
+
+// isSelf tells whether addr is the address of one of the ports.
+func (r *Router) isSelf(addr *net.UDPAddr) bool {
+	for _, p := range r.ports {
+		if a, ok := p.Conn.LocalAddr().(*net.UDPAddr); ok && sameAddr(a, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameAddr(a, b *net.UDPAddr) bool {
+	return a != nil && b != nil && a.IP.Equal(b.IP) && a.Port == b.Port
+}
+
+// port returns the port attached to network n, if any.
+func (r *Router) port(n uint16) *Port {
+	for _, p := range r.ports {
+		if p.Network == n {
+			return p
+		}
+	}
+	return nil
+}
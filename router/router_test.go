@@ -0,0 +1,351 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+	. "github.com/Nortech-ai/bacnet/test_utils"
+)
+
+func newClient(t *testing.T, ip string) *bacnet.Client {
+	c := bacnet.NewClient(Listen(t, ip))
+	c.APDUTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// newRouter runs a router between networks 1 and 2, with 127.0.0.1 and
+// 127.0.0.2 as its ports and 127.0.0.11 and 127.0.0.12 as their broadcast
+// addresses, returning the ports along with the sockets at the latter.
+func newRouter(t *testing.T) (*Router, [2]net.PacketConn) {
+	var ports []Port
+	var broadcasts [2]net.PacketConn
+	for i := range broadcasts {
+		broadcasts[i] = Listen(t, fmt.Sprintf("127.0.0.%d", 11+i))
+		ports = append(ports, Port{
+			Network:   uint16(1 + i),
+			Conn:      Listen(t, fmt.Sprintf("127.0.0.%d", 1+i)),
+			Broadcast: broadcasts[i].LocalAddr().(*net.UDPAddr),
+		})
+	}
+	r, err := New(ports...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go r.Serve()
+	return r, broadcasts
+}
+
+func TestRouteReadProperty(t *testing.T) {
+	r, _ := newRouter(t)
+
+	device := newClient(t, "127.0.0.3")
+	device.Handle(func(addr net.Addr, msg plumbing.BACnet) {
+		req, ok := msg.(*services.ConfirmedReadProperty)
+		if !ok {
+			return
+		}
+		if _, ok := addr.(*bacnet.RoutedAddr); !ok {
+			t.Errorf("expected request from network 1, got %s", addr)
+		}
+		reply, err := bacnet.NewCACK(services.ServiceConfirmedReadProperty,
+			objects.ObjectTypeAnalogValue, 1, objects.PropertyIdPresentValue, float32(42))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		reply[7] = req.APDU.InvokeID
+		device.Send(addr, reply)
+	})
+
+	c := newClient(t, "127.0.0.4")
+	addr := &bacnet.RoutedAddr{
+		Router: r.ports[0].Conn.LocalAddr(),
+		Dst:    plumbing.NewBACnetIPAddress(2, device.LocalAddr().(*net.UDPAddr)),
+	}
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 1}
+	value, err := c.ReadProperty(context.Background(), addr, oid, objects.PropertyIdPresentValue, objects.ArrayAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != float32(42) {
+		t.Errorf("expected 42, got %v", value)
+	}
+}
+
+func TestRouteGlobalBroadcast(t *testing.T) {
+	r, broadcasts := newRouter(t)
+
+	whois, err := bacnet.NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	whois, err = bacnet.Route(whois, plumbing.GlobalBroadcast())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := Listen(t, "127.0.0.5")
+	sender.WriteTo(whois, r.ports[0].Conn.LocalAddr())
+
+	var msg plumbing.BACnet
+	// Skip the I-Am-Router-To-Network the router starts with.
+	for {
+		msg = Receive(t, broadcasts[1], bacnet.Parse)
+		if _, ok := msg.(*services.NetworkMessage); !ok {
+			break
+		}
+	}
+	w, ok := msg.(*services.UnconfirmedWhoIs)
+	if !ok {
+		t.Fatalf("expected WhoIs, got %T", msg)
+	}
+	src, ok := w.NPDU.Source()
+	if !ok || src.Net != 1 || src.String() != plumbing.NewBACnetIPAddress(1, sender.LocalAddr().(*net.UDPAddr)).String() {
+		t.Errorf("unexpected source %v", src)
+	}
+	if w.NPDU.Hop != 0xfe {
+		t.Errorf("expected hop count 254, got %d", w.NPDU.Hop)
+	}
+}
+
+func TestRouterNetworkMessages(t *testing.T) {
+	r, _ := newRouter(t)
+	conn := Listen(t, "127.0.0.6")
+	port1 := r.ports[0].Conn.LocalAddr()
+
+	// Another router on network 1 reaches network 5.
+	iam, err := bacnet.NewIAmRouterToNetwork([]uint16{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteTo(iam, port1)
+
+	whois, err := bacnet.NewWhoIsRouterToNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(r.Routes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if routes := r.Routes(); len(routes) != 1 || routes[0].Network != 5 || routes[0].Port != 1 {
+		t.Fatalf("unexpected routes %+v", routes)
+	}
+
+	// Only network 2 is reachable through the router from network 1.
+	conn.WriteTo(whois, port1)
+	m, ok := Receive(t, conn, bacnet.Parse).(*services.NetworkMessage)
+	if !ok || m.GetType() != services.NetworkMessageIAmRouterToNetwork {
+		t.Fatalf("expected I-Am-Router-To-Network, got %v", m)
+	}
+	dec, err := m.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Networks) != 1 || dec.Networks[0] != 2 {
+		t.Errorf("expected network 2, got %v", dec.Networks)
+	}
+
+	// Network 7 is unknown.
+	sack, err := bacnet.NewSACK(services.ServiceConfirmedWriteProperty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sack, err = bacnet.Route(sack, plumbing.NewBACnetAddress(7, []byte{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteTo(sack, port1)
+	m, ok = Receive(t, conn, bacnet.Parse).(*services.NetworkMessage)
+	if !ok || m.GetType() != services.NetworkMessageRejectMessageToNetwork {
+		t.Fatalf("expected Reject-Message-To-Network, got %v", m)
+	}
+	if dec, err := m.Decode(); err != nil || dec.Reason != services.NetworkRejectUnknownNetwork {
+		t.Errorf("expected unknown network, got %+v %v", dec, err)
+	}
+}
+
+func TestRouterWhoIsUnknownNetwork(t *testing.T) {
+	r, broadcasts := newRouter(t)
+	conn := Listen(t, "127.0.0.6")
+
+	// Skip the I-Am-Router-To-Network sent by the router as it starts.
+	for _, b := range broadcasts {
+		Receive(t, b, bacnet.Parse)
+	}
+
+	whois, err := bacnet.NewWhoIsRouterToNetwork(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.WriteTo(whois, r.ports[0].Conn.LocalAddr())
+	m, ok := Receive(t, broadcasts[1], bacnet.Parse).(*services.NetworkMessage)
+	if !ok || m.GetType() != services.NetworkMessageWhoIsRouterToNetwork {
+		t.Fatalf("expected Who-Is-Router-To-Network on network 2, got %v", m)
+	}
+	if src, ok := m.NPDU.Source(); !ok || src.Net != 1 {
+		t.Errorf("expected a source on network 1, got %+v", src)
+	}
+	if dec, err := m.Decode(); err != nil || len(dec.Networks) != 1 || dec.Networks[0] != 9 {
+		t.Errorf("expected network 9, got %+v %v", dec, err)
+	}
+
+	// A router on network 2 answers, and network 1 learns about it.
+	iam, err := bacnet.NewIAmRouterToNetwork([]uint16{9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Listen(t, "127.0.0.7").WriteTo(iam, r.ports[1].Conn.LocalAddr())
+	m, ok = Receive(t, broadcasts[0], bacnet.Parse).(*services.NetworkMessage)
+	if !ok || m.GetType() != services.NetworkMessageIAmRouterToNetwork {
+		t.Fatalf("expected I-Am-Router-To-Network on network 1, got %v", m)
+	}
+	if dec, err := m.Decode(); err != nil || len(dec.Networks) != 1 || dec.Networks[0] != 9 {
+		t.Errorf("expected network 9, got %+v %v", dec, err)
+	}
+}
+
+// expectNothing fails when conn receives a message within 200 ms.
+func expectNothing(t *testing.T, conn net.PacketConn) {
+	t.Helper()
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, _, err := conn.ReadFrom(buf); err == nil {
+		msg, _ := bacnet.Parse(buf[:n])
+		t.Errorf("unexpected message %+v", msg)
+	}
+}
+
+// relay runs the network of segment, a socket where every member broadcasts:
+// the broadcasts are forwarded to every member, their sender included, as a
+// BBMD does.
+func relay(segment net.PacketConn, members ...net.Addr) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := segment.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		fwd, err := bacnet.SetBVLCFunction(buf[:n], plumbing.BVLCFuncForwardedNPDU, addr.(*net.UDPAddr))
+		if err != nil {
+			continue
+		}
+		for _, m := range members {
+			segment.WriteTo(fwd, m)
+		}
+	}
+}
+
+func TestRoutersSharingNetwork(t *testing.T) {
+	// Routers a and b share network 1, a reaching network 2 and b network 3.
+	segment := Listen(t, "127.0.0.21")
+	newSharing := func(network uint16, ip, otherIP, broadcastIP string) (*Router, net.PacketConn) {
+		broadcast := Listen(t, broadcastIP)
+		r, err := New(
+			Port{Network: 1, Conn: Listen(t, ip), Broadcast: segment.LocalAddr().(*net.UDPAddr)},
+			Port{Network: network, Conn: Listen(t, otherIP), Broadcast: broadcast.LocalAddr().(*net.UDPAddr)},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r, broadcast
+	}
+	a, broadcastA := newSharing(2, "127.0.0.1", "127.0.0.2", "127.0.0.12")
+	b, broadcastB := newSharing(3, "127.0.0.3", "127.0.0.4", "127.0.0.14")
+	// peer is a third router on network 1.
+	peer := Listen(t, "127.0.0.5")
+	go relay(segment, a.ports[0].Conn.LocalAddr(), b.ports[0].Conn.LocalAddr(), peer.LocalAddr())
+	go a.Serve()
+	go b.Serve()
+
+	iam, err := bacnet.NewIAmRouterToNetwork([]uint16{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same announcement twice only teaches the routers once.
+	peer.WriteTo(iam, segment.LocalAddr())
+	peer.WriteTo(iam, segment.LocalAddr())
+	deadline := time.Now().Add(time.Second)
+	for len(a.Routes()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	routes := a.Routes()
+	if len(routes) != 2 || routes[0].Network != 3 || routes[0].Port != 1 || routes[0].NextHop.String() != b.ports[0].Conn.LocalAddr().String() ||
+		routes[1].Network != 5 || routes[1].NextHop.String() != peer.LocalAddr().String() {
+		t.Fatalf("unexpected routes %+v", routes)
+	}
+	// Network 2 hears of every other network once.
+	announced := map[uint16]int{}
+	for i := 0; i < 3; i++ {
+		m, ok := Receive(t, broadcastA, bacnet.Parse).(*services.NetworkMessage)
+		if !ok || m.GetType() != services.NetworkMessageIAmRouterToNetwork {
+			t.Fatalf("expected I-Am-Router-To-Network, got %v", m)
+		}
+		dec, err := m.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range dec.Networks {
+			announced[n]++
+		}
+	}
+	if len(announced) != 3 || announced[1] != 1 || announced[3] != 1 || announced[5] != 1 {
+		t.Errorf("expected networks 1, 3 and 5 announced once, got %v", announced)
+	}
+
+	// A global broadcast from network 2 reaches network 3 through both
+	// routers, and never comes back to network 2.
+	whois, err := bacnet.NewWhois()
+	if err != nil {
+		t.Fatal(err)
+	}
+	whois, err = bacnet.Route(whois, plumbing.GlobalBroadcast())
+	if err != nil {
+		t.Fatal(err)
+	}
+	Listen(t, "127.0.0.6").WriteTo(whois, a.ports[1].Conn.LocalAddr())
+	var msg plumbing.BACnet
+	for {
+		msg = Receive(t, broadcastB, bacnet.Parse)
+		if _, ok := msg.(*services.NetworkMessage); !ok {
+			break
+		}
+	}
+	if w, ok := msg.(*services.UnconfirmedWhoIs); !ok {
+		t.Errorf("expected WhoIs, got %T", msg)
+	} else if src, ok := w.NPDU.Source(); !ok || src.Net != 2 {
+		t.Errorf("expected a source on network 2, got %v", src)
+	}
+	expectNothing(t, broadcastA)
+
+	// Messages to unknown networks from remote networks are rejected to their
+	// originator.
+	npdu := plumbing.NewNPDU(false, false, false, false)
+	npdu.SetDestination(plumbing.NewBACnetAddress(7, nil))
+	npdu.SetSource(plumbing.NewBACnetAddress(9, []byte{0x2a}))
+	routed := services.NewUnconfirmedWhoIs(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu)
+	routed.SetLength()
+	req, err := routed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer.WriteTo(req, a.ports[0].Conn.LocalAddr())
+	for {
+		m, ok := Receive(t, peer, bacnet.Parse).(*services.NetworkMessage)
+		if !ok || m.GetType() != services.NetworkMessageRejectMessageToNetwork {
+			continue
+		}
+		if dst, ok := m.NPDU.Destination(); !ok || dst.Net != 9 || !bytes.Equal(dst.Mac, []byte{0x2a}) {
+			t.Errorf("expected a reject to 9:2a, got %+v", m.NPDU)
+		}
+		break
+	}
+}
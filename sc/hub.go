@@ -0,0 +1,210 @@
+package sc
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// connectTimeout bounds the wait for the Connect-Request of a new node.
+const connectTimeout = 10 * time.Second
+
+// Hub is a minimal BACnet/SC hub function, forwarding the messages between the
+// nodes connected to it. It is an http.Handler to be served over TLS, such as
+// with http.ListenAndServeTLS. It is safe for concurrent use.
+type Hub struct {
+	vmac []byte
+	uuid [plumbing.SCUUIDLen]byte
+
+	mu    sync.Mutex
+	nodes map[string]*hubConn
+}
+
+type hubConn struct {
+	ws   *wsConn
+	vmac []byte
+	uuid [plumbing.SCUUIDLen]byte
+}
+
+// NewHub creates a Hub with the virtual MAC address vmac and the device UUID uuid.
+func NewHub(vmac []byte, uuid [plumbing.SCUUIDLen]byte) *Hub {
+	return &Hub{
+		vmac:  append([]byte{}, vmac...),
+		uuid:  uuid,
+		nodes: map[string]*hubConn{},
+	}
+}
+
+// Nodes returns the virtual MAC addresses of the connected nodes, sorted.
+func (h *Hub) Nodes() [][]byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	vmacs := make([][]byte, 0, len(h.nodes))
+	for _, c := range h.nodes {
+		vmacs = append(vmacs, c.vmac)
+	}
+	sort.Slice(vmacs, func(i, j int) bool { return bytes.Compare(vmacs[i], vmacs[j]) < 0 })
+	return vmacs
+}
+
+// ServeHTTP accepts the WebSocket connection of a node and forwards its
+// messages until it disconnects.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := acceptWebSocket(w, r, plumbing.SCHubProtocol)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	c, ok := h.accept(ws)
+	if !ok {
+		return
+	}
+	defer h.unregister(c)
+
+	for {
+		b, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var m plumbing.BVLCSC
+		if err := m.UnmarshalBinary(b); err != nil {
+			continue
+		}
+		if !h.handle(c, &m) {
+			return
+		}
+	}
+}
+
+// accept waits for the Connect-Request of a node and registers it, replacing
+// an earlier connection of the same node.
+func (h *Hub) accept(ws *wsConn) (*hubConn, bool) {
+	ws.conn.SetReadDeadline(time.Now().Add(connectTimeout))
+	b, err := ws.ReadMessage()
+	ws.conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return nil, false
+	}
+	var m plumbing.BVLCSC
+	if err := m.UnmarshalBinary(b); err != nil || m.Function != plumbing.BVLCSCFuncConnectRequest {
+		return nil, false
+	}
+	var req plumbing.SCConnect
+	if err := req.UnmarshalBinary(m.Payload); err != nil {
+		h.send(ws, &m, plumbing.BVLCSCFuncResult, nakPayload(&m, plumbing.SCErrorCodeMessageIncomplete))
+		return nil, false
+	}
+	c := &hubConn{ws: ws, vmac: req.VMAC, uuid: req.UUID}
+
+	h.mu.Lock()
+	old, ok := h.nodes[string(c.vmac)]
+	duplicate := ok && old.uuid != c.uuid ||
+		sameVMAC(c.vmac, h.vmac) || sameVMAC(c.vmac, plumbing.SCBroadcastVMAC())
+	if !duplicate {
+		h.nodes[string(c.vmac)] = c
+	}
+	h.mu.Unlock()
+
+	if duplicate {
+		h.send(ws, &m, plumbing.BVLCSCFuncResult, nakPayload(&m, plumbing.SCErrorCodeNodeDuplicateVMAC))
+		return nil, false
+	}
+	if ok {
+		old.ws.Close()
+	}
+
+	accept := &plumbing.SCConnect{
+		VMAC:       h.vmac,
+		UUID:       h.uuid,
+		MaxBVLCLen: DEFAULT_MAX_BVLC_LENGTH,
+		MaxNPDULen: DEFAULT_MAX_NPDU_LENGTH,
+	}
+	payload, err := accept.MarshalBinary()
+	if err != nil {
+		h.unregister(c)
+		return nil, false
+	}
+	h.send(ws, &m, plumbing.BVLCSCFuncConnectAccept, payload)
+	return c, true
+}
+
+func (h *Hub) unregister(c *hubConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.nodes[string(c.vmac)] == c {
+		delete(h.nodes, string(c.vmac))
+	}
+}
+
+// handle handles a message from the node of c, telling whether its connection
+// is to be kept. Messages with a destination are forwarded to it, with the
+// node as their origin.
+func (h *Hub) handle(c *hubConn, m *plumbing.BVLCSC) bool {
+	if m.Destination != nil {
+		h.forward(c, m)
+		return true
+	}
+	if code, ok := checkOptions(m); !ok {
+		h.send(c.ws, m, plumbing.BVLCSCFuncResult, nakPayload(m, code))
+		return true
+	}
+
+	switch m.Function {
+	case plumbing.BVLCSCFuncHeartbeatRequest:
+		h.send(c.ws, m, plumbing.BVLCSCFuncHeartbeatAck, nil)
+	case plumbing.BVLCSCFuncDisconnectRequest:
+		h.send(c.ws, m, plumbing.BVLCSCFuncDisconnectAck, nil)
+		return false
+	case plumbing.BVLCSCFuncAddressResolution, plumbing.BVLCSCFuncAdvertisementSolicitation:
+		h.send(c.ws, m, plumbing.BVLCSCFuncResult,
+			nakPayload(m, plumbing.SCErrorCodeOptionalFunctionalityNotSupported))
+	case plumbing.BVLCSCFuncResult, plumbing.BVLCSCFuncHeartbeatAck, plumbing.BVLCSCFuncDisconnectAck,
+		plumbing.BVLCSCFuncEncapsulatedNPDU, plumbing.BVLCSCFuncAdvertisement,
+		plumbing.BVLCSCFuncAddressResolutionAck, plumbing.BVLCSCFuncProprietaryMessage:
+	default:
+		h.send(c.ws, m, plumbing.BVLCSCFuncResult, nakPayload(m, plumbing.SCErrorCodeBVLCFunctionUnknown))
+	}
+	return true
+}
+
+func (h *Hub) forward(c *hubConn, m *plumbing.BVLCSC) {
+	dst := m.Destination
+	m.Origin, m.Destination = c.vmac, nil
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	var dsts []*hubConn
+	if sameVMAC(dst, plumbing.SCBroadcastVMAC()) {
+		for _, n := range h.nodes {
+			if n != c {
+				dsts = append(dsts, n)
+			}
+		}
+	} else if n, ok := h.nodes[string(dst)]; ok {
+		dsts = append(dsts, n)
+	}
+	h.mu.Unlock()
+
+	for _, n := range dsts {
+		n.ws.WriteMessage(b)
+	}
+}
+
+// send answers m on ws with a message of function f.
+func (h *Hub) send(ws *wsConn, m *plumbing.BVLCSC, f uint8, payload []byte) {
+	r := plumbing.NewBVLCSC(f, m.MessageID, payload)
+	b, err := r.MarshalBinary()
+	if err != nil {
+		return
+	}
+	ws.WriteMessage(b)
+}
@@ -0,0 +1,338 @@
+// Package sc implements the BACnet Secure Connect datalink of Annex AB: nodes
+// exchanging BVLC-SC messages through a hub over TLS WebSockets, and a minimal
+// hub forwarding the messages between the nodes connected to it.
+package sc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// Default BACnet/SC parameters.
+const (
+	DEFAULT_HEARTBEAT_TIMEOUT = 300 * time.Second
+	DEFAULT_MAX_BVLC_LENGTH   = 1600
+	DEFAULT_MAX_NPDU_LENGTH   = 1497
+)
+
+// NodeConfig configures a Node.
+type NodeConfig struct {
+	// VMAC is the virtual MAC address of the node and UUID its device UUID,
+	// telling apart reconnections from duplicated VMACs.
+	VMAC []byte
+	UUID [plumbing.SCUUIDLen]byte
+	// TLSConfig configures the TLS connection to the hub, such as the
+	// certificate of the node and the ones it trusts.
+	TLSConfig *tls.Config
+	// HeartbeatTimeout is the idle time after which a Heartbeat-Request is
+	// sent to the hub, DEFAULT_HEARTBEAT_TIMEOUT if zero.
+	HeartbeatTimeout time.Duration
+}
+
+// Message is an NPDU received from the node with the virtual MAC address Source.
+type Message struct {
+	Source []byte
+	NPDU   []byte
+}
+
+// NAKError is the BVLC-Result NAK a peer answered a message with.
+type NAKError struct {
+	plumbing.SCResult
+}
+
+func (e *NAKError) Error() string {
+	return fmt.Sprintf("BVLC-SC function %d NAK: error class %d code %d: %s",
+		e.Function, e.ErrorClass, e.ErrorCode, e.Details)
+}
+
+// Node is a BACnet/SC node connected to a hub. It is safe for concurrent use.
+type Node struct {
+	ws      *wsConn
+	vmac    []byte
+	hubVMAC []byte
+
+	mu     sync.Mutex
+	nextID uint16
+	// active tells whether a message went through since the last heartbeat.
+	active bool
+
+	messages chan Message
+	done     chan struct{}
+	err      error
+}
+
+// Dial connects a node configured with config to the hub at the wss:// URL
+// hubURL, returning once the hub accepted the connection or answering its
+// NAK as a *NAKError.
+func Dial(ctx context.Context, hubURL string, config NodeConfig) (*Node, error) {
+	if len(config.VMAC) != plumbing.SCVMACLen {
+		return nil, fmt.Errorf("VMAC %x: %v", config.VMAC, common.ErrWrongStructure)
+	}
+	ws, err := dialWebSocket(ctx, hubURL, plumbing.SCHubProtocol, config.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		ws:       ws,
+		vmac:     append([]byte{}, config.VMAC...),
+		messages: make(chan Message, 64),
+		done:     make(chan struct{}),
+	}
+
+	if err := n.connect(ctx, config.UUID); err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	timeout := config.HeartbeatTimeout
+	if timeout <= 0 {
+		timeout = DEFAULT_HEARTBEAT_TIMEOUT
+	}
+	go n.readLoop()
+	go n.heartbeat(timeout)
+	return n, nil
+}
+
+// connect exchanges the Connect-Request and Connect-Accept with the hub.
+func (n *Node) connect(ctx context.Context, uuid [plumbing.SCUUIDLen]byte) error {
+	req := &plumbing.SCConnect{
+		VMAC:       n.vmac,
+		UUID:       uuid,
+		MaxBVLCLen: DEFAULT_MAX_BVLC_LENGTH,
+		MaxNPDULen: DEFAULT_MAX_NPDU_LENGTH,
+	}
+	payload, err := req.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := n.write(plumbing.NewBVLCSC(plumbing.BVLCSCFuncConnectRequest, n.messageID(), payload)); err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		n.ws.conn.SetReadDeadline(deadline)
+		defer n.ws.conn.SetReadDeadline(time.Time{})
+	}
+	b, err := n.ws.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("connecting: %v", err)
+	}
+	var m plumbing.BVLCSC
+	if err := m.UnmarshalBinary(b); err != nil {
+		return fmt.Errorf("connecting: %v", err)
+	}
+
+	switch m.Function {
+	case plumbing.BVLCSCFuncConnectAccept:
+		var accept plumbing.SCConnect
+		if err := accept.UnmarshalBinary(m.Payload); err != nil {
+			return fmt.Errorf("connecting: %v", err)
+		}
+		n.hubVMAC = accept.VMAC
+		return nil
+	case plumbing.BVLCSCFuncResult:
+		var r plumbing.SCResult
+		if err := r.UnmarshalBinary(m.Payload); err != nil {
+			return fmt.Errorf("connecting: %v", err)
+		}
+		return &NAKError{r}
+	}
+	return fmt.Errorf("connecting: BVLC-SC function %d: %v", m.Function, common.ErrWrongPayload)
+}
+
+// VMAC returns the virtual MAC address of the node.
+func (n *Node) VMAC() []byte {
+	return n.vmac
+}
+
+// HubVMAC returns the virtual MAC address of the hub the node is connected to.
+func (n *Node) HubVMAC() []byte {
+	return n.hubVMAC
+}
+
+// Send sends npdu in an Encapsulated-NPDU to the node with the virtual MAC
+// address dst, or to every node for plumbing.SCBroadcastVMAC.
+func (n *Node) Send(dst []byte, npdu []byte) error {
+	if len(dst) != plumbing.SCVMACLen {
+		return fmt.Errorf("destination VMAC %x: %v", dst, common.ErrWrongStructure)
+	}
+	m := plumbing.NewBVLCSC(plumbing.BVLCSCFuncEncapsulatedNPDU, n.messageID(), npdu)
+	m.Destination = dst
+	return n.write(m)
+}
+
+// Receive waits for the next NPDU sent to the node, returning an error
+// wrapping io.EOF once the connection to the hub is closed.
+func (n *Node) Receive(ctx context.Context) (Message, error) {
+	select {
+	case m := <-n.messages:
+		return m, nil
+	case <-n.done:
+		return Message{}, n.err
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// Close disconnects the node from the hub.
+func (n *Node) Close() error {
+	n.write(plumbing.NewBVLCSC(plumbing.BVLCSCFuncDisconnectRequest, n.messageID(), nil))
+	return n.ws.Close()
+}
+
+func (n *Node) messageID() uint16 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nextID++
+	return n.nextID
+}
+
+func (n *Node) write(m *plumbing.BVLCSC) error {
+	b, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.active = true
+	n.mu.Unlock()
+	return n.ws.WriteMessage(b)
+}
+
+func (n *Node) readLoop() {
+	defer close(n.done)
+	for {
+		b, err := n.ws.ReadMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				n.err = fmt.Errorf("reading from hub: %w", io.EOF)
+			} else {
+				n.err = fmt.Errorf("reading from hub: %v: %w", err, io.EOF)
+			}
+			return
+		}
+		n.mu.Lock()
+		n.active = true
+		n.mu.Unlock()
+
+		var m plumbing.BVLCSC
+		if err := m.UnmarshalBinary(b); err != nil {
+			continue
+		}
+		if !n.handle(&m) {
+			n.ws.Close()
+		}
+	}
+}
+
+// handle handles a message from the hub, telling whether the connection is
+// to be kept.
+func (n *Node) handle(m *plumbing.BVLCSC) bool {
+	if code, ok := checkOptions(m); !ok {
+		n.nak(m, code)
+		return true
+	}
+
+	switch m.Function {
+	case plumbing.BVLCSCFuncEncapsulatedNPDU:
+		// NPDUs nobody is reading are dropped, as datagrams would be.
+		select {
+		case n.messages <- Message{Source: m.Origin, NPDU: m.Payload}:
+		default:
+		}
+	case plumbing.BVLCSCFuncHeartbeatRequest:
+		n.reply(m, plumbing.BVLCSCFuncHeartbeatAck, nil)
+	case plumbing.BVLCSCFuncAdvertisementSolicitation:
+		adv := &plumbing.SCAdvertisement{
+			HubStatus:  1,
+			MaxBVLCLen: DEFAULT_MAX_BVLC_LENGTH,
+			MaxNPDULen: DEFAULT_MAX_NPDU_LENGTH,
+		}
+		payload, _ := adv.MarshalBinary()
+		n.reply(m, plumbing.BVLCSCFuncAdvertisement, payload)
+	case plumbing.BVLCSCFuncAddressResolution:
+		// Direct connections are not accepted.
+		n.nak(m, plumbing.SCErrorCodeOptionalFunctionalityNotSupported)
+	case plumbing.BVLCSCFuncDisconnectRequest:
+		n.reply(m, plumbing.BVLCSCFuncDisconnectAck, nil)
+		return false
+	case plumbing.BVLCSCFuncResult, plumbing.BVLCSCFuncHeartbeatAck, plumbing.BVLCSCFuncAdvertisement,
+		plumbing.BVLCSCFuncAddressResolutionAck, plumbing.BVLCSCFuncDisconnectAck, plumbing.BVLCSCFuncProprietaryMessage:
+	default:
+		n.nak(m, plumbing.SCErrorCodeBVLCFunctionUnknown)
+	}
+	return true
+}
+
+// reply answers m with a message of function f, sent back to its origin.
+func (n *Node) reply(m *plumbing.BVLCSC, f uint8, payload []byte) {
+	r := plumbing.NewBVLCSC(f, m.MessageID, payload)
+	r.Destination = m.Origin
+	n.write(r)
+}
+
+func (n *Node) nak(m *plumbing.BVLCSC, code uint16) {
+	// Results are never answered, not to loop between nodes.
+	if m.Function == plumbing.BVLCSCFuncResult {
+		return
+	}
+	n.reply(m, plumbing.BVLCSCFuncResult, nakPayload(m, code))
+}
+
+// heartbeat sends a Heartbeat-Request to the hub whenever the connection was
+// idle for timeout.
+func (n *Node) heartbeat(timeout time.Duration) {
+	ticker := time.NewTicker(timeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+		}
+		n.mu.Lock()
+		idle := !n.active
+		n.active = false
+		n.mu.Unlock()
+		if idle {
+			n.write(plumbing.NewBVLCSC(plumbing.BVLCSCFuncHeartbeatRequest, n.messageID(), nil))
+		}
+	}
+}
+
+// checkOptions tells whether every header option of m that must be
+// understood is, along with the error code of the NAK otherwise.
+func checkOptions(m *plumbing.BVLCSC) (uint16, bool) {
+	for _, options := range [][]plumbing.SCOption{m.DestOptions, m.DataOptions} {
+		for _, o := range options {
+			if o.MustUnderstand && o.Type != plumbing.SCOptionSecurePath {
+				return plumbing.SCErrorCodeHeaderNotUnderstood, false
+			}
+		}
+	}
+	return 0, true
+}
+
+func nakPayload(m *plumbing.BVLCSC, code uint16) []byte {
+	r := &plumbing.SCResult{
+		Function:   m.Function,
+		NAK:        true,
+		ErrorClass: uint16(objects.ErrorClassCommunication),
+		ErrorCode:  code,
+	}
+	payload, _ := r.MarshalBinary()
+	return payload
+}
+
+func sameVMAC(a, b []byte) bool {
+	return len(a) == plumbing.SCVMACLen && bytes.Equal(a, b)
+}
@@ -0,0 +1,168 @@
+package sc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+func TestBVLCSCMarshal(t *testing.T) {
+	m := &plumbing.BVLCSC{
+		Function:    plumbing.BVLCSCFuncEncapsulatedNPDU,
+		MessageID:   0x1234,
+		Origin:      []byte{1, 2, 3, 4, 5, 6},
+		DestOptions: []plumbing.SCOption{{Type: plumbing.SCOptionSecurePath, MustUnderstand: true}},
+		DataOptions: []plumbing.SCOption{
+			{Type: plumbing.SCOptionProprietary, Data: []byte{0xaa}},
+			{Type: plumbing.SCOptionProprietary, Data: []byte{0xbb, 0xcc}},
+		},
+		Payload: []byte{0x01, 0x00},
+	}
+	want := []byte{
+		0x01, 0x0b, 0x12, 0x34, // Function, flags and message ID
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, // Origin
+		0x41,                   // Secure path
+		0xbf, 0x00, 0x01, 0xaa, // Proprietary, more options
+		0x3f, 0x00, 0x02, 0xbb, 0xcc, // Proprietary
+		0x01, 0x00, // NPDU
+	}
+
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, b); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	var got plumbing.BVLCSC
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m, &got); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+// newTestHub serves a hub over TLS with a self-signed certificate, returning
+// its URL and the TLS configuration of the nodes trusting it.
+func newTestHub(t *testing.T) (*Hub, string, *tls.Config) {
+	hub := NewHub([]byte{0, 0, 0, 0, 0, 0xff}, [16]byte{0xff})
+	srv := httptest.NewTLSServer(hub)
+	t.Cleanup(srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	return hub, "wss" + strings.TrimPrefix(srv.URL, "https"), &tls.Config{RootCAs: pool}
+}
+
+func dialTestNode(t *testing.T, url string, config *tls.Config, vmac byte) *Node {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	n, err := Dial(ctx, url, NodeConfig{
+		VMAC:      []byte{0, 0, 0, 0, 0, vmac},
+		UUID:      [16]byte{vmac},
+		TLSConfig: config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+func receive(t *testing.T, n *Node) Message {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m, err := n.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestHubForwarding(t *testing.T) {
+	hub, url, config := newTestHub(t)
+	a := dialTestNode(t, url, config, 1)
+	b := dialTestNode(t, url, config, 2)
+	c := dialTestNode(t, url, config, 3)
+
+	if !bytes.Equal(a.HubVMAC(), []byte{0, 0, 0, 0, 0, 0xff}) {
+		t.Errorf("unexpected hub VMAC %x", a.HubVMAC())
+	}
+	if got := hub.Nodes(); len(got) != 3 {
+		t.Errorf("expected 3 nodes, got %x", got)
+	}
+
+	npdu := []byte{0x01, 0x00, 0x10, 0x08}
+	if err := a.Send(b.VMAC(), npdu); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, b); !bytes.Equal(m.Source, a.VMAC()) || !bytes.Equal(m.NPDU, npdu) {
+		t.Errorf("unexpected unicast %+v", m)
+	}
+
+	if err := b.Send(plumbing.SCBroadcastVMAC(), npdu); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []*Node{a, c} {
+		if m := receive(t, n); !bytes.Equal(m.Source, b.VMAC()) {
+			t.Errorf("unexpected broadcast %+v", m)
+		}
+	}
+}
+
+func TestHubDuplicateVMAC(t *testing.T) {
+	_, url, config := newTestHub(t)
+	dialTestNode(t, url, config, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := Dial(ctx, url, NodeConfig{
+		VMAC:      []byte{0, 0, 0, 0, 0, 1},
+		UUID:      [16]byte{0xee},
+		TLSConfig: config,
+	})
+	var nak *NAKError
+	if !errors.As(err, &nak) || nak.ErrorCode != plumbing.SCErrorCodeNodeDuplicateVMAC {
+		t.Errorf("expected duplicate VMAC NAK, got %v", err)
+	}
+}
+
+func TestNodeHeartbeat(t *testing.T) {
+	_, url, config := newTestHub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	n, err := Dial(ctx, url, NodeConfig{
+		VMAC:             []byte{0, 0, 0, 0, 0, 1},
+		TLSConfig:        config,
+		HeartbeatTimeout: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	// The hub acknowledges the heartbeats, keeping the connection up.
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-n.done:
+		t.Fatalf("connection lost: %v", n.err)
+	default:
+	}
+
+	n.Close()
+	if _, err := n.Receive(context.Background()); err == nil {
+		t.Error("expected an error after Close")
+	}
+}
@@ -0,0 +1,293 @@
+package sc
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+)
+
+// The WebSocket opcodes of RFC 6455.
+const (
+	wsContinuation = 0x0
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessageLen bounds the WebSocket messages received, BVLC-SC messages
+// being far shorter.
+const maxMessageLen = 1 << 16
+
+// wsConn is the minimal WebSocket connection BACnet/SC needs: binary
+// messages, answering pings and closing.
+type wsConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	client bool
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// dialWebSocket opens a WebSocket with protocol to the wss:// URL u.
+func dialWebSocket(ctx context.Context, u string, protocol string, config *tls.Config) (*wsConn, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", u, err)
+	}
+	if parsed.Scheme != "wss" {
+		return nil, fmt.Errorf("scheme of %s: %v", u, common.ErrWrongStructure)
+	}
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "443")
+	}
+
+	d := tls.Dialer{Config: config}
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("dialing %s: %v", u, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    parsed,
+		Host:   parsed.Host,
+		Header: http.Header{
+			"Upgrade":                {"websocket"},
+			"Connection":             {"Upgrade"},
+			"Sec-WebSocket-Key":      {key},
+			"Sec-WebSocket-Version":  {"13"},
+			"Sec-WebSocket-Protocol": {protocol},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("upgrading %s: %v", u, err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("upgrading %s: %v", u, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) ||
+		resp.Header.Get("Sec-WebSocket-Protocol") != protocol {
+		conn.Close()
+		return nil, fmt.Errorf("upgrading %s: status %s: %v", u, resp.Status, common.ErrWrongPayload)
+	}
+
+	return &wsConn{conn: conn, r: r, client: true}, nil
+}
+
+// acceptWebSocket upgrades the request r to a WebSocket with protocol.
+func acceptWebSocket(w http.ResponseWriter, r *http.Request, protocol string) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!headerContains(r.Header, "Sec-WebSocket-Protocol", protocol) {
+		http.Error(w, "expected a "+protocol+" WebSocket", http.StatusBadRequest)
+		return nil, fmt.Errorf("upgrading from %s: %v", r.RemoteAddr, common.ErrWrongPayload)
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "cannot upgrade", http.StatusInternalServerError)
+		return nil, fmt.Errorf("upgrading from %s: %v", r.RemoteAddr, common.ErrNotImplemented)
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("upgrading from %s: %v", r.RemoteAddr, err)
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAccept(key) + "\r\n" +
+		"Sec-WebSocket-Protocol: " + protocol + "\r\n\r\n"
+	if _, err := conn.Write([]byte(resp)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("upgrading from %s: %v", r.RemoteAddr, err)
+	}
+
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+func headerContains(h http.Header, name, value string) bool {
+	for _, v := range h.Values(name) {
+		for _, s := range strings.Split(v, ",") {
+			if strings.TrimSpace(s) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage returns the next binary message, answering pings on the way.
+// It returns io.EOF once the peer closed the connection.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.writeFrame(wsClose, nil)
+			return nil, io.EOF
+		}
+
+		if len(msg)+len(payload) > maxMessageLen {
+			return nil, fmt.Errorf("reading message of %d octets: %v", len(msg)+len(payload), common.ErrTooBigValue)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, uint8, []byte, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.r, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := h[0]&0x80 != 0
+	opcode := h[0] & 0x0f
+	masked := h[1]&0x80 != 0
+
+	l := uint64(h[1] & 0x7f)
+	switch l {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		l = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		l = binary.BigEndian.Uint64(ext[:])
+	}
+	if l > maxMessageLen {
+		return false, 0, nil, fmt.Errorf("reading frame of %d octets: %v", l, common.ErrTooBigValue)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, l)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends b as a binary message.
+func (c *wsConn) WriteMessage(b []byte) error {
+	return c.writeFrame(wsBinary, b)
+}
+
+func (c *wsConn) writeFrame(opcode uint8, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	if opcode == wsClose {
+		c.closed = true
+	}
+
+	h := []byte{0x80 | opcode, 0}
+	switch l := len(payload); {
+	case l < 126:
+		h[1] = uint8(l)
+	case l <= 0xffff:
+		h[1] = 126
+		h = binary.BigEndian.AppendUint16(h, uint16(l))
+	default:
+		h[1] = 127
+		h = binary.BigEndian.AppendUint64(h, uint64(l))
+	}
+
+	frame := payload
+	if c.client {
+		// Frames from clients are masked.
+		h[1] |= 0x80
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		h = append(h, mask[:]...)
+		frame = make([]byte, len(payload))
+		for i := range payload {
+			frame[i] = payload[i] ^ mask[i%4]
+		}
+	}
+
+	if _, err := c.conn.Write(append(h, frame...)); err != nil {
+		return fmt.Errorf("writing frame: %v", err)
+	}
+	return nil
+}
+
+// Close sends a close frame and closes the connection.
+func (c *wsConn) Close() error {
+	c.writeFrame(wsClose, nil)
+	return c.conn.Close()
+}
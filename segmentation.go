@@ -0,0 +1,234 @@
+package bacnet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// requestSegmented sends the confirmed request b, whose APDU starts at offset,
+// in segments and waits for its reply.
+func (c *Client) requestSegmented(ctx context.Context, addr net.Addr, key tsmKey, ch chan tsmResult, b []byte, offset int) (plumbing.BACnet, error) {
+	var apdu plumbing.APDU
+	if err := apdu.UnmarshalBinary(b[offset:]); err != nil {
+		return nil, fmt.Errorf("building request: %v", err)
+	}
+	frames, err := segmentFrames(b[:offset], &apdu, c.MaxAPDULength, c.WindowSize)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.sendSegments(ctx, addr, ch, frames)
+	if err != nil {
+		return nil, err
+	}
+	for r == nil {
+		next, err := c.await(ctx, ch, c.APDUTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invoke ID %d to %s: %w", key.invokeID, addr, err)
+		}
+		// Duplicated Segment-ACKs for the last window may still be arriving.
+		if next.apdu != nil && next.apdu.Type == plumbing.SegmentAck {
+			continue
+		}
+		r = &next
+	}
+	return c.confirm(ctx, addr, key, ch, *r)
+}
+
+// sendSegments sends frames, the segments of a message, a window at a time.
+// The first segment is sent alone to learn the window size the peer accepts.
+// A window is resent from its first unacknowledged segment on a negative
+// Segment-ACK, on a Segment-ACK out of the window or on timeout, up to
+// APDURetries times in a row without progress. A message other than a
+// Segment-ACK ends the transmission early and is returned.
+func (c *Client) sendSegments(ctx context.Context, addr net.Addr, ch chan tsmResult, frames [][]byte) (*tsmResult, error) {
+	window := 1
+	retries := 0
+	for base := 0; base < len(frames); {
+		end := base + window
+		if end > len(frames) {
+			end = len(frames)
+		}
+		for _, f := range frames[base:end] {
+			if err := c.send(ctx, addr, f); err != nil {
+				return nil, err
+			}
+		}
+
+		r, err := c.await(ctx, ch, c.SegmentTimeout)
+		if err != nil && !errors.Is(err, common.ErrTimeout) {
+			return nil, err
+		}
+		if err == nil {
+			if r.err != nil {
+				return nil, r.err
+			}
+			if r.apdu == nil || r.apdu.Type != plumbing.SegmentAck {
+				return &r, nil
+			}
+
+			if r.apdu.WindowSize > 0 {
+				window = int(r.apdu.WindowSize)
+			}
+			// Sequence numbers wrap at 256, so the acknowledged segment is
+			// found relative to the one before the window, which a NAK
+			// acknowledges when the whole window got lost.
+			acked := base - 1 + int(r.apdu.SequenceNumber-uint8(base-1))
+			if acked >= base && acked < end {
+				base = acked + 1
+				retries = 0
+				continue
+			}
+		}
+
+		if retries++; retries > c.APDURetries {
+			if err == nil {
+				err = common.ErrTimeout
+			}
+			return nil, fmt.Errorf("segment %d to %s after %d retries: %w", base, addr, c.APDURetries, err)
+		}
+	}
+	return nil, nil
+}
+
+// receiveSegments collects the segments of a message starting with first,
+// acknowledging them a window at a time, and parses the reassembled message.
+// The first segment out of order in a window is answered with a negative
+// Segment-ACK for the last segment received in order, so that the peer
+// resends the ones after it; the others are dropped until the peer resends,
+// which the segment that got the NAK arriving again also tells.
+func (c *Client) receiveSegments(ctx context.Context, addr net.Addr, key tsmKey, ch chan tsmResult, first tsmResult) (plumbing.BACnet, error) {
+	a := first.apdu
+	if a.SequenceNumber != 0 {
+		return nil, fmt.Errorf("first segment number %d: %v", a.SequenceNumber, common.ErrWrongStructure)
+	}
+
+	window := a.WindowSize
+	if window > c.WindowSize {
+		window = c.WindowSize
+	}
+	if window == 0 {
+		window = 1
+	}
+	// Segment-ACKs are sent from the server side when the peer is the client.
+	server := !key.server
+	ack := func(seq uint8, nak bool) error {
+		b, err := NewSegmentACK(a.InvokeID, seq, window, nak, server)
+		if err != nil {
+			return err
+		}
+		return c.send(ctx, addr, b)
+	}
+
+	data := append([]byte{}, a.Segment...)
+	last := a.SequenceNumber
+	more := a.Flags&plumbing.MoreSegments != 0
+	if err := ack(last, false); err != nil {
+		return nil, err
+	}
+
+	windowStart := last + 1
+	// naked is the segment answered with a NAK, -1 until one is.
+	naked := -1
+	for more {
+		r, err := c.await(ctx, ch, c.SegmentTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d from %s: %w", last+1, addr, err)
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		s := r.apdu
+		if s == nil || !s.IsSegmented() {
+			continue
+		}
+		if s.SequenceNumber != last+1 {
+			if naked >= 0 && naked != int(s.SequenceNumber) {
+				continue
+			}
+			if err := ack(last, true); err != nil {
+				return nil, err
+			}
+			windowStart = last + 1
+			naked = int(s.SequenceNumber)
+			continue
+		}
+
+		data = append(data, s.Segment...)
+		last = s.SequenceNumber
+		more = s.Flags&plumbing.MoreSegments != 0
+		naked = -1
+		if !more || last-windowStart+1 >= window {
+			if err := ack(last, false); err != nil {
+				return nil, err
+			}
+			windowStart = last + 1
+		}
+	}
+
+	full, err := plumbing.Reassemble(a, data)
+	if err != nil {
+		return nil, err
+	}
+	b, err := frame(first.header, full)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNPDU(b)
+}
+
+// receiveRequest reassembles a segmented request from addr starting with
+// first and passes it to the handler.
+func (c *Client) receiveRequest(addr net.Addr, key tsmKey, ch chan tsmResult, first tsmResult) {
+	msg, err := c.receiveSegments(context.Background(), addr, key, ch, first)
+	c.unregister(key)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	h := c.handler
+	c.mu.Unlock()
+	if h != nil {
+		h(addr, msg)
+	}
+}
+
+// segmentFrames splits a into segments of up to maxLen octets and frames each
+// of them after header, the BVLC and NPDU of the message.
+func segmentFrames(header []byte, a *plumbing.APDU, maxLen int, windowSize uint8) ([][]byte, error) {
+	segments, err := a.Segments(maxLen, windowSize)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([][]byte, len(segments))
+	for i, s := range segments {
+		if frames[i], err = frame(header, s); err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+// frame appends a to header, the BVLC and NPDU of a message, and fixes the
+// BVLC length accordingly.
+func frame(header []byte, a *plumbing.APDU) ([]byte, error) {
+	b := make([]byte, len(header)+a.MarshalLen())
+	copy(b, header)
+	if err := a.MarshalTo(b[len(header):]); err != nil {
+		return nil, fmt.Errorf("framing APDU: %v", err)
+	}
+	// The headers received on a transport are bare NPDUs, without a BVLC
+	// length to set.
+	if len(header) > 0 && (header[0] == plumbing.BVLCType || header[0] == plumbing.BVLCType6) {
+		binary.BigEndian.PutUint16(b[2:4], uint16(len(b)))
+	}
+	return b, nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// Abort is a BACnet message terminating a transaction.
+type Abort struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+type AbortDec struct {
+	InvokeID uint8
+	// Reason is one of the AbortReason constants.
+	Reason AbortReason
+	// Server tells whether the server of the transaction aborted it.
+	Server bool
+}
+
+// NewAbort creates a Abort.
+func NewAbort(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *Abort {
+	r := &Abort{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.Abort, 0, nil),
+	}
+	r.SetLength()
+
+	return r
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a Abort frame.
+func (r *Abort) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < r.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal Abort - marshal length %d binary length %d: %v",
+			r.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+
+	var offset int = 0
+	if err := r.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling Abort %+v: %v", r, common.ErrTooShortToParse,
+		)
+	}
+	offset += r.BVLC.MarshalLen()
+
+	if err := r.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling Abort %+v: %v", r, common.ErrTooShortToParse,
+		)
+	}
+	offset += r.NPDU.MarshalLen()
+
+	if err := r.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling Abort %+v: %v", r, common.ErrTooShortToParse,
+		)
+	}
+
+	return nil
+}
+
+// MarshalBinary returns the byte sequence generated from a Abort instance.
+func (r *Abort) MarshalBinary() ([]byte, error) {
+	b := make([]byte, r.MarshalLen())
+	if err := r.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+// MarshalTo puts the byte sequence in the byte array given as b.
+func (r *Abort) MarshalTo(b []byte) error {
+	if len(b) < r.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal Abort - marshal length %d binary length %d: %v",
+			r.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	var offset = 0
+	if err := r.BVLC.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling Abort: %v", err)
+	}
+	offset += r.BVLC.MarshalLen()
+
+	if err := r.NPDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling Abort: %v", err)
+	}
+	offset += r.NPDU.MarshalLen()
+
+	if err := r.APDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling Abort: %v", err)
+	}
+
+	return nil
+}
+
+// MarshalLen returns the serial length of Abort.
+func (r *Abort) MarshalLen() int {
+	l := r.BVLC.MarshalLen()
+	l += r.NPDU.MarshalLen()
+	l += r.APDU.MarshalLen()
+
+	return l
+}
+
+// SetLength sets the length in Length field.
+func (r *Abort) SetLength() {
+	if r.BVLC != nil {
+		r.BVLC.Length = uint16(r.MarshalLen())
+	}
+}
+
+func (r *Abort) Decode() (AbortDec, error) {
+	return AbortDec{
+		InvokeID: r.APDU.InvokeID,
+		Reason:   AbortReason(r.APDU.Reason),
+		Server:   r.APDU.Flags&plumbing.SRV != 0,
+	}, nil
+}
+
+func (r *Abort) GetService() uint8 {
+	return r.APDU.Service
+}
+
+func (r *Abort) GetType() uint8 {
+	return r.APDU.Type
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// BVLLMessage is a BACnet/IP or BACnet/IPv6 message carrying no NPDU, such as
+// BVLC-Result or Register-Foreign-Device. Its type is BVLC.Function.
+type BVLLMessage struct {
+	*plumbing.BVLC
+	Data []byte
+}
+
+// BDTEntry is an entry of a Broadcast Distribution Table.
+type BDTEntry struct {
+	Address *net.UDPAddr
+	// Mask is the broadcast distribution mask, all ones for BBMDs that
+	// forward broadcasts as unicast messages.
+	Mask net.IPMask
+}
+
+// FDTEntry is an entry of a Foreign Device Table.
+type FDTEntry struct {
+	Address *net.UDPAddr
+	// TTL is the time to live the foreign device registered with and
+	// Remaining the seconds left before the entry is purged, both in seconds.
+	TTL       uint16
+	Remaining uint16
+}
+
+// BVLLMessageDec holds the decoded Data of a BVLLMessage. Only the fields
+// carried by the function are set.
+type BVLLMessageDec struct {
+	ResultCode uint16
+	BDT        []BDTEntry
+	FDT        []FDTEntry
+	// TTL is the time to live of a Register-Foreign-Device in seconds.
+	TTL uint16
+	// Address is the foreign device of a Delete-FDT-Entry and the original
+	// source of a Forwarded-Address-Resolution.
+	Address *net.UDPAddr
+	// VMAC is the virtual MAC address an Address-Resolution looks for.
+	VMAC []byte
+}
+
+const (
+	bdtEntryLen = plumbing.BIPAddressLen + 4
+	fdtEntryLen = plumbing.BIPAddressLen + 4
+)
+
+func NewBVLLMessage(bvlc *plumbing.BVLC) *BVLLMessage {
+	m := &BVLLMessage{
+		BVLC: bvlc,
+	}
+	m.SetLength()
+
+	return m
+}
+
+// ResultData encodes the Data of a BVLC-Result.
+func ResultData(code uint16) []byte {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, code)
+	return data
+}
+
+// BDTData encodes the Data of a Write-BDT or a Read-BDT-Ack.
+func BDTData(entries []BDTEntry) []byte {
+	data := make([]byte, bdtEntryLen*len(entries))
+	for i, e := range entries {
+		b := data[i*bdtEntryLen:]
+		plumbing.EncodeBIPAddress(b, e.Address)
+		copy(b[plumbing.BIPAddressLen:bdtEntryLen], e.Mask)
+	}
+	return data
+}
+
+// FDTData encodes the Data of a Read-FDT-Ack.
+func FDTData(entries []FDTEntry) []byte {
+	data := make([]byte, fdtEntryLen*len(entries))
+	for i, e := range entries {
+		b := data[i*fdtEntryLen:]
+		plumbing.EncodeBIPAddress(b, e.Address)
+		binary.BigEndian.PutUint16(b[plumbing.BIPAddressLen:], e.TTL)
+		binary.BigEndian.PutUint16(b[plumbing.BIPAddressLen+2:], e.Remaining)
+	}
+	return data
+}
+
+// RegisterForeignDeviceData encodes the Data of a Register-Foreign-Device.
+func RegisterForeignDeviceData(ttl uint16) []byte {
+	data := make([]byte, 2)
+	binary.BigEndian.PutUint16(data, ttl)
+	return data
+}
+
+// DeleteFDTEntryData encodes the Data of a Delete-FDT-Entry.
+func DeleteFDTEntryData(addr *net.UDPAddr) []byte {
+	data := make([]byte, plumbing.BIPAddressLen)
+	plumbing.EncodeBIPAddress(data, addr)
+	return data
+}
+
+// BIP6AddressData encodes the Data of a BACnet/IPv6 Delete-FDT-Entry.
+func BIP6AddressData(addr *net.UDPAddr) []byte {
+	data := make([]byte, plumbing.BIP6AddressLen)
+	plumbing.EncodeBIP6Address(data, addr)
+	return data
+}
+
+// AddressResolutionData encodes the Data of an Address-Resolution, or of a
+// Forwarded-Address-Resolution when origin is set.
+func AddressResolutionData(target []byte, origin *net.UDPAddr) []byte {
+	data := append([]byte{}, target...)
+	if origin != nil {
+		data = append(data, BIP6AddressData(origin)...)
+	}
+	return data
+}
+
+func (m *BVLLMessage) UnmarshalBinary(b []byte) error {
+	if err := m.BVLC.UnmarshalBinary(b); err != nil {
+		return fmt.Errorf(
+			"unmarshalling BVLLMessage %+v: %v", m, common.ErrTooShortToParse,
+		)
+	}
+	if m.BVLC.CarriesNPDU() {
+		return fmt.Errorf(
+			"unmarshalling BVLLMessage function %x: %v", m.BVLC.Function, common.ErrWrongPayload,
+		)
+	}
+	offset := m.BVLC.MarshalLen()
+
+	m.Data = append([]byte{}, b[offset:]...)
+
+	return nil
+}
+
+func (m *BVLLMessage) MarshalBinary() ([]byte, error) {
+	b := make([]byte, m.MarshalLen())
+	if err := m.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+func (m *BVLLMessage) MarshalTo(b []byte) error {
+	if len(b) < m.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal BVLLMessage - marshal length %d binary length %d: %v",
+			m.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	if err := m.BVLC.MarshalTo(b); err != nil {
+		return fmt.Errorf("marshalling BVLLMessage: %v", err)
+	}
+	copy(b[m.BVLC.MarshalLen():], m.Data)
+
+	return nil
+}
+
+func (m *BVLLMessage) MarshalLen() int {
+	return m.BVLC.MarshalLen() + len(m.Data)
+}
+
+func (m *BVLLMessage) SetLength() {
+	m.BVLC.Length = uint16(m.MarshalLen())
+}
+
+// GetType returns the BVLL function.
+func (m *BVLLMessage) GetType() uint8 {
+	return m.BVLC.Function
+}
+
+// GetService returns 0, BVLL messages having no service.
+func (m *BVLLMessage) GetService() uint8 {
+	return 0
+}
+
+// Decode decodes Data according to the BVLL function.
+func (m *BVLLMessage) Decode() (BVLLMessageDec, error) {
+	if m.BVLC.Type == plumbing.BVLCType6 {
+		return m.decode6()
+	}
+
+	var dec BVLLMessageDec
+	d := m.Data
+
+	switch m.BVLC.Function {
+	case plumbing.BVLCFuncReadBDT, plumbing.BVLCFuncReadFDT:
+	case plumbing.BVLCFuncResult, plumbing.BVLCFuncRegisterForeignDevice:
+		if len(d) < 2 {
+			return dec, fmt.Errorf("decoding BVLL function %x %x: %v", m.BVLC.Function, d, common.ErrTooShortToParse)
+		}
+		if m.BVLC.Function == plumbing.BVLCFuncResult {
+			dec.ResultCode = binary.BigEndian.Uint16(d)
+		} else {
+			dec.TTL = binary.BigEndian.Uint16(d)
+		}
+	case plumbing.BVLCFuncWriteBDT, plumbing.BVLCFuncReadBDTAck:
+		if len(d)%bdtEntryLen != 0 {
+			return dec, fmt.Errorf("decoding BDT %x: %v", d, common.ErrWrongStructure)
+		}
+		for i := 0; i < len(d); i += bdtEntryLen {
+			dec.BDT = append(dec.BDT, BDTEntry{
+				Address: plumbing.DecodeBIPAddress(d[i:]),
+				Mask:    net.IPv4Mask(d[i+6], d[i+7], d[i+8], d[i+9]),
+			})
+		}
+	case plumbing.BVLCFuncReadFDTAck:
+		if len(d)%fdtEntryLen != 0 {
+			return dec, fmt.Errorf("decoding FDT %x: %v", d, common.ErrWrongStructure)
+		}
+		for i := 0; i < len(d); i += fdtEntryLen {
+			dec.FDT = append(dec.FDT, FDTEntry{
+				Address:   plumbing.DecodeBIPAddress(d[i:]),
+				TTL:       binary.BigEndian.Uint16(d[i+6:]),
+				Remaining: binary.BigEndian.Uint16(d[i+8:]),
+			})
+		}
+	case plumbing.BVLCFuncDeleteFDTEntry:
+		if len(d) < plumbing.BIPAddressLen {
+			return dec, fmt.Errorf("decoding Delete-FDT-Entry %x: %v", d, common.ErrTooShortToParse)
+		}
+		dec.Address = plumbing.DecodeBIPAddress(d)
+	default:
+		return dec, fmt.Errorf("decoding BVLL function %x: %v", m.BVLC.Function, common.ErrNotImplemented)
+	}
+
+	return dec, nil
+}
+
+func (m *BVLLMessage) decode6() (BVLLMessageDec, error) {
+	var dec BVLLMessageDec
+	d := m.Data
+
+	switch m.BVLC.Function {
+	case plumbing.BVLC6FuncAddressResolutionAck, plumbing.BVLC6FuncVirtualAddressResolution,
+		plumbing.BVLC6FuncVirtualAddressResolutionAck:
+	case plumbing.BVLC6FuncResult, plumbing.BVLC6FuncRegisterForeignDevice:
+		if len(d) < 2 {
+			return dec, fmt.Errorf("decoding BVLL6 function %x %x: %v", m.BVLC.Function, d, common.ErrTooShortToParse)
+		}
+		if m.BVLC.Function == plumbing.BVLC6FuncResult {
+			dec.ResultCode = binary.BigEndian.Uint16(d)
+		} else {
+			dec.TTL = binary.BigEndian.Uint16(d)
+		}
+	case plumbing.BVLC6FuncAddressResolution, plumbing.BVLC6FuncForwardedAddressResolution:
+		l := plumbing.VMACLen
+		if m.BVLC.Function == plumbing.BVLC6FuncForwardedAddressResolution {
+			l += plumbing.BIP6AddressLen
+		}
+		if len(d) < l {
+			return dec, fmt.Errorf("decoding Address-Resolution %x: %v", d, common.ErrTooShortToParse)
+		}
+		dec.VMAC = append([]byte{}, d[:plumbing.VMACLen]...)
+		if l > plumbing.VMACLen {
+			dec.Address = plumbing.DecodeBIP6Address(d[plumbing.VMACLen:])
+		}
+	case plumbing.BVLC6FuncDeleteFDTEntry:
+		if len(d) < plumbing.BIP6AddressLen {
+			return dec, fmt.Errorf("decoding Delete-FDT-Entry %x: %v", d, common.ErrTooShortToParse)
+		}
+		dec.Address = plumbing.DecodeBIP6Address(d)
+	default:
+		return dec, fmt.Errorf("decoding BVLL6 function %x: %v", m.BVLC.Function, common.ErrNotImplemented)
+	}
+
+	return dec, nil
+}
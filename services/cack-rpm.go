@@ -2,9 +2,7 @@ package services
 
 import (
 	"fmt"
-	"log"
 
-	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
 	"github.com/Nortech-ai/bacnet/plumbing"
 )
@@ -24,101 +22,276 @@ type ComplexACKRPMDec struct {
 
 func (c *ComplexACK) DecodeRPM() (ComplexACKRPMDec, error) {
 	decCACK := ComplexACKRPMDec{}
-	context := []uint8{8}
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
+	}
+	decode := func(context uint8, o *objects.Object) (*objects.Object, error) {
+		switch {
+		case context == 1 && o.TagNumber == 2:
+			propId, err := objects.DecUnsignedInteger(o)
+			if err != nil {
+				return nil, fmt.Errorf("decode Context object case 1: %v", err)
+			}
+			return &objects.Object{
+				TagNumber: 2,
+				TagClass:  true,
+				Value:     propId,
+				Data:      o.Data,
+				Length:    o.Length,
+			}, nil
+		case context == 4 && (o.TagNumber == 0 || o.TagNumber == 3):
+			objId, err := objects.DecObjectIdentifier(o)
+			if err != nil {
+				return nil, fmt.Errorf("decode Context object case 0: %v", err)
+			}
+			return decodedTag(o, objId), nil
+		case context == 4 && o.TagNumber == 1:
+			value, err := objects.DecUnsignedInteger(o)
+			if err != nil {
+				return nil, fmt.Errorf("decode Context object case 1: %v", err)
+			}
+			return decodedTag(o, uint16(value)), nil
+		}
+		// Keep the tags this decoder doesn't know, undecoded.
+		return o, nil
+	}
 	objs := make([]*objects.Object, 0)
-	for i, obj := range c.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decCACK, fmt.Errorf(
-				"ComplexACKRPM object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
-		}
-
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 && enc_obj.Data == nil {
-			context = append(context, enc_obj.TagNumber)
-			continue
+	for r.Remaining() > 0 {
+		obj, err := r.ReadContext(0)
+		if err != nil {
+			return decCACK, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
 		}
-		if enc_obj.Length == 7 && enc_obj.Data == nil {
-			if len(context) == 0 {
-				return decCACK, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
+		objId, err := objects.DecObjectIdentifier(obj)
+		if err != nil {
+			return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
 		}
+		decCACK.ObjectType = objId.ObjectType
+		decCACK.InstanceId = objId.InstanceNumber
 
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				decCACK.ObjectType = objId.ObjectType
-				decCACK.InstanceId = objId.InstanceNumber
-			case combine(1, 2):
-				propId, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 1: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 2,
-					TagClass:  true,
-					Value:     propId,
-					Data:      enc_obj.Data,
-					Length:    enc_obj.Length,
-				})
-			case combine(4, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 0,
-					TagClass:  true,
-					Value:     objId,
-					Length:    uint8(obj.MarshalLen()),
-				})
-			case combine(4, 1):
-				value, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 1: %v", err)
-				}
-				propId := uint16(value)
-				objs = append(objs, &objects.Object{
-					TagNumber: 1,
-					TagClass:  true,
-					Value:     propId,
-					Length:    uint8(obj.MarshalLen()),
-				})
-			case combine(4, 3):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
+		tags, err := readTags(r, 1, decode)
+		if err != nil {
+			return decCACK, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
+		}
+		objs = append(objs, tags...)
+	}
+	decCACK.Tags = objs
+
+	if err := r.End(); err != nil {
+		return decCACK, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
+	}
+	return decCACK, nil
+}
+
+// PropertyAccessError is the error of reading one of the properties of a
+// ReadPropertyMultiple.
+type PropertyAccessError = objects.BACnetError
+
+// PropertyResult is the result of reading a property in a ReadPropertyMultiple,
+// holding either its value or Error.
+type PropertyResult struct {
+	PropertyId uint16
+	// ArrayIndex is ArrayAll without an array index.
+	ArrayIndex uint32
+	// Tags holds the tags of the value, possibly constructed.
+	Tags []*objects.Object
+	// Value is the decoded value. The properties known to the schema of the
+	// objects package decode to the Go type of their datatype, the others to
+	// the value of their application tag, or a []interface{} of them. It is
+	// nil for the values that fail to decode, left to Tags.
+	Value interface{}
+	Error *PropertyAccessError
+}
+
+// ReadAccessResult holds the results of reading the properties of an object.
+type ReadAccessResult struct {
+	ObjectId objects.ObjectIdentifier
+	Results  []PropertyResult
+}
+
+// Encode encodes the result of each property, its Tags or its Error.
+func (res *ReadAccessResult) Encode(w *objects.TagWriter) error {
+	if err := w.Write(objects.EncObjectIdentifier(true, 0, res.ObjectId.ObjectType, res.ObjectId.InstanceNumber)); err != nil {
+		return err
+	}
+	w.Open(1)
+	for _, pr := range res.Results {
+		if err := w.Write(objects.ContextTag(2, objects.EncUnsignedInteger(uint(pr.PropertyId)))); err != nil {
+			return err
+		}
+		if pr.ArrayIndex != objects.ArrayAll {
+			if err := w.Write(objects.ContextTag(3, objects.EncUnsignedInteger(uint(pr.ArrayIndex)))); err != nil {
+				return err
+			}
+		}
+		if pr.Error != nil {
+			w.Open(5)
+			for _, o := range ErrorObjects(pr.Error.ErrorClass, pr.Error.ErrorCode) {
+				if err := w.Write(o.(*objects.Object)); err != nil {
+					return err
 				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 3,
-					TagClass:  true,
-					Value:     objId,
-					Length:    uint8(obj.MarshalLen()),
-				})
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
 			}
-		} else {
-			tag, err := decodeAppTags(enc_obj, &obj)
-			if err != nil {
-				return decCACK, fmt.Errorf("decode Application Tag: %v", err)
+			if err := w.Close(5); err != nil {
+				return err
 			}
-			objs = append(objs, tag)
+			continue
+		}
+		w.Open(4)
+		for _, o := range pr.Tags {
+			if err := w.Write(o); err != nil {
+				return err
+			}
+		}
+		if err := w.Close(4); err != nil {
+			return err
 		}
 	}
-	decCACK.Tags = objs
+	return w.Close(1)
+}
 
-	return decCACK, nil
+// Decode decodes the results of each property, decoding their value after the
+// schema of the objects package.
+func (res *ReadAccessResult) Decode(r *objects.TagReader) error {
+	*res = ReadAccessResult{}
+	obj, err := r.ReadContext(0)
+	if err != nil {
+		return fmt.Errorf("decoding ReadAccessResult: %v", err)
+	}
+	if res.ObjectId, err = objects.DecObjectIdentifier(obj); err != nil {
+		return fmt.Errorf("decoding ReadAccessResult: %v", err)
+	}
+	if err := r.Enter(1); err != nil {
+		return fmt.Errorf("decoding ReadAccessResult: %v", err)
+	}
+	for !r.Done() {
+		pr, err := decodePropertyResult(r, res.ObjectId.ObjectType)
+		if err != nil {
+			return fmt.Errorf("decoding ReadAccessResult of %v: %v", res.ObjectId, err)
+		}
+		res.Results = append(res.Results, pr)
+	}
+	return r.Leave(1)
+}
+
+func decodePropertyResult(r *objects.TagReader, objectType uint16) (PropertyResult, error) {
+	pr := PropertyResult{ArrayIndex: objects.ArrayAll}
+	obj, err := r.ReadContext(2)
+	if err != nil {
+		return pr, err
+	}
+	pid, err := objects.DecUnsignedInteger(obj)
+	if err != nil {
+		return pr, err
+	}
+	pr.PropertyId = uint16(pid)
+	if r.PeekContext(3) {
+		obj, err := r.ReadContext(3)
+		if err != nil {
+			return pr, err
+		}
+		if pr.ArrayIndex, err = objects.DecUnsignedInteger(obj); err != nil {
+			return pr, err
+		}
+	}
+
+	if r.PeekContext(5) {
+		if err := r.Enter(5); err != nil {
+			return pr, err
+		}
+		decErr, err := readErrorType(r)
+		if err != nil {
+			return pr, err
+		}
+		pr.Error = decErr.Err()
+		return pr, r.Leave(5)
+	}
+
+	if err := r.Enter(4); err != nil {
+		return pr, err
+	}
+	for depth := r.Depth(); r.Depth() > depth || !r.Done(); {
+		o, err := r.Read()
+		if err != nil {
+			return pr, err
+		}
+		pr.Tags = append(pr.Tags, o)
+	}
+	if err := r.Leave(4); err != nil {
+		return pr, err
+	}
+	pr.Value = decodeResultValue(pr.Tags, objectType, pr.PropertyId, pr.ArrayIndex)
+	return pr, nil
+}
+
+// decodeResultValue decodes the value of a property from its tags, returning
+// nil when it fails so that the other properties decode anyway.
+func decodeResultValue(tags []*objects.Object, objectType, propertyId uint16, arrayIndex uint32) interface{} {
+	if _, ok := objects.LookupPropertyType(objectType, propertyId); ok {
+		w := objects.NewTagWriter()
+		for _, o := range tags {
+			if err := w.Write(o); err != nil {
+				return nil
+			}
+		}
+		b, err := w.Bytes()
+		if err != nil {
+			return nil
+		}
+		v, err := objects.DecodeProperty(objectType, propertyId, arrayIndex, objects.NewTagReader(b))
+		if err != nil {
+			return nil
+		}
+		return v
+	}
+
+	var values []interface{}
+	for _, o := range tags {
+		if o.TagClass {
+			continue
+		}
+		var obj objects.APDUPayload = o
+		tag, err := decodeAppTags(o, &obj)
+		if err != nil {
+			return nil
+		}
+		values = append(values, tag.Value)
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// ReadPropertyMultipleACKObjects creates the objects of the ComplexACK of a
+// ReadPropertyMultiple.
+func ReadPropertyMultipleACKObjects(results []ReadAccessResult) ([]objects.APDUPayload, error) {
+	w := objects.NewTagWriter()
+	for i := range results {
+		if err := results[i].Encode(w); err != nil {
+			return nil, fmt.Errorf("encoding ReadPropertyMultiple result of %v: %v", results[i].ObjectId, err)
+		}
+	}
+	return w.Objects()
+}
+
+// DecodeRPMResults decodes the ComplexACK of a ReadPropertyMultiple into the
+// results of the properties of each object read.
+func (c *ComplexACK) DecodeRPMResults() (map[objects.ObjectIdentifier][]PropertyResult, error) {
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
+	}
+	results := map[objects.ObjectIdentifier][]PropertyResult{}
+	for r.Remaining() > 0 {
+		var res ReadAccessResult
+		if err := res.Decode(r); err != nil {
+			return nil, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
+		}
+		results[res.ObjectId] = append(results[res.ObjectId], res.Results...)
+	}
+
+	if err := r.End(); err != nil {
+		return nil, fmt.Errorf("failed to decode ComplexACKRPM: %v", err)
+	}
+	return results, nil
 }
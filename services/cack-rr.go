@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
@@ -27,12 +26,7 @@ type LogBufferCACKDec struct {
 	Tags       []*objects.Object
 }
 
-type StatusFlags struct {
-	InAlarm      bool
-	Fault        bool
-	Overridden   bool
-	OutOfService bool
-}
+type StatusFlags = objects.StatusFlags
 
 func (c *ComplexACK) DecodeRR() (LogBufferCACKDec, error) {
 	decCACK := LogBufferCACKDec{}
@@ -45,117 +39,77 @@ func (c *ComplexACK) DecodeRR() (LogBufferCACKDec, error) {
 		)
 	}
 
-	context := []uint8{8}
-	objs := make([]*objects.Object, 0)
-	for i, obj := range c.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decCACK, fmt.Errorf(
-				"LogBufferCACK object at index %d is not Object type: %s",
-				i, common.ErrInvalidObjectType,
-			)
-		}
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
+	}
+	objId, propId, _, err := readPropertyReference(r)
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
+	}
+	decCACK.ObjectType = objId.ObjectType
+	decCACK.InstanceId = objId.InstanceNumber
+	decCACK.PropertyId = propId
 
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
-		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decCACK, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
-		}
+	obj, err := r.ReadContext(3)
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
+	}
+	if decCACK.FirstItem, decCACK.LastItem, decCACK.MoreItems, err = decResultsFlag(obj); err != nil {
+		return decCACK, fmt.Errorf("decode Context object case 3: %v", err)
+	}
+	if obj, err = r.ReadContext(4); err != nil {
+		return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
+	}
+	if decCACK.ItemCount, err = objects.DecUnsignedInteger(obj); err != nil {
+		return decCACK, fmt.Errorf("decode Context object case 4: %v", err)
+	}
 
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				decCACK.ObjectType = objId.ObjectType
-				decCACK.InstanceId = objId.InstanceNumber
-			case combine(8, 1):
-				value, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 1: %v", err)
-				}
-				propId := uint16(value)
-				decCACK.PropertyId = propId
-			case combine(8, 3):
-				first, last, more, err := decResultsFlag(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 3: %v", err)
-				}
-				decCACK.FirstItem = first
-				decCACK.LastItem = last
-				decCACK.MoreItems = more
-			case combine(8, 4):
-				data, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 4: %v", err)
-				}
-				decCACK.ItemCount = data
-			case combine(1, 2):
-				value, err := objects.DecReal(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 2: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 2,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     value,
-				})
-			case combine(5, 2):
-				value, err := decStatusFlags(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 2: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 2,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     value,
-				})
-			case combine(1, 0):
-				value, err := objects.DecLogStatus(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 0,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     value,
-				})
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
+	decCACK.Tags, err = readTags(r, 5, func(context uint8, o *objects.Object) (*objects.Object, error) {
+		switch {
+		case context == 1 && o.TagNumber == 0:
+			value, err := objects.DecLogStatus(o)
+			if err != nil {
+				return nil, fmt.Errorf("decode Context object case 0: %v", err)
+			}
+			return decodedTag(o, value), nil
+		case context == 1 && o.TagNumber == 2:
+			value, err := objects.DecReal(o)
+			if err != nil {
+				return nil, fmt.Errorf("decode Context object case 2: %v", err)
 			}
-		} else {
-			tag, err := decodeAppTags(enc_obj, &obj)
+			return decodedTag(o, value), nil
+		case context == 5 && o.TagNumber == 2:
+			value, err := decStatusFlags(o)
 			if err != nil {
-				return decCACK, fmt.Errorf("decode Application Tag: %v", err)
+				return nil, fmt.Errorf("decode Context object case 2: %v", err)
 			}
-			objs = append(objs, tag)
+			return decodedTag(o, value), nil
+		}
+		// Keep the tags this decoder doesn't know, undecoded.
+		return o, nil
+	})
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
+	}
+	// The sequence number of the first item is kept undecoded.
+	if r.PeekContext(6) {
+		if obj, err = r.ReadContext(6); err != nil {
+			return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
 		}
+		decCACK.Tags = append(decCACK.Tags, obj)
 	}
-	decCACK.Tags = objs
 
+	if err := r.End(); err != nil {
+		return decCACK, fmt.Errorf("failed to decode LogBufferCACK: %v", err)
+	}
 	return decCACK, nil
 }
 
 func decResultsFlag(obj objects.APDUPayload) (bool, bool, bool, error) {
 	var first, last, more bool
 	enc_obj, ok := obj.(*objects.Object)
-	if !ok {
+	if !ok || len(enc_obj.Data) < 2 {
 		return false, false, false, common.ErrInvalidObjectType
 	}
 	first = enc_obj.Data[1]&0x80 == 0x80
@@ -167,7 +121,7 @@ func decResultsFlag(obj objects.APDUPayload) (bool, bool, bool, error) {
 func decStatusFlags(obj objects.APDUPayload) (StatusFlags, error) {
 	var status StatusFlags
 	enc_obj, ok := obj.(*objects.Object)
-	if !ok {
+	if !ok || len(enc_obj.Data) < 2 {
 		return status, common.ErrInvalidObjectType
 	}
 	status.InAlarm = enc_obj.Data[1]&0x80 == 0x80
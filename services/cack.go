@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
@@ -20,7 +19,12 @@ type ComplexACKDec struct {
 	ObjectType uint16
 	InstanceId uint32
 	PropertyId uint16
+	ArrayIndex uint32
 	Tags       []*objects.Object
+	// Value is the property value decoded after the schema of the objects
+	// package, nil for the properties missing from it and for the values not
+	// fitting it. Tags hold the value anyway.
+	Value interface{}
 }
 
 func ComplexACKObjects(objectType uint16, instN uint32, propertyId uint16, value interface{}) []objects.APDUPayload {
@@ -120,11 +124,13 @@ func (c *ComplexACK) MarshalLen() int {
 }
 
 func (u *ComplexACK) SetLength() {
-	u.BVLC.Length = uint16(u.MarshalLen())
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
 }
 
 func (c *ComplexACK) Decode() (ComplexACKDec, error) {
-	decCACK := ComplexACKDec{}
+	decCACK := ComplexACKDec{ArrayIndex: objects.ArrayAll}
 
 	if len(c.APDU.Objects) < 3 {
 		return decCACK, fmt.Errorf(
@@ -134,99 +140,55 @@ func (c *ComplexACK) Decode() (ComplexACKDec, error) {
 		)
 	}
 
-	context := []uint8{8}
-	objs := make([]*objects.Object, 0)
-	for i, obj := range c.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decCACK, fmt.Errorf(
-				"ComplexACK object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
-		}
-
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
-		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decCACK, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
+	b, err := c.APDU.ServiceData()
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode CACK: %v", err)
+	}
+	r := objects.NewTagReader(b)
+	objId, propId, index, err := readPropertyReference(r)
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode CACK: %v", err)
+	}
+	if propId == objects.PropertyIdLogBuffer {
+		return decCACK, fmt.Errorf("PropertyIdLogBuffer should use ComplexACK.DecodeRR()")
+	}
+	decCACK.ObjectType = objId.ObjectType
+	decCACK.InstanceId = objId.InstanceNumber
+	decCACK.PropertyId = propId
+	decCACK.ArrayIndex = index
+
+	value := b[r.Offset():]
+	decCACK.Tags, err = readTags(r, 3, func(context uint8, o *objects.Object) (*objects.Object, error) {
+		if context != 3 {
+			return o, nil
 		}
-
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				decCACK.ObjectType = objId.ObjectType
-				decCACK.InstanceId = objId.InstanceNumber
-			case combine(8, 1):
-				value, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 1: %v", err)
-				}
-				propId := uint16(value)
-				if propId == objects.PropertyIdLogBuffer {
-					return decCACK, fmt.Errorf("PropertyIdLogBuffer should use ComplexACK.DecodeRR()")
-				}
-				decCACK.PropertyId = propId
-			case combine(3, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 0,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     objId,
-				})
-			case combine(3, 1):
-				propId, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 1: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 1,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     propId,
-				})
-			case combine(3, 3):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCACK, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 3,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     objId,
-				})
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
+		switch o.TagNumber {
+		case 0, 3:
+			objId, err := objects.DecObjectIdentifier(o)
+			if err != nil {
+				return nil, fmt.Errorf("decode Context object case %d: %v", o.TagNumber, err)
 			}
-		} else {
-			tag, err := decodeAppTags(enc_obj, &obj)
+			return decodedTag(o, objId), nil
+		case 1:
+			propId, err := objects.DecUnsignedInteger(o)
 			if err != nil {
-				return decCACK, fmt.Errorf("decode Application Tag: %v", err)
+				return nil, fmt.Errorf("decode Context object case 1: %v", err)
 			}
-			objs = append(objs, tag)
+			return decodedTag(o, propId), nil
 		}
+		// Keep the tags this decoder doesn't know, undecoded.
+		return o, nil
+	})
+	if err != nil {
+		return decCACK, fmt.Errorf("failed to decode CACK: %v", err)
 	}
-	decCACK.Tags = objs
 
+	if err := r.End(); err != nil {
+		return decCACK, fmt.Errorf("failed to decode CACK: %v", err)
+	}
+	decCACK.Value = decodePropertyValue(
+		objects.NewTagReader(value), 3, decCACK.ObjectType, decCACK.PropertyId, decCACK.ArrayIndex,
+	)
 	return decCACK, nil
 }
 
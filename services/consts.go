@@ -1,5 +1,7 @@
 package services
 
+import "fmt"
+
 // Services in APDU of which type is unconfirmed request.
 const (
 	ServiceUnconfirmedIAm uint8 = iota
@@ -45,3 +47,110 @@ const (
 	ServiceConfirmedRequestKey
 	ServiceConfirmedReadRange
 )
+
+// Network layer message types.
+const (
+	NetworkMessageWhoIsRouterToNetwork          uint8 = 0x00
+	NetworkMessageIAmRouterToNetwork            uint8 = 0x01
+	NetworkMessageICouldBeRouterToNetwork       uint8 = 0x02
+	NetworkMessageRejectMessageToNetwork        uint8 = 0x03
+	NetworkMessageRouterBusyToNetwork           uint8 = 0x04
+	NetworkMessageRouterAvailableToNetwork      uint8 = 0x05
+	NetworkMessageInitializeRoutingTable        uint8 = 0x06
+	NetworkMessageInitializeRoutingTableAck     uint8 = 0x07
+	NetworkMessageEstablishConnectionToNetwork  uint8 = 0x08
+	NetworkMessageDisconnectConnectionToNetwork uint8 = 0x09
+	NetworkMessageWhatIsNetworkNumber           uint8 = 0x12
+	NetworkMessageNetworkNumberIs               uint8 = 0x13
+)
+
+// Reasons of a Reject-Message-To-Network.
+const (
+	NetworkRejectOther uint8 = iota
+	NetworkRejectUnknownNetwork
+	NetworkRejectRouterBusy
+	NetworkRejectUnknownMessageType
+	NetworkRejectMessageTooLong
+	NetworkRejectSecurityError
+	NetworkRejectAddressingError
+)
+
+// RejectReason is the reason of a Reject, one of the RejectReason constants.
+type RejectReason uint8
+
+// Reasons of a Reject.
+const (
+	RejectReasonOther RejectReason = iota
+	RejectReasonBufferOverflow
+	RejectReasonInconsistentParameters
+	RejectReasonInvalidParameterDataType
+	RejectReasonInvalidTag
+	RejectReasonMissingRequiredParameter
+	RejectReasonParameterOutOfRange
+	RejectReasonTooManyArguments
+	RejectReasonUndefinedEnumeration
+	RejectReasonUnrecognizedService
+)
+
+// RejectReasonMap holds the names of the reasons of a Reject.
+var RejectReasonMap = map[uint8]string{
+	uint8(RejectReasonOther):                    "Other",
+	uint8(RejectReasonBufferOverflow):           "BufferOverflow",
+	uint8(RejectReasonInconsistentParameters):   "InconsistentParameters",
+	uint8(RejectReasonInvalidParameterDataType): "InvalidParameterDataType",
+	uint8(RejectReasonInvalidTag):               "InvalidTag",
+	uint8(RejectReasonMissingRequiredParameter): "MissingRequiredParameter",
+	uint8(RejectReasonParameterOutOfRange):      "ParameterOutOfRange",
+	uint8(RejectReasonTooManyArguments):         "TooManyArguments",
+	uint8(RejectReasonUndefinedEnumeration):     "UndefinedEnumeration",
+	uint8(RejectReasonUnrecognizedService):      "UnrecognizedService",
+}
+
+func (r RejectReason) String() string {
+	if s, ok := RejectReasonMap[uint8(r)]; ok {
+		return s
+	}
+	return fmt.Sprintf("RejectReason(%d)", uint8(r))
+}
+
+// AbortReason is the reason of an Abort, one of the AbortReason constants.
+type AbortReason uint8
+
+// Reasons of an Abort.
+const (
+	AbortReasonOther AbortReason = iota
+	AbortReasonBufferOverflow
+	AbortReasonInvalidAPDUInThisState
+	AbortReasonPreemptedByHigherPriorityTask
+	AbortReasonSegmentationNotSupported
+	AbortReasonSecurityError
+	AbortReasonInsufficientSecurity
+	AbortReasonWindowSizeOutOfRange
+	AbortReasonApplicationExceededReplyTime
+	AbortReasonOutOfResources
+	AbortReasonTSMTimeout
+	AbortReasonAPDUTooLong
+)
+
+// AbortReasonMap holds the names of the reasons of an Abort.
+var AbortReasonMap = map[uint8]string{
+	uint8(AbortReasonOther):                         "Other",
+	uint8(AbortReasonBufferOverflow):                "BufferOverflow",
+	uint8(AbortReasonInvalidAPDUInThisState):        "InvalidAPDUInThisState",
+	uint8(AbortReasonPreemptedByHigherPriorityTask): "PreemptedByHigherPriorityTask",
+	uint8(AbortReasonSegmentationNotSupported):      "SegmentationNotSupported",
+	uint8(AbortReasonSecurityError):                 "SecurityError",
+	uint8(AbortReasonInsufficientSecurity):          "InsufficientSecurity",
+	uint8(AbortReasonWindowSizeOutOfRange):          "WindowSizeOutOfRange",
+	uint8(AbortReasonApplicationExceededReplyTime):  "ApplicationExceededReplyTime",
+	uint8(AbortReasonOutOfResources):                "OutOfResources",
+	uint8(AbortReasonTSMTimeout):                    "TSMTimeout",
+	uint8(AbortReasonAPDUTooLong):                   "APDUTooLong",
+}
+
+func (r AbortReason) String() string {
+	if s, ok := AbortReasonMap[uint8(r)]; ok {
+		return s
+	}
+	return fmt.Sprintf("AbortReason(%d)", uint8(r))
+}
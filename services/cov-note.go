@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
@@ -24,6 +23,29 @@ type COVNotificationDec struct {
 	ObjInstanceNum uint32
 	Lifetime       uint32
 	Tags           []*objects.Object
+	Values         []objects.BACnetPropertyValue
+}
+
+// COVNotificationObjects creates the objects of a COV notification to the
+// subscriber processId, carrying the present value and the status flags of an
+// object of the device devInstN with timeRemaining seconds left.
+func COVNotificationObjects(pid uint, devInstN uint32, oid uint16, instN uint32, timeRemaining uint, value float32) []objects.APDUPayload {
+	return []objects.APDUPayload{
+		objects.ContextTag(0, objects.EncUnsignedInteger(pid)),
+		objects.EncObjectIdentifier(true, 1, objects.ObjectTypeDevice, devInstN),
+		objects.EncObjectIdentifier(true, 2, oid, instN),
+		objects.ContextTag(3, objects.EncUnsignedInteger(timeRemaining)),
+		objects.EncOpeningTag(4),
+		objects.ContextTag(0, objects.EncUnsignedInteger(uint(objects.PropertyIdPresentValue))),
+		objects.EncOpeningTag(2),
+		objects.EncReal(value),
+		objects.EncClosingTag(2),
+		objects.ContextTag(0, objects.EncUnsignedInteger(uint(objects.PropertyIdStatusFlags))),
+		objects.EncOpeningTag(2),
+		objects.EncBitString(make([]bool, 4)),
+		objects.EncClosingTag(2),
+		objects.EncClosingTag(4),
+	}
 }
 
 // NewConfirmedCOV creates a UnconfirmedCOVNotification.
@@ -135,93 +157,88 @@ func (u *COVNotification) MarshalLen() int {
 
 // SetLength sets the length in Length field.
 func (u *COVNotification) SetLength() {
-	u.BVLC.Length = uint16(u.MarshalLen())
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
 }
 
 func (u *COVNotification) Decode() (COVNotificationDec, error) {
 	decCOV := COVNotificationDec{}
 
-	context := []uint8{8}
-	objs := make([]*objects.Object, 0)
-	for i, obj := range u.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decCOV, fmt.Errorf(
-				"ComplexACK object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
+	b, err := u.APDU.ServiceData()
+	if err != nil {
+		return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
+	}
+	r := objects.NewTagReader(b)
+	obj, err := r.ReadContext(0)
+	if err != nil {
+		return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
+	}
+	if decCOV.ProcessId, err = objects.DecUnsignedInteger(obj); err != nil {
+		return decCOV, fmt.Errorf("decode ProcessId: %v", err)
+	}
+	for _, id := range []struct {
+		tagNumber  uint8
+		objectType *uint16
+		instance   *uint32
+	}{
+		{1, &decCOV.DeviceType, &decCOV.DevInstanceNum},
+		{2, &decCOV.ObjectType, &decCOV.ObjInstanceNum},
+	} {
+		if obj, err = r.ReadContext(id.tagNumber); err != nil {
+			return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
 		}
+		objId, err := objects.DecObjectIdentifier(obj)
+		if err != nil {
+			return decCOV, fmt.Errorf("decode MonitoredObjID: %v", err)
+		}
+		*id.objectType, *id.instance = objId.ObjectType, objId.InstanceNumber
+	}
+	if obj, err = r.ReadContext(3); err != nil {
+		return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
+	}
+	if decCOV.Lifetime, err = objects.DecUnsignedInteger(obj); err != nil {
+		return decCOV, fmt.Errorf("decode Lifetime: %v", err)
+	}
 
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
+	values := b[r.Offset():]
+	decCOV.Tags, err = readTags(r, 4, func(context uint8, o *objects.Object) (*objects.Object, error) {
+		if context != 4 || o.TagNumber != 0 {
+			// Keep the tags this decoder doesn't know, undecoded.
+			return o, nil
 		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decCOV, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
+		prop, err := objects.DecUnsignedInteger(o)
+		if err != nil {
+			return nil, fmt.Errorf("decode PropertyId: %v", err)
 		}
+		return decodedTag(o, prop), nil
+	})
+	if err != nil {
+		return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
+	}
+	if err := r.End(); err != nil {
+		return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
+	}
+	if decCOV.Values, err = covValues(objects.NewTagReader(values)); err != nil {
+		return decCOV, fmt.Errorf("failed to decode COVNotification: %v", err)
+	}
+	return decCOV, nil
+}
 
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				prop, err := objects.DecUnsignedInteger(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode ProcessId: %v", err)
-				}
-				decCOV.ProcessId = prop
-			case combine(8, 1):
-				prop, err := objects.DecObjectIdentifier(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode MonitoredObjID: %v", err)
-				}
-				decCOV.DeviceType = prop.ObjectType
-				decCOV.DevInstanceNum = prop.InstanceNumber
-			case combine(8, 2):
-				prop, err := objects.DecObjectIdentifier(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode MonitoredObjID: %v", err)
-				}
-				decCOV.ObjectType = prop.ObjectType
-				decCOV.ObjInstanceNum = prop.InstanceNumber
-			case combine(8, 3):
-				prop, err := objects.DecUnsignedInteger(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode Lifetime: %v", err)
-				}
-				decCOV.Lifetime = prop
-			case combine(4, 0):
-				prop, err := objects.DecUnsignedInteger(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode PropertyId: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 0,
-					TagClass:  true,
-					Value:     prop,
-					Length:    uint8(enc_obj.MarshalLen()),
-				})
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
-			}
-		} else {
-			// log.Println("TagNumber", enc_obj.TagNumber)
-			tag, err := decodeAppTags(enc_obj, &obj)
-			if err != nil {
-				return decCOV, fmt.Errorf("decode Application Tag: %v", err)
-			}
-			objs = append(objs, tag)
+// covValues decodes the list of values [4] of a COV notification r is on.
+func covValues(r *objects.TagReader) ([]objects.BACnetPropertyValue, error) {
+	if err := r.Enter(4); err != nil {
+		return nil, err
+	}
+	var values []objects.BACnetPropertyValue
+	for !r.Done() {
+		var v objects.BACnetPropertyValue
+		if err := v.Decode(r); err != nil {
+			return nil, err
 		}
+		values = append(values, v)
 	}
-	decCOV.Tags = objs
-	return decCOV, nil
+	return values, r.Leave(4)
 }
 
 func (u *COVNotification) GetService() uint8 {
@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
@@ -59,10 +58,13 @@ func NewConfirmedSubscribeCOV(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) (*Confir
 
 // UnmarshalBinary sets the values retrieved from byte sequence in a ConfirmedCOV frame.
 func (u *ConfirmedCOV) UnmarshalBinary(b []byte) error {
-	if l := len(b); l < u.MarshalLen() {
+	// Only the headers bound the length, cancellations being shorter than
+	// subscriptions.
+	minLen := u.BVLC.MarshalLen() + u.NPDU.MarshalLen() + (&plumbing.APDU{Type: u.APDU.Type}).MarshalLen()
+	if l := len(b); l < minLen {
 		return fmt.Errorf(
-			"failed to unmarshal ConfirmedCOV - marshal length %d binary length %d: %v",
-			u.MarshalLen(), l,
+			"failed to unmarshal ConfirmedCOV - minimum length %d binary length %d: %v",
+			minLen, l,
 			common.ErrTooShortToParse,
 		)
 	}
@@ -140,83 +142,73 @@ func (u *ConfirmedCOV) MarshalLen() int {
 
 // SetLength sets the length in Length field.
 func (u *ConfirmedCOV) SetLength() {
-	u.BVLC.Length = uint16(u.MarshalLen())
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
 }
 
 func (u *ConfirmedCOV) Decode() (ConfirmedCOVDec, error) {
 	decCOV := ConfirmedCOVDec{}
 
-	if len(u.APDU.Objects) != 4 {
+	// Cancellations only carry the process ID and the monitored object.
+	if len(u.APDU.Objects) != 4 && len(u.APDU.Objects) != 2 {
 		return decCOV, fmt.Errorf(
-			"failed to decode ConfirmedCOV - number of objects %d: %v",
+			"failed to decode ConfirmedCOV - number of objects %d: %w",
 			len(u.APDU.Objects),
 			common.ErrWrongObjectCount,
 		)
 	}
 
-	context := []uint8{8}
-	for i, obj := range u.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
+	r, err := serviceReader(u.APDU)
+	if err != nil {
+		return decCOV, fmt.Errorf("failed to decode ConfirmedCOV: %w", err)
+	}
+	obj, err := r.ReadContext(0)
+	if err != nil {
+		return decCOV, fmt.Errorf("failed to decode ConfirmedCOV: %w", err)
+	}
+	if decCOV.ProcessId, err = objects.DecUnsignedInteger(obj); err != nil {
+		return decCOV, fmt.Errorf("decode ProcessId: %w", err)
+	}
+	if obj, err = r.ReadContext(1); err != nil {
+		return decCOV, fmt.Errorf("failed to decode ConfirmedCOV: %w", err)
+	}
+	objId, err := objects.DecObjectIdentifier(obj)
+	if err != nil {
+		return decCOV, fmt.Errorf("decode MonitoredObjID: %w", err)
+	}
+	decCOV.MonitoredObjType = objId.ObjectType
+	decCOV.MonitoredInstNum = objId.InstanceNumber
+
+	if r.Remaining() > 0 {
+		if obj, err = r.ReadContext(2); err != nil {
+			return decCOV, fmt.Errorf("failed to decode ConfirmedCOV: %w", err)
+		}
+		if len(obj.Data) != 1 {
 			return decCOV, fmt.Errorf(
-				"ComplexACK object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
+				"ConfirmedCOV object at offset %d has invalid data length: %w",
+				r.Offset(), common.ErrInvalidObjectType,
 			)
 		}
-
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
-		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decCOV, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
+		decCOV.ExpectConfirmed = common.IntToBool(int(obj.Data[0]))
+		if obj, err = r.ReadContext(3); err != nil {
+			return decCOV, fmt.Errorf("failed to decode ConfirmedCOV: %w", err)
 		}
-
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				proc, err := objects.DecUnsignedInteger(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode ProcessId: %v", err)
-				}
-				decCOV.ProcessId = proc
-			case combine(8, 1):
-				objId, err := objects.DecObjectIdentifier(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode MonitoredObjID: %v", err)
-				}
-				decCOV.MonitoredObjType = objId.ObjectType
-				decCOV.MonitoredInstNum = objId.InstanceNumber
-			case combine(8, 2):
-				if len(enc_obj.Data) != 1 {
-					return decCOV, fmt.Errorf(
-						"LogBufferCACK object at index %d has invalid data length: %v",
-						i, common.ErrInvalidObjectType,
-					)
-				}
-				decCOV.ExpectConfirmed = common.IntToBool(int(enc_obj.Data[0]))
-			case combine(8, 3):
-				life, err := objects.DecUnsignedInteger(enc_obj)
-				if err != nil {
-					return decCOV, fmt.Errorf("decode Lifetime: %v", err)
-				}
-				decCOV.Lifetime = life
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
-			}
-		} else {
-			log.Printf("Not encoded object: %+v\n", enc_obj)
+		if decCOV.Lifetime, err = objects.DecUnsignedInteger(obj); err != nil {
+			return decCOV, fmt.Errorf("decode Lifetime: %w", err)
 		}
 	}
 
+	if err := r.End(); err != nil {
+		return decCOV, fmt.Errorf("failed to decode ConfirmedCOV: %w", err)
+	}
 	return decCOV, nil
 }
+
+func (u *ConfirmedCOV) GetService() uint8 {
+	return u.APDU.Service
+}
+
+func (u *ConfirmedCOV) GetType() uint8 {
+	return u.APDU.Type
+}
@@ -16,19 +16,100 @@ type Error struct {
 }
 
 type ErrorDec struct {
-	ErrorClass uint8
-	ErrorCode  uint8
+	ErrorClass uint16
+	ErrorCode  uint16
 }
 
-func ErrorObjects(errClass, errCode uint8) []objects.APDUPayload {
+// Err returns the error class and code as an error, which errors.Is matches
+// with the sentinel errors of the objects package.
+func (d ErrorDec) Err() *objects.BACnetError {
+	return objects.NewBACnetError(d.ErrorClass, d.ErrorCode)
+}
+
+// WritePropertyMultipleErrorDec is the error of a WritePropertyMultiple,
+// telling the first write that failed.
+type WritePropertyMultipleErrorDec struct {
+	ErrorDec
+	FirstFailedWrite objects.BACnetObjectPropertyReference
+}
+
+// ChangeListErrorDec is the error of an AddListElement or a
+// RemoveListElement, telling the first element that failed, 1 for the first.
+type ChangeListErrorDec struct {
+	ErrorDec
+	FirstFailedElement uint32
+}
+
+// CreateObjectErrorDec is the error of a CreateObject, telling the first
+// initial value that failed, 1 for the first or 0 when none did.
+type CreateObjectErrorDec struct {
+	ErrorDec
+	FirstFailedElement uint32
+}
+
+func ErrorObjects(errClass, errCode uint16) []objects.APDUPayload {
 	objs := make([]objects.APDUPayload, 2)
 
-	objs[0] = objects.EncEnumerated(errClass)
-	objs[1] = objects.EncEnumerated(errCode)
+	objs[0] = objects.EncEnumerated32(uint32(errClass))
+	objs[1] = objects.EncEnumerated32(uint32(errCode))
 
 	return objs
 }
 
+// writeErrorType writes the error class and code in the constructed value 0
+// beginning the service specific errors.
+func writeErrorType(w *objects.TagWriter, errClass, errCode uint16) error {
+	w.Open(0)
+	for _, o := range ErrorObjects(errClass, errCode) {
+		if err := w.Write(o.(*objects.Object)); err != nil {
+			return err
+		}
+	}
+	return w.Close(0)
+}
+
+// WritePropertyMultipleErrorObjects creates the objects of the error of a
+// WritePropertyMultiple whose write of ref failed first.
+func WritePropertyMultipleErrorObjects(errClass, errCode uint16, ref objects.BACnetObjectPropertyReference) ([]objects.APDUPayload, error) {
+	w := objects.NewTagWriter()
+	if err := writeErrorType(w, errClass, errCode); err != nil {
+		return nil, err
+	}
+	w.Open(1)
+	if err := ref.Encode(w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(1); err != nil {
+		return nil, err
+	}
+	return w.Objects()
+}
+
+// ChangeListErrorObjects creates the objects of the error of an
+// AddListElement or a RemoveListElement whose element firstFailedElement,
+// counted from 1, failed first.
+func ChangeListErrorObjects(errClass, errCode uint16, firstFailedElement uint32) ([]objects.APDUPayload, error) {
+	return elementErrorObjects(errClass, errCode, firstFailedElement)
+}
+
+// CreateObjectErrorObjects creates the objects of the error of a CreateObject
+// whose initial value firstFailedElement, counted from 1, failed first. Pass 0
+// when the error is not about an initial value.
+func CreateObjectErrorObjects(errClass, errCode uint16, firstFailedElement uint32) ([]objects.APDUPayload, error) {
+	return elementErrorObjects(errClass, errCode, firstFailedElement)
+}
+
+func elementErrorObjects(errClass, errCode uint16, firstFailedElement uint32) ([]objects.APDUPayload, error) {
+	w := objects.NewTagWriter()
+	if err := writeErrorType(w, errClass, errCode); err != nil {
+		return nil, err
+	}
+	if err := w.Write(objects.ContextTag(1, objects.EncUnsignedInteger(uint(firstFailedElement)))); err != nil {
+		return nil, err
+	}
+	return w.Objects()
+}
+
 func NewError(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *Error {
 	e := &Error{
 		BVLC: bvlc,
@@ -122,40 +203,144 @@ func (e *Error) MarshalLen() int {
 
 // SetLength sets the length in Length field.
 func (e *Error) SetLength() {
-	e.BVLC.Length = uint16(e.MarshalLen())
+	if e.BVLC != nil {
+		e.BVLC.Length = uint16(e.MarshalLen())
+	}
 }
 
+// Decode decodes the error class and code of an Error, those beginning the
+// service specific errors included. DecodeWPM, DecodeChangeList and
+// DecodeCreateObject decode the rest of these.
 func (e *Error) Decode() (ErrorDec, error) {
-	decErr := ErrorDec{}
+	r, err := serviceReader(e.APDU)
+	if err != nil {
+		return ErrorDec{}, fmt.Errorf("failed to decode Error: %v", err)
+	}
+	if !r.PeekContext(0) {
+		decErr, err := readErrorType(r)
+		if err != nil {
+			return decErr, fmt.Errorf("failed to decode Error: %v", err)
+		}
+		if err := r.End(); err != nil {
+			return decErr, fmt.Errorf("failed to decode Error: %v", err)
+		}
+		return decErr, nil
+	}
 
-	if len(e.APDU.Objects) != 2 {
-		return decErr, fmt.Errorf(
-			"failed to decode Error - object count: %d: %v",
-			len(e.APDU.Objects),
-			common.ErrWrongObjectCount,
-		)
+	decErr, err := readNestedErrorType(r)
+	if err != nil {
+		return decErr, fmt.Errorf("failed to decode Error: %v", err)
+	}
+	for r.Remaining() > 0 {
+		if err := r.Skip(); err != nil {
+			return decErr, fmt.Errorf("failed to decode Error: %v", err)
+		}
 	}
+	return decErr, nil
+}
 
-	for i, obj := range e.APDU.Objects {
-		switch i {
-		case 0:
-			errClass, err := objects.DecEnumerated(obj)
-			if err != nil {
-				return decErr, fmt.Errorf("failed to decode Enumerated Object: %v", err)
-			}
-			decErr.ErrorClass = uint8(errClass)
-		case 1:
-			errCode, err := objects.DecEnumerated(obj)
-			if err != nil {
-				return decErr, fmt.Errorf("failed to decode Enumerated Object: %v", err)
-			}
-			decErr.ErrorCode = uint8(errCode)
+// readErrorType reads an error class and code.
+func readErrorType(r *objects.TagReader) (ErrorDec, error) {
+	decErr := ErrorDec{}
+	for _, v := range []*uint16{&decErr.ErrorClass, &decErr.ErrorCode} {
+		obj, err := r.ReadPrimitive()
+		if err != nil {
+			return decErr, err
+		}
+		if obj.TagClass || obj.TagNumber != objects.TagEnumerated {
+			return decErr, fmt.Errorf("error class or code of tag %d: %v", obj.TagNumber, common.ErrWrongTagNumber)
 		}
+		enum, err := objects.DecEnumerated(obj)
+		if err != nil {
+			return decErr, fmt.Errorf("failed to decode Enumerated Object: %v", err)
+		}
+		if enum > 0xFFFF {
+			return decErr, fmt.Errorf("error class or code %d: %v", enum, common.ErrTooBigValue)
+		}
+		*v = uint16(enum)
 	}
+	return decErr, nil
+}
 
+// readNestedErrorType reads the error class and code in the constructed value
+// 0 beginning the service specific errors.
+func readNestedErrorType(r *objects.TagReader) (ErrorDec, error) {
+	if err := r.Enter(0); err != nil {
+		return ErrorDec{}, err
+	}
+	decErr, err := readErrorType(r)
+	if err != nil {
+		return decErr, err
+	}
+	return decErr, r.Leave(0)
+}
+
+// DecodeWPM decodes the error of a WritePropertyMultiple.
+func (e *Error) DecodeWPM() (WritePropertyMultipleErrorDec, error) {
+	decErr := WritePropertyMultipleErrorDec{}
+
+	r, err := serviceReader(e.APDU)
+	if err != nil {
+		return decErr, fmt.Errorf("failed to decode WritePropertyMultiple Error: %v", err)
+	}
+	if decErr.ErrorDec, err = readNestedErrorType(r); err != nil {
+		return decErr, fmt.Errorf("failed to decode WritePropertyMultiple Error: %v", err)
+	}
+	if err := r.Enter(1); err != nil {
+		return decErr, fmt.Errorf("failed to decode WritePropertyMultiple Error: %v", err)
+	}
+	if err := decErr.FirstFailedWrite.Decode(r); err != nil {
+		return decErr, fmt.Errorf("failed to decode WritePropertyMultiple Error: %v", err)
+	}
+	if err := r.Leave(1); err != nil {
+		return decErr, fmt.Errorf("failed to decode WritePropertyMultiple Error: %v", err)
+	}
+
+	if err := r.End(); err != nil {
+		return decErr, fmt.Errorf("failed to decode WritePropertyMultiple Error: %v", err)
+	}
 	return decErr, nil
 }
 
+// DecodeChangeList decodes the error of an AddListElement or a
+// RemoveListElement.
+func (e *Error) DecodeChangeList() (ChangeListErrorDec, error) {
+	decErr, element, err := e.decodeElementError()
+	if err != nil {
+		return ChangeListErrorDec{}, fmt.Errorf("failed to decode ChangeList Error: %v", err)
+	}
+	return ChangeListErrorDec{ErrorDec: decErr, FirstFailedElement: element}, nil
+}
+
+// DecodeCreateObject decodes the error of a CreateObject.
+func (e *Error) DecodeCreateObject() (CreateObjectErrorDec, error) {
+	decErr, element, err := e.decodeElementError()
+	if err != nil {
+		return CreateObjectErrorDec{}, fmt.Errorf("failed to decode CreateObject Error: %v", err)
+	}
+	return CreateObjectErrorDec{ErrorDec: decErr, FirstFailedElement: element}, nil
+}
+
+func (e *Error) decodeElementError() (ErrorDec, uint32, error) {
+	r, err := serviceReader(e.APDU)
+	if err != nil {
+		return ErrorDec{}, 0, err
+	}
+	decErr, err := readNestedErrorType(r)
+	if err != nil {
+		return decErr, 0, err
+	}
+	obj, err := r.ReadContext(1)
+	if err != nil {
+		return decErr, 0, err
+	}
+	element, err := objects.DecUnsignedInteger(obj)
+	if err != nil {
+		return decErr, 0, err
+	}
+	return decErr, element, r.End()
+}
+
 func (u *Error) GetService() uint8 {
 	return u.APDU.Service
 }
@@ -130,7 +130,9 @@ func (u *UnconfirmedIAm) MarshalLen() int {
 
 // SetLength sets the length in Length field.
 func (u *UnconfirmedIAm) SetLength() {
-	u.BVLC.Length = uint16(u.MarshalLen())
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
 }
 
 func (u *UnconfirmedIAm) Decode() (UnconfirmedIAmDec, error) {
@@ -281,7 +283,9 @@ func (u *UnicastIAm) MarshalLen() int {
 // SetLength sets the length in the BVLC Length field.
 func (u *UnicastIAm) SetLength() {
 	//same as other
-	u.BVLC.Length = uint16(u.MarshalLen())
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
 }
 
 // Decode extracts the relevant fields from the UnicastIAm message.
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// UnconfirmedIHave is a BACnet message.
+type UnconfirmedIHave struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+type UnconfirmedIHaveDec struct {
+	DeviceId   objects.ObjectIdentifier
+	ObjectId   objects.ObjectIdentifier
+	ObjectName string
+}
+
+// IHaveObjects creates the objects of an I-Have of the object oid named name
+// in the device deviceInstance.
+func IHaveObjects(deviceInstance uint32, oid objects.ObjectIdentifier, name string) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 3)
+
+	objs[0] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objects.ObjectTypeDevice, deviceInstance)
+	objs[1] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, oid.ObjectType, oid.InstanceNumber)
+	objs[2] = objects.EncString(name)
+
+	return objs
+}
+
+// NewUnconfirmedIHave creates a UnconfirmedIHave.
+func NewUnconfirmedIHave(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedIHave {
+	u := &UnconfirmedIHave{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedIHave, nil),
+	}
+	u.SetLength()
+	return u
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a UnconfirmedIHave frame.
+func (u *UnconfirmedIHave) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal UnconfirmedIHave - marshal length %d binary length %d: %v",
+			u.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling UnconfirmedIHave %+v: %v",
+			u, common.ErrTooShortToParse,
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling UnconfirmedIHave %+v: %v",
+			u, common.ErrTooShortToParse,
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling UnconfirmedIHave %+v: %v",
+			u, common.ErrTooShortToParse,
+		)
+	}
+
+	return nil
+}
+
+// MarshalBinary returns the byte sequence generated from a UnconfirmedIHave instance.
+func (u *UnconfirmedIHave) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+// MarshalTo puts the byte sequence in the byte array given as b.
+func (u *UnconfirmedIHave) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal UnconfirmedIHave - marshal length %d binary length %d: %v",
+			u.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling UnconfirmedIHave: %v", err)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling UnconfirmedIHave: %v", err)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling UnconfirmedIHave: %v", err)
+	}
+
+	return nil
+}
+
+// MarshalLen returns the serial length of UnconfirmedIHave.
+func (u *UnconfirmedIHave) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedIHave) Decode() (UnconfirmedIHaveDec, error) {
+	decIHave := UnconfirmedIHaveDec{}
+
+	if len(u.APDU.Objects) != 3 {
+		return decIHave, fmt.Errorf(
+			"failed to decode UnconfirmedIHave - number of objects %d: %v",
+			len(u.APDU.Objects),
+			common.ErrWrongObjectCount,
+		)
+	}
+
+	r, err := serviceReader(u.APDU)
+	if err != nil {
+		return decIHave, fmt.Errorf("failed to decode UnconfirmedIHave: %v", err)
+	}
+	for _, oid := range []*objects.ObjectIdentifier{&decIHave.DeviceId, &decIHave.ObjectId} {
+		obj, err := r.ReadPrimitive()
+		if err != nil {
+			return decIHave, fmt.Errorf("failed to decode UnconfirmedIHave: %v", err)
+		}
+		if obj.TagClass || obj.TagNumber != objects.TagBACnetObjectIdentifier {
+			return decIHave, fmt.Errorf(
+				"failed to decode UnconfirmedIHave - tag %d: %v", obj.TagNumber, common.ErrWrongTagNumber,
+			)
+		}
+		if *oid, err = objects.DecObjectIdentifier(obj); err != nil {
+			return decIHave, fmt.Errorf("decode Application object case 12: %v", err)
+		}
+	}
+	if decIHave.DeviceId.ObjectType != objects.ObjectTypeDevice {
+		return decIHave, fmt.Errorf(
+			"failed to decode UnconfirmedIHave - device object type %d: %v",
+			decIHave.DeviceId.ObjectType, common.ErrInvalidObjectType,
+		)
+	}
+	obj, err := r.ReadPrimitive()
+	if err != nil {
+		return decIHave, fmt.Errorf("failed to decode UnconfirmedIHave: %v", err)
+	}
+	if decIHave.ObjectName, err = objects.DecString(obj); err != nil {
+		return decIHave, fmt.Errorf("decode Application object case 7: %v", err)
+	}
+
+	if err := r.End(); err != nil {
+		return decIHave, fmt.Errorf("failed to decode UnconfirmedIHave: %v", err)
+	}
+	return decIHave, nil
+}
+
+// SetLength sets the length in Length field.
+func (u *UnconfirmedIHave) SetLength() {
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
+}
+
+func (u *UnconfirmedIHave) GetService() uint8 {
+	return u.APDU.Service
+}
+
+func (u *UnconfirmedIHave) GetType() uint8 {
+	return u.APDU.Type
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// NetworkMessage is a network layer message, such as Who-Is-Router-To-Network.
+// Its type is NPDU.MessageType and it carries Data instead of an APDU.
+type NetworkMessage struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	Data []byte
+}
+
+// RoutingTablePort is an entry of an Initialize-Routing-Table message.
+type RoutingTablePort struct {
+	Network uint16
+	PortID  uint8
+	Info    []byte
+}
+
+// NetworkMessageDec holds the decoded Data of a NetworkMessage. Only the
+// fields carried by the message type are set.
+type NetworkMessageDec struct {
+	// Networks lists the DNETs of Who-Is-Router-To-Network, I-Am-Router-To-Network,
+	// Router-Busy-To-Network and Router-Available-To-Network, the DNET of
+	// I-Could-Be-Router-To-Network and Reject-Message-To-Network and the network
+	// number of Network-Number-Is.
+	Networks         []uint16
+	PerformanceIndex uint8
+	Reason           uint8
+	Ports            []RoutingTablePort
+	// Configured tells whether a Network-Number-Is comes from a router
+	// configured with the network number rather than one that learnt it.
+	Configured bool
+}
+
+// NewNetworkMessage creates a NetworkMessage with a copy of npdu flagged as
+// carrying a network layer message, leaving npdu as it is.
+func NewNetworkMessage(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *NetworkMessage {
+	n := *npdu
+	n.Control |= 0x80
+	m := &NetworkMessage{
+		BVLC: bvlc,
+		NPDU: &n,
+	}
+	m.SetLength()
+
+	return m
+}
+
+// NetworksData encodes a list of network numbers.
+func NetworksData(networks []uint16) []byte {
+	data := make([]byte, 2*len(networks))
+	for i, n := range networks {
+		binary.BigEndian.PutUint16(data[2*i:], n)
+	}
+	return data
+}
+
+// ICouldBeRouterData encodes the Data of an I-Could-Be-Router-To-Network.
+func ICouldBeRouterData(network uint16, performanceIndex uint8) []byte {
+	return append(NetworksData([]uint16{network}), performanceIndex)
+}
+
+// RejectMessageData encodes the Data of a Reject-Message-To-Network.
+func RejectMessageData(reason uint8, network uint16) []byte {
+	return append([]byte{reason}, NetworksData([]uint16{network})...)
+}
+
+// RoutingTableData encodes the Data of an Initialize-Routing-Table or its Ack.
+func RoutingTableData(ports []RoutingTablePort) ([]byte, error) {
+	if len(ports) > 0xFF {
+		return nil, fmt.Errorf("encoding %d routing table ports: %v", len(ports), common.ErrWrongObjectCount)
+	}
+	data := []byte{uint8(len(ports))}
+	for _, p := range ports {
+		if len(p.Info) > 0xFF {
+			return nil, fmt.Errorf("encoding port info length %d: %v", len(p.Info), common.ErrWrongStructure)
+		}
+		data = append(data, NetworksData([]uint16{p.Network})...)
+		data = append(data, p.PortID, uint8(len(p.Info)))
+		data = append(data, p.Info...)
+	}
+	return data, nil
+}
+
+// NetworkNumberIsData encodes the Data of a Network-Number-Is.
+func NetworkNumberIsData(network uint16, configured bool) []byte {
+	return append(NetworksData([]uint16{network}), uint8(common.BoolToInt(configured)))
+}
+
+func (m *NetworkMessage) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < m.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal NetworkMessage - marshal length %d binary length %d: %v",
+			m.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+
+	var offset int = 0
+	if err := m.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling NetworkMessage %+v: %v", m, common.ErrTooShortToParse,
+		)
+	}
+	offset += m.BVLC.MarshalLen()
+
+	if err := m.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling NetworkMessage %+v: %v", m, common.ErrTooShortToParse,
+		)
+	}
+	if !m.NPDU.IsNetworkMessage() {
+		return fmt.Errorf(
+			"unmarshalling NetworkMessage control %x: %v", m.NPDU.Control, common.ErrWrongPayload,
+		)
+	}
+	offset += m.NPDU.MarshalLen()
+
+	m.Data = append([]byte{}, b[offset:]...)
+
+	return nil
+}
+
+func (m *NetworkMessage) MarshalBinary() ([]byte, error) {
+	b := make([]byte, m.MarshalLen())
+	if err := m.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+func (m *NetworkMessage) MarshalTo(b []byte) error {
+	if len(b) < m.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal NetworkMessage - marshal length %d binary length %d: %v",
+			m.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	var offset = 0
+	if err := m.BVLC.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling NetworkMessage: %v", err)
+	}
+	offset += m.BVLC.MarshalLen()
+
+	if err := m.NPDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling NetworkMessage: %v", err)
+	}
+	offset += m.NPDU.MarshalLen()
+
+	copy(b[offset:], m.Data)
+
+	return nil
+}
+
+func (m *NetworkMessage) MarshalLen() int {
+	l := m.BVLC.MarshalLen()
+	l += m.NPDU.MarshalLen()
+	l += len(m.Data)
+
+	return l
+}
+
+func (m *NetworkMessage) SetLength() {
+	if m.BVLC != nil {
+		m.BVLC.Length = uint16(m.MarshalLen())
+	}
+}
+
+// GetType returns the network layer message type.
+func (m *NetworkMessage) GetType() uint8 {
+	return m.NPDU.MessageType
+}
+
+// GetService returns 0, network layer messages having no service.
+func (m *NetworkMessage) GetService() uint8 {
+	return 0
+}
+
+// Decode decodes Data according to the message type. Proprietary and
+// connection messages are not decoded.
+func (m *NetworkMessage) Decode() (NetworkMessageDec, error) {
+	var dec NetworkMessageDec
+	d := m.Data
+
+	switch m.NPDU.MessageType {
+	case NetworkMessageWhatIsNetworkNumber:
+	case NetworkMessageWhoIsRouterToNetwork, NetworkMessageIAmRouterToNetwork,
+		NetworkMessageRouterBusyToNetwork, NetworkMessageRouterAvailableToNetwork:
+		if len(d)%2 != 0 {
+			return dec, fmt.Errorf("decoding networks %x: %v", d, common.ErrWrongStructure)
+		}
+		for i := 0; i < len(d); i += 2 {
+			dec.Networks = append(dec.Networks, binary.BigEndian.Uint16(d[i:]))
+		}
+	case NetworkMessageICouldBeRouterToNetwork:
+		if len(d) < 3 {
+			return dec, fmt.Errorf("decoding I-Could-Be-Router %x: %v", d, common.ErrTooShortToParse)
+		}
+		dec.Networks = []uint16{binary.BigEndian.Uint16(d)}
+		dec.PerformanceIndex = d[2]
+	case NetworkMessageRejectMessageToNetwork:
+		if len(d) < 3 {
+			return dec, fmt.Errorf("decoding Reject-Message %x: %v", d, common.ErrTooShortToParse)
+		}
+		dec.Reason = d[0]
+		dec.Networks = []uint16{binary.BigEndian.Uint16(d[1:])}
+	case NetworkMessageInitializeRoutingTable, NetworkMessageInitializeRoutingTableAck:
+		if len(d) < 1 {
+			return dec, fmt.Errorf("decoding routing table %x: %v", d, common.ErrTooShortToParse)
+		}
+		offset := 1
+		for i := 0; i < int(d[0]); i++ {
+			if len(d) < offset+4 || len(d) < offset+4+int(d[offset+3]) {
+				return dec, fmt.Errorf("decoding routing table %x: %v", d, common.ErrTooShortToParse)
+			}
+			infoLen := int(d[offset+3])
+			dec.Ports = append(dec.Ports, RoutingTablePort{
+				Network: binary.BigEndian.Uint16(d[offset:]),
+				PortID:  d[offset+2],
+				Info:    d[offset+4 : offset+4+infoLen],
+			})
+			offset += 4 + infoLen
+		}
+	case NetworkMessageNetworkNumberIs:
+		if len(d) < 3 {
+			return dec, fmt.Errorf("decoding Network-Number-Is %x: %v", d, common.ErrTooShortToParse)
+		}
+		dec.Networks = []uint16{binary.BigEndian.Uint16(d)}
+		dec.Configured = d[2] == 1
+	default:
+		return dec, fmt.Errorf("decoding network message type %x: %v", m.NPDU.MessageType, common.ErrNotImplemented)
+	}
+
+	return dec, nil
+}
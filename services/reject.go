@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// Reject is a BACnet message rejecting a confirmed request.
+type Reject struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+type RejectDec struct {
+	InvokeID uint8
+	// Reason is one of the RejectReason constants.
+	Reason RejectReason
+}
+
+// NewReject creates a Reject.
+func NewReject(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *Reject {
+	r := &Reject{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.Reject, 0, nil),
+	}
+	r.SetLength()
+
+	return r
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a Reject frame.
+func (r *Reject) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < r.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal Reject - marshal length %d binary length %d: %v",
+			r.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+
+	var offset int = 0
+	if err := r.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling Reject %+v: %v", r, common.ErrTooShortToParse,
+		)
+	}
+	offset += r.BVLC.MarshalLen()
+
+	if err := r.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling Reject %+v: %v", r, common.ErrTooShortToParse,
+		)
+	}
+	offset += r.NPDU.MarshalLen()
+
+	if err := r.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling Reject %+v: %v", r, common.ErrTooShortToParse,
+		)
+	}
+
+	return nil
+}
+
+// MarshalBinary returns the byte sequence generated from a Reject instance.
+func (r *Reject) MarshalBinary() ([]byte, error) {
+	b := make([]byte, r.MarshalLen())
+	if err := r.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+// MarshalTo puts the byte sequence in the byte array given as b.
+func (r *Reject) MarshalTo(b []byte) error {
+	if len(b) < r.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal Reject - marshal length %d binary length %d: %v",
+			r.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	var offset = 0
+	if err := r.BVLC.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling Reject: %v", err)
+	}
+	offset += r.BVLC.MarshalLen()
+
+	if err := r.NPDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling Reject: %v", err)
+	}
+	offset += r.NPDU.MarshalLen()
+
+	if err := r.APDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling Reject: %v", err)
+	}
+
+	return nil
+}
+
+// MarshalLen returns the serial length of Reject.
+func (r *Reject) MarshalLen() int {
+	l := r.BVLC.MarshalLen()
+	l += r.NPDU.MarshalLen()
+	l += r.APDU.MarshalLen()
+
+	return l
+}
+
+// SetLength sets the length in Length field.
+func (r *Reject) SetLength() {
+	if r.BVLC != nil {
+		r.BVLC.Length = uint16(r.MarshalLen())
+	}
+}
+
+func (r *Reject) Decode() (RejectDec, error) {
+	return RejectDec{InvokeID: r.APDU.InvokeID, Reason: RejectReason(r.APDU.Reason)}, nil
+}
+
+func (r *Reject) GetService() uint8 {
+	return r.APDU.Service
+}
+
+func (r *Reject) GetType() uint8 {
+	return r.APDU.Type
+}
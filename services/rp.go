@@ -19,6 +19,7 @@ type ConfirmedReadPropertyDec struct {
 	ObjectType  uint16
 	InstanceNum uint32
 	PropertyId  uint16
+	ArrayIndex  uint32
 }
 
 func ConfirmedReadPropertyObjects(objectType uint16, instN uint32, propId uint16) []objects.APDUPayload {
@@ -30,6 +31,20 @@ func ConfirmedReadPropertyObjects(objectType uint16, instN uint32, propId uint16
 	return objs
 }
 
+// ConfirmedReadPropertyArrayObjects creates the objects of a ReadProperty request
+// for a single array element. Passing objects.ArrayAll reads the whole property.
+func ConfirmedReadPropertyArrayObjects(objectType uint16, instN uint32, propId uint16, arrayIndex uint32) []objects.APDUPayload {
+	objs := ConfirmedReadPropertyObjects(objectType, instN, propId)
+	if arrayIndex != objects.ArrayAll {
+		objs = append(objs, objects.ContextTag(2, objects.EncUnsignedInteger(uint(arrayIndex))))
+	}
+
+	return objs
+}
+
+// ConfirmedReadPropertyMultipleObjects creates the objects of a
+// ReadPropertyMultiple request reading whole properties of a single object. See
+// ReadPropertyMultipleObjects for several objects and array indices.
 func ConfirmedReadPropertyMultipleObjects(objectType uint16, instN uint32, propIds []uint16) []objects.APDUPayload {
 	length := 3 + (1 * len(propIds))
 	objs := make([]objects.APDUPayload, length)
@@ -148,64 +163,37 @@ func (c *ConfirmedReadProperty) MarshalLen() int {
 }
 
 func (c *ConfirmedReadProperty) SetLength() {
-	c.BVLC.Length = uint16(c.MarshalLen())
+	if c.BVLC != nil {
+		c.BVLC.Length = uint16(c.MarshalLen())
+	}
 }
 
 func (c *ConfirmedReadProperty) Decode() (ConfirmedReadPropertyDec, error) {
-	decCRP := ConfirmedReadPropertyDec{}
+	decCRP := ConfirmedReadPropertyDec{ArrayIndex: objects.ArrayAll}
 
-	if len(c.APDU.Objects) != 2 {
+	if len(c.APDU.Objects) < 2 {
 		return decCRP, fmt.Errorf(
-			"failed to decode ConfirmedRP - object count %d: %v",
+			"failed to decode ConfirmedRP - object count %d: %w",
 			len(c.APDU.Objects),
 			common.ErrWrongObjectCount,
 		)
 	}
 
-	context := []uint8{8}
-	for i, obj := range c.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decCRP, fmt.Errorf(
-				"ComplexACK object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
-		}
-
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
-		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decCRP, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
-		}
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCRP, fmt.Errorf("decoding ConfirmedRP: %v", err)
-				}
-				decCRP.ObjectType = objId.ObjectType
-				decCRP.InstanceNum = objId.InstanceNumber
-			case combine(8, 2):
-				value, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCRP, fmt.Errorf("decoding ConfirmedRP: %v", err)
-				}
-				propId := uint16(value)
-				decCRP.PropertyId = propId
-			}
-		}
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return decCRP, fmt.Errorf("failed to decode ConfirmedRP: %w", err)
+	}
+	objId, propId, index, err := readPropertyReference(r)
+	if err != nil {
+		return decCRP, fmt.Errorf("decoding ConfirmedRP: %w", err)
+	}
+	decCRP.ObjectType = objId.ObjectType
+	decCRP.InstanceNum = objId.InstanceNumber
+	decCRP.PropertyId = propId
+	decCRP.ArrayIndex = index
+
+	if err := r.End(); err != nil {
+		return decCRP, fmt.Errorf("failed to decode ConfirmedRP: %w", err)
 	}
 	return decCRP, nil
 }
@@ -2,12 +2,72 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
 )
 
+// ReadAccessSpecification holds the properties read from an object. The
+// property identifiers objects.PropertyIdAll, objects.PropertyIdRequired and
+// objects.PropertyIdOptional read several properties at once.
+type ReadAccessSpecification struct {
+	ObjectId   objects.ObjectIdentifier
+	Properties []objects.BACnetPropertyReference
+}
+
+func (s *ReadAccessSpecification) Encode(w *objects.TagWriter) error {
+	if err := w.Write(objects.EncObjectIdentifier(true, 0, s.ObjectId.ObjectType, s.ObjectId.InstanceNumber)); err != nil {
+		return err
+	}
+	w.Open(1)
+	for i := range s.Properties {
+		if err := s.Properties[i].Encode(w); err != nil {
+			return err
+		}
+	}
+	return w.Close(1)
+}
+
+func (s *ReadAccessSpecification) Decode(r *objects.TagReader) error {
+	*s = ReadAccessSpecification{}
+	obj, err := r.ReadContext(0)
+	if err != nil {
+		return fmt.Errorf("decoding ReadAccessSpecification: %w", err)
+	}
+	if s.ObjectId, err = objects.DecObjectIdentifier(obj); err != nil {
+		return fmt.Errorf("decoding ReadAccessSpecification: %w", err)
+	}
+	if err := r.Enter(1); err != nil {
+		return fmt.Errorf("decoding ReadAccessSpecification: %w", err)
+	}
+	for !r.Done() {
+		var ref objects.BACnetPropertyReference
+		if err := ref.Decode(r); err != nil {
+			return fmt.Errorf("decoding ReadAccessSpecification of %v: %w", s.ObjectId, err)
+		}
+		s.Properties = append(s.Properties, ref)
+	}
+	if len(s.Properties) == 0 {
+		return fmt.Errorf("decoding ReadAccessSpecification of %v - no property: %w", s.ObjectId, common.ErrWrongStructure)
+	}
+	return r.Leave(1)
+}
+
+// ReadPropertyMultipleObjects creates the objects of a ReadPropertyMultiple
+// request reading specs.
+func ReadPropertyMultipleObjects(specs []ReadAccessSpecification) ([]objects.APDUPayload, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("encoding ReadPropertyMultiple - no object: %v", common.ErrWrongStructure)
+	}
+	w := objects.NewTagWriter()
+	for i := range specs {
+		if err := specs[i].Encode(w); err != nil {
+			return nil, fmt.Errorf("encoding ReadPropertyMultiple of %v: %v", specs[i].ObjectId, err)
+		}
+	}
+	return w.Objects()
+}
+
 type ConfirmedReadPropMultDec struct {
 	ObjectType  uint16
 	InstanceNum uint32
@@ -17,66 +77,69 @@ type ConfirmedReadPropMultDec struct {
 func (c *ConfirmedReadProperty) DecodeRPM() (ConfirmedReadPropMultDec, error) {
 	decRPM := ConfirmedReadPropMultDec{}
 
-	context := []uint8{8}
-	objs := make([]*objects.Object, 0)
-	for i, obj := range c.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decRPM, fmt.Errorf(
-				"ComplexACKRPM object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return decRPM, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
+	}
+	decode := func(context uint8, o *objects.Object) (*objects.Object, error) {
+		if context != 1 || o.TagNumber != 0 {
+			// Keep the tags this decoder doesn't know, undecoded.
+			return o, nil
 		}
-
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 && enc_obj.Data == nil {
-			context = append(context, enc_obj.TagNumber)
-			continue
+		propId, err := objects.DecUnsignedInteger(o)
+		if err != nil {
+			return nil, fmt.Errorf("decode Context object case 0: %w", err)
 		}
-		if enc_obj.Length == 7 && enc_obj.Data == nil {
-			if len(context) == 0 {
-				return decRPM, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
+		return decodedTag(o, propId), nil
+	}
+	objs := make([]*objects.Object, 0)
+	for r.Remaining() > 0 {
+		obj, err := r.ReadContext(0)
+		if err != nil {
+			return decRPM, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
 		}
+		objId, err := objects.DecObjectIdentifier(obj)
+		if err != nil {
+			return decRPM, fmt.Errorf("decode Context object case 0: %w", err)
+		}
+		decRPM.ObjectType = objId.ObjectType
+		decRPM.InstanceNum = objId.InstanceNumber
 
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decRPM, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				decRPM.ObjectType = objId.ObjectType
-				decRPM.InstanceNum = objId.InstanceNumber
-			case combine(1, 0):
-				propId, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decRPM, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 0,
-					TagClass:  true,
-					Length:    uint8(obj.MarshalLen()),
-					Value:     propId,
-				})
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
-			}
-		} else {
-			tag, err := decodeAppTags(enc_obj, &obj)
-			if err != nil {
-				return decRPM, fmt.Errorf("decode Application Tag: %v", err)
-			}
-			objs = append(objs, tag)
+		tags, err := readTags(r, 1, decode)
+		if err != nil {
+			return decRPM, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
 		}
+		objs = append(objs, tags...)
 	}
 	decRPM.Tags = objs
 
+	if err := r.End(); err != nil {
+		return decRPM, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
+	}
 	return decRPM, nil
 }
+
+// DecodeRPMSpecs decodes the objects and properties read by a
+// ReadPropertyMultiple request.
+func (c *ConfirmedReadProperty) DecodeRPMSpecs() ([]ReadAccessSpecification, error) {
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
+	}
+	var specs []ReadAccessSpecification
+	for r.Remaining() > 0 {
+		var spec ReadAccessSpecification
+		if err := spec.Decode(r); err != nil {
+			return nil, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("failed to decode ConfirmedRPM: %w", common.ErrWrongObjectCount)
+	}
+
+	if err := r.End(); err != nil {
+		return nil, fmt.Errorf("failed to decode ConfirmedRPM: %w", err)
+	}
+	return specs, nil
+}
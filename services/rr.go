@@ -63,7 +63,9 @@ func (c *ConfirmedReadRange) MarshalLen() int {
 }
 
 func (c *ConfirmedReadRange) SetLength() {
-	c.BVLC.Length = uint16(c.MarshalLen())
+	if c.BVLC != nil {
+		c.BVLC.Length = uint16(c.MarshalLen())
+	}
 }
 
 func (c *ConfirmedReadRange) UnmarshalBinary(b []byte) error {
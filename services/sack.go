@@ -101,7 +101,9 @@ func (s *SimpleACK) MarshalLen() int {
 }
 
 func (s *SimpleACK) SetLength() {
-	s.BVLC.Length = uint16(s.MarshalLen())
+	if s.BVLC != nil {
+		s.BVLC.Length = uint16(s.MarshalLen())
+	}
 }
 
 func (u *SimpleACK) GetService() uint8 {
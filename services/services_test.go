@@ -5,10 +5,14 @@
 package services_test
 
 import (
+	"bytes"
+	"errors"
+	"net"
 	"testing"
 
 	"github.com/Nortech-ai/bacnet"
 	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
 	"github.com/Nortech-ai/bacnet/plumbing"
 	"github.com/Nortech-ai/bacnet/services"
 	"github.com/google/go-cmp/cmp"
@@ -92,6 +96,214 @@ func TestUnconfirmedIAm(t *testing.T) {
 		},
 	}
 
+	for _, c := range testcases {
+		t.Run(c.description, func(t *testing.T) {
+			t.Run("Decode", func(t *testing.T) {
+				msg, err := bacnet.Parse(c.serialized)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// The APDU Data holds the service data msg was parsed from.
+				ignoreData := cmp.FilterPath(func(p cmp.Path) bool { return p.String() == "APDU.Data" }, cmp.Ignore())
+				want, got := c.structured, msg
+				if diff := cmp.Diff(want, got, ignoreData); diff != "" {
+					t.Errorf("differs: (-want +got)\n%s", diff)
+				}
+			})
+			t.Run("Serialize", func(t *testing.T) {
+				b, err := c.structured.MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want, got := c.serialized, b
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("differs: (-want +got)\n%s", diff)
+				}
+			})
+		})
+	}
+}
+func TestNetworkMessage(t *testing.T) {
+	t.Helper()
+	newMsg := func(messageType uint8, data []byte) *services.NetworkMessage {
+		npdu := plumbing.NewNPDU(true, false, false, false)
+		npdu.MessageType = messageType
+		m := services.NewNetworkMessage(plumbing.NewBVLC(plumbing.BVLCFuncBroadcast), npdu)
+		m.Data = data
+		m.SetLength()
+		return m
+	}
+	routingTable, err := services.RoutingTableData([]services.RoutingTablePort{
+		{Network: 2001, PortID: 1, Info: []byte{0xaa}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testcases = []testCase{
+		{
+			description: "Who-Is-Router-To-Network frame",
+			structured:  newMsg(services.NetworkMessageWhoIsRouterToNetwork, services.NetworksData([]uint16{2001})),
+			serialized: []byte{
+				0x81, 0x0b, 0x00, 0x09, // BVLC
+				0x01, 0x80, 0x00, // NPDU
+				0x07, 0xd1, // DNET
+			},
+		},
+		{
+			description: "Initialize-Routing-Table-Ack frame",
+			structured:  newMsg(services.NetworkMessageInitializeRoutingTableAck, routingTable),
+			serialized: []byte{
+				0x81, 0x0b, 0x00, 0x0d, // BVLC
+				0x01, 0x80, 0x07, // NPDU
+				0x01, 0x07, 0xd1, 0x01, 0x01, 0xaa, // Ports
+			},
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.description, func(t *testing.T) {
+			t.Run("Decode", func(t *testing.T) {
+				msg, err := bacnet.Parse(c.serialized)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want, got := c.structured, msg
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("differs: (-want +got)\n%s", diff)
+				}
+			})
+			t.Run("Serialize", func(t *testing.T) {
+				b, err := c.structured.MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want, got := c.serialized, b
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("differs: (-want +got)\n%s", diff)
+				}
+			})
+		})
+	}
+
+	dec, err := newMsg(services.NetworkMessageInitializeRoutingTableAck, routingTable).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Ports) != 1 || dec.Ports[0].Network != 2001 || dec.Ports[0].PortID != 1 {
+		t.Errorf("unexpected ports %+v", dec.Ports)
+	}
+
+	npdu := plumbing.NewNPDU(false, false, false, false)
+	if services.NewNetworkMessage(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), npdu); npdu.Control != 0 {
+		t.Errorf("NewNetworkMessage changed the control of its NPDU to %#x", npdu.Control)
+	}
+}
+
+func TestBVLLMessage(t *testing.T) {
+	t.Helper()
+	newMsg := func(function uint8, data []byte) *services.BVLLMessage {
+		m := services.NewBVLLMessage(plumbing.NewBVLC(function))
+		m.Data = data
+		m.SetLength()
+		return m
+	}
+	fdt := []services.FDTEntry{{
+		Address:   &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 0xbac0},
+		TTL:       60,
+		Remaining: 90,
+	}}
+	var testcases = []testCase{
+		{
+			description: "Register-Foreign-Device frame",
+			structured:  newMsg(plumbing.BVLCFuncRegisterForeignDevice, services.RegisterForeignDeviceData(300)),
+			serialized: []byte{
+				0x81, 0x05, 0x00, 0x06, // BVLC
+				0x01, 0x2c, // TTL
+			},
+		},
+		{
+			description: "Read-FDT-Ack frame",
+			structured:  newMsg(plumbing.BVLCFuncReadFDTAck, services.FDTData(fdt)),
+			serialized: []byte{
+				0x81, 0x07, 0x00, 0x0e, // BVLC
+				0xc0, 0xa8, 0x01, 0x02, 0xba, 0xc0, 0x00, 0x3c, 0x00, 0x5a, // FDT entry
+			},
+		},
+	}
+
+	for _, c := range testcases {
+		t.Run(c.description, func(t *testing.T) {
+			t.Run("Decode", func(t *testing.T) {
+				msg, err := bacnet.Parse(c.serialized)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want, got := c.structured, msg
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("differs: (-want +got)\n%s", diff)
+				}
+			})
+			t.Run("Serialize", func(t *testing.T) {
+				b, err := c.structured.MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want, got := c.serialized, b
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("differs: (-want +got)\n%s", diff)
+				}
+			})
+		})
+	}
+
+	dec, err := newMsg(plumbing.BVLCFuncReadFDTAck, services.FDTData(fdt)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.FDT) != 1 || dec.FDT[0].Address.String() != "192.168.1.2:47808" || dec.FDT[0].Remaining != 90 {
+		t.Errorf("unexpected FDT %+v", dec.FDT)
+	}
+}
+
+func TestBVLL6Message(t *testing.T) {
+	t.Helper()
+	newMsg := func(function uint8, dst, data []byte) *services.BVLLMessage {
+		m := services.NewBVLLMessage(plumbing.NewBVLC6(function, []byte{0x00, 0x00, 0x01}, dst))
+		m.Data = data
+		m.SetLength()
+		return m
+	}
+	origin := &net.UDPAddr{IP: net.ParseIP("fd00::2"), Port: 0xbac0}
+	var testcases = []testCase{
+		{
+			description: "Address-Resolution-Ack frame",
+			structured:  newMsg(plumbing.BVLC6FuncAddressResolutionAck, []byte{0x00, 0x00, 0x02}, []byte{}),
+			serialized: []byte{
+				0x82, 0x05, 0x00, 0x0a, // BVLC
+				0x00, 0x00, 0x01, // Source VMAC
+				0x00, 0x00, 0x02, // Destination VMAC
+			},
+		},
+		{
+			description: "Forwarded-Address-Resolution frame",
+			structured: newMsg(plumbing.BVLC6FuncForwardedAddressResolution, nil,
+				services.AddressResolutionData([]byte{0x00, 0x00, 0x03}, origin)),
+			serialized: []byte{
+				0x82, 0x04, 0x00, 0x1c, // BVLC
+				0x00, 0x00, 0x01, // Source VMAC
+				0x00, 0x00, 0x03, // Target VMAC
+				0xfd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xba, 0xc0, // Original source
+			},
+		},
+	}
+
 	for _, c := range testcases {
 		t.Run(c.description, func(t *testing.T) {
 			t.Run("Decode", func(t *testing.T) {
@@ -118,7 +330,17 @@ func TestUnconfirmedIAm(t *testing.T) {
 			})
 		})
 	}
+
+	dec, err := newMsg(plumbing.BVLC6FuncForwardedAddressResolution, nil,
+		services.AddressResolutionData([]byte{0x00, 0x00, 0x03}, origin)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.VMAC, []byte{0x00, 0x00, 0x03}) || dec.Address.String() != "[fd00::2]:47808" {
+		t.Errorf("unexpected Address-Resolution %+v", dec)
+	}
 }
+
 func TestBoolToInt(t *testing.T) {
 	cases := []struct {
 		description string
@@ -174,3 +396,433 @@ func TestIntToBool(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeNesting(t *testing.T) {
+	cack := services.NewComplexACK(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	cack.APDU.Objects = []objects.APDUPayload{
+		objects.EncObjectIdentifier(true, 0, objects.ObjectTypeAnalogValue, 1),
+		objects.ContextTag(1, objects.EncUnsignedInteger(uint(objects.PropertyIdPresentValue))),
+		objects.EncOpeningTag(3),
+		objects.EncReal(1),
+	}
+	if _, err := cack.Decode(); err == nil {
+		t.Error("expected an error decoding an unclosed constructed value")
+	}
+
+	cack.APDU.Objects = append(cack.APDU.Objects, objects.EncClosingTag(4))
+	if _, err := cack.Decode(); err == nil {
+		t.Error("expected an error decoding a mismatched closing tag")
+	}
+
+	cack.APDU.Objects[4] = objects.EncClosingTag(3)
+	dec, err := cack.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Tags) != 1 || dec.Tags[0].Value != float32(1) {
+		t.Errorf("unexpected tags %+v", dec.Tags)
+	}
+}
+
+func TestDecodeServiceData(t *testing.T) {
+	b, err := bacnet.NewReadPropertyArray(objects.ObjectTypeAnalogValue, 2, objects.PropertyIdPriorityArray, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := msg.(*services.ConfirmedReadProperty)
+	if !bytes.Equal(rp.APDU.Data, b[len(b)-len(rp.APDU.Data):]) {
+		t.Errorf("service data %x not parsed from %x", rp.APDU.Data, b)
+	}
+	dec, err := rp.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ConfirmedReadPropertyDec{
+		ObjectType:  objects.ObjectTypeAnalogValue,
+		InstanceNum: 2,
+		PropertyId:  objects.PropertyIdPriorityArray,
+		ArrayIndex:  16,
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	rp = services.NewConfirmedReadProperty(
+		plumbing.NewBVLC(plumbing.BVLCFuncUnicast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	rp.APDU.Objects[0], rp.APDU.Objects[1] = rp.APDU.Objects[1], rp.APDU.Objects[0]
+	if _, err := rp.Decode(); !errors.Is(err, common.ErrWrongTagNumber) {
+		t.Errorf("got %v decoding the property identifier first, want ErrWrongTagNumber", err)
+	}
+
+	whois := services.NewUnconfirmedWhoIs(
+		plumbing.NewBVLC(plumbing.BVLCFuncBroadcast),
+		plumbing.NewNPDU(false, false, false, false),
+	)
+	whois.APDU.Objects = []objects.APDUPayload{objects.ContextTag(0, objects.EncUnsignedInteger(1))}
+	if _, err := whois.Decode(); err == nil {
+		t.Error("expected an error decoding a Who-Is without a high limit")
+	}
+}
+
+func TestUnconfirmedWhoHas(t *testing.T) {
+	b, err := bacnet.NewWhoHasName(10, 20, "AI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x81, 0x0b, 0x00, 0x10, // BVLC
+		0x01, 0x00, // NPDU
+		0x10, 0x07, // APDU
+		0x09, 0x0a, // Device instance low limit
+		0x19, 0x14, // Device instance high limit
+		0x3b, 0x00, 'A', 'I', // Object name
+	}
+	if diff := cmp.Diff(want, b); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := msg.(*services.UnconfirmedWhoHas).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.ObjectId != nil || dec.ObjectName != "AI" || !dec.Matches(15) || dec.Matches(21) {
+		t.Errorf("unexpected Who-Has %+v", dec)
+	}
+
+	b, err = bacnet.NewWhoHas(-1, -1, objects.ObjectTypeAnalogInput, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, err = bacnet.Parse(b); err != nil {
+		t.Fatal(err)
+	}
+	dec, err = msg.(*services.UnconfirmedWhoHas).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 7}
+	if dec.ObjectId == nil || *dec.ObjectId != oid || !dec.Matches(4194303) {
+		t.Errorf("unexpected Who-Has %+v", dec)
+	}
+}
+
+func TestUnconfirmedIHave(t *testing.T) {
+	b, err := bacnet.NewIHave(9, objects.ObjectTypeAnalogInput, 7, "AI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := msg.(*services.UnconfirmedIHave).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.UnconfirmedIHaveDec{
+		DeviceId:   objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 9},
+		ObjectId:   objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogInput, InstanceNumber: 7},
+		ObjectName: "AI",
+	}
+	if diff := cmp.Diff(want, dec); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	ihave := msg.(*services.UnconfirmedIHave)
+	ihave.APDU.Objects[0] = objects.EncObjectIdentifier(false, objects.TagBACnetObjectIdentifier, objects.ObjectTypeAnalogInput, 9)
+	ihave.APDU.Data = nil
+	if _, err := ihave.Decode(); err == nil {
+		t.Error("expected an error decoding an I-Have of a non-device")
+	}
+}
+
+func TestConfirmedWritePropertyMultiple(t *testing.T) {
+	oid := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	specs := []services.WriteAccessSpecification{{
+		ObjectId: oid,
+		Values: []objects.BACnetPropertyValue{
+			{PropertyId: objects.PropertyIdPresentValue, ArrayIndex: objects.ArrayAll, Value: []*objects.Object{objects.EncReal(1.5)}, Priority: 8},
+			{PropertyId: objects.PropertyIdObjectName, ArrayIndex: objects.ArrayAll, Value: []*objects.Object{objects.EncString("AV")}},
+		},
+	}}
+	b, err := bacnet.NewWritePropertyMultiple(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := msg.(*services.ConfirmedWritePropertyMultiple).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec.Specs) != 1 || dec.Specs[0].ObjectId != oid || len(dec.Specs[0].Values) != 2 {
+		t.Fatalf("unexpected specs %+v", dec.Specs)
+	}
+	pv := dec.Specs[0].Values[0]
+	if v, err := objects.DecReal(pv.Value[0]); err != nil || v != 1.5 || pv.Priority != 8 {
+		t.Errorf("unexpected value %+v", pv)
+	}
+
+	if _, err := bacnet.NewWritePropertyMultiple(nil); err == nil {
+		t.Error("expected an error writing no object")
+	}
+
+	ref := objects.BACnetObjectPropertyReference{ObjectId: oid, PropertyId: objects.PropertyIdObjectName, ArrayIndex: objects.ArrayAll}
+	b, err = bacnet.NewWritePropertyMultipleError(9, objects.ErrorClassProperty, objects.ErrorCodeUnknownProperty, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err = bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := msg.(*services.Error).APDU.InvokeID; id != 9 {
+		t.Errorf("expected invoke ID 9, got %d", id)
+	}
+	decErr, err := msg.(*services.Error).DecodeWPM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.WritePropertyMultipleErrorDec{
+		ErrorDec:         services.ErrorDec{ErrorClass: objects.ErrorClassProperty, ErrorCode: objects.ErrorCodeUnknownProperty},
+		FirstFailedWrite: ref,
+	}
+	if diff := cmp.Diff(want, decErr); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestReadPropertyMultipleSpecs(t *testing.T) {
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	device := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeDevice, InstanceNumber: 9}
+	specs := []services.ReadAccessSpecification{
+		{ObjectId: av, Properties: []objects.BACnetPropertyReference{
+			{PropertyId: objects.PropertyIdAll, ArrayIndex: objects.ArrayAll},
+		}},
+		{ObjectId: device, Properties: []objects.BACnetPropertyReference{
+			{PropertyId: objects.PropertyIdObjectList, ArrayIndex: 0},
+			{PropertyId: objects.PropertyIdObjectName, ArrayIndex: objects.ArrayAll},
+		}},
+	}
+	b, err := bacnet.NewReadPropertyMultipleSpecs(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := msg.(*services.ConfirmedReadProperty).DecodeRPMSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(specs, got); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestReadPropertyMultipleResults(t *testing.T) {
+	av := objects.ObjectIdentifier{ObjectType: objects.ObjectTypeAnalogValue, InstanceNumber: 3}
+	objs, err := services.ReadPropertyMultipleACKObjects([]services.ReadAccessResult{{
+		ObjectId: av,
+		Results: []services.PropertyResult{
+			{PropertyId: objects.PropertyIdPresentValue, ArrayIndex: objects.ArrayAll, Tags: []*objects.Object{objects.EncReal(21.5)}},
+			{PropertyId: objects.PropertyIdUnits, ArrayIndex: objects.ArrayAll, Tags: []*objects.Object{objects.EncEnumerated(62)}},
+			{PropertyId: 600, ArrayIndex: objects.ArrayAll, Tags: []*objects.Object{objects.EncUnsignedInteger(1), objects.EncUnsignedInteger(2)}},
+			// A value not fitting the schema fails alone.
+			{PropertyId: objects.PropertyIdObjectName, ArrayIndex: objects.ArrayAll, Tags: []*objects.Object{objects.EncReal(1)}},
+			{PropertyId: objects.PropertyIdDescription, ArrayIndex: objects.ArrayAll, Error: &services.PropertyAccessError{
+				ErrorClass: objects.ErrorClassProperty, ErrorCode: objects.ErrorCodeUnknownProperty,
+			}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cack := services.NewComplexACK(plumbing.NewBVLC(plumbing.BVLCFuncUnicast), plumbing.NewNPDU(false, false, false, false))
+	cack.APDU.Service = services.ServiceConfirmedReadPropMultiple
+	cack.APDU.Objects = objs
+	cack.SetLength()
+	b, err := cack.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := msg.(*services.ComplexACK).DecodeRPMResults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[av]) != 5 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	got := results[av]
+	values := []interface{}{got[0].Value, got[1].Value, got[2].Value}
+	want := []interface{}{float32(21.5), objects.EngineeringUnits(62), []interface{}{uint32(1), uint32(2)}}
+	if diff := cmp.Diff(want, values); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if got[3].Value != nil || len(got[3].Tags) != 1 || got[3].Error != nil {
+		t.Errorf("unexpected result %+v", got[3])
+	}
+	if got[4].Error == nil || got[4].Error.ErrorCode != objects.ErrorCodeUnknownProperty || got[4].Tags != nil {
+		t.Errorf("unexpected result %+v", got[4])
+	}
+}
+
+func TestRejectAbort(t *testing.T) {
+	b, err := bacnet.NewReject(7, services.RejectReasonUnrecognizedService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b[len(b)-3:], []byte{plumbing.Reject << 4, 7, uint8(services.RejectReasonUnrecognizedService)}) {
+		t.Errorf("unexpected Reject %x", b)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reject, err := msg.(*services.Reject).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(services.RejectDec{InvokeID: 7, Reason: services.RejectReasonUnrecognizedService}, reject); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if s := reject.Reason.String(); s != "UnrecognizedService" {
+		t.Errorf("unexpected reject reason %q", s)
+	}
+
+	b, err = bacnet.NewAbort(8, services.AbortReasonSegmentationNotSupported, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err = bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abort, err := msg.(*services.Abort).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.AbortDec{InvokeID: 8, Reason: services.AbortReasonSegmentationNotSupported, Server: true}
+	if diff := cmp.Diff(want, abort); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	if s := abort.Reason.String(); s != "SegmentationNotSupported" {
+		t.Errorf("unexpected abort reason %q", s)
+	}
+	if s := services.AbortReason(64).String(); s != "AbortReason(64)" {
+		t.Errorf("unexpected abort reason %q", s)
+	}
+
+	if _, err := bacnet.Parse(b[:len(b)-1]); err == nil {
+		t.Error("expected an error parsing a truncated Abort")
+	}
+}
+
+func TestServiceErrors(t *testing.T) {
+	parse := func(b []byte, err error) *services.Error {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := bacnet.Parse(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg.(*services.Error)
+	}
+
+	// Codes past 255 are encoded on two bytes.
+	e := parse(bacnet.NewError(services.ServiceConfirmedWriteProperty, objects.ErrorClassProperty, 300))
+	decErr, err := e.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(services.ErrorDec{ErrorClass: objects.ErrorClassProperty, ErrorCode: 300}, decErr); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	e = parse(bacnet.NewChangeListError(
+		4, services.ServiceConfirmedAddListElement,
+		objects.ErrorClassResources, objects.ErrorCodeNoSpaceToAddListElement, 3,
+	))
+	if e.APDU.InvokeID != 4 {
+		t.Errorf("expected invoke ID 4, got %d", e.APDU.InvokeID)
+	}
+	changeList, err := e.DecodeChangeList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ChangeListErrorDec{
+		ErrorDec:           services.ErrorDec{ErrorClass: objects.ErrorClassResources, ErrorCode: objects.ErrorCodeNoSpaceToAddListElement},
+		FirstFailedElement: 3,
+	}
+	if diff := cmp.Diff(want, changeList); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	// Decode reads the error class and code of the service specific errors.
+	if decErr, err = e.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(decErr.Err(), objects.ErrNoSpaceToAddListElement) {
+		t.Errorf("%v is not %v", decErr.Err(), objects.ErrNoSpaceToAddListElement)
+	}
+
+	e = parse(bacnet.NewCreateObjectError(5, objects.ErrorClassObject, objects.ErrorCodeDynamicCreationNotSupported, 0))
+	createObject, err := e.DecodeCreateObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if createObject.FirstFailedElement != 0 || !errors.Is(createObject.Err(), objects.ErrDynamicCreationNotSupported) {
+		t.Errorf("unexpected CreateObject error %+v", createObject)
+	}
+	if _, err := e.DecodeWPM(); err == nil {
+		t.Error("expected an error decoding a CreateObject error as a WritePropertyMultiple one")
+	}
+
+	if got, want := objects.ErrUnknownProperty.Error(), "BACnet error Property: UnknownProperty"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSubscribeCOVCancel(t *testing.T) {
+	b, err := bacnet.NewSubscribeCOV(objects.ObjectTypeAnalogValue, 1, 42, 0, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := bacnet.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cov, err := msg.(*services.ConfirmedCOV).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := services.ConfirmedCOVDec{ProcessId: 42, MonitoredObjType: objects.ObjectTypeAnalogValue, MonitoredInstNum: 1}
+	if diff := cmp.Diff(want, cov); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if _, err := bacnet.Parse(b[:len(b)-4]); err == nil {
+		t.Error("expected an error parsing a truncated cancellation")
+	}
+}
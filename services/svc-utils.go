@@ -2,9 +2,9 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
 )
 
 func decodeAppTags(enc_obj *objects.Object, obj *objects.APDUPayload) (*objects.Object, error) {
@@ -82,14 +82,14 @@ func decodeAppTags(enc_obj *objects.Object, obj *objects.APDUPayload) (*objects.
 		length = (*obj).MarshalLen()
 		value = data
 	case objects.TagDate:
-		data, err := objects.DecDate(*obj)
+		data, err := objects.DecBACnetDate(*obj)
 		if err != nil {
 			return nil, fmt.Errorf("decode Application object case 9: %v", err)
 		}
 		length = (*obj).MarshalLen()
 		value = data
 	case objects.TagTime:
-		data, err := objects.DecTime(*obj)
+		data, err := objects.DecBACnetTime(*obj)
 		if err != nil {
 			return nil, fmt.Errorf("decode Application object case 8: %v", err)
 		}
@@ -103,17 +103,124 @@ func decodeAppTags(enc_obj *objects.Object, obj *objects.APDUPayload) (*objects.
 		length = (*obj).MarshalLen()
 		value = objId
 	default:
-		log.Printf("Unknown AppTag object: tag class %t tag number %d\n", enc_obj.TagClass, enc_obj.TagNumber)
+		// The tags of the other types are kept undecoded, with their Data.
 	}
 	return &objects.Object{
 		TagNumber: enc_obj.TagNumber,
 		TagClass:  false,
-		Length:    uint8(length),
+		Length:    uint32(length),
 		Data:      enc_obj.Data,
 		Value:     value,
 	}, nil
 }
 
-func combine(t, s uint8) uint16 {
-	return uint16(t)<<8 | uint16(s)
+// serviceReader returns a TagReader of the service data of a.
+func serviceReader(a *plumbing.APDU) (*objects.TagReader, error) {
+	b, err := a.ServiceData()
+	if err != nil {
+		return nil, err
+	}
+	return objects.NewTagReader(b), nil
+}
+
+// readPropertyReference reads the object identifier 0, the property identifier
+// 1 and the optional array index 2 beginning the ReadProperty and
+// WriteProperty services and their ComplexACKs. The array index is ArrayAll
+// without one.
+func readPropertyReference(r *objects.TagReader) (objects.ObjectIdentifier, uint16, uint32, error) {
+	obj, err := r.ReadContext(0)
+	if err != nil {
+		return objects.ObjectIdentifier{}, 0, 0, err
+	}
+	objId, err := objects.DecObjectIdentifier(obj)
+	if err != nil {
+		return objId, 0, 0, fmt.Errorf("decode Context object case 0: %w", err)
+	}
+	if obj, err = r.ReadContext(1); err != nil {
+		return objId, 0, 0, err
+	}
+	propId, err := objects.DecUnsignedInteger(obj)
+	if err != nil {
+		return objId, 0, 0, fmt.Errorf("decode Context object case 1: %w", err)
+	}
+	if !r.PeekContext(2) {
+		return objId, uint16(propId), objects.ArrayAll, nil
+	}
+	if obj, err = r.ReadContext(2); err != nil {
+		return objId, 0, 0, err
+	}
+	index, err := objects.DecUnsignedInteger(obj)
+	if err != nil {
+		return objId, 0, 0, fmt.Errorf("decode Context object case 2: %w", err)
+	}
+	return objId, uint16(propId), index, nil
+}
+
+// readTags reads the tags of the constructed value tagNumber, from its opening
+// tag to its closing one, leaving out those of the constructed values. The
+// application tags are decoded, the context tags by decode given the tag
+// number of the constructed value they are in. They are kept undecoded with a
+// nil decode.
+func readTags(r *objects.TagReader, tagNumber uint8, decode func(context uint8, o *objects.Object) (*objects.Object, error)) ([]*objects.Object, error) {
+	if err := r.Enter(tagNumber); err != nil {
+		return nil, err
+	}
+	tags := make([]*objects.Object, 0)
+	for depth := r.Depth(); ; {
+		context, _ := r.Context()
+		o, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		if r.Depth() < depth {
+			return tags, nil
+		}
+		if o.IsOpeningTag() || o.IsClosingTag() {
+			continue
+		}
+		tag := o
+		switch {
+		case !o.TagClass:
+			var obj objects.APDUPayload = o
+			if tag, err = decodeAppTags(o, &obj); err != nil {
+				return nil, fmt.Errorf("decode Application Tag: %w", err)
+			}
+		case decode != nil:
+			if tag, err = decode(context, o); err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+}
+
+// decodedTag returns the context tag o along with its decoded value.
+func decodedTag(o *objects.Object, value interface{}) *objects.Object {
+	return &objects.Object{
+		TagNumber: o.TagNumber,
+		TagClass:  true,
+		Length:    uint32(o.MarshalLen()),
+		Value:     value,
+	}
+}
+
+// decodePropertyValue decodes the value in the constructed value tagNumber r
+// is on after the schema of the property. It returns nil for the properties
+// missing from the schema and for the values not fitting it, which are left to
+// the tags.
+func decodePropertyValue(r *objects.TagReader, tagNumber uint8, objectType, propertyId uint16, arrayIndex uint32) interface{} {
+	if _, ok := objects.LookupPropertyType(objectType, propertyId); !ok {
+		return nil
+	}
+	if err := r.Enter(tagNumber); err != nil {
+		return nil
+	}
+	v, err := objects.DecodeProperty(objectType, propertyId, arrayIndex, r)
+	if err != nil {
+		return nil
+	}
+	if err := r.Leave(tagNumber); err != nil {
+		return nil
+	}
+	return v
 }
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// UnconfirmedWhoHas is a BACnet message.
+type UnconfirmedWhoHas struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+type UnconfirmedWhoHasDec struct {
+	// LowLimit and HighLimit are the range of device instances asked, both -1
+	// when every device is.
+	LowLimit  int32
+	HighLimit int32
+	// ObjectId is nil when the object is looked up by ObjectName.
+	ObjectId   *objects.ObjectIdentifier
+	ObjectName string
+}
+
+// WhoHasObjects creates the objects of a Who-Has of the object oid, or of the
+// object named name when oid is nil. The devices asked are those from
+// lowLimit to highLimit, or every device when the limits are negative.
+func WhoHasObjects(lowLimit, highLimit int32, oid *objects.ObjectIdentifier, name string) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, 3)
+	if lowLimit >= 0 && highLimit >= 0 {
+		objs = append(objs,
+			objects.ContextTag(0, objects.EncUnsignedInteger(uint(lowLimit))),
+			objects.ContextTag(1, objects.EncUnsignedInteger(uint(highLimit))),
+		)
+	}
+	if oid != nil {
+		objs = append(objs, objects.EncObjectIdentifier(true, 2, oid.ObjectType, oid.InstanceNumber))
+	} else {
+		objs = append(objs, objects.ContextTag(3, objects.EncString(name)))
+	}
+	return objs
+}
+
+// NewUnconfirmedWhoHas creates a UnconfirmedWhoHas.
+func NewUnconfirmedWhoHas(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *UnconfirmedWhoHas {
+	u := &UnconfirmedWhoHas{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.UnConfirmedReq, ServiceUnconfirmedWhoHas, nil),
+	}
+	u.SetLength()
+	return u
+}
+
+// UnmarshalBinary sets the values retrieved from byte sequence in a UnconfirmedWhoHas frame.
+func (u *UnconfirmedWhoHas) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < u.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal UnconfirmedWhoHas - marshal length %d binary length %d: %v",
+			u.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+
+	var offset int = 0
+	if err := u.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling UnconfirmedWhoHas %+v: %v",
+			u, common.ErrTooShortToParse,
+		)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling UnconfirmedWhoHas %+v: %v",
+			u, common.ErrTooShortToParse,
+		)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling UnconfirmedWhoHas %+v: %v",
+			u, common.ErrTooShortToParse,
+		)
+	}
+
+	return nil
+}
+
+// MarshalBinary returns the byte sequence generated from a UnconfirmedWhoHas instance.
+func (u *UnconfirmedWhoHas) MarshalBinary() ([]byte, error) {
+	b := make([]byte, u.MarshalLen())
+	if err := u.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+// MarshalTo puts the byte sequence in the byte array given as b.
+func (u *UnconfirmedWhoHas) MarshalTo(b []byte) error {
+	if len(b) < u.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal UnconfirmedWhoHas - marshal length %d binary length %d: %v",
+			u.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	var offset = 0
+	if err := u.BVLC.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling UnconfirmedWhoHas: %v", err)
+	}
+	offset += u.BVLC.MarshalLen()
+
+	if err := u.NPDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling UnconfirmedWhoHas: %v", err)
+	}
+	offset += u.NPDU.MarshalLen()
+
+	if err := u.APDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling UnconfirmedWhoHas: %v", err)
+	}
+
+	return nil
+}
+
+// MarshalLen returns the serial length of UnconfirmedWhoHas.
+func (u *UnconfirmedWhoHas) MarshalLen() int {
+	l := u.BVLC.MarshalLen()
+	l += u.NPDU.MarshalLen()
+	l += u.APDU.MarshalLen()
+
+	return l
+}
+
+func (u *UnconfirmedWhoHas) Decode() (UnconfirmedWhoHasDec, error) {
+	decWhoHas := UnconfirmedWhoHasDec{LowLimit: -1, HighLimit: -1}
+
+	r, err := serviceReader(u.APDU)
+	if err != nil {
+		return decWhoHas, fmt.Errorf("failed to decode UnconfirmedWhoHas: %v", err)
+	}
+	if r.PeekContext(0) {
+		for _, limit := range []struct {
+			tagNumber uint8
+			value     *int32
+		}{{0, &decWhoHas.LowLimit}, {1, &decWhoHas.HighLimit}} {
+			obj, err := r.ReadContext(limit.tagNumber)
+			if err != nil {
+				return decWhoHas, fmt.Errorf("failed to decode UnconfirmedWhoHas: %v", err)
+			}
+			value, err := objects.DecUnsignedInteger(obj)
+			if err != nil {
+				return decWhoHas, fmt.Errorf("decode Context object case %d: %v", limit.tagNumber, err)
+			}
+			if value > 4194303 {
+				return decWhoHas, fmt.Errorf("decode device instance limit %d: %v", value, common.ErrTooBigValue)
+			}
+			*limit.value = int32(value)
+		}
+	}
+
+	switch {
+	case r.PeekContext(2):
+		obj, err := r.ReadContext(2)
+		if err != nil {
+			return decWhoHas, fmt.Errorf("failed to decode UnconfirmedWhoHas: %v", err)
+		}
+		oid, err := objects.DecObjectIdentifier(obj)
+		if err != nil {
+			return decWhoHas, fmt.Errorf("decode Context object case 2: %v", err)
+		}
+		decWhoHas.ObjectId = &oid
+	case r.PeekContext(3):
+		obj, err := r.ReadContext(3)
+		if err != nil {
+			return decWhoHas, fmt.Errorf("failed to decode UnconfirmedWhoHas: %v", err)
+		}
+		// DecString checks the application tag of the character string.
+		obj.TagClass, obj.TagNumber = false, objects.TagCharacterString
+		if decWhoHas.ObjectName, err = objects.DecString(obj); err != nil {
+			return decWhoHas, fmt.Errorf("decode Context object case 3: %v", err)
+		}
+	default:
+		return decWhoHas, fmt.Errorf(
+			"failed to decode UnconfirmedWhoHas - no object identifier nor name: %v", common.ErrWrongStructure,
+		)
+	}
+
+	if err := r.End(); err != nil {
+		return decWhoHas, fmt.Errorf("failed to decode UnconfirmedWhoHas: %v", err)
+	}
+	return decWhoHas, nil
+}
+
+// Matches tells whether the device deviceInstance is asked.
+func (d UnconfirmedWhoHasDec) Matches(deviceInstance uint32) bool {
+	if d.LowLimit < 0 || d.HighLimit < 0 {
+		return true
+	}
+	return deviceInstance >= uint32(d.LowLimit) && deviceInstance <= uint32(d.HighLimit)
+}
+
+// SetLength sets the length in Length field.
+func (u *UnconfirmedWhoHas) SetLength() {
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
+}
+
+func (u *UnconfirmedWhoHas) GetService() uint8 {
+	return u.APDU.Service
+}
+
+func (u *UnconfirmedWhoHas) GetType() uint8 {
+	return u.APDU.Type
+}
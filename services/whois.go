@@ -2,7 +2,6 @@ package services
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/Nortech-ai/bacnet/common"
 	"github.com/Nortech-ai/bacnet/objects"
@@ -116,77 +115,38 @@ func (u *UnconfirmedWhoIs) MarshalLen() int {
 func (u *UnconfirmedWhoIs) Decode() (UnconfirmedWhoIsDec, error) {
 	decWhois := UnconfirmedWhoIsDec{}
 
-	context := []uint8{8}
+	r, err := serviceReader(u.APDU)
+	if err != nil {
+		return decWhois, fmt.Errorf("failed to decode UnconfirmedWhoIs: %v", err)
+	}
 	objs := make([]*objects.Object, 0)
-	for i, obj := range u.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decWhois, fmt.Errorf(
-				"ComplexACK object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
-		}
-
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
-		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decWhois, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
-		}
-
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				lowRange, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decWhois, fmt.Errorf("decode Context object case 0: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 0,
-					TagClass:  true,
-					Value:     lowRange,
-					Length:    uint8(obj.MarshalLen()),
-				})
-			case combine(8, 1):
-				highRange, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decWhois, fmt.Errorf("decode Context object case 1: %v", err)
-				}
-				objs = append(objs, &objects.Object{
-					TagNumber: 1,
-					TagClass:  true,
-					Value:     highRange,
-					Length:    uint8(obj.MarshalLen()),
-				})
-			default:
-				log.Printf("Unknown Context object: context %v tag class %t tag number %d\n", context, enc_obj.TagClass, enc_obj.TagNumber)
+	// The range limits come both or none.
+	if r.PeekContext(0) {
+		for _, tagNumber := range []uint8{0, 1} {
+			obj, err := r.ReadContext(tagNumber)
+			if err != nil {
+				return decWhois, fmt.Errorf("failed to decode UnconfirmedWhoIs: %v", err)
 			}
-		} else {
-			tag, err := decodeAppTags(enc_obj, &obj)
+			limit, err := objects.DecUnsignedInteger(obj)
 			if err != nil {
-				return decWhois, fmt.Errorf("decode Application Tag: %v", err)
+				return decWhois, fmt.Errorf("decode Context object case %d: %v", tagNumber, err)
 			}
-			objs = append(objs, tag)
+			objs = append(objs, decodedTag(obj, limit))
 		}
 	}
 	decWhois.Tags = objs
 
+	if err := r.End(); err != nil {
+		return decWhois, fmt.Errorf("failed to decode UnconfirmedWhoIs: %v", err)
+	}
 	return decWhois, nil
 }
 
 // SetLength sets the length in Length field.
 func (u *UnconfirmedWhoIs) SetLength() {
-	u.BVLC.Length = uint16(u.MarshalLen())
+	if u.BVLC != nil {
+		u.BVLC.Length = uint16(u.MarshalLen())
+	}
 }
 
 func (u *UnconfirmedWhoIs) GetService() uint8 {
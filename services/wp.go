@@ -19,6 +19,7 @@ type ConfirmedWritePropertyDec struct {
 	ObjectType  uint16
 	InstanceNum uint32
 	PropertyId  uint16
+	ArrayIndex  uint32
 	Value       float32
 	Priority    uint8
 	Tags        []*objects.Object
@@ -44,6 +45,27 @@ func ConfirmedWritePropertyObjects(objectType uint16, instN uint32, propertyId u
 	return objs
 }
 
+// ConfirmedWritePropertyValueObjects creates the objects of a WriteProperty request
+// carrying an already encoded application tagged value. An arrayIndex of
+// objects.ArrayAll and a priority of 0 leave the optional parameters out.
+func ConfirmedWritePropertyValueObjects(objectType uint16, instN uint32, propertyId uint16, arrayIndex uint32, value *objects.Object, priority uint8) []objects.APDUPayload {
+	objs := make([]objects.APDUPayload, 0, 7)
+
+	objs = append(objs, objects.EncObjectIdentifier(true, 0, objectType, instN))
+	objs = append(objs, objects.ContextTag(1, objects.EncUnsignedInteger(uint(propertyId))))
+	if arrayIndex != objects.ArrayAll {
+		objs = append(objs, objects.ContextTag(2, objects.EncUnsignedInteger(uint(arrayIndex))))
+	}
+	objs = append(objs, objects.EncOpeningTag(3))
+	objs = append(objs, value)
+	objs = append(objs, objects.EncClosingTag(3))
+	if priority != 0 {
+		objs = append(objs, objects.ContextTag(4, objects.EncUnsignedInteger(uint(priority))))
+	}
+
+	return objs
+}
+
 func NewConfirmedWriteProperty(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedWriteProperty {
 	c := &ConfirmedWriteProperty{
 		BVLC: bvlc,
@@ -131,80 +153,53 @@ func (c *ConfirmedWriteProperty) MarshalLen() int {
 }
 
 func (c *ConfirmedWriteProperty) SetLength() {
-	c.BVLC.Length = uint16(c.MarshalLen())
+	if c.BVLC != nil {
+		c.BVLC.Length = uint16(c.MarshalLen())
+	}
 }
 
 func (c *ConfirmedWriteProperty) Decode() (ConfirmedWritePropertyDec, error) {
-	decCWP := ConfirmedWritePropertyDec{}
+	decCWP := ConfirmedWritePropertyDec{ArrayIndex: objects.ArrayAll}
 
-	if len(c.APDU.Objects) != 5 {
+	if len(c.APDU.Objects) < 5 {
 		return decCWP, fmt.Errorf(
-			"failed to decode ConfirmedWP - object count %d: %v",
+			"failed to decode ConfirmedWP - object count %d: %w",
 			len(c.APDU.Objects),
 			common.ErrWrongObjectCount,
 		)
 	}
 
-	context := []uint8{8}
-	objs := make([]*objects.Object, 0)
-	for i, obj := range c.APDU.Objects {
-		enc_obj, ok := obj.(*objects.Object)
-		if !ok {
-			return decCWP, fmt.Errorf(
-				"ComplexACK object at index %d is not Object type: %v",
-				i, common.ErrInvalidObjectType,
-			)
-		}
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return decCWP, fmt.Errorf("failed to decode ConfirmedWP: %w", err)
+	}
+	objId, propId, index, err := readPropertyReference(r)
+	if err != nil {
+		return decCWP, fmt.Errorf("decoding ConfirmedWP: %w", err)
+	}
+	decCWP.ObjectType = objId.ObjectType
+	decCWP.InstanceNum = objId.InstanceNumber
+	decCWP.PropertyId = propId
+	decCWP.ArrayIndex = index
 
-		// add or remove context based on opening and closing tags
-		if enc_obj.Length == 6 {
-			context = append(context, enc_obj.TagNumber)
-			continue
-		}
-		if enc_obj.Length == 7 {
-			if len(context) == 0 {
-				return decCWP, fmt.Errorf(
-					"LogBufferCACK object at index %d has mismatched closing tag: %v",
-					i, common.ErrInvalidObjectType,
-				)
-			}
-			context = context[:len(context)-1]
-			continue
+	if decCWP.Tags, err = readTags(r, 3, nil); err != nil {
+		return decCWP, fmt.Errorf("failed to decode ConfirmedWP: %w", err)
+	}
+	if r.PeekContext(4) {
+		obj, err := r.ReadContext(4)
+		if err != nil {
+			return decCWP, fmt.Errorf("failed to decode ConfirmedWP: %w", err)
 		}
-
-		if enc_obj.TagClass {
-			c := combine(context[len(context)-1], enc_obj.TagNumber)
-			switch c {
-			case combine(8, 0):
-				objId, err := objects.DecObjectIdentifier(obj)
-				if err != nil {
-					return decCWP, fmt.Errorf("decoding ConfirmedWP: %v", err)
-				}
-				decCWP.ObjectType = objId.ObjectType
-				decCWP.InstanceNum = objId.InstanceNumber
-			case combine(8, 1):
-				propId, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCWP, fmt.Errorf("decoding ConfirmedWP: %v", err)
-				}
-				decCWP.PropertyId = uint16(propId)
-			case combine(8, 4):
-				priority, err := objects.DecUnsignedInteger(obj)
-				if err != nil {
-					return decCWP, fmt.Errorf("decoding ConfirmedWP: %v", err)
-				}
-				decCWP.Priority = uint8(priority)
-			}
-		} else {
-			tag, err := decodeAppTags(enc_obj, &obj)
-			if err != nil {
-				return decCWP, fmt.Errorf("decode Application Tag: %v", err)
-			}
-			objs = append(objs, tag)
+		priority, err := objects.DecUnsignedInteger(obj)
+		if err != nil {
+			return decCWP, fmt.Errorf("decoding ConfirmedWP: %w", err)
 		}
+		decCWP.Priority = uint8(priority)
 	}
-	decCWP.Tags = objs
 
+	if err := r.End(); err != nil {
+		return decCWP, fmt.Errorf("failed to decode ConfirmedWP: %w", err)
+	}
 	return decCWP, nil
 }
 
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/objects"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// ConfirmedWritePropertyMultiple is a BACnet message.
+type ConfirmedWritePropertyMultiple struct {
+	*plumbing.BVLC
+	*plumbing.NPDU
+	*plumbing.APDU
+}
+
+// WriteAccessSpecification holds the values written to the properties of an
+// object.
+type WriteAccessSpecification struct {
+	ObjectId objects.ObjectIdentifier
+	Values   []objects.BACnetPropertyValue
+}
+
+func (s *WriteAccessSpecification) Encode(w *objects.TagWriter) error {
+	if err := w.Write(objects.EncObjectIdentifier(true, 0, s.ObjectId.ObjectType, s.ObjectId.InstanceNumber)); err != nil {
+		return err
+	}
+	w.Open(1)
+	for i := range s.Values {
+		if err := s.Values[i].Encode(w); err != nil {
+			return err
+		}
+	}
+	return w.Close(1)
+}
+
+func (s *WriteAccessSpecification) Decode(r *objects.TagReader) error {
+	*s = WriteAccessSpecification{}
+	obj, err := r.ReadContext(0)
+	if err != nil {
+		return fmt.Errorf("decoding WriteAccessSpecification: %w", err)
+	}
+	if s.ObjectId, err = objects.DecObjectIdentifier(obj); err != nil {
+		return fmt.Errorf("decoding WriteAccessSpecification: %w", err)
+	}
+	if err := r.Enter(1); err != nil {
+		return fmt.Errorf("decoding WriteAccessSpecification: %w", err)
+	}
+	for !r.Done() {
+		var v objects.BACnetPropertyValue
+		if err := v.Decode(r); err != nil {
+			return fmt.Errorf("decoding WriteAccessSpecification of %v: %w", s.ObjectId, err)
+		}
+		s.Values = append(s.Values, v)
+	}
+	if len(s.Values) == 0 {
+		return fmt.Errorf("decoding WriteAccessSpecification of %v - no value: %w", s.ObjectId, common.ErrWrongStructure)
+	}
+	return r.Leave(1)
+}
+
+type ConfirmedWritePropertyMultipleDec struct {
+	Specs []WriteAccessSpecification
+}
+
+// WritePropertyMultipleObjects creates the objects of a WritePropertyMultiple
+// request writing specs.
+func WritePropertyMultipleObjects(specs []WriteAccessSpecification) ([]objects.APDUPayload, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("encoding WritePropertyMultiple - no object: %v", common.ErrWrongStructure)
+	}
+	w := objects.NewTagWriter()
+	for i := range specs {
+		if err := specs[i].Encode(w); err != nil {
+			return nil, fmt.Errorf("encoding WritePropertyMultiple of %v: %v", specs[i].ObjectId, err)
+		}
+	}
+	return w.Objects()
+}
+
+// NewConfirmedWritePropertyMultiple creates a ConfirmedWritePropertyMultiple.
+func NewConfirmedWritePropertyMultiple(bvlc *plumbing.BVLC, npdu *plumbing.NPDU) *ConfirmedWritePropertyMultiple {
+	c := &ConfirmedWritePropertyMultiple{
+		BVLC: bvlc,
+		NPDU: npdu,
+		APDU: plumbing.NewAPDU(plumbing.ConfirmedReq, ServiceConfirmedWritePropMultiple, nil),
+	}
+	c.SetLength()
+
+	return c
+}
+
+func (c *ConfirmedWritePropertyMultiple) UnmarshalBinary(b []byte) error {
+	if l := len(b); l < c.MarshalLen() {
+		return fmt.Errorf(
+			"failed to unmarshal ConfirmedWritePropertyMultiple - marshal length %d binary length %d: %v",
+			c.MarshalLen(), l,
+			common.ErrTooShortToParse,
+		)
+	}
+
+	var offset int = 0
+	if err := c.BVLC.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling ConfirmedWritePropertyMultiple %+v: %v",
+			c, common.ErrTooShortToParse,
+		)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling ConfirmedWritePropertyMultiple %+v: %v",
+			c, common.ErrTooShortToParse,
+		)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.UnmarshalBinary(b[offset:]); err != nil {
+		return fmt.Errorf(
+			"unmarshalling ConfirmedWritePropertyMultiple %+v: %v",
+			c, common.ErrTooShortToParse,
+		)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedWritePropertyMultiple) MarshalBinary() ([]byte, error) {
+	b := make([]byte, c.MarshalLen())
+	if err := c.MarshalTo(b); err != nil {
+		return nil, fmt.Errorf("failed to marshal binary: %v", err)
+	}
+	return b, nil
+}
+
+func (c *ConfirmedWritePropertyMultiple) MarshalTo(b []byte) error {
+	if len(b) < c.MarshalLen() {
+		return fmt.Errorf(
+			"failed to marshal ConfirmedWritePropertyMultiple - marshal length %d binary length %d: %v",
+			c.MarshalLen(), len(b),
+			common.ErrTooShortToMarshalBinary,
+		)
+	}
+	var offset = 0
+	if err := c.BVLC.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling ConfirmedWritePropertyMultiple: %v", err)
+	}
+	offset += c.BVLC.MarshalLen()
+
+	if err := c.NPDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling ConfirmedWritePropertyMultiple: %v", err)
+	}
+	offset += c.NPDU.MarshalLen()
+
+	if err := c.APDU.MarshalTo(b[offset:]); err != nil {
+		return fmt.Errorf("marshalling ConfirmedWritePropertyMultiple: %v", err)
+	}
+
+	return nil
+}
+
+func (c *ConfirmedWritePropertyMultiple) MarshalLen() int {
+	l := c.BVLC.MarshalLen()
+	l += c.NPDU.MarshalLen()
+	l += c.APDU.MarshalLen()
+
+	return l
+}
+
+func (c *ConfirmedWritePropertyMultiple) SetLength() {
+	if c.BVLC != nil {
+		c.BVLC.Length = uint16(c.MarshalLen())
+	}
+}
+
+func (c *ConfirmedWritePropertyMultiple) Decode() (ConfirmedWritePropertyMultipleDec, error) {
+	decWPM := ConfirmedWritePropertyMultipleDec{}
+
+	r, err := serviceReader(c.APDU)
+	if err != nil {
+		return decWPM, fmt.Errorf("failed to decode ConfirmedWritePropertyMultiple: %w", err)
+	}
+	for r.Remaining() > 0 {
+		var spec WriteAccessSpecification
+		if err := spec.Decode(r); err != nil {
+			return decWPM, fmt.Errorf("failed to decode ConfirmedWritePropertyMultiple: %w", err)
+		}
+		decWPM.Specs = append(decWPM.Specs, spec)
+	}
+	if len(decWPM.Specs) == 0 {
+		return decWPM, fmt.Errorf("failed to decode ConfirmedWritePropertyMultiple: %w", common.ErrWrongObjectCount)
+	}
+
+	if err := r.End(); err != nil {
+		return decWPM, fmt.Errorf("failed to decode ConfirmedWritePropertyMultiple: %w", err)
+	}
+	return decWPM, nil
+}
+
+func (c *ConfirmedWritePropertyMultiple) GetService() uint8 {
+	return c.APDU.Service
+}
+
+func (c *ConfirmedWritePropertyMultiple) GetType() uint8 {
+	return c.APDU.Type
+}
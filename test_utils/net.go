@@ -0,0 +1,36 @@
+package test_utils
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// Listen opens a UDP socket on an ephemeral port of ip, closed when the test ends.
+func Listen(t *testing.T, ip string) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", ip+":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Receive returns the next message received on conn, decoded with Parse.
+func Receive(t *testing.T, conn net.PacketConn, Parse func([]byte) (plumbing.BACnet, error)) plumbing.BACnet {
+	t.Helper()
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := Parse(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
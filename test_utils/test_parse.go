@@ -237,3 +237,105 @@ func TestParseReadPropertyMultiple(t *testing.T, Parse func([]byte) (plumbing.BA
 	AssertEqualTag(t, close4, resultReadPropMultiple.APDU.Objects[9])
 	AssertEqualTag(t, close1, resultReadPropMultiple.APDU.Objects[10])
 }
+
+func TestParseIAmRouterToNetwork(t *testing.T, Parse func([]byte) (plumbing.BACnet, error)) {
+	result, err := Parse([]byte{
+		0x81, 0x0b, 0x00, 0x0b, 0x01, 0x80, 0x01,
+		0x07, 0xd1, 0x07, 0xd2,
+	})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	msg, ok := result.(*services.NetworkMessage)
+	if !ok {
+		t.Fatalf("Didn't get NetworkMessage: %v", result)
+	}
+	AssertEqual(t, services.NetworkMessageIAmRouterToNetwork, msg.GetType())
+	dec, err := msg.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, []uint16{2001, 2002}, dec.Networks)
+}
+
+func TestParseWhatIsNetworkNumber(t *testing.T, Parse func([]byte) (plumbing.BACnet, error)) {
+	result, err := Parse([]byte{0x81, 0x0b, 0x00, 0x07, 0x01, 0x80, 0x12})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	msg, ok := result.(*services.NetworkMessage)
+	if !ok {
+		t.Fatalf("Didn't get NetworkMessage: %v", result)
+	}
+	AssertEqual(t, services.NetworkMessageWhatIsNetworkNumber, msg.GetType())
+	AssertEqual(t, 0, len(msg.Data))
+}
+
+func TestParseForwardedWhois(t *testing.T, Parse func([]byte) (plumbing.BACnet, error)) {
+	result, err := Parse([]byte{
+		0x81, 0x04, 0x00, 0x0e, 0xc0, 0xa8, 0x01, 0x02, 0xba, 0xc0,
+		0x01, 0x00, 0x10, 0x08,
+	})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	resultWhois, ok := result.(*services.UnconfirmedWhoIs)
+	if !ok {
+		t.Fatalf("Didn't get Whois: %v", result)
+	}
+	AssertEqual(t, uint8(plumbing.BVLCFuncForwardedNPDU), resultWhois.BVLC.Function)
+	AssertEqual(t, "192.168.1.2:47808", resultWhois.BVLC.Origin.String())
+	AssertEqual(t, services.ServiceUnconfirmedWhoIs, resultWhois.APDU.Service)
+}
+
+func TestParseBIP6UnicastReadProperty(t *testing.T, Parse func([]byte) (plumbing.BACnet, error)) {
+	result, err := Parse([]byte{
+		0x82, 0x01, 0x00, 0x1d, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02,
+		0x01, 0x00, 0x30, 0x01, 0x0c, 0x0c, 0x02,
+		0x00, 0x00, 0x65, 0x19, 0x4b, 0x3e, 0xc4, 0x02, 0x00, 0x00, 0x65, 0x3f,
+	})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	resultReadProp, ok := result.(*services.ComplexACK)
+	if !ok {
+		t.Fatalf("Didn't get ComplexACK: %v", result)
+	}
+	AssertEqual(t, uint8(plumbing.BVLCType6), resultReadProp.BVLC.Type)
+	AssertEqual(t, uint8(plumbing.BVLC6FuncOriginalUnicast), resultReadProp.BVLC.Function)
+	AssertEqual(t, []byte{0x00, 0x00, 0x01}, resultReadProp.BVLC.SourceVMAC)
+	AssertEqual(t, []byte{0x00, 0x00, 0x02}, resultReadProp.BVLC.DestVMAC)
+	AssertEqual(t, services.ServiceConfirmedReadProperty, resultReadProp.APDU.Service)
+	AssertEqual(t, 5, len(resultReadProp.APDU.Objects))
+}
+
+func TestParseNPDUReadProperty(t *testing.T, ParseNPDU func([]byte) (plumbing.BACnet, error)) {
+	npdu := []byte{
+		0x01, 0x04, 0x00, 0x05, 0x01, 0x0c, 0x0c, 0x00, 0x80, 0x00, 0x01, 0x19, 0x55,
+	}
+	result, err := ParseNPDU(npdu)
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	resultReadProp, ok := result.(*services.ConfirmedReadProperty)
+	if !ok {
+		t.Fatalf("Didn't get ReadProperty: %v", result)
+	}
+	if resultReadProp.BVLC != nil {
+		t.Errorf("Expected no BVLC, got %+v", resultReadProp.BVLC)
+	}
+	AssertEqual(t, uint8(1), resultReadProp.APDU.InvokeID)
+	dec, err := resultReadProp.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, uint32(1), dec.InstanceNum)
+	AssertEqual(t, uint16(objects.PropertyIdPresentValue), dec.PropertyId)
+
+	// Messages without BVLC marshal back to the bare NPDU.
+	b, err := resultReadProp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertEqual(t, npdu, b)
+}
@@ -2,6 +2,7 @@ package test_utils
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/Nortech-ai/bacnet/objects"
@@ -33,7 +34,7 @@ func AssertEqualTag(t *testing.T, expected *objects.Object, actual objects.APDUP
 }
 
 func AssertEqual(t *testing.T, expected, actual interface{}) {
-	if expected != actual {
+	if !reflect.DeepEqual(expected, actual) {
 		t.Errorf("Expected %v, got %v", expected, actual)
 	}
 }
@@ -0,0 +1,451 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+const maxFrameLen = 1 << 16
+
+// Virtual MAC address resolution of BIP6, retried as confirmed requests are.
+const (
+	resolveTimeout = time.Second
+	resolveRetries = 3
+)
+
+// BIP is a Transport over BACnet/IP, the MAC addresses being the IPv4 address
+// and the UDP port of the stations, as in plumbing.NewBACnetIPAddress. It may
+// register as a foreign device with a BBMD, which then distributes its
+// broadcasts.
+type BIP struct {
+	conn      net.PacketConn
+	broadcast net.Addr
+	mac       []byte
+	queue     *receiveQueue
+
+	// bbmd is the BBMD the Transport is registered with as a foreign device,
+	// if any, and results the BVLC-Results awaited per BBMD.
+	mu      sync.Mutex
+	bbmd    net.Addr
+	results map[string]chan uint16
+}
+
+// NewBIP creates a Transport over conn, broadcasting to broadcast, such as the
+// directed broadcast address of the IP subnet. It owns conn from now on and
+// closes it on Close.
+func NewBIP(conn net.PacketConn, broadcast net.Addr) *BIP {
+	t := &BIP{
+		conn:      conn,
+		broadcast: broadcast,
+		queue:     newReceiveQueue(),
+		results:   map[string]chan uint16{},
+	}
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		t.mac = bipMAC(addr)
+	}
+	go t.readLoop()
+	return t
+}
+
+// Send sends npdu as an Original-Unicast-NPDU to dst, or as an
+// Original-Broadcast-NPDU, which a foreign device sends to its BBMD as a
+// Distribute-Broadcast-To-Network instead.
+func (t *BIP) Send(dst plumbing.BACnetAddress, npdu []byte) error {
+	f, addr := uint8(plumbing.BVLCFuncBroadcast), t.broadcast
+	t.mu.Lock()
+	if t.bbmd != nil {
+		f, addr = plumbing.BVLCFuncDistributeBroadcast, t.bbmd
+	}
+	t.mu.Unlock()
+	if !dst.IsBroadcast() {
+		if len(dst.Mac) != plumbing.BIPAddressLen {
+			return fmt.Errorf("sending to B/IP address %x: %v", dst.Mac, common.ErrWrongStructure)
+		}
+		f, addr = plumbing.BVLCFuncUnicast, BIPAddr(dst.Mac)
+	}
+	b, err := marshalFrame(plumbing.NewBVLC(f), npdu)
+	if err != nil {
+		return err
+	}
+	if _, err := t.conn.WriteTo(b, addr); err != nil {
+		return fmt.Errorf("sending to %s: %v", addr, err)
+	}
+	return nil
+}
+
+func (t *BIP) Receive(ctx context.Context) (Packet, error) {
+	return t.queue.receive(ctx)
+}
+
+func (t *BIP) LocalAddress() plumbing.BACnetAddress {
+	return localAddress(t.mac)
+}
+
+func (t *BIP) Close() error {
+	return t.conn.Close()
+}
+
+// RegisterForeignDevice sends a Register-Foreign-Device for ttl seconds to
+// bbmd and waits for its BVLC-Result until ctx is done, returning its result
+// code. Once a registration succeeded, the broadcasts are sent to bbmd until
+// DeleteForeignDevice.
+func (t *BIP) RegisterForeignDevice(ctx context.Context, bbmd net.Addr, ttl uint16) (uint16, error) {
+	req, err := marshalFrame(plumbing.NewBVLC(plumbing.BVLCFuncRegisterForeignDevice), services.RegisterForeignDeviceData(ttl))
+	if err != nil {
+		return 0, fmt.Errorf("building Register-Foreign-Device: %v", err)
+	}
+	code, err := t.request(ctx, bbmd, req)
+	if err != nil {
+		return 0, err
+	}
+	if code == plumbing.BVLCResultSuccessful {
+		t.mu.Lock()
+		t.bbmd = bbmd
+		t.mu.Unlock()
+	}
+	return code, nil
+}
+
+// DeleteForeignDevice stops sending the broadcasts to bbmd, if the Transport
+// registered with it, and sends it a Delete-Foreign-Device-Table-Entry of the
+// Transport, waiting for its BVLC-Result until ctx is done.
+func (t *BIP) DeleteForeignDevice(ctx context.Context, bbmd net.Addr) (uint16, error) {
+	t.mu.Lock()
+	if t.bbmd != nil && t.bbmd.String() == bbmd.String() {
+		t.bbmd = nil
+	}
+	t.mu.Unlock()
+
+	if len(t.mac) != plumbing.BIPAddressLen {
+		return 0, fmt.Errorf("deleting %s address %s: %v", t.conn.LocalAddr().Network(), t.conn.LocalAddr(), common.ErrWrongStructure)
+	}
+	req, err := marshalFrame(plumbing.NewBVLC(plumbing.BVLCFuncDeleteFDTEntry), services.DeleteFDTEntryData(BIPAddr(t.mac)))
+	if err != nil {
+		return 0, fmt.Errorf("building Delete-Foreign-Device-Table-Entry: %v", err)
+	}
+	return t.request(ctx, bbmd, req)
+}
+
+// request sends the BVLL message req to addr and waits for its BVLC-Result
+// until ctx is done.
+func (t *BIP) request(ctx context.Context, addr net.Addr, req []byte) (uint16, error) {
+	peer := addr.String()
+	ch := make(chan uint16, 1)
+	t.mu.Lock()
+	t.results[peer] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.results, peer)
+		t.mu.Unlock()
+	}()
+
+	if _, err := t.conn.WriteTo(req, addr); err != nil {
+		return 0, fmt.Errorf("sending to %s: %v", addr, err)
+	}
+	select {
+	case code := <-ch:
+		return code, nil
+	case <-t.queue.done:
+		return 0, t.queue.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// result hands the BVLC-Result b from addr over to the request awaiting it.
+func (t *BIP) result(addr net.Addr, b []byte) {
+	if len(b) < 2 {
+		return
+	}
+	t.mu.Lock()
+	ch, ok := t.results[addr.String()]
+	t.mu.Unlock()
+	if ok {
+		select {
+		case ch <- binary.BigEndian.Uint16(b):
+		default:
+		}
+	}
+}
+
+// readLoop receives the NPDUs, the forwarded ones coming from their original
+// source, and the BVLC-Results. The broadcasts of the Transport that loop back
+// are dropped.
+func (t *BIP) readLoop() {
+	buf := make([]byte, maxFrameLen)
+	for {
+		n, addr, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			t.queue.fail(fmt.Errorf("reading: %v: %w", err, common.ErrClientClosed))
+			return
+		}
+		b := append([]byte{}, buf[:n]...)
+		var bvlc plumbing.BVLC
+		if err := bvlc.UnmarshalBinary(b); err != nil || bvlc.Type != plumbing.BVLCType {
+			continue
+		}
+		if bvlc.Function == plumbing.BVLCFuncResult {
+			t.result(addr, b[bvlc.MarshalLen():])
+			continue
+		}
+		if !bvlc.CarriesNPDU() {
+			continue
+		}
+		src, ok := addr.(*net.UDPAddr)
+		if bvlc.Origin != nil {
+			src, ok = bvlc.Origin, true
+		}
+		if !ok || bytes.Equal(bipMAC(src), t.mac) {
+			continue
+		}
+
+		p := Packet{
+			Source: localAddress(bipMAC(src)),
+			NPDU:   b[bvlc.MarshalLen():],
+		}
+		if bvlc.Function == plumbing.BVLCFuncUnicast {
+			p.Destination = t.LocalAddress()
+		}
+		t.queue.deliver(p)
+	}
+}
+
+func bipMAC(addr *net.UDPAddr) []byte {
+	return plumbing.NewBACnetIPAddress(plumbing.LocalNetwork, addr).Mac
+}
+
+// BIPAddr returns the IPv4 address and UDP port of the B/IP MAC address mac,
+// as the reverse of plumbing.NewBACnetIPAddress.
+func BIPAddr(mac []byte) *net.UDPAddr {
+	return &net.UDPAddr{
+		IP:   net.IPv4(mac[0], mac[1], mac[2], mac[3]),
+		Port: int(mac[4])<<8 | int(mac[5]),
+	}
+}
+
+// BIP6 is a Transport over BACnet/IPv6, the MAC addresses being the virtual
+// MAC addresses of the nodes. It resolves the B/IPv6 addresses of its peers
+// with Address-Resolution and answers the address resolutions of others.
+type BIP6 struct {
+	conn      net.PacketConn
+	vmac      []byte
+	multicast net.Addr
+	queue     *receiveQueue
+
+	mu        sync.Mutex
+	addrs     map[string]net.Addr
+	resolving map[string]chan struct{}
+}
+
+// NewBIP6 creates a Transport over conn with the virtual MAC address vmac,
+// broadcasting to multicast, such as plumbing.BIP6Multicast. Broadcasts are
+// only received on a conn that joined the multicast group. It owns conn from
+// now on and closes it on Close.
+func NewBIP6(conn net.PacketConn, vmac []byte, multicast net.Addr) (*BIP6, error) {
+	if len(vmac) != plumbing.VMACLen {
+		return nil, fmt.Errorf("VMAC %x: %v", vmac, common.ErrWrongStructure)
+	}
+	t := &BIP6{
+		conn:      conn,
+		vmac:      append([]byte{}, vmac...),
+		multicast: multicast,
+		queue:     newReceiveQueue(),
+		addrs:     map[string]net.Addr{},
+		resolving: map[string]chan struct{}{},
+	}
+	go t.readLoop()
+	return t, nil
+}
+
+// Send sends npdu as an Original-Unicast-NPDU to dst, resolving its B/IPv6
+// address first if unknown, or as an Original-Broadcast-NPDU.
+func (t *BIP6) Send(dst plumbing.BACnetAddress, npdu []byte) error {
+	return t.SendContext(context.Background(), dst, npdu)
+}
+
+// SendContext is Send giving up on resolving the B/IPv6 address of dst once
+// ctx is done.
+func (t *BIP6) SendContext(ctx context.Context, dst plumbing.BACnetAddress, npdu []byte) error {
+	bvlc, addr := plumbing.NewBVLC6(plumbing.BVLC6FuncOriginalBroadcast, t.vmac, nil), t.multicast
+	if !dst.IsBroadcast() {
+		if len(dst.Mac) != plumbing.VMACLen {
+			return fmt.Errorf("sending to VMAC %x: %v", dst.Mac, common.ErrWrongStructure)
+		}
+		var err error
+		if addr, err = t.resolve(ctx, dst.Mac); err != nil {
+			return err
+		}
+		bvlc = plumbing.NewBVLC6(plumbing.BVLC6FuncOriginalUnicast, t.vmac, dst.Mac)
+	}
+	b, err := marshalFrame(bvlc, npdu)
+	if err != nil {
+		return err
+	}
+	if _, err := t.conn.WriteTo(b, addr); err != nil {
+		return fmt.Errorf("sending to %s: %v", addr, err)
+	}
+	return nil
+}
+
+func (t *BIP6) Receive(ctx context.Context) (Packet, error) {
+	return t.queue.receive(ctx)
+}
+
+func (t *BIP6) LocalAddress() plumbing.BACnetAddress {
+	return localAddress(t.vmac)
+}
+
+func (t *BIP6) Close() error {
+	return t.conn.Close()
+}
+
+// resolve returns the B/IPv6 address of the node with the virtual MAC address
+// vmac, multicasting an Address-Resolution for unknown ones until ctx is done.
+func (t *BIP6) resolve(ctx context.Context, vmac []byte) (net.Addr, error) {
+	t.mu.Lock()
+	if addr, ok := t.addrs[string(vmac)]; ok {
+		t.mu.Unlock()
+		return addr, nil
+	}
+	ch, ok := t.resolving[string(vmac)]
+	if !ok {
+		ch = make(chan struct{})
+		t.resolving[string(vmac)] = ch
+	}
+	t.mu.Unlock()
+
+	req, err := t.marshal(plumbing.BVLC6FuncAddressResolution, nil, services.AddressResolutionData(vmac, nil))
+	if err != nil {
+		return nil, fmt.Errorf("building Address-Resolution: %v", err)
+	}
+	for retry := 0; retry <= resolveRetries; retry++ {
+		if _, err := t.conn.WriteTo(req, t.multicast); err != nil {
+			return nil, fmt.Errorf("sending to %s: %v", t.multicast, err)
+		}
+
+		timer := time.NewTimer(resolveTimeout)
+		select {
+		case <-ch:
+			timer.Stop()
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return t.addrs[string(vmac)], nil
+		case <-t.queue.done:
+			timer.Stop()
+			return nil, t.queue.err
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return nil, fmt.Errorf("resolving VMAC %x after %d retries: %w", vmac, resolveRetries, common.ErrTimeout)
+}
+
+// learn records addr as the B/IPv6 address of vmac, waking up the resolutions
+// awaiting it.
+func (t *BIP6) learn(vmac []byte, addr net.Addr) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addrs[string(vmac)] = addr
+	if ch, ok := t.resolving[string(vmac)]; ok {
+		close(ch)
+		delete(t.resolving, string(vmac))
+	}
+}
+
+func (t *BIP6) marshal(f uint8, dst, data []byte) ([]byte, error) {
+	return marshalFrame(plumbing.NewBVLC6(f, t.vmac, dst), data)
+}
+
+// readLoop receives the NPDUs and answers the address resolutions, learning
+// the B/IPv6 address of every node heard from. Messages for another virtual
+// MAC address and the multicasts of the Transport that loop back are dropped.
+func (t *BIP6) readLoop() {
+	buf := make([]byte, maxFrameLen)
+	for {
+		n, addr, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			t.queue.fail(fmt.Errorf("reading: %v: %w", err, common.ErrClientClosed))
+			return
+		}
+		// The frame is kept, its VMACs being slices of it.
+		b := append([]byte{}, buf[:n]...)
+		var bvlc plumbing.BVLC
+		if err := bvlc.UnmarshalBinary(b); err != nil || bvlc.Type != plumbing.BVLCType6 {
+			continue
+		}
+		if bytes.Equal(bvlc.SourceVMAC, t.vmac) || bvlc.DestVMAC != nil && !bytes.Equal(bvlc.DestVMAC, t.vmac) {
+			continue
+		}
+		src := addr
+		if bvlc.Origin != nil {
+			src = bvlc.Origin
+		}
+
+		switch bvlc.Function {
+		case plumbing.BVLC6FuncAddressResolution, plumbing.BVLC6FuncForwardedAddressResolution:
+			m := services.NewBVLLMessage(&plumbing.BVLC{})
+			if err := m.UnmarshalBinary(b); err != nil {
+				continue
+			}
+			dec, err := m.Decode()
+			if err != nil {
+				continue
+			}
+			// Forwarded resolutions are answered to their original source.
+			if dec.Address != nil {
+				src = dec.Address
+			}
+			t.learn(bvlc.SourceVMAC, src)
+			if bytes.Equal(dec.VMAC, t.vmac) {
+				if reply, err := t.marshal(plumbing.BVLC6FuncAddressResolutionAck, bvlc.SourceVMAC, nil); err == nil {
+					t.conn.WriteTo(reply, src)
+				}
+			}
+			continue
+		case plumbing.BVLC6FuncVirtualAddressResolution:
+			t.learn(bvlc.SourceVMAC, src)
+			if reply, err := t.marshal(plumbing.BVLC6FuncVirtualAddressResolutionAck, bvlc.SourceVMAC, nil); err == nil {
+				t.conn.WriteTo(reply, src)
+			}
+			continue
+		}
+
+		t.learn(bvlc.SourceVMAC, src)
+		if !bvlc.CarriesNPDU() {
+			continue
+		}
+		p := Packet{
+			Source: localAddress(bvlc.SourceVMAC),
+			NPDU:   b[bvlc.MarshalLen():],
+		}
+		if bvlc.DestVMAC != nil {
+			p.Destination = t.LocalAddress()
+		}
+		t.queue.deliver(p)
+	}
+}
+
+// marshalFrame returns the message made of bvlc followed by payload, setting
+// its length.
+func marshalFrame(bvlc *plumbing.BVLC, payload []byte) ([]byte, error) {
+	bvlc.Length = uint16(bvlc.MarshalLen() + len(payload))
+	b := make([]byte, bvlc.Length)
+	if err := bvlc.MarshalTo(b); err != nil {
+		return nil, err
+	}
+	copy(b[bvlc.MarshalLen():], payload)
+	return b, nil
+}
@@ -0,0 +1,256 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// defaultReorderDelay holds back the reordered NPDUs if Impairments do not say.
+const defaultReorderDelay = 10 * time.Millisecond
+
+// MemoryNetwork is an in-memory datalink, delivering the NPDUs sent by its
+// transports right away unless impaired with SetImpairments. It is meant for
+// tests running clients and servers in a single process, several networks
+// standing for several subnets. It is safe for concurrent use.
+type MemoryNetwork struct {
+	mu          sync.Mutex
+	ports       map[string]*Memory
+	impairments Impairments
+	rand        *rand.Rand
+	stats       MemoryStats
+}
+
+// Impairments degrade the delivery of the NPDUs on a MemoryNetwork. Every
+// delivery to a transport is impaired on its own, a broadcast being possibly
+// lost for some of them only. The draws come from a random source seeded with
+// Seed, so that the NPDUs sent in the same order are impaired the same way on
+// every run.
+type Impairments struct {
+	// Loss is the probability of dropping an NPDU and Duplication the one of
+	// delivering it twice.
+	Loss        float64
+	Duplication float64
+	// Delay is the time every NPDU takes to be delivered.
+	Delay time.Duration
+	// Reordering is the probability of holding an NPDU back ReorderDelay
+	// longer, 10 ms if zero, for the following ones to overtake it.
+	Reordering   float64
+	ReorderDelay time.Duration
+	Seed         int64
+}
+
+// MemoryStats counts the deliveries of the NPDUs on a MemoryNetwork.
+type MemoryStats struct {
+	Delivered  int
+	Lost       int
+	Duplicated int
+	Reordered  int
+}
+
+// Memory is a Transport attached to a MemoryNetwork.
+type Memory struct {
+	network *MemoryNetwork
+	mac     []byte
+	queue   *receiveQueue
+
+	// delayed holds the delayed packets by delivery time, the ones due at
+	// the same time in the order they were sent.
+	mu      sync.Mutex
+	delayed []delayedPacket
+	timer   *time.Timer
+}
+
+type delayedPacket struct {
+	at time.Time
+	p  Packet
+}
+
+// NewMemoryNetwork creates an empty MemoryNetwork.
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{ports: map[string]*Memory{}}
+}
+
+// SetImpairments impairs the NPDUs sent from now on with imp, reseeding the
+// random source. The zero Impairments deliver the NPDUs right away.
+func (n *MemoryNetwork) SetImpairments(imp Impairments) {
+	if imp.Reordering > 0 && imp.ReorderDelay == 0 {
+		imp.ReorderDelay = defaultReorderDelay
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.impairments = imp
+	n.rand = rand.New(rand.NewSource(imp.Seed))
+}
+
+// Stats returns the deliveries counted so far.
+func (n *MemoryNetwork) Stats() MemoryStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+// Attach returns a Transport on the network with the MAC address mac, which
+// must be unique on the network.
+func (n *MemoryNetwork) Attach(mac []byte) (*Memory, error) {
+	if len(mac) == 0 {
+		return nil, fmt.Errorf("attaching empty MAC address: %v", common.ErrWrongStructure)
+	}
+	m := &Memory{
+		network: n,
+		mac:     append([]byte{}, mac...),
+		queue:   newReceiveQueue(),
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.ports[string(mac)]; ok {
+		return nil, fmt.Errorf("attaching duplicate MAC address %x: %v", mac, common.ErrWrongStructure)
+	}
+	n.ports[string(mac)] = m
+	return m, nil
+}
+
+type delivery struct {
+	m     *Memory
+	delay time.Duration
+}
+
+// send delivers npdu from src to dst, or to every other transport for broadcasts.
+func (n *MemoryNetwork) send(src *Memory, dst plumbing.BACnetAddress, npdu []byte) {
+	now := time.Now()
+	n.mu.Lock()
+	var dsts []*Memory
+	if dst.IsBroadcast() {
+		for _, m := range n.ports {
+			if m != src {
+				dsts = append(dsts, m)
+			}
+		}
+		// The draws follow the MAC addresses, not the map order.
+		sort.Slice(dsts, func(i, j int) bool { return bytes.Compare(dsts[i].mac, dsts[j].mac) < 0 })
+	} else if m, ok := n.ports[string(dst.Mac)]; ok {
+		dsts = append(dsts, m)
+	}
+	deliveries := n.impair(dsts)
+	n.mu.Unlock()
+
+	for _, d := range deliveries {
+		p := Packet{
+			Source:      localAddress(src.mac),
+			Destination: localAddress(dst.Mac),
+			NPDU:        append([]byte{}, npdu...),
+		}
+		if d.delay == 0 {
+			d.m.queue.deliver(p)
+			continue
+		}
+		d.m.deliverAt(now.Add(d.delay), p)
+	}
+}
+
+// impair draws the deliveries to dsts. n.mu must be held.
+func (n *MemoryNetwork) impair(dsts []*Memory) []delivery {
+	imp := n.impairments
+	deliveries := make([]delivery, 0, len(dsts))
+	for _, m := range dsts {
+		if imp.Loss > 0 && n.rand.Float64() < imp.Loss {
+			n.stats.Lost++
+			continue
+		}
+		copies := 1
+		if imp.Duplication > 0 && n.rand.Float64() < imp.Duplication {
+			n.stats.Duplicated++
+			copies++
+		}
+		for i := 0; i < copies; i++ {
+			delay := imp.Delay
+			if imp.Reordering > 0 && n.rand.Float64() < imp.Reordering {
+				n.stats.Reordered++
+				delay += imp.ReorderDelay
+			}
+			n.stats.Delivered++
+			deliveries = append(deliveries, delivery{m, delay})
+		}
+	}
+	return deliveries
+}
+
+// deliverAt delivers p at the time at.
+func (m *Memory) deliverAt(at time.Time, p Packet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := sort.Search(len(m.delayed), func(i int) bool { return m.delayed[i].at.After(at) })
+	m.delayed = append(m.delayed, delayedPacket{})
+	copy(m.delayed[i+1:], m.delayed[i:])
+	m.delayed[i] = delayedPacket{at, p}
+	if i == 0 {
+		m.schedule()
+	}
+}
+
+// schedule arms the timer for the first delayed packet. m.mu must be held.
+func (m *Memory) schedule() {
+	d := time.Until(m.delayed[0].at)
+	if m.timer == nil {
+		m.timer = time.AfterFunc(d, m.flush)
+		return
+	}
+	m.timer.Reset(d)
+}
+
+// flush delivers the delayed packets that are due, holding m.mu for them to
+// keep their order.
+func (m *Memory) flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for len(m.delayed) > 0 && !m.delayed[0].at.After(now) {
+		m.queue.deliver(m.delayed[0].p)
+		m.delayed = m.delayed[1:]
+	}
+	if len(m.delayed) > 0 {
+		m.schedule()
+	}
+}
+
+// Send delivers npdu to the transport with the MAC address dst.Mac, if any,
+// or to every other transport for broadcasts.
+func (m *Memory) Send(dst plumbing.BACnetAddress, npdu []byte) error {
+	select {
+	case <-m.queue.done:
+		return m.queue.err
+	default:
+	}
+	if bytes.Equal(dst.Mac, m.mac) {
+		return fmt.Errorf("sending to own MAC address %x: %v", dst.Mac, common.ErrWrongStructure)
+	}
+	m.network.send(m, dst, npdu)
+	return nil
+}
+
+func (m *Memory) Receive(ctx context.Context) (Packet, error) {
+	return m.queue.receive(ctx)
+}
+
+func (m *Memory) LocalAddress() plumbing.BACnetAddress {
+	return localAddress(m.mac)
+}
+
+// Close detaches the transport from its network.
+func (m *Memory) Close() error {
+	m.network.mu.Lock()
+	if m.network.ports[string(m.mac)] == m {
+		delete(m.network.ports, string(m.mac))
+	}
+	m.network.mu.Unlock()
+	m.queue.fail(fmt.Errorf("transport %x: %w", m.mac, common.ErrClientClosed))
+	return nil
+}
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/mstp"
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// npduExpectingReply is the NPDU control flag of the messages expecting a reply.
+const npduExpectingReply = 0x04
+
+// MSTP is a Transport over an MS/TP master node, the MAC addresses being
+// single octets.
+type MSTP struct {
+	node *mstp.Node
+}
+
+// NewMSTP creates a Transport over node. It owns node from now on and closes
+// it on Close.
+func NewMSTP(node *mstp.Node) *MSTP {
+	return &MSTP{node: node}
+}
+
+// Send queues npdu for the station at dst once the node holds the token. It
+// is sent expecting a reply if its NPDU control says so.
+func (t *MSTP) Send(dst plumbing.BACnetAddress, npdu []byte) error {
+	mac := uint8(mstp.BroadcastAddress)
+	if !dst.IsBroadcast() {
+		if len(dst.Mac) != 1 {
+			return fmt.Errorf("sending to MS/TP address %x: %v", dst.Mac, common.ErrWrongStructure)
+		}
+		mac = dst.Mac[0]
+	}
+	expectingReply := len(npdu) > 1 && npdu[1]&npduExpectingReply != 0 && mac != mstp.BroadcastAddress
+	return t.node.Send(mac, npdu, expectingReply)
+}
+
+func (t *MSTP) Receive(ctx context.Context) (Packet, error) {
+	m, err := t.node.Receive(ctx)
+	if err != nil {
+		return Packet{}, err
+	}
+	p := Packet{
+		Source:      localAddress([]byte{m.Source}),
+		Destination: localAddress([]byte{m.Destination}),
+		NPDU:        m.NPDU,
+	}
+	if m.Destination == mstp.BroadcastAddress {
+		p.Destination.Mac = nil
+	}
+	return p, nil
+}
+
+func (t *MSTP) LocalAddress() plumbing.BACnetAddress {
+	return localAddress([]byte{t.node.Address()})
+}
+
+func (t *MSTP) Close() error {
+	return t.node.Close()
+}
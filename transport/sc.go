@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/sc"
+)
+
+// SC is a Transport over a BACnet/SC node, the MAC addresses being the
+// 6-octet virtual MAC addresses of the nodes.
+type SC struct {
+	node *sc.Node
+}
+
+// NewSC creates a Transport over node. It owns node from now on and closes it
+// on Close.
+func NewSC(node *sc.Node) *SC {
+	return &SC{node: node}
+}
+
+// Send sends npdu to the node at dst through the hub, or to every node for
+// broadcasts.
+func (t *SC) Send(dst plumbing.BACnetAddress, npdu []byte) error {
+	vmac := plumbing.SCBroadcastVMAC()
+	if !dst.IsBroadcast() {
+		if len(dst.Mac) != plumbing.SCVMACLen {
+			return fmt.Errorf("sending to BACnet/SC address %x: %v", dst.Mac, common.ErrWrongStructure)
+		}
+		vmac = dst.Mac
+	}
+	return t.node.Send(vmac, npdu)
+}
+
+// Receive waits for the next NPDU. The hub does not tell the broadcasts apart,
+// so they all come addressed to the node.
+func (t *SC) Receive(ctx context.Context) (Packet, error) {
+	m, err := t.node.Receive(ctx)
+	if err != nil {
+		return Packet{}, err
+	}
+	return Packet{
+		Source:      localAddress(m.Source),
+		Destination: t.LocalAddress(),
+		NPDU:        m.NPDU,
+	}, nil
+}
+
+func (t *SC) LocalAddress() plumbing.BACnetAddress {
+	return localAddress(t.node.VMAC())
+}
+
+func (t *SC) Close() error {
+	return t.node.Close()
+}
@@ -0,0 +1,102 @@
+// Package transport abstracts the BACnet datalinks behind the Transport
+// interface, sending and receiving NPDUs between BACnetAddresses. BACnet/IP,
+// BACnet/IPv6, MS/TP, BACnet/SC and an in-memory network implement it, and
+// bacnet.NewTransportClient runs a Client over any of them. Only the BACnet/IP
+// and BACnet/IPv6 transports frame the NPDUs with a BVLC.
+package transport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Nortech-ai/bacnet/plumbing"
+)
+
+// Packet is an NPDU received from Source, Destination being either the
+// address of the Transport or a broadcast. Both are local to the datalink, the
+// remote ones being carried by the NPDU itself.
+type Packet struct {
+	Source      plumbing.BACnetAddress
+	Destination plumbing.BACnetAddress
+	NPDU        []byte
+}
+
+// Addr is the address of a station on the datalink of a Transport, as a
+// net.Addr.
+type Addr struct {
+	plumbing.BACnetAddress
+}
+
+// Network returns "bacnet".
+func (a *Addr) Network() string {
+	return "bacnet"
+}
+
+// Transport sends and receives NPDUs over a BACnet datalink. Implementations
+// are safe for concurrent use.
+type Transport interface {
+	// Send sends npdu to the station with the MAC address dst.Mac on the
+	// datalink, or broadcasts it if dst.Mac is empty.
+	Send(dst plumbing.BACnetAddress, npdu []byte) error
+	// Receive waits for the next NPDU sent to the Transport or broadcast,
+	// returning an error once the Transport is closed.
+	Receive(ctx context.Context) (Packet, error)
+	// LocalAddress returns the address of the Transport on its datalink.
+	LocalAddress() plumbing.BACnetAddress
+	// Close closes the Transport and the datalink it runs on.
+	Close() error
+}
+
+// ContextSender is a Transport whose Send may wait, such as for the address
+// of dst, SendContext giving up once ctx is done.
+type ContextSender interface {
+	Transport
+	SendContext(ctx context.Context, dst plumbing.BACnetAddress, npdu []byte) error
+}
+
+// receiveQueue hands over the packets read by the datalink goroutine of a
+// Transport to Receive, dropping them when nobody keeps up.
+type receiveQueue struct {
+	packets chan Packet
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+func newReceiveQueue() *receiveQueue {
+	return &receiveQueue{
+		packets: make(chan Packet, 64),
+		done:    make(chan struct{}),
+	}
+}
+
+func (q *receiveQueue) deliver(p Packet) {
+	select {
+	case q.packets <- p:
+	default:
+	}
+}
+
+// fail closes the queue, Receive returning err from now on.
+func (q *receiveQueue) fail(err error) {
+	q.once.Do(func() {
+		q.err = err
+		close(q.done)
+	})
+}
+
+func (q *receiveQueue) receive(ctx context.Context) (Packet, error) {
+	select {
+	case p := <-q.packets:
+		return p, nil
+	case <-q.done:
+		return Packet{}, q.err
+	case <-ctx.Done():
+		return Packet{}, ctx.Err()
+	}
+}
+
+// localAddress returns the address of mac on the local network.
+func localAddress(mac []byte) plumbing.BACnetAddress {
+	return plumbing.NewBACnetAddress(plumbing.LocalNetwork, mac)
+}
@@ -0,0 +1,215 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Nortech-ai/bacnet/common"
+	"github.com/Nortech-ai/bacnet/plumbing"
+	"github.com/Nortech-ai/bacnet/services"
+)
+
+var testNPDU = []byte{0x01, 0x04, 0x02, 0x75, 0x01, 0x0c, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x19, 0x55}
+
+func receive(t *testing.T, tr Transport) Packet {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	p, err := tr.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func attach(t *testing.T, n *MemoryNetwork, mac byte) *Memory {
+	m, err := n.Attach([]byte{mac})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestMemoryNetwork(t *testing.T) {
+	n := NewMemoryNetwork()
+	a, b, c := attach(t, n, 1), attach(t, n, 2), attach(t, n, 3)
+	if _, err := n.Attach([]byte{1}); err == nil {
+		t.Error("expected an error attaching a duplicate MAC address")
+	}
+
+	if err := a.Send(b.LocalAddress(), testNPDU); err != nil {
+		t.Fatal(err)
+	}
+	p := receive(t, b)
+	if !bytes.Equal(p.Source.Mac, []byte{1}) || !bytes.Equal(p.Destination.Mac, []byte{2}) || !bytes.Equal(p.NPDU, testNPDU) {
+		t.Errorf("unexpected unicast %+v", p)
+	}
+
+	if err := c.Send(plumbing.GlobalBroadcast(), testNPDU); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*Memory{a, b} {
+		if p := receive(t, m); !bytes.Equal(p.Source.Mac, []byte{3}) || !p.Destination.IsBroadcast() {
+			t.Errorf("unexpected broadcast %+v", p)
+		}
+	}
+
+	c.Close()
+	if _, err := c.Receive(context.Background()); !errors.Is(err, common.ErrClientClosed) {
+		t.Errorf("expected closed transport, got %v", err)
+	}
+}
+
+// impairedRun sends count numbered NPDUs through a network impaired with imp
+// and returns what was received in order, with the network statistics.
+func impairedRun(t *testing.T, imp Impairments, count int) ([]byte, MemoryStats) {
+	n := NewMemoryNetwork()
+	n.SetImpairments(imp)
+	a, b := attach(t, n, 1), attach(t, n, 2)
+	for i := 0; i < count; i++ {
+		if err := a.Send(b.LocalAddress(), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imp.Delay+imp.ReorderDelay+100*time.Millisecond)
+	defer cancel()
+	var got []byte
+	for {
+		p, err := b.Receive(ctx)
+		if err != nil {
+			return got, n.Stats()
+		}
+		got = append(got, p.NPDU[0])
+	}
+}
+
+func TestMemoryImpairments(t *testing.T) {
+	imp := Impairments{Loss: 0.1, Duplication: 0.1, Delay: time.Millisecond, Reordering: 0.2, Seed: 7}
+	got, stats := impairedRun(t, imp, 50)
+	if stats.Lost == 0 || stats.Duplicated == 0 || stats.Reordered == 0 {
+		t.Errorf("expected every impairment, got %+v", stats)
+	}
+	if len(got) != stats.Delivered || stats.Delivered != 50-stats.Lost+stats.Duplicated {
+		t.Errorf("received %d NPDUs with %+v", len(got), stats)
+	}
+	reordered := false
+	for i := 1; i < len(got); i++ {
+		reordered = reordered || got[i] < got[i-1]
+	}
+	if !reordered {
+		t.Errorf("expected reordered NPDUs, got %v", got)
+	}
+
+	again, againStats := impairedRun(t, imp, 50)
+	if !bytes.Equal(got, again) || stats != againStats {
+		t.Errorf("expected the same run with the same seed, got %v %+v then %v %+v", got, stats, again, againStats)
+	}
+}
+
+func listen(t *testing.T, network, address string) net.PacketConn {
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		t.Skipf("listening on %s: %v", address, err)
+	}
+	return conn
+}
+
+func TestBIP(t *testing.T) {
+	connA, connB := listen(t, "udp4", "127.0.0.1:0"), listen(t, "udp4", "127.0.0.1:0")
+	// Each transport broadcasts to the other one.
+	a, b := NewBIP(connA, connB.LocalAddr()), NewBIP(connB, connA.LocalAddr())
+	t.Cleanup(func() { a.Close(); b.Close() })
+
+	if err := a.Send(b.LocalAddress(), testNPDU); err != nil {
+		t.Fatal(err)
+	}
+	if p := receive(t, b); !bytes.Equal(p.Source.Mac, a.LocalAddress().Mac) || p.Destination.IsBroadcast() {
+		t.Errorf("unexpected unicast %+v", p)
+	}
+
+	if err := b.Send(plumbing.GlobalBroadcast(), testNPDU); err != nil {
+		t.Fatal(err)
+	}
+	if p := receive(t, a); !bytes.Equal(p.Source.Mac, b.LocalAddress().Mac) || !p.Destination.IsBroadcast() {
+		t.Errorf("unexpected broadcast %+v", p)
+	}
+}
+
+func TestBIP6AddressResolution(t *testing.T) {
+	connA, connB := listen(t, "udp6", "[::1]:0"), listen(t, "udp6", "[::1]:0")
+	// Each transport multicasts to the other one.
+	a, err := NewBIP6(connA, plumbing.NewVMAC(1), connB.LocalAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err := NewBIP6(connB, plumbing.NewVMAC(2), connA.LocalAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+
+	if err := a.Send(b.LocalAddress(), testNPDU); err != nil {
+		t.Fatal(err)
+	}
+	p := receive(t, b)
+	if !bytes.Equal(p.Source.Mac, plumbing.NewVMAC(1)) || !bytes.Equal(p.Destination.Mac, plumbing.NewVMAC(2)) ||
+		!bytes.Equal(p.NPDU, testNPDU) {
+		t.Errorf("unexpected unicast %+v", p)
+	}
+
+	// b learnt the address of a from its Address-Resolution.
+	if err := b.Send(a.LocalAddress(), testNPDU); err != nil {
+		t.Fatal(err)
+	}
+	if p := receive(t, a); !bytes.Equal(p.Source.Mac, plumbing.NewVMAC(2)) {
+		t.Errorf("unexpected unicast %+v", p)
+	}
+}
+
+func TestBIP6ForwardedAddressResolution(t *testing.T) {
+	conn, origin := listen(t, "udp6", "[::1]:0"), listen(t, "udp6", "[::1]:0")
+	t.Cleanup(func() { origin.Close() })
+	b, err := NewBIP6(listen(t, "udp6", "[::1]:0"), plumbing.NewVMAC(2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	a, err := NewBIP6(conn, plumbing.NewVMAC(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	// Only the node owning the target VMAC answers, to the original source
+	// of a forwarded resolution.
+	for _, target := range []uint32{3, 2} {
+		req, err := a.marshal(plumbing.BVLC6FuncForwardedAddressResolution, nil,
+			services.AddressResolutionData(plumbing.NewVMAC(target), origin.LocalAddr().(*net.UDPAddr)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.WriteTo(req, b.conn.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := make([]byte, 1500)
+	origin.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := origin.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ack plumbing.BVLC
+	if err := ack.UnmarshalBinary(buf[:n]); err != nil {
+		t.Fatal(err)
+	}
+	if ack.Function != plumbing.BVLC6FuncAddressResolutionAck || !bytes.Equal(ack.SourceVMAC, plumbing.NewVMAC(2)) ||
+		!bytes.Equal(ack.DestVMAC, plumbing.NewVMAC(1)) {
+		t.Errorf("expected Address-Resolution-Ack from %x, got %+v", plumbing.NewVMAC(2), ack)
+	}
+}